@@ -0,0 +1,126 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// GitHubActionsResult describes what SetupGitHubActions did.
+type GitHubActionsResult struct {
+	InstallResult
+	Version  string // Of the tool cache, e.g. "1.22.3" or "1.23.0-rc.1".
+	CacheHit bool   // Whether the release was already in the tool cache.
+}
+
+// SetupGitHubActions installs the release selected by version spec (see
+// Resolve) into the tool cache of a GitHub Actions runner, in the layout of
+// setup-go, e.g. $RUNNER_TOOL_CACHE/go/1.22.3/x64, with a marker file
+// x64.complete. Like setup-go, GOROOT is added to the file in $GITHUB_ENV,
+// GOROOT/bin to $GITHUB_PATH, and outputs go-version, goroot and cache-hit to
+// $GITHUB_OUTPUT, for each of these variables that is set. Spec "tip" is not
+// supported. Options are as with Install, with the os and arch of the host by
+// default. With DryRun, nothing is written.
+func (c *Client) SetupGitHubActions(ctx context.Context, spec string, opts *InstallOptions) (GitHubActionsResult, error) {
+	var o InstallOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Os == "" {
+		o.Os = runtime.GOOS
+	}
+	if o.Arch == "" {
+		o.Arch = runtime.GOARCH
+	}
+	toolCache := os.Getenv("RUNNER_TOOL_CACHE")
+	if toolCache == "" {
+		return GitHubActionsResult{}, fmt.Errorf("RUNNER_TOOL_CACHE not set, not running in github actions")
+	}
+	if spec == "tip" || strings.HasPrefix(spec, "tip@") {
+		return GitHubActionsResult{}, fmt.Errorf("spec %q not supported in tool cache", spec)
+	}
+
+	rels, err := c.ListAll(ctx)
+	if err != nil {
+		return GitHubActionsResult{}, err
+	}
+	rel, err := Resolve(rels, spec)
+	if err != nil {
+		return GitHubActionsResult{}, err
+	}
+	v, err := ParseVersion(rel.Version)
+	if err != nil {
+		return GitHubActionsResult{}, err
+	}
+	r := GitHubActionsResult{Version: actionsVersion(v)}
+	arch := actionsArch(o.Arch)
+	dir := filepath.Join(toolCache, "go", r.Version, arch)
+	marker := filepath.Join(toolCache, "go", r.Version, arch+".complete")
+
+	r.InstallResult, err = c.installWith(ctx, rels, rel.Version, dir, &o)
+	if err != nil {
+		return r, err
+	}
+	_, err = os.Stat(marker)
+	r.CacheHit = err == nil && r.Action == InstallNone
+	if o.DryRun {
+		return r, nil
+	}
+	if err := os.WriteFile(marker, nil, 0666); err != nil {
+		return r, fmt.Errorf("writing tool cache marker: %v", err)
+	}
+	files := []struct{ env, text string }{
+		{"GITHUB_ENV", "GOROOT=" + dir + "\n"},
+		{"GITHUB_PATH", filepath.Join(dir, "bin") + "\n"},
+		{"GITHUB_OUTPUT", fmt.Sprintf("go-version=%s\ngoroot=%s\ncache-hit=%v\n", r.Version, dir, r.CacheHit)},
+	}
+	for _, f := range files {
+		if p := os.Getenv(f.env); p != "" {
+			if err := appendFile(p, f.text); err != nil {
+				return r, fmt.Errorf("writing to %s: %v", f.env, err)
+			}
+		}
+	}
+	return r, nil
+}
+
+// actionsVersion returns the semver of v used in tool caches, e.g. "1.21.0"
+// for go1.21 and "1.23.0-rc.1" for go1.23rc1.
+func actionsVersion(v Version) string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += fmt.Sprintf("-%s.%d", v.Pre, v.PreN)
+	}
+	return s
+}
+
+// actionsArch returns the architecture name of Node.js used in tool caches,
+// e.g. "x64" for amd64.
+func actionsArch(goarch string) string {
+	switch goarch {
+	case "amd64":
+		return "x64"
+	case "386":
+		return "ia32"
+	}
+	if _, ok := parseARM(goarch); ok {
+		return "arm"
+	}
+	return goarch
+}
+
+// appendFile appends text to the file at path, creating it if needed.
+func appendFile(path, text string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
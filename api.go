@@ -0,0 +1,307 @@
+package goreleases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API is an http.Handler serving a small JSON API for listing and resolving
+// releases, and fetching them into configured roots, for running a toolchain
+// service. Use http.StripPrefix to serve under a path. Requests:
+//
+//	GET /releases, optionally with query parameters all=true, os, arch and kind, see ListOptions.
+//	GET /resolve?spec=1.22, see Resolve.
+//	GET /roots, the installed and current versions of each root.
+//	POST /fetch, with a JSON APIFetch body, starts a job that installs a release in a root. Returns the APIJob.
+//	GET /jobs and /jobs/<id>, returns all recent jobs or a single job.
+//
+// Errors are returned as JSON object with field "error". The API does no
+// authentication or authorization, it should only be reachable by trusted
+// clients, e.g. through a reverse proxy.
+type API struct {
+	Client *Client // For listing, and for roots without client. If nil, a zero Client is used.
+
+	// Roots releases can be fetched into, by name as used in requests.
+	Roots map[string]*Manager
+
+	// Maximum number of finished jobs remembered. If zero, 100.
+	MaxJobs int
+
+	mu     sync.Mutex
+	jobs   []*APIJob
+	nextID int
+	locks  map[string]*sync.Mutex // Per root, for one fetch at a time.
+}
+
+// APIFetch is a request to fetch a release into a root.
+type APIFetch struct {
+	Root string `json:"root"`
+	Spec string `json:"spec"` // Version spec, see Resolve.
+	Os   string `json:"os"`   // Default runtime.GOOS.
+	Arch string `json:"arch"` // Default runtime.GOARCH.
+}
+
+// States of an APIJob.
+const (
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// APIJob is a fetch started through the API.
+type APIJob struct {
+	ID       int        `json:"id"`
+	Request  APIFetch   `json:"request"`
+	State    string     `json:"state"` // JobRunning, JobDone or JobFailed.
+	Error    string     `json:"error,omitempty"`
+	Version  string     `json:"version,omitempty"` // Resolved version.
+	Dir      string     `json:"dir,omitempty"`     // Of the install.
+	Action   string     `json:"action,omitempty"`  // InstallNone or InstallNew.
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"` // Nil while running.
+}
+
+// APIRoot describes a root in the API.
+type APIRoot struct {
+	Installed []string `json:"installed"`
+	Current   string   `json:"current,omitempty"`
+}
+
+func (a *API) client() *Client {
+	if a.Client != nil {
+		return a.Client
+	}
+	return &Client{}
+}
+
+func (a *API) manager(name string) (*Manager, bool) {
+	m, ok := a.Roots[name]
+	if !ok {
+		return nil, false
+	}
+	if m.Client == nil {
+		xm := *m
+		xm.Client = a.client()
+		m = &xm
+	}
+	return m, true
+}
+
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	p := r.URL.Path
+	if r.Method == "POST" && p == "/fetch" {
+		a.serveFetch(w, r)
+		return
+	} else if r.Method != "GET" && r.Method != "HEAD" {
+		apiError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	switch {
+	case p == "/releases":
+		opts := &ListOptions{All: q.Get("all") == "true", Os: q.Get("os"), Arch: q.Get("arch"), Kind: q.Get("kind")}
+		rels, err := a.client().ListFiltered(ctx, opts)
+		if err != nil {
+			apiServerError(w, "listing releases", err)
+			return
+		}
+		apiWrite(w, http.StatusOK, rels)
+	case p == "/resolve":
+		rels, err := a.client().ListAll(ctx)
+		if err != nil {
+			apiServerError(w, "listing releases", err)
+			return
+		}
+		rel, err := Resolve(rels, q.Get("spec"))
+		if err != nil {
+			apiError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		apiWrite(w, http.StatusOK, rel)
+	case p == "/roots":
+		roots := map[string]APIRoot{}
+		for name, m := range a.Roots {
+			installed, err := m.Installed()
+			if err != nil {
+				apiServerError(w, "listing installs", err)
+				return
+			}
+			current, err := m.Current()
+			if err != nil {
+				apiServerError(w, "reading current version", err)
+				return
+			}
+			if installed == nil {
+				installed = []string{}
+			}
+			roots[name] = APIRoot{installed, current}
+		}
+		apiWrite(w, http.StatusOK, roots)
+	case p == "/jobs":
+		a.mu.Lock()
+		l := make([]APIJob, len(a.jobs))
+		for i, j := range a.jobs {
+			l[i] = *j
+		}
+		a.mu.Unlock()
+		apiWrite(w, http.StatusOK, l)
+	case strings.HasPrefix(p, "/jobs/"):
+		id, err := strconv.Atoi(strings.TrimPrefix(p, "/jobs/"))
+		if err != nil {
+			apiError(w, http.StatusNotFound, "no such job")
+			return
+		}
+		a.mu.Lock()
+		var job *APIJob
+		for _, j := range a.jobs {
+			if j.ID == id {
+				xj := *j
+				job = &xj
+			}
+		}
+		a.mu.Unlock()
+		if job == nil {
+			apiError(w, http.StatusNotFound, "no such job")
+			return
+		}
+		apiWrite(w, http.StatusOK, job)
+	default:
+		apiError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (a *API) serveFetch(w http.ResponseWriter, r *http.Request) {
+	var req APIFetch
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiError(w, http.StatusBadRequest, fmt.Sprintf("parsing request: %v", err))
+		return
+	}
+	m, ok := a.manager(req.Root)
+	if !ok {
+		apiError(w, http.StatusBadRequest, fmt.Sprintf("unknown root %q", req.Root))
+		return
+	}
+	if req.Spec == "" || req.Spec == "tip" || strings.HasPrefix(req.Spec, "tip@") {
+		apiError(w, http.StatusBadRequest, "missing or unsupported spec")
+		return
+	}
+	if req.Os == "" {
+		req.Os = runtime.GOOS
+	}
+	if req.Arch == "" {
+		req.Arch = runtime.GOARCH
+	}
+
+	a.mu.Lock()
+	a.nextID++
+	job := &APIJob{ID: a.nextID, Request: req, State: JobRunning, Started: time.Now()}
+	a.jobs = append(a.jobs, job)
+	a.trimJobs()
+	xjob := *job
+	a.mu.Unlock()
+
+	// The job continues after the request is done.
+	go a.run(context.Background(), m, job)
+	apiWrite(w, http.StatusAccepted, xjob)
+}
+
+// trimJobs removes the oldest finished jobs beyond MaxJobs. Must be called with
+// a.mu held.
+func (a *API) trimJobs() {
+	max := a.MaxJobs
+	if max <= 0 {
+		max = 100
+	}
+	var finished int
+	for _, j := range a.jobs {
+		if j.State != JobRunning {
+			finished++
+		}
+	}
+	var l []*APIJob
+	for _, j := range a.jobs {
+		if finished > max && j.State != JobRunning {
+			finished--
+			continue
+		}
+		l = append(l, j)
+	}
+	a.jobs = l
+}
+
+func (a *API) run(ctx context.Context, m *Manager, job *APIJob) {
+	a.mu.Lock()
+	if a.locks == nil {
+		a.locks = map[string]*sync.Mutex{}
+	}
+	l, ok := a.locks[job.Request.Root]
+	if !ok {
+		l = &sync.Mutex{}
+		a.locks[job.Request.Root] = l
+	}
+	a.mu.Unlock()
+
+	l.Lock()
+	version, dir, action, err := a.fetch(ctx, m, job.Request)
+	l.Unlock()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	job.Version, job.Dir, job.Action = version, dir, action
+	now := time.Now()
+	job.Finished = &now
+	if err != nil {
+		job.State = JobFailed
+		job.Error = err.Error()
+	} else {
+		job.State = JobDone
+	}
+	a.trimJobs()
+}
+
+func (a *API) fetch(ctx context.Context, m *Manager, req APIFetch) (version, dir, action string, err error) {
+	rels, err := m.client().ListAll(ctx)
+	if err != nil {
+		return "", "", "", err
+	}
+	rel, err := Resolve(rels, req.Spec)
+	if err != nil {
+		return "", "", "", err
+	}
+	file, err := FindFile(rel, req.Os, req.Arch, KindArchive)
+	if err != nil {
+		return rel.Version, "", "", fmt.Errorf("finding file for %s/%s in %s: %v", req.Os, req.Arch, rel.Version, err)
+	}
+	if SDKInstalled(m.Root, rel.Version) {
+		return rel.Version, m.Path(rel.Version), InstallNone, nil
+	}
+	dir, err = m.Install(ctx, file)
+	return rel.Version, dir, InstallNew, err
+}
+
+func apiWrite(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	enc.Encode(v)
+}
+
+func apiError(w http.ResponseWriter, status int, msg string) {
+	apiWrite(w, status, map[string]string{"error": msg})
+}
+
+func apiServerError(w http.ResponseWriter, msg string, err error) {
+	log.Printf("goreleases api: %s: %v", msg, err)
+	apiError(w, http.StatusInternalServerError, msg)
+}
@@ -0,0 +1,81 @@
+package goreleases
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI(t *testing.T) {
+	src := newTestSource(t, "go1.22.3")
+	root := t.TempDir()
+	api := &API{Client: &Client{Source: src}, Roots: map[string]*Manager{"default": {Root: root}}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	do := func(method, path, body string, status int, v interface{}) {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("%s %s: got status %d, expected %d", method, path, resp.StatusCode, status)
+		}
+		if v != nil {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatalf("%s %s: parsing response: %v", method, path, err)
+			}
+		}
+	}
+
+	var rels []Release
+	do("GET", "/releases?os=linux", "", http.StatusOK, &rels)
+	if len(rels) != 1 || rels[0].Version != "go1.22.3" {
+		t.Fatalf("got releases %v", rels)
+	}
+	var rel Release
+	do("GET", "/resolve?spec=1.22", "", http.StatusOK, &rel)
+	if rel.Version != "go1.22.3" {
+		t.Fatalf("resolved %v", rel)
+	}
+	do("GET", "/resolve?spec=1.21", "", http.StatusNotFound, nil)
+	do("POST", "/fetch", `{"root": "other", "spec": "1.22"}`, http.StatusBadRequest, nil)
+
+	var job APIJob
+	do("POST", "/fetch", `{"root": "default", "spec": "1.22", "os": "linux", "arch": "amd64"}`, http.StatusAccepted, &job)
+	if job.State != JobRunning || job.Finished != nil {
+		t.Fatalf("got new job %#v, expected running without finished time", job)
+	}
+	for i := 0; job.State == JobRunning; i++ {
+		if i == 100 {
+			t.Fatalf("job did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+		do("GET", fmt.Sprintf("/jobs/%d", job.ID), "", http.StatusOK, &job)
+	}
+	if job.State != JobDone || job.Version != "go1.22.3" || job.Action != InstallNew || job.Finished == nil || job.Finished.Before(job.Started) {
+		t.Fatalf("got job %#v", job)
+	}
+
+	var roots map[string]APIRoot
+	do("GET", "/roots", "", http.StatusOK, &roots)
+	if r := roots["default"]; len(r.Installed) != 1 || r.Installed[0] != "go1.22.3" {
+		t.Fatalf("got roots %v", roots)
+	}
+	var jobs []APIJob
+	do("GET", "/jobs", "", http.StatusOK, &jobs)
+	if len(jobs) != 1 {
+		t.Fatalf("got jobs %v", jobs)
+	}
+	do("GET", "/jobs/123", "", http.StatusNotFound, nil)
+}
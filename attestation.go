@@ -0,0 +1,211 @@
+package goreleases
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Types of install manifest attestations: An in-toto statement, with the
+// files of the install as subjects, in a DSSE envelope signed with ed25519.
+const (
+	InTotoPayloadType     = "application/vnd.in-toto+json"
+	InTotoStatementType   = "https://in-toto.io/Statement/v1"
+	ManifestPredicateType = "https://github.com/mjl-/goreleases/install-manifest/v1"
+)
+
+// ManifestPredicate is the predicate of an install manifest attestation,
+// describing the install and the release file it was extracted from.
+type ManifestPredicate struct {
+	Version    string          `json:"version"`
+	Filename   string          `json:"filename"`      // Of the release file.
+	Sha256     string          `json:"sha256"`        // Of the release file.
+	URL        string          `json:"url,omitempty"` // Download URL, from the OriginFile if present.
+	Entries    []ManifestEntry `json:"entries"`
+	Provenance *Provenance     `json:"provenance,omitempty"`
+}
+
+// ErrAttestationSignature is returned by VerifyManifestAttestation if no
+// signature of the attestation is valid for the key.
+var ErrAttestationSignature = errors.New("bad attestation signature")
+
+type inTotoStatement struct {
+	Type          string            `json:"_type"`
+	Subject       []inTotoSubject   `json:"subject"`
+	PredicateType string            `json:"predicateType"`
+	Predicate     ManifestPredicate `json:"predicate"`
+}
+
+type inTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type dsseEnvelope struct {
+	PayloadType string          `json:"payloadType"`
+	Payload     string          `json:"payload"` // Base64.
+	Signatures  []dsseSignature `json:"signatures"`
+}
+
+type dsseSignature struct {
+	KeyID string `json:"keyid,omitempty"`
+	Sig   string `json:"sig"` // Base64.
+}
+
+// dssePAE returns the pre-authentication encoding of a DSSE payload, the
+// data that is signed.
+func dssePAE(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// attestationKeyID returns the key ID for pub in signatures, the first 8 bytes
+// of its sha256 in hex.
+func attestationKeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// ExportManifestAttestation writes an attestation of the install in directory
+// dir, as created by FetchSDK, Manager or Install, signed with key, to w. The
+// install is first checked against its manifest, including file checksums. The
+// attestation is a DSSE envelope with an in-toto statement, its subjects are
+// the files of the install with their sha256, its predicate a
+// ManifestPredicate of type ManifestPredicateType.
+func ExportManifestAttestation(w io.Writer, dir string, key ed25519.PrivateKey) error {
+	if len(key) != ed25519.PrivateKeySize {
+		return fmt.Errorf("bad ed25519 private key")
+	}
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return fmt.Errorf("reading manifest: %v", err)
+	}
+	if err := checkManifestEntries(dir, m.Entries); err != nil {
+		return err
+	}
+
+	st := inTotoStatement{
+		Type:          InTotoStatementType,
+		PredicateType: ManifestPredicateType,
+		Predicate: ManifestPredicate{
+			Version:    m.Version,
+			Filename:   m.Filename,
+			Sha256:     m.Sha256,
+			Entries:    m.Entries,
+			Provenance: m.Provenance,
+		},
+	}
+	if o, err := ReadOrigin(dir); err == nil && o.Sha256 == m.Sha256 {
+		st.Predicate.URL = o.URL
+	}
+	for _, e := range m.Entries {
+		if e.Type == EntryFile {
+			st.Subject = append(st.Subject, inTotoSubject{e.Name, map[string]string{"sha256": e.Sha256}})
+		}
+	}
+	sort.Slice(st.Subject, func(i, j int) bool {
+		return st.Subject[i].Name < st.Subject[j].Name
+	})
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	sig := ed25519.Sign(key, dssePAE(InTotoPayloadType, payload))
+	env := dsseEnvelope{
+		PayloadType: InTotoPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []dsseSignature{{attestationKeyID(key.Public().(ed25519.PublicKey)), base64.StdEncoding.EncodeToString(sig)}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(env)
+}
+
+// VerifyManifestAttestation reads an attestation written by
+// ExportManifestAttestation from r, verifies its signature with pub, and
+// returns its predicate. Use CheckInstall to verify an install against it.
+func VerifyManifestAttestation(r io.Reader, pub ed25519.PublicKey) (*ManifestPredicate, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("bad ed25519 public key")
+	}
+	var env dsseEnvelope
+	if err := json.NewDecoder(io.LimitReader(r, 64<<20)).Decode(&env); err != nil {
+		return nil, fmt.Errorf("parsing attestation: %v", err)
+	}
+	if env.PayloadType != InTotoPayloadType {
+		return nil, fmt.Errorf("attestation has payload type %q, expected %q", env.PayloadType, InTotoPayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decoding attestation payload: %v", err)
+	}
+	pae := dssePAE(env.PayloadType, payload)
+	var ok bool
+	for _, s := range env.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err == nil && ed25519.Verify(pub, pae, sig) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return nil, ErrAttestationSignature
+	}
+
+	var st inTotoStatement
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return nil, fmt.Errorf("parsing attestation statement: %v", err)
+	}
+	if st.Type != InTotoStatementType || st.PredicateType != ManifestPredicateType {
+		return nil, fmt.Errorf("statement of type %q with predicate %q, expected %q with %q", st.Type, st.PredicateType, InTotoStatementType, ManifestPredicateType)
+	}
+	// The subjects must be the files of the predicate.
+	files := map[string]string{}
+	for _, e := range st.Predicate.Entries {
+		if e.Type == EntryFile {
+			files[e.Name] = e.Sha256
+		}
+	}
+	if len(st.Subject) != len(files) {
+		return nil, fmt.Errorf("attestation has %d subjects for %d files", len(st.Subject), len(files))
+	}
+	for _, s := range st.Subject {
+		if sum, ok := files[s.Name]; !ok || s.Digest["sha256"] != sum {
+			return nil, fmt.Errorf("attestation subject %s does not match predicate", s.Name)
+		}
+	}
+	return &st.Predicate, nil
+}
+
+// CheckInstall checks that the install in directory dir has the entries of the
+// attestation, including file checksums. Entries not in the attestation, like
+// the manifest itself, are ignored.
+func (p *ManifestPredicate) CheckInstall(dir string) error {
+	return checkManifestEntries(dir, p.Entries)
+}
+
+// checkManifestEntries returns an error naming the entries that are missing or
+// differ in dir.
+func checkManifestEntries(dir string, entries []ManifestEntry) error {
+	damaged, err := damagedEntries(dir, &Manifest{Entries: entries})
+	if err != nil {
+		return err
+	}
+	if len(damaged) == 0 {
+		return nil
+	}
+	var names []string
+	for i, e := range damaged {
+		if i == 5 {
+			names = append(names, fmt.Sprintf("and %d more", len(damaged)-i))
+			break
+		}
+		names = append(names, e.Name)
+	}
+	return fmt.Errorf("install does not match manifest: %s", strings.Join(names, ", "))
+}
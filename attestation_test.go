@@ -0,0 +1,64 @@
+package goreleases
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifestAttestation(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/go": "binary"})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}}
+	dir := filepath.Join(t.TempDir(), "go1.22.3")
+	if err := c.install(context.Background(), file, dir, nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	var b bytes.Buffer
+	if err := ExportManifestAttestation(&b, dir, key); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	p, err := VerifyManifestAttestation(bytes.NewReader(b.Bytes()), pub)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if p.Version != "go1.22.3" || p.Sha256 != file.Sha256 || len(p.Entries) != 3 {
+		t.Fatalf("predicate %#v", p)
+	}
+	if err := p.CheckInstall(dir); err != nil {
+		t.Fatalf("check install: %v", err)
+	}
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	if _, err := VerifyManifestAttestation(bytes.NewReader(b.Bytes()), other); err != ErrAttestationSignature {
+		t.Fatalf("verify with other key: %v", err)
+	}
+	var env dsseEnvelope
+	if err := json.Unmarshal(b.Bytes(), &env); err != nil {
+		t.Fatalf("parsing envelope: %v", err)
+	}
+	env.Payload = env.Payload[1:]
+	buf, _ := json.Marshal(env)
+	if _, err := VerifyManifestAttestation(bytes.NewReader(buf), pub); err == nil {
+		t.Fatalf("verify of modified payload succeeded")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "bin", "go"), []byte("BINARY"), 0755); err != nil {
+		t.Fatalf("corrupting: %v", err)
+	}
+	if err := p.CheckInstall(dir); err == nil {
+		t.Fatalf("check of modified install succeeded")
+	}
+	if err := ExportManifestAttestation(&b, dir, key); err == nil {
+		t.Fatalf("export of modified install succeeded")
+	}
+}
@@ -0,0 +1,121 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// GoogleSigner is the subject common name of the certificate signing Go
+// releases for Windows, for Client.AuthenticodeSigner.
+const GoogleSigner = "Google LLC"
+
+// AuthenticodeError is returned for a Windows executable or installer without
+// a valid Authenticode signature by the expected signer, see
+// Client.AuthenticodeSigner.
+type AuthenticodeError struct {
+	Path   string
+	Signer string // Expected signer.
+	Output string // Of the verification command.
+	Err    error  // From running the verification command, if it failed.
+}
+
+func (e *AuthenticodeError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("authenticode signature of %s: %v: %s", e.Path, e.Err, e.Output)
+	}
+	return fmt.Sprintf("authenticode signature of %s not by %q: %s", e.Path, e.Signer, e.Output)
+}
+
+func (e *AuthenticodeError) Unwrap() error {
+	return e.Err
+}
+
+// authenticodeCommand returns the command verifying the Authenticode signature
+// of path, failing if it is not valid, and printing the subject of the signer:
+// Get-AuthenticodeSignature of PowerShell on Windows, osslsigncode elsewhere.
+var authenticodeCommand = func(ctx context.Context, path string) (*exec.Cmd, error) {
+	if runtime.GOOS == "windows" {
+		script := `$s = Get-AuthenticodeSignature -LiteralPath $env:GORELEASES_PATH; if ($s.Status -ne 'Valid') { Write-Output $s.StatusMessage; exit 1 }; Write-Output $s.SignerCertificate.Subject`
+		cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+		cmd.Env = append(cmd.Environ(), "GORELEASES_PATH="+path)
+		return cmd, nil
+	}
+	if _, err := exec.LookPath("osslsigncode"); err != nil {
+		return nil, fmt.Errorf("verifying authenticode signatures requires osslsigncode: %v", err)
+	}
+	return exec.CommandContext(ctx, "osslsigncode", "verify", "-in", path), nil
+}
+
+// verifyAuthenticode checks the Authenticode signature of the file at path is
+// valid and by signer.
+func verifyAuthenticode(ctx context.Context, path, signer string) error {
+	cmd, err := authenticodeCommand(ctx, path)
+	if err != nil {
+		return err
+	}
+	buf, err := cmd.CombinedOutput()
+	out := strings.TrimSpace(string(buf))
+	if err != nil {
+		return &AuthenticodeError{path, signer, out, err}
+	}
+	if !hasCommonName(signerSubject(out), signer) {
+		return &AuthenticodeError{path, signer, out, nil}
+	}
+	return nil
+}
+
+// signerSubject returns the subject of the signer certificate from the output
+// of the verification command. PowerShell prints only the subject. For
+// osslsigncode, it is the first "Subject:" line after "Signer's certificate:",
+// the issuer and other certificates in the chain are skipped.
+func signerSubject(output string) string {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "Signer's certificate") {
+			continue
+		}
+		for _, line := range lines[i+1:] {
+			if s := strings.TrimSpace(line); strings.HasPrefix(s, "Subject:") {
+				return strings.TrimSpace(strings.TrimPrefix(s, "Subject:"))
+			}
+		}
+		return ""
+	}
+	if len(lines) == 1 {
+		return strings.TrimSpace(lines[0])
+	}
+	return ""
+}
+
+// hasCommonName returns whether subject, like "CN=Google LLC, O=Google LLC" of
+// PowerShell or "/O=Google LLC/CN=Google LLC" of osslsigncode, has common name
+// cn.
+func hasCommonName(subject, cn string) bool {
+	for _, s := range strings.FieldsFunc(subject, func(r rune) bool { return r == ',' || r == '/' }) {
+		if strings.TrimSpace(s) == "CN="+cn {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyAuthenticodeTree checks the Authenticode signatures of the .exe files
+// in bin and pkg/tool/*/ of goroot.
+func verifyAuthenticodeTree(ctx context.Context, goroot, signer string) error {
+	bins, err := installExecutables(goroot)
+	if err != nil {
+		return err
+	}
+	for _, p := range bins {
+		if strings.HasSuffix(filepath.Base(p), ".exe") {
+			if err := verifyAuthenticode(ctx, p, signer); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
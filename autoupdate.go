@@ -0,0 +1,150 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// AutoUpdater keeps the latest release of a version spec installed and active
+// in a Manager root, e.g. the latest patch release of go1.22.
+type AutoUpdater struct {
+	Manager *Manager
+
+	// Version spec to follow, e.g. "1.22" for the latest patch release of the
+	// minor, or "latest", see Resolve.
+	Spec string
+
+	// Platform of the installs, default runtime.GOOS and runtime.GOARCH.
+	Os   string
+	Arch string
+
+	// Time between checks in Run, randomly lengthened or shortened by up to 10%.
+	// If zero, one hour.
+	Interval time.Duration
+
+	// If positive, older installs of the minor of the active version are
+	// removed once the active version has been active for Grace, e.g. to let
+	// running builds finish. If zero, older installs are kept.
+	Grace time.Duration
+
+	// If not nil, called by Run with errors from checks. Checking continues after
+	// errors.
+	Errors func(err error)
+
+	// If not nil, called after a version was installed and made active.
+	Updated func(version string)
+}
+
+func (u *AutoUpdater) platform() (string, string) {
+	goos, goarch := u.Os, u.Arch
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	return goos, goarch
+}
+
+// Check resolves the spec once, installs the release if needed, and makes it
+// the active version. Older installs past the grace period are removed. The
+// active version is returned.
+func (u *AutoUpdater) Check(ctx context.Context) (string, error) {
+	m := u.Manager
+	rels, err := m.client().ListAll(ctx)
+	if err != nil {
+		return "", err
+	}
+	rel, err := Resolve(rels, u.Spec)
+	if err != nil {
+		return "", err
+	}
+	current, err := m.Current()
+	if err != nil {
+		return "", err
+	}
+	if current != rel.Version {
+		if !SDKInstalled(m.Root, rel.Version) {
+			goos, goarch := u.platform()
+			file, err := FindFile(rel, goos, goarch, KindArchive)
+			if err != nil {
+				return current, fmt.Errorf("finding file for %s/%s in %s: %v", goos, goarch, rel.Version, err)
+			}
+			if _, err := m.Install(ctx, file); err != nil {
+				return current, fmt.Errorf("installing %s: %v", rel.Version, err)
+			}
+		}
+		if err := m.Use(rel.Version); err != nil {
+			return current, fmt.Errorf("activating %s: %v", rel.Version, err)
+		}
+		current = rel.Version
+		if u.Updated != nil {
+			u.Updated(current)
+		}
+	}
+	if u.Grace > 0 {
+		if err := u.removeOld(current); err != nil {
+			return current, fmt.Errorf("removing old installs: %v", err)
+		}
+	}
+	return current, nil
+}
+
+// removeOld removes installs older than current with the same minor, if
+// current has been active for the grace period, as indicated by the
+// modification time of CurrentFile, or the current link for older roots.
+func (u *AutoUpdater) removeOld(current string) error {
+	m := u.Manager
+	fi, err := os.Stat(filepath.Join(m.Root, CurrentFile))
+	if os.IsNotExist(err) {
+		fi, err = os.Lstat(filepath.Join(m.Root, CurrentLink))
+	}
+	if err != nil || time.Since(fi.ModTime()) < u.Grace {
+		return nil
+	}
+	cv, err := ParseVersion(current)
+	if err != nil {
+		return nil
+	}
+	installed, err := m.Installed()
+	if err != nil {
+		return err
+	}
+	for _, v := range installed {
+		iv, err := ParseVersion(v)
+		if err != nil || !iv.SameMinor(cv) || iv.Compare(cv) >= 0 {
+			continue
+		}
+		if err := m.Remove(v); err != nil {
+			return fmt.Errorf("%s: %v", v, err)
+		}
+	}
+	return nil
+}
+
+// Run calls Check periodically until ctx is done, and returns ctx.Err().
+func (u *AutoUpdater) Run(ctx context.Context) error {
+	interval := u.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	for {
+		if _, err := u.Check(ctx); err != nil && ctx.Err() == nil && u.Errors != nil {
+			u.Errors(err)
+		}
+
+		d := interval + time.Duration((rand.Float64()*0.2-0.1)*float64(interval))
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package goreleases
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAutoUpdater(t *testing.T) {
+	src := newTestSource(t, "go1.22.2")
+	m := &Manager{Root: t.TempDir(), Client: &Client{Source: src}}
+	var updated []string
+	u := &AutoUpdater{Manager: m, Spec: "1.22", Os: "linux", Arch: "amd64", Grace: time.Hour, Updated: func(v string) { updated = append(updated, v) }}
+	ctx := context.Background()
+
+	check := func(expect string, installed int) {
+		t.Helper()
+		v, err := u.Check(ctx)
+		if err != nil || v != expect {
+			t.Fatalf("check: got %q %v, expected %q", v, err, expect)
+		}
+		if cur, err := m.Current(); err != nil || cur != expect {
+			t.Fatalf("current: got %q %v, expected %q", cur, err, expect)
+		}
+		if l, err := m.Installed(); err != nil || len(l) != installed {
+			t.Fatalf("installed: got %v %v, expected %d", l, err, installed)
+		}
+	}
+	check("go1.22.2", 1)
+	check("go1.22.2", 1)
+	src.addRelease(t, "go1.22.3")
+	check("go1.22.3", 2) // Old version kept during grace period.
+	u.Grace = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	check("go1.22.3", 1)
+	if len(updated) != 2 {
+		t.Fatalf("updated called for %v", updated)
+	}
+}
+
+func TestAutoUpdaterShims(t *testing.T) {
+	src := newTestSource(t, "go1.22.3")
+	m := &Manager{Root: t.TempDir(), Client: &Client{Source: src}, Shims: true}
+	// Make creating the symlink fail, as on systems without symlinks.
+	if err := os.MkdirAll(filepath.Join(m.Root, "."+CurrentLink+"-new", "x"), 0777); err != nil {
+		t.Fatalf("mkdir: %s", err)
+	}
+	var updated int
+	u := &AutoUpdater{Manager: m, Spec: "1.22", Os: "linux", Arch: "amd64", Updated: func(v string) { updated++ }}
+	for i := 0; i < 3; i++ {
+		if v, err := u.Check(context.Background()); err != nil || v != "go1.22.3" {
+			t.Fatalf("check: got %q %v", v, err)
+		}
+	}
+	if updated != 1 {
+		t.Fatalf("updated %d times, expected once", updated)
+	}
+}
+
+func TestAutoUpdaterService(t *testing.T) {
+	c := &Client{BaseURL: "https://mirror.example/", Offline: true}
+	u := &AutoUpdater{Manager: &Manager{Root: "/opt/go sdk", Client: c}, Spec: "1.22", Interval: 10 * time.Minute}
+	s := u.Service("/usr/bin/goreleases")
+	units := s.SystemdUnits()
+	service := units["goreleases-autoupdate.service"]
+	for _, exp := range []string{
+		"Environment=GORELEASES_BASE_URL=https://mirror.example/\n",
+		"Environment=GORELEASES_OFFLINE=true\n",
+		`ExecStart=/usr/bin/goreleases autoupdate -root "/opt/go sdk" -once 1.22` + "\n",
+	} {
+		if !strings.Contains(service, exp) {
+			t.Fatalf("service unit %q does not contain %q", service, exp)
+		}
+	}
+	if timer := units["goreleases-autoupdate.timer"]; !strings.Contains(timer, "OnUnitActiveSec=600s\n") {
+		t.Fatalf("timer unit %q", timer)
+	}
+
+	config, err := s.WinSWConfig()
+	if err != nil {
+		t.Fatalf("winsw config: %v", err)
+	}
+	exp := `<arguments>autoupdate -root &#34;/opt/go sdk&#34; -interval 10m0s 1.22</arguments>`
+	if !strings.Contains(config, exp) || !strings.Contains(config, `<env name="GORELEASES_OFFLINE" value="true"></env>`) {
+		t.Fatalf("winsw config %q", config)
+	}
+
+	c.Exclude = MinimalExclude
+	if s := u.Service("/usr/bin/goreleases"); !reflect.DeepEqual(s.Command[:3], []string{"/usr/bin/goreleases", "-minimal", "autoupdate"}) {
+		t.Fatalf("command with minimal exclude %q", s.Command)
+	}
+
+	for s, exp := range map[string]string{"a": "a", "a b": `"a b"`, `C:\a b\`: `"C:\a b\\"`, `a"b`: `"a\"b"`} {
+		if q := windowsQuote(s); q != exp {
+			t.Fatalf("windowsQuote(%q) = %s, expected %s", s, q, exp)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package goreleases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// BlobStore is an object store, e.g. an S3, GCS or Azure bucket, that a mirror
+// can be uploaded to with MirrorBlobs. Implementations are typically small
+// wrappers around the SDK of the storage provider. Names are relative to the
+// root of the mirror, e.g. "index.html" or "go1.22.3.linux-amd64.tar.gz".
+type BlobStore interface {
+	// Get returns the contents of the named object. If it does not exist, an
+	// error for which os.IsNotExist returns true must be returned.
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+
+	// Put stores the object, replacing any existing object. Size is the
+	// number of bytes r will return.
+	Put(ctx context.Context, name string, r io.Reader, size int64) error
+}
+
+// MirrorBlobs is like Mirror, but uploads the files of releases, their .asc
+// signatures (if available) and .sha256 checksums, and the merged listing in
+// MirrorIndex to store. Files whose .sha256 object in store already has the
+// expected checksum are not uploaded again. Files are verified before upload.
+// The listing is uploaded last, so the mirror never lists files that are not
+// yet present. Serving the bucket as a static website makes it usable as
+// Client.BaseURL.
+func (c *Client) MirrorBlobs(ctx context.Context, store BlobStore, releases ...Release) error {
+	for _, rel := range releases {
+		for _, f := range rel.Files {
+			if err := c.mirrorBlob(ctx, store, f); err != nil {
+				return fmt.Errorf("mirroring %s: %v", f.Filename, err)
+			}
+		}
+	}
+
+	l := append([]Release{}, releases...)
+	if rc, err := store.Get(ctx, MirrorIndex); err == nil {
+		buf, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("reading mirror listing: %v", err)
+		}
+		old, err := parseReleases(buf)
+		if err != nil {
+			return fmt.Errorf("parsing mirror listing: %v", err)
+		}
+		have := map[string]bool{}
+		for _, rel := range releases {
+			have[rel.Version] = true
+		}
+		for _, rel := range old {
+			if !have[rel.Version] {
+				l = append(l, rel)
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("reading mirror listing: %v", err)
+	}
+	SortReleases(l)
+	buf, err := json.MarshalIndent(l, "", " ")
+	if err != nil {
+		return err
+	}
+	if err := store.Put(ctx, MirrorIndex, bytes.NewReader(buf), int64(len(buf))); err != nil {
+		return fmt.Errorf("storing mirror listing: %v", err)
+	}
+	return nil
+}
+
+func (c *Client) mirrorBlob(ctx context.Context, store BlobStore, file File) error {
+	// Names of objects for the listing, signatures and checksums could be
+	// overwritten by a file with that name.
+	if !validFilename(file.Filename) || file.Filename == MirrorIndex || strings.HasSuffix(file.Filename, ".asc") || strings.HasSuffix(file.Filename, ".sha256") {
+		return fmt.Errorf("bad filename %q", file.Filename)
+	}
+	if rc, err := store.Get(ctx, file.Filename+".sha256"); err == nil {
+		buf, err := io.ReadAll(io.LimitReader(rc, 1024))
+		rc.Close()
+		if err == nil && strings.TrimSpace(string(buf)) == file.Sha256 {
+			return nil
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := c.createTemp("goreleases-blob")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		name := f.Name()
+		f.Close()
+		os.Remove(name)
+	}()
+	sum, _, err := c.download(ctx, file, f)
+	if err != nil {
+		return err
+	}
+	if err := c.checkSha256(file, sum); err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := store.Put(ctx, file.Filename, f, fi.Size()); err != nil {
+		return err
+	}
+	sig, err := c.Signature(ctx, file)
+	if err != nil {
+		return err
+	}
+	if sig != nil {
+		if err := store.Put(ctx, file.Filename+".asc", bytes.NewReader(sig), int64(len(sig))); err != nil {
+			return err
+		}
+	}
+	// The checksum is stored last, it marks the file as complete.
+	return store.Put(ctx, file.Filename+".sha256", strings.NewReader(file.Sha256), int64(len(file.Sha256)))
+}
+
+// BlobOpener returns the BlobStore for a URL with a scheme like "s3", "gs" or
+// "azblob", with the mirror at the root of the store, e.g. the bucket and
+// path prefix in "s3://bucket/go/".
+type BlobOpener func(ctx context.Context, u *url.URL) (BlobStore, error)
+
+var blobOpeners = struct {
+	sync.Mutex
+	fns map[string]BlobOpener
+}{fns: map[string]BlobOpener{}}
+
+// RegisterBlobOpener registers an opener for URLs with scheme, e.g. "s3" with
+// a function wrapping the S3 SDK, for use with OpenBlobSource. No openers
+// are registered by default, this package does not depend on cloud SDKs.
+func RegisterBlobOpener(scheme string, fn BlobOpener) {
+	blobOpeners.Lock()
+	defer blobOpeners.Unlock()
+	blobOpeners.fns[scheme] = fn
+}
+
+// OpenBlobSource opens a mirror in object storage at rawURL, e.g.
+// "s3://bucket/go/", "gs://bucket/" or "azblob://container/go/", with the
+// BlobOpener registered for its scheme.
+func OpenBlobSource(ctx context.Context, rawURL string) (*BlobSource, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing url: %v", err)
+	}
+	blobOpeners.Lock()
+	fn := blobOpeners.fns[u.Scheme]
+	blobOpeners.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("no blob opener registered for scheme %q", u.Scheme)
+	}
+	store, err := fn(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", rawURL, err)
+	}
+	return &BlobSource{store}, nil
+}
+
+// BlobSource is a ReleaseSource for a mirror in a BlobStore, as uploaded by
+// MirrorBlobs, without an HTTP server in front. Like a static mirror, List
+// returns all releases in MirrorIndex, regardless of parameter all.
+//
+// Use as Client.Source to list and fetch with a Client.
+type BlobSource struct {
+	Store BlobStore
+}
+
+var _ SignatureSource = &BlobSource{}
+
+// List returns the releases in MirrorIndex.
+func (s *BlobSource) List(ctx context.Context, all bool) ([]Release, error) {
+	rc, err := s.Store.Get(ctx, MirrorIndex)
+	if err != nil {
+		return nil, fmt.Errorf("getting listing: %w", err)
+	}
+	defer rc.Close()
+	buf, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("reading listing: %v", err)
+	}
+	return parseReleases(buf)
+}
+
+// Open returns the object for file.
+func (s *BlobSource) Open(ctx context.Context, file File) (io.ReadCloser, error) {
+	return s.Store.Get(ctx, file.Filename)
+}
+
+// Signature returns the .asc object for file, or nil if absent.
+func (s *BlobSource) Signature(ctx context.Context, file File) ([]byte, error) {
+	rc, err := s.Store.Get(ctx, file.Filename+".asc")
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, 16*1024))
+}
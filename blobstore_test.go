@@ -0,0 +1,188 @@
+package goreleases
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mjl-/goreleases/goreleasestest"
+)
+
+type memBlobStore struct {
+	sync.Mutex
+	objects map[string][]byte
+	puts    int
+}
+
+func (s *memBlobStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	s.Lock()
+	defer s.Unlock()
+	buf, ok := s.objects[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(buf)), nil
+}
+
+func (s *memBlobStore) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.objects[name] = buf
+	s.puts++
+	return nil
+}
+
+func TestMirrorBlobs(t *testing.T) {
+	src := newTestSource(t, "go1.22.3")
+	rel := src.releases[0]
+	file := rel.Files[0]
+
+	store := &memBlobStore{objects: map[string][]byte{}}
+	c := Client{Source: src}
+	if err := c.MirrorBlobs(context.Background(), store, rel); err != nil {
+		t.Fatalf("mirror: %s", err)
+	}
+	if !bytes.Equal(store.objects[file.Filename], src.files[file.Filename]) || string(store.objects[file.Filename+".sha256"]) != file.Sha256 {
+		t.Fatalf("file not uploaded")
+	}
+	rels, err := parseReleases(store.objects[MirrorIndex])
+	if err != nil || len(rels) != 1 || rels[0].Version != "go1.22.3" {
+		t.Fatalf("unexpected listing: %v %v", rels, err)
+	}
+
+	// Second run only uploads the listing.
+	puts := store.puts
+	if err := c.MirrorBlobs(context.Background(), store, rel); err != nil {
+		t.Fatalf("mirror again: %s", err)
+	}
+	if store.puts != puts+1 {
+		t.Fatalf("got %d puts, expected 1", store.puts-puts)
+	}
+
+	for _, name := range []string{"", ".", "..", "sub/" + file.Filename, MirrorIndex, file.Filename + ".sha256", file.Filename + ".asc"} {
+		bad := rel
+		bad.Files = []File{file}
+		bad.Files[0].Filename = name
+		src.files[name] = src.files[file.Filename]
+		store := &memBlobStore{objects: map[string][]byte{}}
+		if err := c.MirrorBlobs(context.Background(), store, bad); err == nil || !strings.Contains(err.Error(), "bad filename") {
+			t.Fatalf("mirror of %q: got %v, expected bad filename", name, err)
+		}
+		if len(store.objects) != 0 {
+			t.Fatalf("mirror of %q stored objects", name)
+		}
+	}
+}
+
+func TestGenericRepo(t *testing.T) {
+	srv := goreleasestest.NewServer("go1.22.3")
+	defer srv.Close()
+
+	var mu sync.Mutex
+	files := map[string][]byte{}
+	repo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "ci" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/repository/go/")
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "GET":
+			buf, ok := files[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(buf)
+		case "PUT":
+			buf, _ := io.ReadAll(r.Body)
+			if sum := r.Header.Get("X-Checksum-Sha256"); sum != "" && sum != fmt.Sprintf("%x", sha256.Sum256(buf)) {
+				http.Error(w, "checksum mismatch", http.StatusConflict)
+				return
+			}
+			files[name] = buf
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer repo.Close()
+
+	up := Client{BaseURL: srv.BaseURL(), NoSignatures: true}
+	rels, err := up.ListSupported(context.Background())
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	gr := &GenericRepo{BaseURL: repo.URL + "/repository/go", Username: "ci", Password: "secret"}
+	if err := up.MirrorBlobs(context.Background(), gr, rels...); err != nil {
+		t.Fatalf("mirror to repository: %v", err)
+	}
+
+	c := Client{Source: gr}
+	rels, err = c.ListAll(context.Background())
+	if err != nil || len(rels) != 1 {
+		t.Fatalf("list from repository: %v %v", rels, err)
+	}
+	file, _ := rels[0].FindFile("linux", "amd64", KindArchive)
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch from repository: %v", err)
+	}
+
+	gr.Password = "wrong"
+	if _, err := c.ListAll(context.Background()); err == nil {
+		t.Fatalf("list with wrong password succeeded")
+	}
+	gr.Password = "secret"
+	if _, err := gr.Get(context.Background(), "absent"); !os.IsNotExist(err) {
+		t.Fatalf("get of absent file: %v", err)
+	}
+}
+
+func TestBlobSource(t *testing.T) {
+	src := newTestSource(t, "go1.22.3")
+	rel := src.releases[0]
+	store := &memBlobStore{objects: map[string][]byte{}}
+	c := Client{Source: src}
+	if err := c.MirrorBlobs(context.Background(), store, rel); err != nil {
+		t.Fatalf("mirror: %s", err)
+	}
+
+	RegisterBlobOpener("testmem", func(ctx context.Context, u *url.URL) (BlobStore, error) {
+		if u.Host != "bucket" || u.Path != "/go/" {
+			return nil, fmt.Errorf("unknown bucket")
+		}
+		return store, nil
+	})
+	bs, err := OpenBlobSource(context.Background(), "testmem://bucket/go/")
+	if err != nil {
+		t.Fatalf("open blob source: %v", err)
+	}
+	c = Client{Source: bs}
+	rels, err := c.ListAll(context.Background())
+	if err != nil || len(rels) != 1 {
+		t.Fatalf("list: %v %v", rels, err)
+	}
+	if err := c.Fetch(context.Background(), rels[0].Files[0], t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	if _, err := OpenBlobSource(context.Background(), "testmem://other/"); err == nil {
+		t.Fatalf("open of unknown bucket succeeded")
+	}
+	if _, err := OpenBlobSource(context.Background(), "s3://bucket/"); err == nil {
+		t.Fatalf("open without registered opener succeeded")
+	}
+}
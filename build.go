@@ -0,0 +1,207 @@
+package goreleases
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// BootstrapMinor returns the minor version of the Go release needed to build
+// Go version v from source, e.g. "1.20" for go1.22.3. Its latest patch
+// release satisfies the requirement. Since Go 1.22, the bootstrap toolchain
+// is at least two minors older, for even minors, bootstrapping from an even
+// minor. Go 1.20 and 1.21 need Go 1.17, Go 1.5 to 1.19 need Go 1.4.
+func BootstrapMinor(v Version) (string, error) {
+	switch {
+	case v.Major != 1 || v.Minor < 5:
+		return "", fmt.Errorf("no known bootstrap requirements for %s", v)
+	case v.Minor < 20:
+		return "1.4", nil
+	case v.Minor < 22:
+		return "1.17", nil
+	}
+	return fmt.Sprintf("1.%d", v.Minor-2-v.Minor%2), nil
+}
+
+// BuildFromSource fetches the source of release, and builds it with the Go
+// installation in bootstrap, for platforms without binary releases. If
+// bootstrap is empty, the required bootstrap release (see BootstrapMinor) is
+// fetched for the host into a temporary directory and used. The built tree is
+// placed in dst, which becomes a GOROOT, replacing an existing install only
+// after a successful build. A manifest is written, see Remove.
+//
+// The build is for the host platform, unless GOOS and GOARCH are set in the
+// environment.
+func (c *Client) BuildFromSource(ctx context.Context, release Release, bootstrap, dst string) error {
+	src, err := FindSource(release)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return err
+	}
+	tmpdir, err := os.MkdirTemp(filepath.Dir(dst), "."+filepath.Base(dst)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	if bootstrap == "" {
+		bootstrap = filepath.Join(tmpdir, "bootstrap")
+		if err := c.fetchBootstrap(ctx, release, bootstrap); err != nil {
+			return fmt.Errorf("fetching bootstrap toolchain: %v", err)
+		}
+	}
+	fm, err := c.fetch(ctx, src, tmpdir, nil)
+	if err != nil {
+		return err
+	}
+	goroot := filepath.Join(tmpdir, "go")
+	if err := makeGoroot(ctx, goroot, bootstrap); err != nil {
+		return err
+	}
+	m := &Manifest{Version: release.Version, Filename: src.Filename, Sha256: src.Sha256, Provenance: fm.Provenance}
+	if err := finishBuild(goroot, dst, tmpdir, m); err != nil {
+		return err
+	}
+	return c.runHooks(ctx, dst, src)
+}
+
+// fetchBootstrap installs the release needed to build release into dir.
+func (c *Client) fetchBootstrap(ctx context.Context, release Release, dir string) error {
+	v, err := ParseVersion(release.Version)
+	if err != nil {
+		return err
+	}
+	minor, err := BootstrapMinor(v)
+	if err != nil {
+		return err
+	}
+	rels, err := c.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+	rel, err := Resolve(rels, minor)
+	if err != nil {
+		return err
+	}
+	file, err := FindFile(rel, runtime.GOOS, runtime.GOARCH, KindArchive)
+	if err != nil {
+		return fmt.Errorf("no %s/%s file in %s: %v", runtime.GOOS, runtime.GOARCH, rel.Version, err)
+	}
+	return c.install(ctx, file, dir, nil)
+}
+
+// makeGoroot runs make.bash (make.bat on Windows) in goroot/src, with the Go
+// installation in bootstrap as GOROOT_BOOTSTRAP. If bootstrap is empty, the
+// GOROOT of the go command in PATH is used.
+func makeGoroot(ctx context.Context, goroot, bootstrap string) error {
+	if bootstrap == "" {
+		var err error
+		bootstrap, err = defaultBootstrap(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	script := "./make.bash"
+	if runtime.GOOS == "windows" {
+		script = `.\make.bat`
+	}
+	cmd := exec.CommandContext(ctx, script)
+	cmd.Dir = filepath.Join(goroot, "src")
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, "GOROOT=") && !strings.HasPrefix(e, "GOROOT_BOOTSTRAP=") && !strings.HasPrefix(e, "GOTOOLCHAIN=") {
+			cmd.Env = append(cmd.Env, e)
+		}
+	}
+	cmd.Env = append(cmd.Env, "GOROOT_BOOTSTRAP="+bootstrap, "GOTOOLCHAIN=local")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("building go: %v: %s", err, lastLines(out.String(), 10))
+	}
+	return nil
+}
+
+// finishBuild writes the manifest with all files in goroot and the
+// UnpackedMarker, and moves goroot to dir, replacing an existing dir by moving
+// it into tmpdir.
+func finishBuild(goroot, dir, tmpdir string, m *Manifest) error {
+	entries, err := walkManifest(goroot)
+	if err != nil {
+		return fmt.Errorf("listing built files: %v", err)
+	}
+	m.Entries = entries
+	if err := writeManifest(goroot, m); err != nil {
+		return fmt.Errorf("writing manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(goroot, UnpackedMarker), nil, 0666); err != nil {
+		return fmt.Errorf("writing marker: %v", err)
+	}
+	if _, err := os.Stat(dir); err == nil {
+		if err := os.Rename(dir, filepath.Join(tmpdir, "old")); err != nil {
+			return fmt.Errorf("moving old install away: %v", err)
+		}
+	}
+	if err := os.Rename(goroot, dir); err != nil {
+		return fmt.Errorf("moving into place: %v", err)
+	}
+	return nil
+}
+
+// defaultBootstrap returns the GOROOT of the go command in PATH.
+func defaultBootstrap(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "go", "env", "GOROOT").Output()
+	if err != nil {
+		return "", fmt.Errorf("finding bootstrap toolchain with go env GOROOT: %v", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func lastLines(s string, n int) string {
+	l := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(l) > n {
+		l = l[len(l)-n:]
+	}
+	return strings.Join(l, "\n")
+}
+
+// walkManifest returns manifest entries for all files in dir, for installs
+// built from source.
+func walkManifest(dir string) ([]ManifestEntry, error) {
+	var l []ManifestEntry
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || p == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		switch {
+		case fi.IsDir():
+			l = append(l, ManifestEntry{Name: name, Type: EntryDir})
+		case fi.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			l = append(l, ManifestEntry{Name: name, Type: EntrySymlink, Linkname: target})
+		default:
+			sum, err := fileSha256(p)
+			if err != nil {
+				return err
+			}
+			l = append(l, ManifestEntry{Name: name, Type: EntryFile, Size: fi.Size(), Sha256: sum})
+		}
+		return nil
+	})
+	return l, err
+}
@@ -0,0 +1,76 @@
+package goreleases
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestBootstrapMinor(t *testing.T) {
+	for v, exp := range map[string]string{
+		"go1.4":     "",
+		"go1.19.13": "1.4",
+		"go1.21.0":  "1.17",
+		"go1.22.3":  "1.20",
+		"go1.23rc1": "1.20",
+		"go1.24.0":  "1.22",
+		"go1.25.1":  "1.22",
+		"go1.26.0":  "1.24",
+	} {
+		got, err := BootstrapMinor(mustParseVersion(t, v))
+		if exp == "" && err == nil || exp != "" && (err != nil || got != exp) {
+			t.Errorf("bootstrap for %s: got %q, %v, expected %q", v, got, err, exp)
+		}
+	}
+}
+
+func mustParseVersion(t *testing.T, s string) Version {
+	t.Helper()
+	v, err := ParseVersion(s)
+	if err != nil {
+		t.Fatalf("parse version %s: %s", s, err)
+	}
+	return v
+}
+
+func TestBuildFromSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses a shell script as make.bash")
+	}
+	srcTgz := makeTgz(t, map[string]string{
+		"go/VERSION":       "go1.22.3\n",
+		"go/src/make.bash": "#!/bin/sh\nmkdir -p ../bin && cat \"$GOROOT_BOOTSTRAP/VERSION\" >../bin/go\n",
+	})
+	src := File{Filename: "go1.22.3.src.tar.gz", Version: "go1.22.3", Sha256: fmt.Sprintf("%x", sha256.Sum256(srcTgz)), Kind: KindSource}
+	bootTgz := makeTgz(t, map[string]string{"go/VERSION": "go1.20.14\n"})
+	boot := File{
+		Filename: "go1.20.14." + runtime.GOOS + "-" + runtime.GOARCH + ".tar.gz",
+		Os:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Version:  "go1.20.14",
+		Sha256:   fmt.Sprintf("%x", sha256.Sum256(bootTgz)),
+		Kind:     KindArchive,
+	}
+	rel := Release{Version: "go1.22.3", Stable: true, Files: []File{src}}
+	ms := &memSource{files: map[string][]byte{src.Filename: srcTgz, boot.Filename: bootTgz}}
+	ms.setReleases([]Release{rel, {Version: "go1.20.14", Stable: true, Files: []File{boot}}})
+	c := Client{Source: ms}
+
+	dst := filepath.Join(t.TempDir(), "go")
+	if err := c.BuildFromSource(context.Background(), rel, "", dst); err != nil {
+		t.Fatalf("build: %s", err)
+	}
+	if buf, err := os.ReadFile(filepath.Join(dst, "bin", "go")); err != nil || string(buf) != "go1.20.14\n" {
+		t.Fatalf("built with bootstrap %q, %v, expected go1.20.14", buf, err)
+	}
+	if m, err := ReadManifest(dst); err != nil || m.Version != "go1.22.3" || checkInstall(dst, m) != nil {
+		t.Fatalf("manifest: %v, %v", m, err)
+	}
+	if err := Remove(dst); err != nil {
+		t.Fatalf("remove: %s", err)
+	}
+}
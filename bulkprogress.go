@@ -0,0 +1,106 @@
+package goreleases
+
+import (
+	"sync"
+	"time"
+)
+
+// BulkProgress is the aggregate progress of a FetchAll or FetchMany, see
+// Client.BulkProgress.
+type BulkProgress struct {
+	// Bytes downloaded so far, and the total to download. The total is
+	// computed from the sizes in the listing before downloading starts, and
+	// lowered for files that turn out to be present or cached, or that fail.
+	Downloaded int64
+	Total      int64
+
+	Files      int // Files downloaded or installed.
+	FilesTotal int // Files to download or install.
+
+	Rate float64       // Bytes per second since the start.
+	ETA  time.Duration // Estimated time remaining, zero if unknown.
+}
+
+// bulkTracker aggregates progress of the files of a bulk operation.
+type bulkTracker struct {
+	mu    sync.Mutex
+	fn    func(BulkProgress)
+	start time.Time
+	p     BulkProgress
+	sizes map[string]int64 // Expected size of files to download, by filename.
+	done  map[string]int64 // Bytes downloaded, by filename.
+}
+
+// newBulkTracker returns a tracker for downloading files, calling fn.
+func newBulkTracker(fn func(BulkProgress), files []File) *bulkTracker {
+	t := &bulkTracker{fn: fn, start: time.Now(), sizes: map[string]int64{}, done: map[string]int64{}}
+	for _, f := range files {
+		if _, ok := t.sizes[f.Filename]; !ok {
+			t.sizes[f.Filename] = f.Size
+			t.p.Total += f.Size
+			t.p.FilesTotal++
+		}
+	}
+	return t
+}
+
+// client returns a copy of c that reports its download progress to t, and
+// still calls the Progress of c.
+func (t *bulkTracker) client(c *Client) *Client {
+	nc := *c
+	nc.Progress = func(file File, downloaded, total int64) {
+		if c.Progress != nil {
+			c.Progress(file, downloaded, total)
+		}
+		t.progress(file, downloaded)
+	}
+	return &nc
+}
+
+func (t *bulkTracker) progress(file File, downloaded int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.sizes[file.Filename]; !ok {
+		return
+	}
+	t.p.Downloaded += downloaded - t.done[file.Filename]
+	t.done[file.Filename] = downloaded
+	t.report()
+}
+
+// finish records the end of the download of file. If ok, the file is complete,
+// with fewer bytes downloaded than expected if it came from the archive cache.
+// Otherwise the file was not downloaded, because it was already present or it
+// failed.
+func (t *bulkTracker) finish(file File, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	size, tracked := t.sizes[file.Filename]
+	if !tracked {
+		return
+	}
+	delete(t.sizes, file.Filename)
+	n := t.done[file.Filename]
+	if ok {
+		t.p.Files++
+		t.p.Total += n - size
+	} else {
+		t.p.FilesTotal--
+		t.p.Total -= size
+		t.p.Downloaded -= n
+	}
+	t.report()
+}
+
+// report calls fn with the current progress, with lock held so calls are
+// serialized.
+func (t *bulkTracker) report() {
+	p := t.p
+	if elapsed := time.Since(t.start).Seconds(); elapsed > 0 {
+		p.Rate = float64(p.Downloaded) / elapsed
+	}
+	if p.Rate > 0 && p.Total > p.Downloaded {
+		p.ETA = time.Duration(float64(p.Total-p.Downloaded) / p.Rate * float64(time.Second))
+	}
+	t.fn(p)
+}
@@ -0,0 +1,259 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// A bundle is a tar file for moving releases to disconnected networks. It
+// contains the release files (with .asc signatures if available) under
+// "files/", and a "bundle.json" with the releases and the checksums of all
+// files in the bundle. The bundle.json is signed with ed25519, the signature
+// is in "bundle.json.sig". The bundle.json and its signature are at the end of
+// the bundle.
+
+type bundleIndex struct {
+	Created  time.Time
+	Releases []Release
+	Sha256   map[string]string // Filename (including .asc files) to hex sha256.
+}
+
+// ExportBundle writes a bundle with releases to w, signed with key. Files are
+// downloaded and verified first.
+func (c *Client) ExportBundle(ctx context.Context, w io.Writer, key ed25519.PrivateKey, releases ...Release) error {
+	if len(key) != ed25519.PrivateKeySize {
+		return fmt.Errorf("bad ed25519 private key")
+	}
+	tw := tar.NewWriter(w)
+	index := bundleIndex{time.Now(), releases, map[string]string{}}
+
+	add := func(name string, size int64, r io.Reader) error {
+		h := &tar.Header{Typeflag: tar.TypeReg, Name: name, Size: size, Mode: 0644, ModTime: index.Created}
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		_, err := io.Copy(tw, r)
+		return err
+	}
+
+	for _, rel := range releases {
+		for _, file := range rel.Files {
+			if err := c.exportBundleFile(ctx, file, index.Sha256, add); err != nil {
+				return fmt.Errorf("adding %s: %v", file.Filename, err)
+			}
+		}
+	}
+
+	buf, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	sig := ed25519.Sign(key, buf)
+	if err := add("bundle.json", int64(len(buf)), bytes.NewReader(buf)); err != nil {
+		return err
+	}
+	if err := add("bundle.json.sig", int64(len(sig)), bytes.NewReader(sig)); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func (c *Client) exportBundleFile(ctx context.Context, file File, sums map[string]string, add func(string, int64, io.Reader) error) error {
+	if file.Filename == "" || filepath.Base(file.Filename) != file.Filename {
+		return fmt.Errorf("bad filename")
+	}
+	f, err := c.createTemp("goreleases-bundle")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		name := f.Name()
+		f.Close()
+		os.Remove(name)
+	}()
+	sum, _, err := c.download(ctx, file, f)
+	if err != nil {
+		return err
+	}
+	if err := c.checkSha256(file, sum); err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := add("files/"+file.Filename, fi.Size(), f); err != nil {
+		return err
+	}
+	sums[file.Filename] = sum
+
+	sig, err := c.Signature(ctx, file)
+	if err != nil {
+		return err
+	}
+	if sig != nil {
+		if err := add("files/"+file.Filename+".asc", int64(len(sig)), bytes.NewReader(sig)); err != nil {
+			return err
+		}
+		sums[file.Filename+".asc"] = fmt.Sprintf("%x", sha256.Sum256(sig))
+	}
+	return nil
+}
+
+// ErrBundleSignature is returned by ImportBundle if the bundle signature is
+// missing or invalid.
+var ErrBundleSignature = errors.New("bad bundle signature")
+
+// ImportBundle reads a bundle from r, verifies its signature with pub, and
+// the checksums and pgp signatures of its files, and adds the releases to the
+// mirror in directory dst, see Mirror. The mirror can be used with DirSource.
+// Nothing is added to dst unless all verification passes. The imported
+// releases are returned.
+func ImportBundle(r io.Reader, pub ed25519.PublicKey, dst string) ([]Release, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("bad ed25519 public key")
+	}
+	if err := os.MkdirAll(dst, 0777); err != nil {
+		return nil, err
+	}
+	staging, err := os.MkdirTemp(dst, ".bundle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	var indexBuf, sig []byte
+	have := map[string]bool{}
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading bundle: %v", err)
+		}
+		switch {
+		case h.Name == "bundle.json":
+			indexBuf, err = io.ReadAll(io.LimitReader(tr, 64<<20))
+		case h.Name == "bundle.json.sig":
+			sig, err = io.ReadAll(io.LimitReader(tr, 1024))
+		case strings.HasPrefix(h.Name, "files/") && h.Typeflag == tar.TypeReg:
+			name := strings.TrimPrefix(h.Name, "files/")
+			if name == "" || filepath.Base(name) != name || strings.HasPrefix(name, ".") {
+				return nil, fmt.Errorf("bad file name %q in bundle", h.Name)
+			}
+			err = writeFileFrom(filepath.Join(staging, name), tr)
+			have[name] = true
+		default:
+			return nil, fmt.Errorf("unexpected entry %q in bundle", h.Name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s from bundle: %v", h.Name, err)
+		}
+	}
+	if indexBuf == nil || sig == nil || !ed25519.Verify(pub, indexBuf, sig) {
+		return nil, ErrBundleSignature
+	}
+	var index bundleIndex
+	if err := json.Unmarshal(indexBuf, &index); err != nil {
+		return nil, fmt.Errorf("parsing bundle index: %v", err)
+	}
+
+	// Verify all files against the signed index, and the listing.
+	for name := range have {
+		exp, ok := index.Sha256[name]
+		if !ok {
+			return nil, fmt.Errorf("file %s not in bundle index", name)
+		}
+		if sum, err := fileSha256(filepath.Join(staging, name)); err != nil {
+			return nil, err
+		} else if sum != exp {
+			return nil, fmt.Errorf("checksum mismatch for %s, got %s, expected %s", name, sum, exp)
+		}
+	}
+	for _, rel := range index.Releases {
+		for _, f := range rel.Files {
+			if !have[f.Filename] || index.Sha256[f.Filename] != f.Sha256 {
+				return nil, fmt.Errorf("file %s missing from bundle or with wrong checksum", f.Filename)
+			}
+			if have[f.Filename+".asc"] {
+				if err := verifyFileSignature(filepath.Join(staging, f.Filename)); err != nil {
+					return nil, fmt.Errorf("%s: %v", f.Filename, err)
+				}
+			}
+		}
+	}
+
+	// Move into place and update the listing.
+	for name := range have {
+		if err := os.Rename(filepath.Join(staging, name), filepath.Join(dst, name)); err != nil {
+			return nil, err
+		}
+	}
+	for _, rel := range index.Releases {
+		for _, f := range rel.Files {
+			if err := os.WriteFile(filepath.Join(dst, f.Filename+".sha256"), []byte(f.Sha256), 0666); err != nil {
+				return nil, err
+			}
+		}
+	}
+	l := append([]Release{}, index.Releases...)
+	if old, err := readMirrorIndex(dst); err == nil {
+		have := map[string]bool{}
+		for _, rel := range index.Releases {
+			have[rel.Version] = true
+		}
+		for _, rel := range old {
+			if !have[rel.Version] {
+				l = append(l, rel)
+			}
+		}
+	}
+	SortReleases(l)
+	if err := writeMirrorIndex(dst, l); err != nil {
+		return nil, err
+	}
+	return index.Releases, nil
+}
+
+func writeFileFrom(p string, r io.Reader) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// verifyFileSignature checks the pgp signature in p+".asc" for file p.
+func verifyFileSignature(p string) error {
+	sig, err := os.ReadFile(p + ".asc")
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := openpgp.CheckArmoredDetachedSignature(signingKey, f, bytes.NewReader(sig)); err != nil {
+		return fmt.Errorf("verifying pgp signature on go release: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package goreleases
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"strings"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	src := newTestSource(t, "go1.22.3")
+	rel := src.releases[0]
+
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generating key: %s", err)
+	}
+	var buf bytes.Buffer
+	c := Client{Source: src}
+	if err := c.ExportBundle(context.Background(), &buf, key, rel); err != nil {
+		t.Fatalf("export: %s", err)
+	}
+
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	if _, err := ImportBundle(bytes.NewReader(buf.Bytes()), otherPub, t.TempDir()); err != ErrBundleSignature {
+		t.Fatalf("import with wrong key: got %v, expected ErrBundleSignature", err)
+	}
+
+	// The release file is the first entry, corrupt the first byte of its data,
+	// after the tar header.
+	bad := append([]byte{}, buf.Bytes()...)
+	bad[512] ^= 1
+	if _, err := ImportBundle(bytes.NewReader(bad), pub, t.TempDir()); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("import of corrupted bundle: got %v, expected checksum mismatch", err)
+	}
+
+	dir := t.TempDir()
+	rels, err := ImportBundle(bytes.NewReader(buf.Bytes()), pub, dir)
+	if err != nil {
+		t.Fatalf("import: %s", err)
+	}
+	if len(rels) != 1 || rels[0].Version != "go1.22.3" {
+		t.Fatalf("unexpected imported releases: %v", rels)
+	}
+
+	mc := Client{Source: DirSource{dir}}
+	l, err := mc.ListAll(context.Background())
+	if err != nil || len(l) != 1 {
+		t.Fatalf("listing imported mirror: %v %v", l, err)
+	}
+	if err := mc.Fetch(context.Background(), l[0].Files[0], t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch from imported mirror: %s", err)
+	}
+}
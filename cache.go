@@ -0,0 +1,268 @@
+package goreleases
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// listCache is a cached release listing, as stored in the cache directory.
+type listCache struct {
+	Time         time.Time       // Time the listing was fetched or last revalidated.
+	ETag         string          // From response, for conditional requests.
+	LastModified string          // From response, for conditional requests.
+	Data         json.RawMessage // Listing JSON as returned by the server.
+}
+
+// listCacheName returns the name for a cached listing, with a hash of the url
+// to keep listings from different servers apart.
+func listCacheName(name, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return fmt.Sprintf("%s-%x", name, sum[:8])
+}
+
+func listCachePath(dir, name string) string {
+	return filepath.Join(dir, "list-"+name+".json")
+}
+
+func readListCache(dir, name string) (*listCache, error) {
+	buf, err := os.ReadFile(listCachePath(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	var lc listCache
+	if err := json.Unmarshal(buf, &lc); err != nil {
+		return nil, fmt.Errorf("parsing cached listing: %v", err)
+	}
+	return &lc, nil
+}
+
+// writeListCache stores lc, first writing to a temporary file that is renamed
+// into place so concurrent readers never see partial files.
+func writeListCache(dir, name string, lc *listCache) error {
+	buf, err := json.Marshal(lc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".list-"+name)
+	if err != nil {
+		return err
+	}
+	tmpname := f.Name()
+	defer func() {
+		if tmpname != "" {
+			os.Remove(tmpname)
+		}
+	}()
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpname, listCachePath(dir, name)); err != nil {
+		return err
+	}
+	tmpname = ""
+	return nil
+}
+
+// validSha256 returns whether sum is a lower case hex sha256, safe to use in
+// file names.
+func validSha256(sum string) bool {
+	return len(sum) == 64 && strings.Trim(sum, "0123456789abcdef") == ""
+}
+
+func archiveCachePath(dir, sum string) string {
+	return filepath.Join(dir, "sha256-"+sum)
+}
+
+// archiveCacheGet copies the cached file with checksum sum to f and rewinds f.
+// If there is no cached file, or its checksum does not match, false is
+// returned. Mismatching cache files are removed.
+func archiveCacheGet(dir, sum string, f *os.File) (bool, error) {
+	if !validSha256(sum) {
+		return false, nil
+	}
+	p := archiveCachePath(dir, sum)
+	cf, err := os.Open(p)
+	if err != nil {
+		return false, nil
+	}
+	defer cf.Close()
+	hr := &hashReader{cf, sha256.New()}
+	if _, err := io.Copy(f, hr); err != nil {
+		return false, fmt.Errorf("copying from archive cache: %v", err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return false, fmt.Errorf("rewinding file from archive cache: %v", err)
+	}
+	if fmt.Sprintf("%x", hr.h.Sum(nil)) != sum {
+		os.Remove(p)
+		if err := f.Truncate(0); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	// The modification time tracks last use, for CleanCache.
+	now := time.Now()
+	os.Chtimes(p, now, now)
+	return true, nil
+}
+
+// archiveCachePut stores a copy of f, which must have checksum sum, in the
+// archive cache.
+func archiveCachePut(dir, sum string, f *os.File) error {
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	tf, err := os.CreateTemp(dir, ".sha256-")
+	if err != nil {
+		return err
+	}
+	tmpname := tf.Name()
+	defer func() {
+		if tmpname != "" {
+			os.Remove(tmpname)
+		}
+	}()
+	if _, err := io.Copy(tf, f); err != nil {
+		tf.Close()
+		return err
+	}
+	if err := tf.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpname, archiveCachePath(dir, sum)); err != nil {
+		return err
+	}
+	tmpname = ""
+	return nil
+}
+
+// CacheCleanResult describes the archive cache after CleanCache.
+type CacheCleanResult struct {
+	Removed int   // Number of files removed.
+	Freed   int64 // Bytes of removed files.
+	Files   int   // Number of files remaining.
+	Size    int64 // Bytes of remaining files.
+}
+
+// CleanCache removes files from the archive cache in ArchiveCacheDir according
+// to ArchiveCacheMaxAge and ArchiveCacheMaxSize of the client. Files are
+// ordered by last use. Leftover temporary files older than a day are removed
+// as well, as are lock files of Client.SharedCache in ArchiveCacheDir and
+// CacheDir that are not in use and whose cached file is gone. Files that cannot
+// be removed, e.g. because they are in use, are skipped and the first error is
+// returned after cleaning.
+func (c *Client) CleanCache() (CacheCleanResult, error) {
+	var r CacheCleanResult
+	if c.CacheDir != "" {
+		removeStaleLocks(c.CacheDir, func(name string) string {
+			if s := strings.TrimPrefix(name, "list-"); s != name {
+				return listCachePath(c.CacheDir, s)
+			}
+			return ""
+		})
+	}
+	if c.ArchiveCacheDir == "" {
+		return r, nil
+	}
+	l, err := os.ReadDir(c.ArchiveCacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return r, err
+	}
+
+	type cacheFile struct {
+		path    string
+		size    int64
+		modtime time.Time
+	}
+	var files []cacheFile
+	var rerr error
+	remove := func(f cacheFile) bool {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			if rerr == nil {
+				rerr = err
+			}
+			return false
+		}
+		r.Removed++
+		r.Freed += f.size
+		return true
+	}
+	now := time.Now()
+	for _, e := range l {
+		fi, err := e.Info()
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		f := cacheFile{filepath.Join(c.ArchiveCacheDir, e.Name()), fi.Size(), fi.ModTime()}
+		switch {
+		case strings.HasPrefix(e.Name(), "sha256-"):
+			files = append(files, f)
+			r.Files++
+			r.Size += f.size
+		case strings.HasPrefix(e.Name(), ".sha256-") && now.Sub(f.modtime) > 24*time.Hour:
+			remove(f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modtime.Before(files[j].modtime)
+	})
+	for _, f := range files {
+		old := c.ArchiveCacheMaxAge > 0 && now.Sub(f.modtime) > c.ArchiveCacheMaxAge
+		large := c.ArchiveCacheMaxSize > 0 && r.Size > c.ArchiveCacheMaxSize
+		if !old && !large {
+			break
+		}
+		if remove(f) {
+			r.Files--
+			r.Size -= f.size
+		}
+	}
+	removeStaleLocks(c.ArchiveCacheDir, func(name string) string {
+		if strings.HasPrefix(name, "sha256-") {
+			return filepath.Join(c.ArchiveCacheDir, name)
+		}
+		return ""
+	})
+	return r, rerr
+}
+
+// removeStaleLocks removes the unlocked lock files in dir for which the cached
+// file, with path returned by cached for the name of the lock, does not exist.
+// Lock files for which cached returns the empty string are kept.
+func removeStaleLocks(dir string, cached func(name string) string) {
+	l, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range l {
+		name := strings.TrimPrefix(e.Name(), ".lock-")
+		if name == e.Name() || !e.Type().IsRegular() {
+			continue
+		}
+		if p := cached(name); p != "" {
+			if _, err := os.Stat(p); os.IsNotExist(err) {
+				removeLockFile(filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+}
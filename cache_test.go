@@ -0,0 +1,310 @@
+package goreleases
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mjl-/goreleases/goreleasestest"
+)
+
+func TestListCache(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte(`[{"version":"go1.22.3","stable":true,"files":[{"filename":"go1.22.3.src.tar.gz","kind":"source"}]}]`)
+	if err := writeListCache(dir, listCacheName("supported", DefaultBaseURL+"?mode=json"), &listCache{Time: time.Now(), Data: data}); err != nil {
+		t.Fatalf("writing cache: %s", err)
+	}
+
+	// A fresh cache is used without making requests.
+	c := Client{CacheDir: dir}
+	rels, err := c.ListSupported(context.Background())
+	if err != nil {
+		t.Fatalf("listing from cache: %s", err)
+	}
+	if len(rels) != 1 || rels[0].Version != "go1.22.3" {
+		t.Fatalf("unexpected releases from cache: %v", rels)
+	}
+
+	// Offline mode uses old cached listings, unless they are too stale.
+	if err := writeListCache(dir, listCacheName("all", DefaultBaseURL+"?mode=json&include=all"), &listCache{Time: time.Now().Add(-24 * time.Hour), Data: data}); err != nil {
+		t.Fatalf("writing cache: %s", err)
+	}
+	c = Client{CacheDir: dir, Offline: true}
+	if _, err := c.ListAll(context.Background()); err != nil {
+		t.Fatalf("listing offline: %s", err)
+	}
+	c.MaxStale = time.Hour
+	if _, err := c.ListAll(context.Background()); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("listing offline with stale cache, got err %v, expected ErrNotCached", err)
+	}
+	c = Client{CacheDir: t.TempDir(), Offline: true}
+	if _, err := c.ListAll(context.Background()); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("listing offline without cache, got err %v, expected ErrNotCached", err)
+	}
+}
+
+func TestCleanCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"sha256-a", "sha256-b", "sha256-c", ".sha256-tmp", "other"} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, make([]byte, 100), 0666); err != nil {
+			t.Fatal(err)
+		}
+		// Files with lower index were used longer ago.
+		tm := now.Add(-time.Duration(5-i) * 24 * time.Hour)
+		if err := os.Chtimes(p, tm, tm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	c := Client{ArchiveCacheDir: dir}
+	r, err := c.CleanCache()
+	if err != nil || r != (CacheCleanResult{1, 100, 3, 300}) || exists(".sha256-tmp") || !exists("other") {
+		t.Fatalf("clean without limits: %v %v", r, err)
+	}
+
+	c.ArchiveCacheMaxAge = 4*24*time.Hour + time.Hour
+	r, err = c.CleanCache()
+	if err != nil || r != (CacheCleanResult{1, 100, 2, 200}) || exists("sha256-a") {
+		t.Fatalf("clean with max age: %v %v", r, err)
+	}
+
+	c.ArchiveCacheMaxSize = 150
+	r, err = c.CleanCache()
+	if err != nil || r != (CacheCleanResult{1, 100, 1, 100}) || exists("sha256-b") || !exists("sha256-c") {
+		t.Fatalf("clean with max size: %v %v", r, err)
+	}
+}
+
+func TestCachingTransport(t *testing.T) {
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("test data"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	ct := &CachingTransport{Dir: dir}
+	hc := &http.Client{Transport: ct}
+	get := func(cache string) {
+		t.Helper()
+		resp, err := hc.Get(srv.URL + "/file")
+		if err != nil {
+			t.Fatalf("get: %s", err)
+		}
+		defer resp.Body.Close()
+		buf, err := io.ReadAll(resp.Body)
+		if err != nil || string(buf) != "test data" || resp.Header.Get("X-Cache") != cache {
+			t.Fatalf("get, got %q, x-cache %q, err %v, expected x-cache %q", buf, resp.Header.Get("X-Cache"), err, cache)
+		}
+	}
+
+	get("")
+	if _, err := os.Stat(archiveCachePath(dir, fmt.Sprintf("%x", sha256.Sum256([]byte("test data"))))); err != nil {
+		t.Fatalf("body not in cache: %s", err)
+	}
+	get("revalidated")
+	if requests != 2 || notModified != 1 {
+		t.Fatalf("got %d requests, %d not modified, expected 2 and 1", requests, notModified)
+	}
+	ct.TTL = time.Hour
+	get("hit")
+	if requests != 2 {
+		t.Fatalf("got %d requests, expected 2", requests)
+	}
+}
+
+func TestListingCache(t *testing.T) {
+	var requests int
+	var mu sync.Mutex
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		<-release
+		fmt.Fprint(w, `[{"version":"go1.22.3","stable":true,"files":[{"filename":"go1.22.3.src.tar.gz","kind":"source"}]}]`)
+	}))
+	defer srv.Close()
+
+	lc := &ListingCache{}
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := Client{BaseURL: srv.URL + "/", ListingCache: lc}
+			rels, err := c.ListSupported(context.Background())
+			if err == nil && (len(rels) != 1 || rels[0].Version != "go1.22.3") {
+				err = fmt.Errorf("unexpected releases %v", rels)
+			}
+			if err == nil {
+				// Modifying the result does not change the cache.
+				rels[0].Files[0].Filename = "changed"
+			}
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("listing: %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("got %d requests for concurrent listings, expected 1", requests)
+	}
+
+	c := Client{BaseURL: srv.URL + "/", ListingCache: lc}
+	if rels, err := c.ListSupported(context.Background()); err != nil || rels[0].Files[0].Filename != "go1.22.3.src.tar.gz" || requests != 1 {
+		t.Fatalf("cached listing: %v %v, %d requests", rels, err, requests)
+	}
+	// The iterator uses the cache too.
+	it, err := c.Releases(context.Background(), false)
+	if err != nil {
+		t.Fatalf("releases: %v", err)
+	}
+	defer it.Close()
+	if !it.Next() || it.Release().Version != "go1.22.3" || it.Next() || it.Err() != nil || requests != 1 {
+		t.Fatalf("iterating cached listing: %v, %d requests", it.Err(), requests)
+	}
+	lc.Clear()
+	if _, err := c.ListSupported(context.Background()); err != nil || requests != 2 {
+		t.Fatalf("listing after clear: %v, %d requests", err, requests)
+	}
+}
+
+func TestListingCacheCanceled(t *testing.T) {
+	lc := &ListingCache{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go lc.get(context.Background(), "x", time.Hour, func() ([]Release, error) {
+		close(started)
+		<-release
+		return nil, context.Canceled
+	})
+	<-started
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	// The leader's canceled context is not the waiter's error, it fetches itself.
+	rels, err := lc.get(context.Background(), "x", time.Hour, func() ([]Release, error) {
+		return []Release{{Version: "go1.22.3"}}, nil
+	})
+	if err != nil || len(rels) != 1 {
+		t.Fatalf("waiter after canceled leader: %v %v", rels, err)
+	}
+}
+
+func TestSharedCache(t *testing.T) {
+	if runtime.GOOS == "linux" {
+		xdg := t.TempDir()
+		t.Setenv("XDG_CACHE_HOME", xdg)
+		var c Client
+		if err := c.UseDefaultCache(); err != nil {
+			t.Fatalf("use default cache: %v", err)
+		}
+		if c.CacheDir != filepath.Join(xdg, "goreleases") || c.ArchiveCacheDir != filepath.Join(xdg, "goreleases", "archives") || !c.SharedCache {
+			t.Fatalf("default cache %q, %q, shared %v", c.CacheDir, c.ArchiveCacheDir, c.SharedCache)
+		}
+	}
+
+	if !fileLocks {
+		t.Skip("file locks not supported")
+	}
+	srv := goreleasestest.NewServer("go1.22.3")
+	defer srv.Close()
+	cachedir, archivedir := t.TempDir(), t.TempDir()
+	newClient := func() *Client {
+		// Separate clients, coordinating only through the file system.
+		return &Client{BaseURL: srv.BaseURL(), NoSignatures: true, CacheDir: cachedir, ArchiveCacheDir: archivedir, SharedCache: true}
+	}
+	rels, err := newClient().ListSupported(context.Background())
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	file, _ := rels[0].FindFile("linux", "amd64", KindArchive)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- newClient().Fetch(context.Background(), file, t.TempDir(), nil)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("fetch: %v", err)
+		}
+	}
+	if n := srv.Requests("/" + file.Filename); n != 1 {
+		t.Fatalf("release file downloaded %d times, expected once", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	unlock, err := lockFile(context.Background(), filepath.Join(cachedir, ".lock-test"))
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if _, err := lockFile(ctx, filepath.Join(cachedir, ".lock-test")); err != context.DeadlineExceeded {
+		t.Fatalf("second lock: %v, expected deadline exceeded", err)
+	}
+	unlock()
+
+	// Lock files are removed with their cached files, unless locked.
+	lockpath := filepath.Join(archivedir, ".lock-sha256-"+file.Sha256)
+	if _, err := os.Stat(lockpath); err != nil {
+		t.Fatalf("lock file: %v", err)
+	}
+	unlock, err = lockFile(context.Background(), lockpath)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	c := newClient()
+	c.ArchiveCacheMaxSize = 1
+	if r, err := c.CleanCache(); err != nil || r.Removed != 1 {
+		t.Fatalf("clean cache: %v, %v", r, err)
+	}
+	if _, err := os.Stat(lockpath); err != nil {
+		t.Fatalf("lock file in use removed: %v", err)
+	}
+	unlock()
+	if _, err := c.CleanCache(); err != nil {
+		t.Fatalf("clean cache: %v", err)
+	}
+	if _, err := os.Stat(lockpath); !os.IsNotExist(err) {
+		t.Fatalf("lock file not removed: %v", err)
+	}
+}
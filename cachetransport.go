@@ -0,0 +1,249 @@
+package goreleases
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CachingTransport is an http.RoundTripper that caches successful responses
+// to GET requests on disk, e.g. for sharing a cache between a Client (as
+// transport of Client.HTTPClient) and other download code.
+//
+// Response bodies are stored by their sha256 checksum, in the same format as
+// the archive cache of a Client, so Dir can be the Client.ArchiveCacheDir and
+// identical bodies are stored once. Stale responses are revalidated with a
+// conditional request, with If-None-Match and If-Modified-Since, and served
+// from the cache if the server reports they have not been modified.
+//
+// Requests with a Range or Authorization header, and responses with
+// "Cache-Control: no-store" are not cached. Responses served from the cache
+// have header "X-Cache" with "hit", or "revalidated" after a conditional
+// request.
+type CachingTransport struct {
+	// Directory for the cache, created if needed.
+	Dir string
+
+	// Cached responses younger than TTL are served without request. If zero,
+	// every use is revalidated.
+	TTL time.Duration
+
+	// Transport for requests. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+}
+
+// httpCache is the metadata of a cached response, stored as JSON.
+type httpCache struct {
+	URL    string
+	Time   time.Time // Time the response was fetched or last revalidated.
+	Sha256 string    // Of body, stored in the archive cache format.
+	Size   int64
+	Header http.Header // Subset: Content-Type, ETag, Last-Modified.
+}
+
+func (t *CachingTransport) transport() http.RoundTripper {
+	if t.Transport == nil {
+		return http.DefaultTransport
+	}
+	return t.Transport
+}
+
+func (t *CachingTransport) metaPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(t.Dir, fmt.Sprintf("http-%x.json", sum[:16]))
+}
+
+// RoundTrip serves req from the cache, or makes the request and caches the
+// response while its body is read.
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" || req.Header.Get("Range") != "" || req.Header.Get("Authorization") != "" {
+		return t.transport().RoundTrip(req)
+	}
+	url := req.URL.String()
+	hc, f := t.read(url)
+	if hc != nil && t.TTL > 0 && time.Since(hc.Time) < t.TTL {
+		return cachedResponse(req, hc, f, "hit"), nil
+	}
+
+	creq := req
+	if hc != nil {
+		creq = req.Clone(req.Context())
+		if etag := hc.Header.Get("ETag"); etag != "" {
+			creq.Header.Set("If-None-Match", etag)
+		}
+		if lm := hc.Header.Get("Last-Modified"); lm != "" {
+			creq.Header.Set("If-Modified-Since", lm)
+		}
+	}
+	resp, err := t.transport().RoundTrip(creq)
+	if err != nil {
+		if f != nil {
+			f.Close()
+		}
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && hc != nil {
+		resp.Body.Close()
+		hc.Time = time.Now()
+		// Failing to update is not a reason to fail the request.
+		t.writeMeta(hc)
+		return cachedResponse(req, hc, f, "revalidated"), nil
+	}
+	if f != nil {
+		f.Close()
+	}
+	if resp.StatusCode != http.StatusOK || strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+		return resp, nil
+	}
+	tf, err := os.CreateTemp(t.Dir, ".sha256-")
+	if err != nil && os.MkdirAll(t.Dir, 0777) == nil {
+		tf, err = os.CreateTemp(t.Dir, ".sha256-")
+	}
+	if err != nil {
+		// Not caching is not a reason to fail the request.
+		return resp, nil
+	}
+	resp.Body = &cacheFill{t: t, url: url, resp: resp, rc: resp.Body, f: tf, h: sha256.New()}
+	return resp, nil
+}
+
+// read returns the cached metadata and opened body for url, or nil.
+func (t *CachingTransport) read(url string) (*httpCache, *os.File) {
+	buf, err := os.ReadFile(t.metaPath(url))
+	if err != nil {
+		return nil, nil
+	}
+	var hc httpCache
+	if err := json.Unmarshal(buf, &hc); err != nil || hc.URL != url {
+		return nil, nil
+	}
+	f, err := os.Open(archiveCachePath(t.Dir, hc.Sha256))
+	if err != nil {
+		return nil, nil
+	}
+	if fi, err := f.Stat(); err != nil || fi.Size() != hc.Size {
+		f.Close()
+		return nil, nil
+	}
+	// The modification time tracks last use, for CleanCache.
+	now := time.Now()
+	os.Chtimes(f.Name(), now, now)
+	return &hc, f
+}
+
+func (t *CachingTransport) writeMeta(hc *httpCache) error {
+	buf, err := json.Marshal(hc)
+	if err != nil {
+		return err
+	}
+	p := t.metaPath(hc.URL)
+	tf, err := os.CreateTemp(t.Dir, ".http-")
+	if err != nil {
+		return err
+	}
+	if _, err := tf.Write(buf); err != nil {
+		tf.Close()
+		os.Remove(tf.Name())
+		return err
+	}
+	if err := tf.Close(); err != nil {
+		os.Remove(tf.Name())
+		return err
+	}
+	if err := os.Rename(tf.Name(), p); err != nil {
+		os.Remove(tf.Name())
+		return err
+	}
+	return nil
+}
+
+// cachedResponse returns a response for req with the cached body in f.
+func cachedResponse(req *http.Request, hc *httpCache, f *os.File, status string) *http.Response {
+	h := hc.Header.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	h.Set("Content-Length", strconv.FormatInt(hc.Size, 10))
+	h.Set("X-Cache", status)
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          f,
+		ContentLength: hc.Size,
+		Request:       req,
+	}
+}
+
+// cacheFill copies a response body into a temporary file while it is read.
+// When the body has been read completely, the file is added to the cache.
+type cacheFill struct {
+	t    *CachingTransport
+	url  string
+	resp *http.Response
+	rc   io.ReadCloser
+	f    *os.File // Nil after a write error or when done.
+	h    hash.Hash
+	n    int64
+}
+
+func (cf *cacheFill) Read(buf []byte) (int, error) {
+	n, err := cf.rc.Read(buf)
+	if n > 0 && cf.f != nil {
+		if _, werr := cf.f.Write(buf[:n]); werr != nil {
+			cf.discard()
+		} else {
+			cf.h.Write(buf[:n])
+			cf.n += int64(n)
+		}
+	}
+	if err == io.EOF && cf.f != nil {
+		cf.store()
+	}
+	return n, err
+}
+
+func (cf *cacheFill) Close() error {
+	cf.discard()
+	return cf.rc.Close()
+}
+
+func (cf *cacheFill) discard() {
+	if cf.f != nil {
+		cf.f.Close()
+		os.Remove(cf.f.Name())
+		cf.f = nil
+	}
+}
+
+// store adds the complete body to the cache, failures are ignored.
+func (cf *cacheFill) store() {
+	f := cf.f
+	cf.f = nil
+	defer os.Remove(f.Name())
+	if err := f.Close(); err != nil || cf.resp.ContentLength >= 0 && cf.n != cf.resp.ContentLength {
+		return
+	}
+	sum := fmt.Sprintf("%x", cf.h.Sum(nil))
+	if err := os.Rename(f.Name(), archiveCachePath(cf.t.Dir, sum)); err != nil {
+		return
+	}
+	hc := &httpCache{URL: cf.url, Time: time.Now(), Sha256: sum, Size: cf.n, Header: http.Header{}}
+	for _, k := range []string{"Content-Type", "ETag", "Last-Modified"} {
+		if v := cf.resp.Header.Get(k); v != "" {
+			hc.Header.Set(k, v)
+		}
+	}
+	cf.t.writeMeta(hc)
+}
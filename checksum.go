@@ -0,0 +1,92 @@
+package goreleases
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"sync"
+)
+
+var checksums = struct {
+	sync.Mutex
+	algs map[string]func() hash.Hash
+}{algs: map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}}
+
+// RegisterChecksum registers a checksum algorithm for use in File.Checksum,
+// e.g. "blake2b-256" with a function calling blake2b.New256. Algorithms
+// "sha256" and "sha512" are registered by default.
+func RegisterChecksum(algorithm string, fn func() hash.Hash) {
+	checksums.Lock()
+	defer checksums.Unlock()
+	checksums.algs[algorithm] = fn
+}
+
+// checksumCheck verifies File.Checksum of data written to it, or the check of
+// Client.Verifier instead.
+type checksumCheck struct {
+	h        hash.Hash
+	checksum string
+	exp      string
+	check    Check // If set, instead of h.
+}
+
+// newChecksumCheck returns a check for the File.Checksum of file, or nil if it
+// has none.
+func newChecksumCheck(file File) (*checksumCheck, error) {
+	if file.Checksum == "" {
+		return nil, nil
+	}
+	alg, exp, ok := strings.Cut(file.Checksum, ":")
+	checksums.Lock()
+	fn := checksums.algs[alg]
+	checksums.Unlock()
+	if !ok || fn == nil {
+		return nil, fmt.Errorf("unsupported checksum %q", file.Checksum)
+	}
+	return &checksumCheck{fn(), file.Checksum, strings.ToLower(exp), nil}, nil
+}
+
+// tee returns a reader that hashes data read from r. For a nil check, r is
+// returned.
+func (ck *checksumCheck) tee(r io.Reader) io.Reader {
+	if ck == nil {
+		return r
+	} else if ck.check != nil {
+		return io.TeeReader(r, ck.check)
+	}
+	return io.TeeReader(r, ck.h)
+}
+
+// verify checks the hashed data against the checksum. A nil check succeeds.
+func (ck *checksumCheck) verify() error {
+	if ck == nil {
+		return nil
+	} else if ck.check != nil {
+		return ck.check.Verify()
+	}
+	if sum := fmt.Sprintf("%x", ck.h.Sum(nil)); sum != ck.exp {
+		return fmt.Errorf("checksum mismatch, got %s, expected %s", sum, ck.checksum)
+	}
+	return nil
+}
+
+// checkSha256 checks the hex sha256 sum of downloaded data against file, unless
+// disabled with Client.InsecureSkipSha256. Files with only a Checksum, from a
+// VerifyingSource, or checked by Client.Verifier, have been verified during
+// download already.
+func (c *Client) checkSha256(file File, sum string) error {
+	if c.InsecureSkipSha256 || c.Verifier != nil || file.Sha256 == "" && (file.Checksum != "" || c.sourceVerified(file)) {
+		return nil
+	}
+	if sum != file.Sha256 {
+		c.metrics.verifyFailed()
+		return fmt.Errorf("checksum mismatch, got %s, expected %s", sum, file.Sha256)
+	}
+	return nil
+}
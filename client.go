@@ -0,0 +1,407 @@
+package goreleases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Client lists and downloads releases, with configurable behaviour. The zero
+// value is a usable Client without caching. The package-level functions use a
+// zero Client with the environment applied, see SetDefaultClient and
+// ApplyEnv.
+type Client struct {
+	// BaseURL is the URL for listing releases (with "?mode=json" added) and for
+	// downloading files (with the filename added). Mirrors must serve the same
+	// JSON listing and files, including .asc signature files unless
+	// NoSignatures is set. If empty, DefaultBaseURL is used. It should end with
+	// a slash.
+	BaseURL string
+
+	// If set, called with the URL of each download from BaseURL, of release
+	// files and their .asc and .sha256 files, right before the request, to
+	// return the URL to request instead, e.g. with another host or with signed
+	// query parameters for a CDN. Checksums and signatures are verified as
+	// usual. FetchResult and OriginFile have the URL before rewriting.
+	RewriteURL func(ctx context.Context, file File, url string) (string, error)
+
+	// If set, no signatures are fetched and verified, only checksums. For
+	// mirrors of sources without signatures, like MicrosoftSource.
+	NoSignatures bool
+
+	// If non-nil, releases are listed and downloaded from Source instead of
+	// BaseURL. The cache options below only apply to listings from BaseURL.
+	Source ReleaseSource
+
+	// URL of the release history page, used for release notes. If empty,
+	// DefaultHistoryURL is used.
+	HistoryURL string
+
+	// URL of the Go vulnerability database, used by VulnCheck. If empty,
+	// DefaultVulnDBURL is used.
+	VulnDBURL string
+
+	// URL of the Go git repository, served by gitiles, for FetchTip. If empty,
+	// DefaultTipURL is used.
+	TipURL string
+
+	// HTTPClient is used for requests. If nil, http.DefaultClient is used,
+	// or a client with Dial.
+	HTTPClient *http.Client
+
+	// If set and HTTPClient is nil, requests are made with a client that
+	// connects with these options, e.g. to only use IPv4, or to connect from
+	// a specific address.
+	Dial *DialOptions
+
+	// If non-nil, all requests, for listings, files, signatures and others,
+	// wait for the rate limiter. Can be shared between clients.
+	RateLimit *RateLimiter
+
+	// If non-empty, release listings are cached in this directory, which is
+	// created if needed. See DefaultCacheDir.
+	CacheDir string
+
+	// Cached listings younger than CacheTTL are used without making a request. If
+	// zero, DefaultCacheTTL is used.
+	CacheTTL time.Duration
+
+	// If non-empty, downloaded release files are stored in this directory, in
+	// a file named after their sha256 checksum. Later fetches of files with the
+	// same checksum use the stored file instead of downloading. The directory
+	// can be shared between clients and processes.
+	ArchiveCacheDir string
+
+	// Limits for the archive cache, enforced by CleanCache, which is also
+	// called after a file has been added to the cache. Files not used for
+	// ArchiveCacheMaxAge are removed. If the files in the cache are larger than
+	// ArchiveCacheMaxSize bytes in total, the least recently used files are
+	// removed. Zero values mean no limit.
+	ArchiveCacheMaxSize int64
+	ArchiveCacheMaxAge  time.Duration
+
+	// If set, CacheDir and ArchiveCacheDir are coordinated with other
+	// processes using them, e.g. an IDE plugin, the command and a CI agent,
+	// with file locks: Only one process at a time refreshes a listing or
+	// downloads a release file into the cache, others wait and use its
+	// result. See UseDefaultCache for a cache shared by all tools of a user.
+	SharedCache bool
+
+	// If non-nil, listings are cached in memory for CacheTTL, before CacheDir,
+	// e.g. shared by all clients of a server. Concurrent listings that need a
+	// refresh make a single request. Not used in Offline mode.
+	ListingCache *ListingCache
+
+	// If set, listings are only read from the cache in CacheDir, no requests are
+	// made. Listing fails with ErrNotCached if no cached listing is available.
+	Offline bool
+
+	// In offline mode, cached listings older than MaxStale are not used. If zero,
+	// cached listings of any age are used.
+	MaxStale time.Duration
+
+	// Paths in releases to extract, relative to the "go" directory and with
+	// slashes, e.g. "bin" or "pkg/tool". A path includes everything below it.
+	// Path elements are matched with path.Match, and element "**" matches any
+	// number of elements, e.g. "**/testdata". If Include is empty, all paths
+	// are included. Paths matching Exclude are not extracted. The checksum of
+	// the complete file is still verified.
+	Include []string
+	Exclude []string
+
+	// If set, files, directories and symbolic links extracted from .tar.gz
+	// and .pkg release files are owned by the uid and gid in the archive,
+	// mapped through OwnerMap if not nil, e.g. for extracting into a file
+	// system for an image that runs as another user. Changing ownership
+	// typically requires running as root. An owner in the Permissions of a
+	// fetch takes precedence.
+	ArchiveOwner bool
+	OwnerMap     func(uid, gid int) (int, int)
+
+	// If set, extraction only allows expected entries, and fails with a
+	// *StrictError otherwise: Only regular files, directories and links, in
+	// the "go" directory, links only to entries in the "go" directory, and
+	// without setuid, setgid or sticky bits. Zip files must only contain
+	// regular files and directories.
+	Strict bool
+
+	// If set, extended attributes in PAX records of .tar.gz release files
+	// ("SCHILY.xattr.*") are set on extracted files and directories. Only
+	// supported on Linux, fetches of files with extended attributes fail
+	// elsewhere, unless skipped by XattrFilter. Long names and large sizes in PAX records are always handled.
+	Xattrs bool
+
+	// If set with Xattrs, called for each extended attribute of an entry
+	// before it is set, with the name of the entry in the archive, e.g.
+	// "go/bin/go", returning the value to set, or false to skip the
+	// attribute, e.g. NoQuarantine.
+	XattrFilter func(name, attr string, value []byte) ([]byte, bool)
+
+	// If non-empty, release files are kept in this directory (created if
+	// needed) after successful verification and extraction, named by their
+	// filename, e.g. for audits or extracting again without downloading. Use
+	// the dst of a fetch to keep the file next to the install.
+	KeepArchiveDir string
+
+	// If set, fetches write an OriginFile into the "go" directory, with the
+	// version, download URL, checksum and time, and the version of this
+	// package. Installs with an OriginFile are not reproducible.
+	WriteOrigin bool
+
+	// If set, called to verify the provenance of release files after their
+	// checksum has been verified, and before extraction. An error aborts the
+	// fetch. The provenance is recorded in the manifest of installs.
+	VerifyProvenance ProvenanceVerifier
+
+	// If set, the sha256 of a file from the listing must match the .sha256
+	// file published next to it at BaseURL before the file is downloaded, so
+	// a single compromised endpoint cannot change both. Not supported with a
+	// Source.
+	CrossVerify bool
+
+	// If set, the sha256 checksums of release files are not verified, e.g. for
+	// internal mirrors that repackage archives. Dangerous: Without signatures,
+	// nothing protects against tampered files. To replace the sha256 with a
+	// checksum published by the mirror, set File.Checksum instead, which is
+	// still verified with this option.
+	InsecureSkipSha256 bool
+
+	// If set, the data of release files is checked by Verifier instead of
+	// against File.Sha256 and File.Checksum, e.g. with
+	// AllVerifiers(Sha256Verifier, AllowlistVerifier(...)) to also require a
+	// corporate allowlist. Also applied to files from the archive cache. Pins,
+	// CrossVerify and signatures are still checked.
+	Verifier Verifier
+
+	// Expected sha256 checksums of release files, by filename, e.g. from a
+	// lock file. A file with a pin is only accepted if its sha256 from the
+	// listing matches the pin.
+	Pins map[string]string
+
+	// Hooks called in order after a successful Fetch, FetchSDK or Install, e.g.
+	// GoVersionHook, or hooks warming the build cache or changing ownership. If
+	// a hook fails, the install is removed and the error returned.
+	PostInstall []InstallHook
+
+	// If set, called while downloading release files, after each read, with
+	// the number of bytes downloaded so far and the expected size, zero if
+	// unknown. Not called for files from the archive cache.
+	Progress func(file File, downloaded, total int64)
+
+	// If set, called with the aggregate progress of FetchAll and FetchMany,
+	// with the total size to download and an estimated time remaining, after
+	// each progress update. Calls are serialized.
+	BulkProgress func(p BulkProgress)
+
+	// If set, called for each step of fetches and installs, e.g. resolving a
+	// version, downloading, extracting each entry and renaming into place, for
+	// detailed live status. For a channel, send the events on it. Called
+	// concurrently for concurrent fetches, e.g. by FetchAll.
+	Events func(Event)
+
+	// Size of the buffers for reading archives and copying file data, default
+	// DefaultBufferSize. One set of buffers is allocated per fetch and reused
+	// for all files.
+	BufferSize int
+
+	// If set, extracted files and their directories are fsynced, as are the
+	// renames of FetchSDK, Manager and Install, so a successful install
+	// survives a power loss. Slower, meant for embedded and edge devices.
+	Sync bool
+
+	// Directory for temporary download files, default os.TempDir. Extraction
+	// by FetchSDK, Manager and Install always happens in a temporary directory
+	// next to the destination, so the install can be renamed into place.
+	TempDir string
+
+	// If set, incomplete downloads from BaseURL are kept in TempDir, named
+	// after their checksum, and resumed with a range request by the next
+	// download of the same file. By default, partial files are always removed.
+	KeepPartial bool
+
+	// If set, an extraction by FetchSDK, Manager or Install of a .tar.gz
+	// release file (or another format read as tar stream) that was
+	// interrupted, e.g. by a crash or power loss, is continued by the next
+	// install of the same file into the same directory: Its temporary
+	// directory is kept, named after the checksum of the file, and files that
+	// were completely extracted are verified by their sha256 and not written
+	// again, their metadata is set as for new files. An extraction with other
+	// permissions or extraction options starts over. The release file is
+	// still read and verified completely. Temporary directories of other
+	// release files for the same directory are removed after a successful
+	// install. Useful on devices with slow storage, like SD cards.
+	ResumeExtract bool
+
+	// If set, extracted files and directories get exactly the mode of their
+	// archive entry, instead of that mode masked by the umask of the process,
+	// so installs are identical across hosts. Ignored for fetches with
+	// Permissions.
+	ExactModes bool
+
+	// If set, extraction is reproducible: Like ExactModes, and directories
+	// get the modification times of their archive entries after extraction,
+	// as do the manifest and marker files of installs, so hashing an extracted
+	// tree, e.g. with TreeDigest, gives the same digest on any machine.
+	// Combine with Permissions for fixed ownership.
+	Reproducible bool
+
+	// If non-nil, fetches first check that the file system of the destination
+	// is suitable, e.g. not mounted noexec, see CheckFilesystem, and fail with
+	// a *FilesystemError before downloading otherwise.
+	Preflight *PreflightOptions
+
+	// On Windows, if set, the access control lists of the extracted tree are
+	// set after extraction, to WindowsACLInherit or WindowsACLAdmins, see
+	// SetWindowsACL. By default, files get the ACLs Windows gives new files.
+	// Ignored on other systems.
+	WindowsACL string
+
+	// If non-empty, Windows executables and installers must have a valid
+	// Authenticode signature with a certificate for this signer, e.g.
+	// GoogleSigner: .msi files before they are extracted or Download
+	// completes, and .exe files in bin and pkg/tool of fetched Windows
+	// releases before the fetch completes. Verified with PowerShell on Windows
+	// and with osslsigncode elsewhere. A failed verification is returned as an
+	// *AuthenticodeError.
+	AuthenticodeSigner string
+
+	// For Proxy and SyncMirror, if they have Metrics.
+	metrics *Metrics
+
+	// For continuing an interrupted extraction, see ResumeExtract.
+	resume *resumeJournal
+}
+
+// ErrNotCached is returned when listing in offline mode while no usable cached
+// listing is present.
+var ErrNotCached = errors.New("no usable cached listing for offline mode")
+
+// DefaultBaseURL is the upstream location of Go releases.
+const DefaultBaseURL = "https://go.dev/dl/"
+
+// DefaultCacheTTL is the time cached listings are used if Client.CacheTTL is zero.
+const DefaultCacheTTL = time.Hour
+
+// DefaultCacheDir returns a per-user cache directory for goreleases, e.g.
+// $XDG_CACHE_HOME/goreleases or ~/.cache/goreleases on Linux.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goreleases"), nil
+}
+
+// DefaultArchiveCacheDir returns a per-user directory for the archive cache,
+// "archives" in DefaultCacheDir.
+func DefaultArchiveCacheDir() (string, error) {
+	dir, err := DefaultCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "archives"), nil
+}
+
+// UseDefaultCache sets CacheDir and ArchiveCacheDir if they are empty to
+// DefaultCacheDir and DefaultArchiveCacheDir, the cache locations of the
+// operating system for the user, like $XDG_CACHE_HOME/goreleases on Linux, and
+// sets SharedCache, so all tools using this package share listings and
+// downloaded release files.
+func (c *Client) UseDefaultCache() error {
+	if c.CacheDir == "" {
+		dir, err := DefaultCacheDir()
+		if err != nil {
+			return err
+		}
+		c.CacheDir = dir
+	}
+	if c.ArchiveCacheDir == "" {
+		dir, err := DefaultArchiveCacheDir()
+		if err != nil {
+			return err
+		}
+		c.ArchiveCacheDir = dir
+	}
+	c.SharedCache = true
+	return nil
+}
+
+func (c *Client) baseURL() string {
+	if c.BaseURL != "" {
+		return c.BaseURL
+	}
+	return DefaultBaseURL
+}
+
+// fileURL returns the download URL of file, or an empty string for files from
+// Client.Source.
+func (c *Client) fileURL(file File) string {
+	if c.Source != nil {
+		return ""
+	}
+	return c.baseURL() + file.Filename
+}
+
+// downloadURL returns the URL for requesting file with suffix, e.g. ".asc",
+// from BaseURL, rewritten by RewriteURL.
+func (c *Client) downloadURL(ctx context.Context, file File, suffix string) (string, error) {
+	u := c.baseURL() + file.Filename + suffix
+	if c.RewriteURL == nil {
+		return u, nil
+	}
+	nu, err := c.RewriteURL(ctx, file, u)
+	if err != nil {
+		return "", fmt.Errorf("rewriting url %s: %w", u, err)
+	}
+	return nu, nil
+}
+
+// get does a GET request for url with the client's HTTP client.
+func (c *Client) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	return c.do(req)
+}
+
+// do makes the request with the client's HTTP client, after waiting for the
+// rate limiter.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.RateLimit != nil {
+		if err := c.RateLimit.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	return c.httpClient().Do(req)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	} else if c.Dial != nil {
+		return dialClient(c.Dial)
+	}
+	return http.DefaultClient
+}
+
+// DefaultBufferSize is the buffer size used if Client.BufferSize is zero.
+const DefaultBufferSize = 32 * 1024
+
+func (c *Client) bufferSize() int {
+	if c.BufferSize > 0 {
+		return c.BufferSize
+	}
+	return DefaultBufferSize
+}
+
+func (c *Client) cacheTTL() time.Duration {
+	if c.CacheTTL != 0 {
+		return c.CacheTTL
+	}
+	return DefaultCacheTTL
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mjl-/goreleases"
+)
+
+// commands are the subcommands, as completed by the completion scripts.
+var commands = "list latest fetch download install verify matrix env sbom doctor autoupdate sync-mirror verify-mirror completion"
+
+// Completion scripts. Versions are completed by running "goreleases
+// __versions". Global flags with a value are skipped when looking for the
+// subcommand.
+var completionScripts = map[string]string{
+	"bash": `_goreleases() {
+	local cur=${COMP_WORDS[COMP_CWORD]} cmd= npos=0 i
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case ${COMP_WORDS[i]} in
+		-baseurl|-cachedir|-format|-os|-arch|-kind|-dst|-shell|-minors|-root|-grace|-interval|-concurrency|-systemd|-winsw) ((i++)) ;;
+		-*) ;;
+		*) if [ -z "$cmd" ]; then cmd=${COMP_WORDS[i]}; else ((npos++)); fi ;;
+		esac
+	done
+	case $cmd in
+	'') COMPREPLY=($(compgen -W "` + commands + `" -- "$cur")) ;;
+	latest|fetch|download) COMPREPLY=($(compgen -W "$(goreleases __versions 2>/dev/null)" -- "$cur")) ;;
+	install)
+		if [ $npos -eq 0 ]; then
+			COMPREPLY=($(compgen -W "$(goreleases __versions 2>/dev/null)" -- "$cur"))
+		else
+			COMPREPLY=($(compgen -d -- "$cur"))
+		fi ;;
+	verify|env|sbom) COMPREPLY=($(compgen -f -- "$cur")) ;;
+	doctor|sync-mirror|verify-mirror) COMPREPLY=($(compgen -d -- "$cur")) ;;
+	completion) COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+	esac
+}
+complete -o filenames -F _goreleases goreleases
+`,
+
+	"zsh": `#compdef goreleases
+_goreleases() {
+	local cmd= npos=0 i
+	for ((i = 2; i < CURRENT; i++)); do
+		case $words[i] in
+		-baseurl|-cachedir|-format|-os|-arch|-kind|-dst|-shell|-minors|-root|-grace|-interval|-concurrency|-systemd|-winsw) ((i++)) ;;
+		-*) ;;
+		*) if [[ -z $cmd ]]; then cmd=$words[i]; else ((npos++)); fi ;;
+		esac
+	done
+	case $cmd in
+	'') compadd -- ` + commands + ` ;;
+	latest|fetch|download) compadd -- ${(f)"$(goreleases __versions 2>/dev/null)"} ;;
+	install)
+		if ((npos == 0)); then
+			compadd -- ${(f)"$(goreleases __versions 2>/dev/null)"}
+		else
+			_files -/
+		fi ;;
+	verify|env|sbom) _files ;;
+	doctor|sync-mirror|verify-mirror) _files -/ ;;
+	completion) compadd -- bash zsh fish ;;
+	esac
+}
+compdef _goreleases goreleases
+`,
+
+	"fish": `set -l commands ` + commands + `
+complete -c goreleases -f
+complete -c goreleases -n "not __fish_seen_subcommand_from $commands" -a "$commands"
+complete -c goreleases -n "__fish_seen_subcommand_from latest fetch download install" -a "(goreleases __versions 2>/dev/null)"
+complete -c goreleases -n "__fish_seen_subcommand_from install verify env sbom doctor sync-mirror verify-mirror" -F
+complete -c goreleases -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+`,
+}
+
+func cmdCompletion(args []string) {
+	fs := flag.NewFlagSet("completion", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases completion bash|zsh|fish")
+		fs.PrintDefaults()
+	}
+	args = parseFlags(fs, args)
+	if len(args) != 1 || completionScripts[args[0]] == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	fmt.Print(completionScripts[args[0]])
+}
+
+// cmdVersions prints the versions for completion, with "latest" and the minor
+// versions. The listing is cached in the default cache directory if no
+// -cachedir is set, so completion is fast after the first use.
+func cmdVersions(args []string) {
+	if client.CacheDir == "" {
+		if dir, err := goreleases.DefaultCacheDir(); err == nil {
+			client.CacheDir = dir
+		}
+	}
+	rels, err := client.ListAll(context.Background())
+	xcheckf(err, "listing releases")
+	l := []string{"latest"}
+	seen := map[string]bool{}
+	for _, rel := range rels {
+		l = append(l, rel.Version)
+		if v, err := goreleases.ParseVersion(strings.TrimPrefix(rel.Version, "go")); err == nil {
+			minor := fmt.Sprintf("go%d.%d", v.Major, v.Minor)
+			if !seen[minor] {
+				seen[minor] = true
+				l = append(l, minor)
+			}
+		}
+	}
+	fmt.Println(strings.Join(l, "\n"))
+}
@@ -0,0 +1,774 @@
+// Command goreleases lists, downloads, verifies and extracts Go toolchain
+// releases, using package github.com/mjl-/goreleases.
+//
+// Usage:
+//
+//	goreleases [flags] list [-all]
+//	goreleases [flags] latest [-all] [minor]
+//	goreleases [flags] fetch [-os os] [-arch arch] [-dst dir] version
+//	goreleases [flags] download [-os os] [-arch arch] [-kind kind] [-dst dir] version
+//	goreleases [flags] install [-os os] [-arch arch] [-n] [-system] [-bootstrap goroot] version [dir]
+//	goreleases [flags] install -github-actions [-os os] [-arch arch] [-n] version
+//	goreleases [flags] verify file ...
+//	goreleases [flags] matrix [-minors n] [-rc]
+//	goreleases [flags] env [-shell sh|fish|powershell] goroot
+//	goreleases [flags] sbom goroot
+//	goreleases [flags] doctor [dir ...]
+//	goreleases [flags] autoupdate [-root dir] [-shims] [-hardlink] [-os os] [-arch arch] [-grace duration] [-interval duration | -once] [-systemd dir | -winsw file] version
+//	goreleases [flags] sync-mirror [-all] [-verify] [-concurrency n] [-interval duration | -once] [-systemd dir | -winsw file] dir
+//	goreleases [flags] verify-mirror [-all] [-concurrency n] dir
+//	goreleases completion bash|zsh|fish
+//
+// Versions can be specified with or without "go" prefix, e.g. "go1.22.3" or
+// "1.22.3", or as "1.22" for its latest patch release, or "latest", see
+// goreleases.Resolve. Fetch extracts the release into dst/go. Install without
+// dir installs into the per-user install root, e.g.
+// ~/.local/share/goreleases/<version> on Linux, see goreleases.UserInstallRoot,
+// also the default root for autoupdate. Install with
+// -github-actions installs into the tool cache of a GitHub Actions runner, and
+// sets GOROOT, PATH and the outputs go-version, goroot and cache-hit, like
+// setup-go. Verify checks local
+// release files against the checksums in the listing and their signatures.
+// Matrix prints the versions to test against in CI, e.g. with -json for a
+// GitHub Actions matrix. Env prints a script setting GOROOT and PATH for an
+// install, e.g. for "eval $(goreleases env /usr/local/go)". Sbom prints a
+// CycloneDX SBOM for an install. Doctor checks connectivity, proxy and TLS
+// configuration, caches, and destination directories for problems, exiting
+// with status 1 if it finds errors. Autoupdate keeps the latest release for a
+// version installed and active in a goreleases.Manager root, checking every
+// interval. Sync-mirror keeps a mirror directory synchronized with the
+// releases. With -systemd, both instead write a systemd service and timer to
+// run them, and with -winsw a WinSW configuration for a Windows service.
+// Verify-mirror audits a mirror directory against the releases, reporting
+// missing, corrupted and extra files, exiting with status 1 if it finds any.
+// Completion prints a shell completion script,
+// e.g. for "source <(goreleases completion bash)", completing versions from
+// the listing, cached in the default cache directory.
+//
+// Environment variables like GORELEASES_BASE_URL and GORELEASES_CACHE_DIR set
+// defaults for the flags, see goreleases.Client.ApplyEnv. Flag -sharedcache,
+// or GORELEASES_SHARED_CACHE=1, caches listings and release files in the
+// per-user cache directory, shared with other tools using the package.
+//
+// All subcommands accept flag -json to write their output as JSON, and flag
+// -format to format their output with a text/template, e.g.:
+//
+//	goreleases list -format '{{range .}}{{.Version}} {{len .Files}}{{"\n"}}{{end}}'
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"text/template"
+	"time"
+
+	"github.com/mjl-/goreleases"
+)
+
+var client goreleases.Client
+
+var (
+	jsonOutput   bool
+	formatOutput string
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: goreleases [flags] list [-all]")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] latest [-all] [minor]")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] fetch [-os os] [-arch arch] [-dst dir] version")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] download [-os os] [-arch arch] [-kind kind] [-dst dir] version")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] install [-os os] [-arch arch] [-n] [-system] [-bootstrap goroot] version [dir]")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] install -github-actions [-os os] [-arch arch] [-n] version")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] verify file ...")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] matrix [-minors n] [-rc]")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] env [-shell sh|fish|powershell] goroot")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] sbom goroot")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] doctor [dir ...]")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] autoupdate [-root dir] [-shims] [-hardlink] [-os os] [-arch arch] [-grace duration] [-interval duration | -once] [-systemd dir | -winsw file] version")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] sync-mirror [-all] [-verify] [-concurrency n] [-interval duration | -once] [-systemd dir | -winsw file] dir")
+	fmt.Fprintln(os.Stderr, "       goreleases [flags] verify-mirror [-all] [-concurrency n] dir")
+	fmt.Fprintln(os.Stderr, "       goreleases completion bash|zsh|fish")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("goreleases: ")
+	flag.Usage = usage
+	flag.StringVar(&client.BaseURL, "baseurl", "", "base url of mirror to use instead of "+goreleases.DefaultBaseURL)
+	flag.StringVar(&client.CacheDir, "cachedir", "", "directory for caching listings, no caching if empty")
+	flag.BoolVar(&client.Offline, "offline", false, "only use cached listings")
+	sharedCache := flag.Bool("sharedcache", false, "cache listings and release files in the per-user cache directory shared with other tools, with locking")
+	minimal := flag.Bool("minimal", false, "extract a minimal GOROOT, without tests, test data and documentation")
+	outputFlags(flag.CommandLine)
+	flag.Parse()
+	if *sharedCache {
+		err := client.UseDefaultCache()
+		xcheckf(err, "using default cache")
+	}
+	err := client.ApplyEnv()
+	xcheckf(err, "applying environment")
+	flag.Visit(func(f *flag.Flag) {
+		// An explicit -offline=false overrides the environment.
+		if f.Name == "offline" {
+			client.Offline = f.Value.String() == "true"
+		}
+	})
+	if *minimal {
+		client.Exclude = goreleases.MinimalExclude
+	}
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "list":
+		cmdList(args)
+	case "latest":
+		cmdLatest(args)
+	case "fetch":
+		cmdFetch(args)
+	case "download":
+		cmdDownload(args)
+	case "install":
+		cmdInstall(args)
+	case "verify":
+		cmdVerify(args)
+	case "matrix":
+		cmdMatrix(args)
+	case "env":
+		cmdEnv(args)
+	case "sbom":
+		cmdSBOM(args)
+	case "doctor":
+		cmdDoctor(args)
+	case "autoupdate":
+		cmdAutoupdate(args)
+	case "sync-mirror":
+		cmdSyncMirror(args)
+	case "verify-mirror":
+		cmdVerifyMirror(args)
+	case "completion":
+		cmdCompletion(args)
+	case "__versions":
+		cmdVersions(args)
+	default:
+		usage()
+	}
+}
+
+func xcheckf(err error, format string, args ...interface{}) {
+	if err != nil {
+		log.Fatalf("%s: %s", fmt.Sprintf(format, args...), err)
+	}
+}
+
+// xcheckFile is like xcheckf for errors from FindFile, suggesting a build from
+// source for platforms without binary releases.
+func xcheckFile(err error, rel goreleases.Release) {
+	var ferr *goreleases.FileNotFoundError
+	if errors.As(err, &ferr) && ferr.Source != nil {
+		if b, berr := goreleases.SuggestSourceBuild(rel, ferr.Os, ferr.Arch); berr == nil {
+			log.Printf("to build from source: %s", b)
+		}
+	}
+	xcheckf(err, "finding file")
+}
+
+// outputFlags adds the flags for output formatting to fs.
+func outputFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&jsonOutput, "json", jsonOutput, "write output as JSON")
+	fs.StringVar(&formatOutput, "format", formatOutput, "format output with text/template")
+}
+
+// output writes v as JSON or formatted with the template if requested, and
+// calls text otherwise.
+func output(v interface{}, text func()) {
+	switch {
+	case formatOutput != "":
+		t, err := template.New("format").Parse(formatOutput)
+		xcheckf(err, "parsing format template")
+		err = t.Execute(os.Stdout, v)
+		xcheckf(err, "executing format template")
+	case jsonOutput:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		err := enc.Encode(v)
+		xcheckf(err, "writing json")
+	default:
+		text()
+	}
+}
+
+// parseFlags parses args with fs, adding the output flags, allowing flags after the positional
+// arguments, e.g. "fetch go1.22.3 -dst /usr/local".
+func parseFlags(fs *flag.FlagSet, args []string) []string {
+	outputFlags(fs)
+	var pos []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return pos
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+}
+
+func list(all bool) []goreleases.Release {
+	rels, err := client.List(context.Background(), all)
+	xcheckf(err, "listing releases")
+	return rels
+}
+
+// findRelease resolves version spec, e.g. "go1.22.3", "1.22" or "latest", in
+// all releases.
+func findRelease(spec string) goreleases.Release {
+	rel, err := goreleases.Resolve(list(true), spec)
+	xcheckf(err, "resolving version")
+	return rel
+}
+
+func cmdList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	all := fs.Bool("all", false, "list all releases instead of only supported releases")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases list [-all]")
+		fs.PrintDefaults()
+	}
+	if len(parseFlags(fs, args)) != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	rels := list(*all)
+	output(rels, func() {
+		for _, rel := range rels {
+			if rel.Stable {
+				fmt.Println(rel.Version)
+			} else {
+				fmt.Println(rel.Version, "(unstable)")
+			}
+		}
+	})
+}
+
+func cmdLatest(args []string) {
+	fs := flag.NewFlagSet("latest", flag.ExitOnError)
+	all := fs.Bool("all", false, "include unstable releases")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases latest [-all] [minor]")
+		fs.PrintDefaults()
+	}
+	args = parseFlags(fs, args)
+	if len(args) > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var minor goreleases.Version
+	if len(args) == 1 {
+		var err error
+		minor, err = goreleases.ParseVersion(args[0])
+		xcheckf(err, "parsing minor version")
+	}
+
+	var best *goreleases.Release
+	var bestv goreleases.Version
+	rels := list(len(args) == 1)
+	for i, rel := range rels {
+		if !rel.Stable && !*all {
+			continue
+		}
+		v, err := goreleases.ParseVersion(rel.Version)
+		if err != nil || len(args) == 1 && !v.SameMinor(minor) {
+			continue
+		}
+		if best == nil || v.Compare(bestv) > 0 {
+			best, bestv = &rels[i], v
+		}
+	}
+	if best == nil {
+		log.Fatalf("no release found")
+	}
+	output(best, func() {
+		fmt.Println(best.Version)
+	})
+}
+
+func cmdFetch(args []string) {
+	fs := flag.NewFlagSet("fetch", flag.ExitOnError)
+	goos := fs.String("os", runtime.GOOS, "os of release")
+	goarch := fs.String("arch", runtime.GOARCH, "arch of release")
+	dst := fs.String("dst", ".", "directory to extract into, release is stored in dst/go")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases fetch [-os os] [-arch arch] [-dst dir] version")
+		fs.PrintDefaults()
+	}
+	args = parseFlags(fs, args)
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	rel := findRelease(args[0])
+	f, err := goreleases.FindFile(rel, *goos, *goarch, goreleases.KindArchive)
+	xcheckFile(err, rel)
+	err = client.Fetch(context.Background(), f, *dst, nil)
+	xcheckf(err, "fetching %s", f.Filename)
+	r := fileResult{filepath.Join(*dst, "go"), f}
+	output(r, func() {
+		fmt.Println(r.Path)
+	})
+}
+
+// fileResult is the output of fetch and download.
+type fileResult struct {
+	Path string
+	File goreleases.File
+}
+
+func cmdDownload(args []string) {
+	fs := flag.NewFlagSet("download", flag.ExitOnError)
+	goos := fs.String("os", runtime.GOOS, "os of release")
+	goarch := fs.String("arch", runtime.GOARCH, "arch of release")
+	kind := fs.String("kind", goreleases.KindArchive, "kind of file: archive, installer or source")
+	dst := fs.String("dst", ".", "directory to store file in")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases download [-os os] [-arch arch] [-kind kind] [-dst dir] version")
+		fs.PrintDefaults()
+	}
+	args = parseFlags(fs, args)
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	rel := findRelease(args[0])
+	f, err := goreleases.FindFile(rel, *goos, *goarch, *kind)
+	xcheckFile(err, rel)
+	err = client.Download(context.Background(), f, *dst)
+	xcheckf(err, "downloading %s", f.Filename)
+	r := fileResult{filepath.Join(*dst, f.Filename), f}
+	output(r, func() {
+		fmt.Println(r.Path)
+	})
+}
+
+func cmdInstall(args []string) {
+	fs := flag.NewFlagSet("install", flag.ExitOnError)
+	var opts goreleases.InstallOptions
+	fs.StringVar(&opts.Os, "os", runtime.GOOS, "os of release")
+	fs.StringVar(&opts.Arch, "arch", runtime.GOARCH, "arch of release")
+	fs.BoolVar(&opts.DryRun, "n", false, "dry run, only show what would be done")
+	fs.BoolVar(&opts.System, "system", false, "system install, owned by root with modes 0755/0644, refusing symlinks at dir")
+	fs.StringVar(&opts.Bootstrap, "bootstrap", "", "goroot of go installation to build version tip with, default from go in PATH")
+	actions := fs.Bool("github-actions", false, "install into the github actions tool cache, and set GOROOT, PATH and outputs")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases install [-os os] [-arch arch] [-n] [-system] [-bootstrap goroot] version [dir]")
+		fmt.Fprintln(os.Stderr, "       goreleases install -github-actions [-os os] [-arch arch] [-n] version")
+		fs.PrintDefaults()
+	}
+	args = parseFlags(fs, args)
+	if *actions {
+		if len(args) != 1 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		r, err := client.SetupGitHubActions(context.Background(), args[0], &opts)
+		xcheckf(err, "installing into tool cache")
+		output(r, func() {
+			if r.CacheHit {
+				fmt.Printf("%s found in tool cache at %s\n", r.Release.Version, r.Dir)
+			} else {
+				fmt.Printf("installed %s in tool cache at %s\n", r.Release.Version, r.Dir)
+			}
+		})
+		return
+	}
+	if len(args) != 1 && len(args) != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var dir string
+	if len(args) == 2 {
+		dir = args[1]
+	}
+	r, err := client.Install(context.Background(), args[0], dir, &opts)
+	xcheckf(err, "installing")
+	output(r, func() {
+		switch r.Action {
+		case goreleases.InstallNone:
+			fmt.Printf("%s already installed in %s\n", r.Release.Version, r.Dir)
+			return
+		case goreleases.InstallNew:
+			fmt.Printf("install %s in %s\n", r.Release.Version, r.Dir)
+		case goreleases.InstallReplaced:
+			fmt.Printf("replace %s with %s in %s\n", r.Previous, r.Release.Version, r.Dir)
+		}
+		fmt.Printf("file %s, %d bytes, sha256 %s\n", r.File.Filename, r.Size, r.File.Sha256)
+		if r.Cached {
+			fmt.Println("from archive cache")
+		} else if r.URL != "" {
+			fmt.Printf("from %s\n", r.URL)
+		}
+	})
+}
+
+func cmdVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases verify file ...")
+		fs.PrintDefaults()
+	}
+	args = parseFlags(fs, args)
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	files := map[string]goreleases.File{}
+	for _, rel := range list(true) {
+		for _, f := range rel.Files {
+			files[f.Filename] = f
+		}
+	}
+	type result struct {
+		Path  string
+		OK    bool
+		Error string `json:",omitempty"`
+	}
+	var results []result
+	failed := false
+	for _, p := range args {
+		r := result{Path: p, OK: true}
+		if err := verify(files, p); err != nil {
+			r = result{p, false, err.Error()}
+			failed = true
+		}
+		results = append(results, r)
+	}
+	output(results, func() {
+		for _, r := range results {
+			if r.OK {
+				fmt.Printf("%s: ok\n", r.Path)
+			} else {
+				log.Printf("%s: %s", r.Path, r.Error)
+			}
+		}
+	})
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// verify checks the local file at p by "downloading" it with a client that
+// reads the file locally, and signatures from the configured source.
+func verify(files map[string]goreleases.File, p string) error {
+	f, ok := files[filepath.Base(p)]
+	if !ok {
+		return fmt.Errorf("not a released file")
+	}
+	tmpdir, err := os.MkdirTemp("", "goreleases-verify")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+	c := goreleases.Client{Source: localSource{p, &client}}
+	return c.Download(context.Background(), f, tmpdir)
+}
+
+// localSource opens a local file, and gets signatures from upstream.
+type localSource struct {
+	path     string
+	upstream *goreleases.Client
+}
+
+func (s localSource) List(ctx context.Context, all bool) ([]goreleases.Release, error) {
+	return s.upstream.List(ctx, all)
+}
+
+func (s localSource) Open(ctx context.Context, file goreleases.File) (io.ReadCloser, error) {
+	return os.Open(s.path)
+}
+
+func (s localSource) Signature(ctx context.Context, file goreleases.File) ([]byte, error) {
+	return s.upstream.Signature(ctx, file)
+}
+
+func cmdMatrix(args []string) {
+	fs := flag.NewFlagSet("matrix", flag.ExitOnError)
+	var opts goreleases.MatrixOptions
+	fs.IntVar(&opts.Minors, "minors", 2, "number of minor versions")
+	fs.BoolVar(&opts.Prerelease, "rc", false, "include prerelease of upcoming minor version")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases matrix [-minors n] [-rc]")
+		fs.PrintDefaults()
+	}
+	if len(parseFlags(fs, args)) != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	l := goreleases.Matrix(list(true), opts)
+	output(l, func() {
+		for _, e := range l {
+			fmt.Println(e.Go)
+		}
+	})
+}
+
+func cmdEnv(args []string) {
+	fs := flag.NewFlagSet("env", flag.ExitOnError)
+	shell := goreleases.ShellSh
+	if runtime.GOOS == "windows" {
+		shell = goreleases.ShellPowerShell
+	}
+	fs.StringVar(&shell, "shell", shell, "shell to write script for: sh, fish or powershell")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases env [-shell sh|fish|powershell] goroot")
+		fs.PrintDefaults()
+	}
+	args = parseFlags(fs, args)
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	goroot, err := filepath.Abs(args[0])
+	xcheckf(err, "absolute path")
+	script, err := goreleases.EnvScript(goroot, shell)
+	xcheckf(err, "generating script")
+	output(struct{ GOROOT, Shell, Script string }{goroot, shell, script}, func() {
+		fmt.Print(script)
+	})
+}
+
+func cmdSBOM(args []string) {
+	fs := flag.NewFlagSet("sbom", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases sbom goroot")
+		fs.PrintDefaults()
+	}
+	args = parseFlags(fs, args)
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	m, err := goreleases.ScanInstall(args[0])
+	xcheckf(err, "reading install")
+	var url string
+	if m.Filename != "" {
+		url = goreleases.DefaultBaseURL + m.Filename
+		if client.BaseURL != "" {
+			url = client.BaseURL + m.Filename
+		}
+	}
+	var b bytes.Buffer
+	err = goreleases.WriteCycloneDX(&b, m, url)
+	xcheckf(err, "writing sbom")
+	// For -json and -format, the SBOM as generic JSON value.
+	var v interface{}
+	err = json.Unmarshal(b.Bytes(), &v)
+	xcheckf(err, "parsing sbom")
+	output(v, func() {
+		os.Stdout.Write(b.Bytes())
+	})
+}
+
+func cmdDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases doctor [dir ...]")
+		fs.PrintDefaults()
+	}
+	dirs := parseFlags(fs, args)
+	findings := client.Diagnose(context.Background(), dirs...)
+	output(findings, func() {
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+	})
+	for _, f := range findings {
+		if f.Severity == goreleases.SeverityError {
+			os.Exit(1)
+		}
+	}
+}
+
+// serviceFlags adds the flags for writing service definitions instead of
+// running.
+func serviceFlags(fs *flag.FlagSet) (systemd, winsw *string) {
+	systemd = fs.String("systemd", "", "write systemd service and timer to directory instead of running, e.g. /etc/systemd/system")
+	winsw = fs.String("winsw", "", "write WinSW xml configuration for a windows service to file instead of running")
+	return
+}
+
+// writeService writes the service definitions requested with the service
+// flags, returning false if none were requested.
+func writeService(s goreleases.Service, systemd, winsw string) bool {
+	if systemd == "" && winsw == "" {
+		return false
+	}
+	if systemd != "" {
+		for name, data := range s.SystemdUnits() {
+			p := filepath.Join(systemd, name)
+			err := os.WriteFile(p, []byte(data), 0644)
+			xcheckf(err, "writing systemd unit")
+			fmt.Printf("wrote %s\n", p)
+		}
+	}
+	if winsw != "" {
+		data, err := s.WinSWConfig()
+		xcheckf(err, "generating winsw configuration")
+		err = os.WriteFile(winsw, []byte(data), 0644)
+		xcheckf(err, "writing winsw configuration")
+		fmt.Printf("wrote %s\n", winsw)
+	}
+	return true
+}
+
+// executable returns the absolute path of this program, for service
+// definitions.
+func executable() string {
+	p, err := os.Executable()
+	xcheckf(err, "finding path of executable")
+	return p
+}
+
+func cmdAutoupdate(args []string) {
+	fs := flag.NewFlagSet("autoupdate", flag.ExitOnError)
+	m := &goreleases.Manager{Client: &client}
+	u := &goreleases.AutoUpdater{Manager: m}
+	fs.StringVar(&m.Root, "root", "", "root directory of installs, with symlink current to the active install, default the per-user install root")
+	fs.BoolVar(&m.Shims, "shims", false, "write shims for go and gofmt in root/bin")
+	fs.BoolVar(&m.Hardlink, "hardlink", false, "hard link files identical to files of other installs")
+	fs.StringVar(&u.Os, "os", "", "os of releases, default of this host")
+	fs.StringVar(&u.Arch, "arch", "", "arch of releases, default of this host")
+	fs.DurationVar(&u.Grace, "grace", 0, "remove older installs of the minor after new version is active this long, zero keeps them")
+	fs.DurationVar(&u.Interval, "interval", time.Hour, "time between checks")
+	once := fs.Bool("once", false, "check once and exit")
+	systemd, winsw := serviceFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases autoupdate [-root dir] [-shims] [-hardlink] [-os os] [-arch arch] [-grace duration] [-interval duration | -once] [-systemd dir | -winsw file] version")
+		fs.PrintDefaults()
+	}
+	args = parseFlags(fs, args)
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if m.Root == "" {
+		root, err := goreleases.UserInstallRoot()
+		xcheckf(err, "per-user install root")
+		m.Root = root
+	}
+	u.Spec = args[0]
+	if writeService(u.Service(executable()), *systemd, *winsw) {
+		return
+	}
+	u.Updated = func(version string) {
+		log.Printf("activated %s in %s", version, m.Root)
+	}
+	if *once {
+		_, err := u.Check(context.Background())
+		xcheckf(err, "checking for update")
+		return
+	}
+	u.Errors = func(err error) {
+		log.Printf("checking for update: %v", err)
+	}
+	u.Run(context.Background())
+}
+
+func cmdSyncMirror(args []string) {
+	fs := flag.NewFlagSet("sync-mirror", flag.ExitOnError)
+	var opts goreleases.SyncMirrorOptions
+	fs.BoolVar(&opts.All, "all", false, "mirror all releases, not only supported releases")
+	fs.BoolVar(&opts.Verify, "verify", false, "verify checksums of all files present in the mirror")
+	fs.IntVar(&opts.Concurrency, "concurrency", 0, "concurrent downloads, default 4")
+	interval := fs.Duration("interval", time.Hour, "time between synchronizations")
+	once := fs.Bool("once", false, "synchronize once and exit")
+	systemd, winsw := serviceFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases sync-mirror [-all] [-verify] [-concurrency n] [-interval duration | -once] [-systemd dir | -winsw file] dir")
+		fs.PrintDefaults()
+	}
+	args = parseFlags(fs, args)
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	dst := args[0]
+	if writeService(client.SyncMirrorService(executable(), dst, &opts, *interval), *systemd, *winsw) {
+		return
+	}
+	sync := func() error {
+		r, err := client.SyncMirror(context.Background(), dst, &opts)
+		for name, err := range r.Failed {
+			log.Printf("%s: %v", name, err)
+		}
+		if err == nil {
+			log.Printf("synchronized, %d new releases, %d downloaded, %d replaced, %d unchanged", len(r.NewReleases), len(r.Downloaded), len(r.Replaced), r.Unchanged)
+		}
+		return err
+	}
+	if *once {
+		xcheckf(sync(), "synchronizing mirror")
+		return
+	}
+	for {
+		if err := sync(); err != nil {
+			log.Printf("synchronizing mirror: %v", err)
+		}
+		time.Sleep(*interval)
+	}
+}
+
+func cmdVerifyMirror(args []string) {
+	fs := flag.NewFlagSet("verify-mirror", flag.ExitOnError)
+	var opts goreleases.VerifyMirrorOptions
+	fs.BoolVar(&opts.All, "all", false, "verify all releases, not only supported releases")
+	fs.IntVar(&opts.Concurrency, "concurrency", 0, "files checksummed concurrently, default 4")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: goreleases verify-mirror [-all] [-concurrency n] dir")
+		fs.PrintDefaults()
+	}
+	args = parseFlags(fs, args)
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	a, err := client.VerifyMirror(context.Background(), args[0], &opts)
+	xcheckf(err, "verifying mirror")
+	output(a, func() {
+		for _, f := range a.Missing {
+			log.Printf("missing: %s", f.Filename)
+		}
+		for _, f := range a.Corrupted {
+			log.Printf("corrupted: %s", f.Filename)
+		}
+		for _, name := range a.Extra {
+			log.Printf("extra: %s", name)
+		}
+		for _, v := range a.StaleListing {
+			log.Printf("stale listing: %s", v)
+		}
+		for name, err := range a.Failed {
+			log.Printf("%s: %v", name, err)
+		}
+		if a.OK() {
+			fmt.Printf("ok, %d files verified\n", a.Checked)
+		}
+	})
+	if !a.OK() {
+		os.Exit(1)
+	}
+}
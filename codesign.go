@@ -0,0 +1,93 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// GoogleTeamID is the Apple team identifier of Google LLC, the signer of Go
+// releases for macOS.
+const GoogleTeamID = "EQHXZ8M8AV"
+
+// CodesignError is returned by CodesignHook for a binary without a valid code
+// signature.
+type CodesignError struct {
+	Path   string // Of the binary.
+	Output string // Of codesign.
+	Err    error  // From running codesign.
+}
+
+func (e *CodesignError) Error() string {
+	return fmt.Sprintf("code signature of %s: %v: %s", e.Path, e.Err, e.Output)
+}
+
+func (e *CodesignError) Unwrap() error {
+	return e.Err
+}
+
+// CodesignHook returns an InstallHook that verifies the code signatures of the
+// executables in bin and pkg/tool/<os_arch> of a macOS install with codesign,
+// returning a *CodesignError for the first binary that is not validly
+// signed. If teamID is not empty, e.g. GoogleTeamID, binaries must be signed
+// with an Apple-issued certificate of that team. Nothing is done for files of
+// other systems, or when not running on macOS.
+func CodesignHook(teamID string) InstallHook {
+	return func(ctx context.Context, goroot string, file File) error {
+		if runtime.GOOS != "darwin" || file.Os != "darwin" {
+			return nil
+		}
+		return verifyCodesign(ctx, "codesign", goroot, teamID)
+	}
+}
+
+// verifyCodesign runs command codesign for the executables of goroot.
+func verifyCodesign(ctx context.Context, codesign, goroot, teamID string) error {
+	args := []string{"--verify", "--strict"}
+	if teamID != "" {
+		args = append(args, fmt.Sprintf(`-R=anchor apple generic and certificate leaf[subject.OU] = "%s"`, teamID))
+	}
+	bins, err := installExecutables(goroot)
+	if err != nil {
+		return err
+	}
+	for _, p := range bins {
+		out, err := exec.CommandContext(ctx, codesign, append(args, p)...).CombinedOutput()
+		if err != nil {
+			return &CodesignError{p, strings.TrimSpace(string(out)), err}
+		}
+	}
+	return nil
+}
+
+// installExecutables returns the paths of the executable files in bin and
+// pkg/tool/*/ of goroot, with executable bits or, for Windows, an .exe
+// extension.
+func installExecutables(goroot string) ([]string, error) {
+	dirs := []string{filepath.Join(goroot, "bin")}
+	tooldirs, err := filepath.Glob(filepath.Join(goroot, "pkg", "tool", "*"))
+	if err != nil {
+		return nil, err
+	}
+	dirs = append(dirs, tooldirs...)
+	var l []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, e := range entries {
+			if fi, err := e.Info(); err == nil && fi.Mode().IsRegular() && (fi.Mode()&0111 != 0 || strings.HasSuffix(e.Name(), ".exe")) {
+				l = append(l, filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	return l, nil
+}
@@ -0,0 +1,58 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// crossVerify checks the sha256 of file against Client.Pins, and with
+// Client.CrossVerify against the .sha256 file at BaseURL.
+func (c *Client) crossVerify(ctx context.Context, file File) error {
+	if pin, ok := c.Pins[file.Filename]; ok && !strings.EqualFold(pin, file.Sha256) {
+		return fmt.Errorf("checksum of %s is %s, does not match pinned %s", file.Filename, file.Sha256, pin)
+	}
+	if !c.CrossVerify {
+		return nil
+	}
+	if c.Source != nil {
+		return fmt.Errorf("cross-verifying checksums not supported with a source")
+	}
+	sum, err := c.publishedSha256(ctx, file)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(sum, file.Sha256) {
+		return fmt.Errorf("checksum of %s in listing is %s, .sha256 file has %s", file.Filename, file.Sha256, sum)
+	}
+	return nil
+}
+
+// publishedSha256 returns the checksum in the .sha256 file of file at
+// BaseURL.
+func (c *Client) publishedSha256(ctx context.Context, file File) (string, error) {
+	u, err := c.downloadURL(ctx, file, ".sha256")
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return "", fmt.Errorf("getting .sha256 file: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching .sha256 file, status %v, expected 200 OK", resp.Status)
+	}
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("reading .sha256 file: %v", err)
+	}
+	// Possibly in the format of sha256sum, with filename.
+	t := strings.Fields(string(buf))
+	if len(t) == 0 {
+		return "", fmt.Errorf("empty .sha256 file")
+	}
+	return t[0], nil
+}
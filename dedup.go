@@ -0,0 +1,67 @@
+package goreleases
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Dedup replaces identical files in the installs in directory root, with the
+// layout of FetchSDK and Manager, with hard links to a single copy. Files are
+// identical if their manifest entries have the same sha256 and size, and their
+// permissions are the same. Hard linked files share permissions and
+// modification times. The number of bytes saved is returned.
+func Dedup(root string) (int64, error) {
+	m := Manager{Root: root}
+	versions, err := m.Installed()
+	if err != nil {
+		return 0, err
+	}
+
+	type key struct {
+		sha256 string
+		size   int64
+		mode   os.FileMode
+	}
+	first := map[key]string{}
+	var saved int64
+	for _, v := range versions {
+		dir := m.Path(v)
+		man, err := ReadManifest(dir)
+		if err != nil {
+			// Not created by us, leave it alone.
+			continue
+		}
+		for _, e := range man.Entries {
+			if e.Type != EntryFile || e.Sha256 == "" {
+				continue
+			}
+			p := filepath.Join(dir, filepath.FromSlash(e.Name))
+			fi, err := os.Lstat(p)
+			if err != nil || !fi.Mode().IsRegular() || fi.Size() != e.Size {
+				continue
+			}
+			k := key{e.Sha256, e.Size, fi.Mode().Perm()}
+			orig, ok := first[k]
+			if !ok {
+				first[k] = p
+				continue
+			}
+			ofi, err := os.Stat(orig)
+			if err != nil || os.SameFile(fi, ofi) {
+				continue
+			}
+			tmp := p + ".goreleases-link"
+			os.Remove(tmp)
+			if err := os.Link(orig, tmp); err != nil {
+				return saved, fmt.Errorf("linking %s: %v", p, err)
+			}
+			if err := os.Rename(tmp, p); err != nil {
+				os.Remove(tmp)
+				return saved, fmt.Errorf("replacing %s: %v", p, err)
+			}
+			saved += e.Size
+		}
+	}
+	return saved, nil
+}
@@ -0,0 +1,329 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DeltaIndexName is the first entry in a delta, with the release files and
+// the manifest of the new install.
+const DeltaIndexName = "delta.json"
+
+// deltaIndex is stored as DeltaIndexName in a delta.
+type deltaIndex struct {
+	From     File
+	To       File
+	Manifest *Manifest // Of the install of To.
+}
+
+// MakeDelta writes a delta from release file from to release file to, e.g. of
+// two patch releases, to w. Both files are fetched, verified and compared, and
+// only the files in to that are new or changed are included. A delta is a
+// .tar.gz file with a DeltaIndexName entry, followed by entries with "go/"
+// prefix.
+//
+// Patch releases change a small fraction of the files, so a delta is much
+// smaller than the release file. Its contents (including the manifest) are
+// not signed, deltas must be distributed over a trusted channel. Apply a delta
+// with ApplyDelta. The include and exclude patterns of the client apply, the
+// install the delta is applied to must use the same patterns.
+func (c *Client) MakeDelta(ctx context.Context, w io.Writer, from, to File) error {
+	tmpdir, err := os.MkdirTemp(c.tempDir(), "goreleases-delta")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	fromdir := filepath.Join(tmpdir, "from")
+	todir := filepath.Join(tmpdir, "to")
+	var fm, tm *Manifest
+	for _, x := range []struct {
+		file File
+		dir  string
+		m    **Manifest
+	}{{from, fromdir, &fm}, {to, todir, &tm}} {
+		if err := os.Mkdir(x.dir, 0777); err != nil {
+			return err
+		}
+		*x.m, err = c.fetch(ctx, x.file, x.dir, nil)
+		if err != nil {
+			return fmt.Errorf("fetching %s: %w", x.file.Filename, err)
+		}
+	}
+	tm.Provenance = nil
+	old := deltaUnchanged(fm, tm)
+
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+	buf, err := json.Marshal(deltaIndex{from, to, tm})
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: DeltaIndexName, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(buf))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(buf); err != nil {
+		return err
+	}
+	// Files first, links can refer to them.
+	for _, files := range []bool{true, false} {
+		for _, e := range tm.Entries {
+			if e.Type == EntryDir || old[e.Name] || (e.Type == EntryFile) != files {
+				continue
+			}
+			if err := deltaAdd(tw, filepath.Join(todir, "go"), e); err != nil {
+				return fmt.Errorf("adding %s: %v", e.Name, err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
+
+// deltaUnchanged returns the names of the files in to that are identical in
+// from, and are not included in a delta.
+func deltaUnchanged(from, to *Manifest) map[string]bool {
+	prev := map[string]ManifestEntry{}
+	for _, e := range from.Entries {
+		prev[e.Name] = e
+	}
+	unchanged := map[string]bool{}
+	for _, e := range to.Entries {
+		if p, ok := prev[e.Name]; ok && e.Type == EntryFile && p == e {
+			unchanged[e.Name] = true
+		}
+	}
+	return unchanged
+}
+
+func deltaAdd(tw *tar.Writer, root string, e ManifestEntry) error {
+	h := &tar.Header{Name: "go/" + e.Name, Linkname: e.Linkname}
+	switch e.Type {
+	case EntrySymlink:
+		h.Typeflag = tar.TypeSymlink
+		return tw.WriteHeader(h)
+	case EntryLink:
+		h.Typeflag = tar.TypeLink
+		return tw.WriteHeader(h)
+	}
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(e.Name)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	h.Typeflag = tar.TypeReg
+	h.Mode = int64(fi.Mode() & 0777)
+	h.Size = fi.Size()
+	h.ModTime = fi.ModTime()
+	if err := tw.WriteHeader(h); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// ApplyDelta reads a delta created by MakeDelta from r and applies it to the
+// install in directory dir, as created by FetchSDK, Manager or Install. The
+// install must be of the release file the delta was made from, and complete.
+//
+// The new install is assembled in a temporary directory next to dir: Unchanged
+// files are hard linked (or copied) from dir, the other files are read from
+// the delta. The checksums of all files are verified against the manifest in
+// the delta. On success, dir is replaced and the manifest of the new install
+// returned. Post-install hooks are called with the new release file.
+func (c *Client) ApplyDelta(ctx context.Context, r io.Reader, dir string) (*Manifest, error) {
+	om, err := ReadManifest(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %v", err)
+	}
+	if err := checkInstall(dir, om); err != nil {
+		return nil, fmt.Errorf("checking install: %v", err)
+	}
+
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("gzip reader: %s", err)
+	}
+	tr := tar.NewReader(gzr)
+	h, err := tr.Next()
+	if err != nil || h.Name != DeltaIndexName {
+		return nil, fmt.Errorf("reading delta: missing %s", DeltaIndexName)
+	}
+	var d deltaIndex
+	if err := json.NewDecoder(tr).Decode(&d); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", DeltaIndexName, err)
+	}
+	if d.Manifest == nil {
+		return nil, fmt.Errorf("delta without manifest")
+	}
+	if d.From.Sha256 != om.Sha256 {
+		return nil, fmt.Errorf("delta is for %s, install is of %s", d.From.Filename, om.Filename)
+	}
+	m := d.Manifest
+	expect := map[string]ManifestEntry{}
+	for _, e := range m.Entries {
+		if strings.HasPrefix(e.Name, "/") || strings.Contains("/"+e.Name+"/", "/../") {
+			return nil, fmt.Errorf("bad name %q in manifest", e.Name)
+		}
+		expect[e.Name] = e
+	}
+
+	tmpdir, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpdir)
+	root := filepath.Join(tmpdir, "go")
+	if err := os.Mkdir(root, 0777); err != nil {
+		return nil, err
+	}
+
+	// Directories and unchanged files from the old install.
+	unchanged := deltaUnchanged(om, m)
+	x := &extractor{sync: c.Sync}
+	for _, e := range m.Entries {
+		p := filepath.Join(root, filepath.FromSlash(e.Name))
+		if e.Type == EntryDir {
+			if err := os.MkdirAll(p, 0777); err != nil {
+				return nil, err
+			}
+		} else if unchanged[e.Name] {
+			if err := x.deltaCopy(filepath.Join(dir, filepath.FromSlash(e.Name)), p, e); err != nil {
+				return nil, fmt.Errorf("copying unchanged %s: %v", e.Name, err)
+			}
+		}
+	}
+
+	// New and changed files from the delta.
+	seen := map[string]bool{}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading next header from delta: %s", err)
+		}
+		name := strings.TrimPrefix(h.Name, "go/")
+		e, ok := expect[name]
+		if !ok || unchanged[name] || seen[name] {
+			return nil, fmt.Errorf("unexpected entry %q in delta", h.Name)
+		}
+		seen[name] = true
+		p, err := dstName(tmpdir, h.Name)
+		if err != nil {
+			return nil, err
+		}
+		os.MkdirAll(filepath.Dir(p), 0777)
+		switch {
+		case h.Typeflag == tar.TypeReg && e.Type == EntryFile:
+			err = x.deltaWrite(p, os.FileMode(h.Mode)&0777, tr, e)
+		case h.Typeflag == tar.TypeSymlink && e.Type == EntrySymlink && h.Linkname == e.Linkname:
+			err = os.Symlink(h.Linkname, p)
+		case h.Typeflag == tar.TypeLink && e.Type == EntryLink && h.Linkname == e.Linkname:
+			var target string
+			target, err = dstName(tmpdir, h.Linkname)
+			if err == nil {
+				err = os.Link(target, p)
+			}
+		default:
+			err = fmt.Errorf("type does not match manifest")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", h.Name, err)
+		}
+	}
+	for _, e := range m.Entries {
+		if e.Type != EntryDir && !unchanged[e.Name] && !seen[e.Name] {
+			return nil, fmt.Errorf("%s missing in delta", e.Name)
+		}
+	}
+	if c.Sync {
+		if err := syncDirs(root); err != nil {
+			return nil, fmt.Errorf("sync: %v", err)
+		}
+	}
+
+	if err := writeManifest(root, m); err != nil {
+		return nil, fmt.Errorf("writing manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, UnpackedMarker), nil, 0666); err != nil {
+		return nil, fmt.Errorf("writing marker: %v", err)
+	}
+	if err := os.Rename(dir, filepath.Join(tmpdir, "old")); err != nil {
+		return nil, fmt.Errorf("moving old install away: %v", err)
+	}
+	if err := os.Rename(root, dir); err != nil {
+		// Try to restore the old install.
+		os.Rename(filepath.Join(tmpdir, "old"), dir)
+		return nil, fmt.Errorf("moving into place: %v", err)
+	}
+	if c.Sync {
+		if err := syncDir(filepath.Dir(dir)); err != nil {
+			return m, fmt.Errorf("sync: %v", err)
+		}
+	}
+	return m, c.runHooks(ctx, dir, d.To)
+}
+
+// deltaCopy hard links src to dst, or copies if linking fails, and verifies
+// the checksum.
+func (x *extractor) deltaCopy(src, dst string, e ManifestEntry) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := os.Link(src, dst); err == nil {
+		return deltaCheck(f, e)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return x.deltaWrite(dst, fi.Mode()&0777, f, e)
+}
+
+// deltaWrite creates dst with the data from r, which must match e.
+func (x *extractor) deltaWrite(dst string, mode os.FileMode, r io.Reader, e ManifestEntry) error {
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := deltaCheck(io.TeeReader(r, struct{ io.Writer }{f}), e); err != nil {
+		return err
+	}
+	if x.sync {
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("sync: %v", err)
+		}
+	}
+	return f.Close()
+}
+
+// deltaCheck reads r completely and checks its size and checksum against e.
+func deltaCheck(r io.Reader, e ManifestEntry) error {
+	hr := &hashReader{r, sha256.New()}
+	n, err := io.Copy(io.Discard, hr)
+	if err != nil {
+		return err
+	}
+	if sum := fmt.Sprintf("%x", hr.h.Sum(nil)); n != e.Size || sum != e.Sha256 {
+		return fmt.Errorf("got size %d, checksum %s, expected size %d, checksum %s", n, sum, e.Size, e.Sha256)
+	}
+	return nil
+}
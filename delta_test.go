@@ -0,0 +1,82 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDelta(t *testing.T) {
+	oldtgz := makeTgz(t, map[string]string{
+		"go/VERSION":          "go1.22.2\n",
+		"go/bin/go":           "binary",
+		"go/src/fmt/print.go": "source",
+		"go/src/old.go":       "removed",
+	})
+	newtgz := makeTgz(t, map[string]string{
+		"go/VERSION":          "go1.22.3\n",
+		"go/bin/go":           "binary",
+		"go/src/fmt/print.go": "source, fixed",
+		"go/src/new.go":       "added",
+	})
+	from := testFile("go1.22.2", oldtgz)
+	to := testFile("go1.22.3", newtgz)
+	c := Client{Source: &memSource{files: map[string][]byte{from.Filename: oldtgz, to.Filename: newtgz}}}
+	ctx := context.Background()
+
+	dir := filepath.Join(t.TempDir(), "go")
+	if err := c.install(ctx, from, dir, nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	var delta bytes.Buffer
+	if err := c.MakeDelta(ctx, &delta, from, to); err != nil {
+		t.Fatalf("make delta: %v", err)
+	}
+
+	gzr, err := gzip.NewReader(bytes.NewReader(delta.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	tr := tar.NewReader(gzr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, h.Name)
+	}
+	exp := []string{DeltaIndexName, "go/VERSION", "go/src/fmt/print.go", "go/src/new.go"}
+	if !reflect.DeepEqual(names, exp) {
+		t.Fatalf("delta entries: got %q, expected %q", names, exp)
+	}
+
+	m, err := c.ApplyDelta(ctx, bytes.NewReader(delta.Bytes()), dir)
+	if err != nil {
+		t.Fatalf("apply delta: %v", err)
+	}
+	if m.Sha256 != to.Sha256 {
+		t.Fatalf("manifest after delta is for %s, expected %s", m.Sha256, to.Sha256)
+	}
+	for name, exp := range map[string]string{"VERSION": "go1.22.3\n", "bin/go": "binary", "src/fmt/print.go": "source, fixed", "src/new.go": "added"} {
+		if buf, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(buf) != exp {
+			t.Errorf("%s: got %q, %v, expected %q", name, buf, err, exp)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src/old.go")); !os.IsNotExist(err) {
+		t.Errorf("removed file still present: %v", err)
+	}
+
+	// Applying again fails, the install is now of the new release.
+	if _, err := c.ApplyDelta(ctx, bytes.NewReader(delta.Bytes()), dir); err == nil {
+		t.Fatalf("applying delta to wrong install succeeded")
+	}
+}
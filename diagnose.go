@@ -0,0 +1,352 @@
+package goreleases
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Severities of a Finding.
+const (
+	SeverityOK      = "ok"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Checks of Client.Diagnose, in Finding.Check.
+const (
+	CheckConnectivity = "connectivity"
+	CheckProxy        = "proxy"
+	CheckTLS          = "tls"
+	CheckCache        = "cache"
+	CheckDestination  = "destination"
+	CheckDiskSpace    = "diskspace"
+)
+
+// DiagnoseMinFree is the free disk space below which Client.Diagnose warns. A
+// release needs about 70MB to download and 250MB when extracted.
+const DiagnoseMinFree = 1 << 30
+
+// Finding is the result of a check by Client.Diagnose.
+type Finding struct {
+	Check    string // E.g. CheckConnectivity.
+	Severity string // SeverityOK, SeverityWarning or SeverityError.
+	Message  string // What was found.
+	Advice   string // What to do about it, for warnings and errors.
+}
+
+func (f Finding) String() string {
+	s := fmt.Sprintf("%s: %s: %s", f.Severity, f.Check, f.Message)
+	if f.Advice != "" {
+		s += "; " + f.Advice
+	}
+	return s
+}
+
+// Diagnose checks the environment of the client for common problems and
+// returns the findings: Connectivity to the listing and download endpoints
+// (or Source), the proxy and TLS configuration, the health of CacheDir and
+// ArchiveCacheDir, and for each of dirs, e.g. a destination for Fetch or
+// Install, whether it is writable and has enough free disk space. Nothing is
+// changed, except for temporary files to test writability.
+func (c *Client) Diagnose(ctx context.Context, dirs ...string) []Finding {
+	var l []Finding
+	add := func(check, severity, advice, format string, args ...interface{}) {
+		l = append(l, Finding{check, severity, fmt.Sprintf(format, args...), advice})
+	}
+
+	c.diagnoseProxy(add)
+	c.diagnoseTLS(add)
+	c.diagnoseConnectivity(ctx, add)
+
+	for _, dir := range []string{c.CacheDir, c.ArchiveCacheDir} {
+		if dir == "" {
+			continue
+		}
+		if err := checkWritable(dir); err != nil {
+			add(CheckCache, SeverityError, "Fix the permissions, or configure another cache directory.", "cache directory %s not writable: %v", dir, err)
+		} else {
+			add(CheckCache, SeverityOK, "", "cache directory %s writable", dir)
+		}
+	}
+	if c.CacheDir != "" {
+		c.diagnoseListCache(add)
+	}
+	if c.ArchiveCacheDir != "" {
+		c.diagnoseArchiveCache(add)
+		dirs = append(dirs, c.ArchiveCacheDir)
+	}
+
+	for _, dir := range dirs {
+		if dir != c.ArchiveCacheDir {
+			if err := checkWritable(dir); err != nil {
+				add(CheckDestination, SeverityError, "Fix the permissions, e.g. run as a user that owns the directory, or use another destination.", "%s not writable: %v", dir, err)
+			} else {
+				add(CheckDestination, SeverityOK, "", "%s writable", dir)
+			}
+		}
+		fi, err := statFS(existingParent(dir))
+		if err != nil {
+			add(CheckDiskSpace, SeverityWarning, "", "free disk space for %s unknown: %v", dir, err)
+			continue
+		}
+		if fi.NoExec && dir != c.ArchiveCacheDir {
+			add(CheckDestination, SeverityError, "Install on a file system mounted without noexec.", "%s is on a file system mounted noexec, installed binaries cannot run", dir)
+		}
+		if fi.Free < DiagnoseMinFree {
+			add(CheckDiskSpace, SeverityWarning, "Free up disk space, e.g. with Prune or CleanCache, or use another file system.", "only %dMB free for %s", fi.Free>>20, dir)
+		} else {
+			add(CheckDiskSpace, SeverityOK, "", "%dMB free for %s", fi.Free>>20, dir)
+		}
+	}
+	return l
+}
+
+// diagnoseProxy reports the proxy used for requests to the base URL.
+func (c *Client) diagnoseProxy(add func(check, severity, advice, format string, args ...interface{})) {
+	if c.Source != nil {
+		return
+	}
+	hc := c.httpClient()
+	var t *http.Transport
+	if hc.Transport == nil {
+		t = http.DefaultTransport.(*http.Transport)
+	} else if tt, ok := hc.Transport.(*http.Transport); ok {
+		t = tt
+	} else {
+		add(CheckProxy, SeverityOK, "", "custom HTTP transport, proxy configuration not checked")
+		return
+	}
+	if t.Proxy == nil {
+		add(CheckProxy, SeverityOK, "", "no proxy used")
+		return
+	}
+	req, err := http.NewRequest("GET", c.baseURL(), nil)
+	if err != nil {
+		add(CheckProxy, SeverityError, "Fix Client.BaseURL.", "bad base URL %q: %v", c.baseURL(), err)
+		return
+	}
+	u, err := t.Proxy(req)
+	if err != nil {
+		add(CheckProxy, SeverityError, "Fix the proxy URL, e.g. in environment variable HTTPS_PROXY.", "bad proxy configuration: %v", err)
+	} else if u == nil {
+		add(CheckProxy, SeverityOK, "", "no proxy used for %s", req.URL.Host)
+	} else {
+		add(CheckProxy, SeverityOK, "", "requests to %s go through proxy %s", req.URL.Host, u.Redacted())
+	}
+}
+
+// diagnoseTLS checks the certificate files configured through the environment
+// and that system roots are available.
+func (c *Client) diagnoseTLS(add func(check, severity, advice, format string, args ...interface{})) {
+	ok := true
+	if p := os.Getenv("SSL_CERT_FILE"); p != "" {
+		if _, err := os.Stat(p); err != nil {
+			ok = false
+			add(CheckTLS, SeverityWarning, "Fix or unset environment variable SSL_CERT_FILE.", "SSL_CERT_FILE: %v", err)
+		}
+	}
+	for _, p := range filepath.SplitList(os.Getenv("SSL_CERT_DIR")) {
+		if _, err := os.Stat(p); err != nil {
+			ok = false
+			add(CheckTLS, SeverityWarning, "Fix or unset environment variable SSL_CERT_DIR.", "SSL_CERT_DIR: %v", err)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		if _, err := x509.SystemCertPool(); err != nil {
+			ok = false
+			add(CheckTLS, SeverityWarning, "Install the CA certificates of the system, e.g. package ca-certificates.", "no system certificates: %v", err)
+		}
+	}
+	if ok {
+		add(CheckTLS, SeverityOK, "", "certificate configuration looks fine")
+	}
+}
+
+// diagnoseConnectivity lists releases and requests the first file in the
+// listing, or lists from the Source.
+func (c *Client) diagnoseConnectivity(ctx context.Context, add func(check, severity, advice, format string, args ...interface{})) {
+	if c.Offline {
+		add(CheckConnectivity, SeverityOK, "", "offline, no requests are made")
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if c.Source != nil {
+		start := time.Now()
+		if rels, err := c.Source.List(ctx, false); err != nil {
+			add(CheckConnectivity, SeverityError, networkAdvice(err), "listing from source: %v", err)
+		} else {
+			add(CheckConnectivity, SeverityOK, "", "listed %d releases from source in %v", len(rels), time.Since(start).Round(time.Millisecond))
+		}
+		return
+	}
+
+	_, listURL := c.listURL(false)
+	start := time.Now()
+	resp, err := c.get(ctx, listURL)
+	var rels []Release
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("status %v, expected 200 OK", resp.Status)
+		} else if err = json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&rels); err != nil {
+			err = fmt.Errorf("parsing listing: %v", err)
+		}
+	}
+	if err != nil {
+		add(CheckConnectivity, SeverityError, networkAdvice(err), "listing from %s: %v", listURL, err)
+		return
+	}
+	add(CheckConnectivity, SeverityOK, "", "listed %d releases from %s in %v", len(rels), listURL, time.Since(start).Round(time.Millisecond))
+
+	if len(rels) == 0 || len(rels[0].Files) == 0 {
+		return
+	}
+	file := rels[0].Files[0]
+	u, err := c.downloadURL(ctx, file, "")
+	var req *http.Request
+	if err == nil {
+		req, err = http.NewRequestWithContext(ctx, "HEAD", u, nil)
+	}
+	if err == nil {
+		start = time.Now()
+		resp, err = c.do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				err = fmt.Errorf("status %v, expected 200 OK", resp.Status)
+			}
+		}
+	}
+	if err != nil {
+		add(CheckConnectivity, SeverityError, networkAdvice(err), "downloading %s: %v", file.Filename, err)
+	} else {
+		add(CheckConnectivity, SeverityOK, "", "download of %s available from %s in %v", file.Filename, resp.Request.URL.Host, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// networkAdvice returns advice for a failed request.
+func networkAdvice(err error) string {
+	var dnsErr *net.DNSError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &dnsErr):
+		return fmt.Sprintf("Check DNS resolution of %s, or configure a proxy in HTTPS_PROXY or a mirror as base URL.", dnsErr.Name)
+	case errors.As(err, &unknownAuthErr):
+		return "The certificate is signed by an unknown authority, often of a TLS-intercepting proxy. Add its CA certificate to the system certificates, or set SSL_CERT_FILE."
+	case errors.As(err, &hostnameErr):
+		return "The certificate is for another host, often of a TLS-intercepting proxy or captive portal. Check the network and proxy configuration."
+	case errors.As(err, &invalidErr):
+		return "The certificate is not valid. Check the system clock, and the network and proxy configuration."
+	case errors.Is(err, context.DeadlineExceeded) || os.IsTimeout(err):
+		return "The request timed out. Check firewalls and whether a proxy is required, in HTTPS_PROXY and NO_PROXY."
+	case strings.Contains(err.Error(), "connection refused"):
+		return "The connection was refused. Check firewalls and whether a proxy is required, in HTTPS_PROXY and NO_PROXY."
+	case strings.Contains(err.Error(), "status 403") || strings.Contains(err.Error(), "status 407"):
+		return "Access was denied, possibly by a proxy. Check proxy credentials in HTTPS_PROXY."
+	}
+	return "Check the network, proxy and base URL configuration."
+}
+
+// diagnoseListCache checks the cached listings are readable.
+func (c *Client) diagnoseListCache(add func(check, severity, advice, format string, args ...interface{})) {
+	entries, err := os.ReadDir(c.CacheDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			add(CheckCache, SeverityError, "Fix the permissions of the cache directory.", "reading cache directory: %v", err)
+		}
+		return
+	}
+	n := 0
+	for _, e := range entries {
+		name, ok := strings.CutPrefix(e.Name(), "list-")
+		if !ok || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		n++
+		if _, err := readListCache(c.CacheDir, strings.TrimSuffix(name, ".json")); err != nil {
+			add(CheckCache, SeverityWarning, "Remove the file, it is fetched again.", "cached listing %s: %v", e.Name(), err)
+		}
+	}
+	add(CheckCache, SeverityOK, "", "%d cached listings", n)
+}
+
+// diagnoseArchiveCache checks for leftover temporary files and the size of the
+// archive cache.
+func (c *Client) diagnoseArchiveCache(add func(check, severity, advice, format string, args ...interface{})) {
+	entries, err := os.ReadDir(c.ArchiveCacheDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			add(CheckCache, SeverityError, "Fix the permissions of the archive cache directory.", "reading archive cache directory: %v", err)
+		}
+		return
+	}
+	var files, temps int
+	var size int64
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(e.Name(), "sha256-"):
+			files++
+			size += fi.Size()
+		case strings.HasPrefix(e.Name(), ".sha256-") && time.Since(fi.ModTime()) > time.Hour:
+			temps++
+		}
+	}
+	if temps > 0 {
+		add(CheckCache, SeverityWarning, "Run CleanCache to remove them.", "%d leftover temporary files of interrupted downloads in archive cache", temps)
+	}
+	if c.ArchiveCacheMaxSize > 0 && size > c.ArchiveCacheMaxSize {
+		add(CheckCache, SeverityWarning, "Run CleanCache to enforce the limit.", "archive cache has %dMB, over its limit of %dMB", size>>20, c.ArchiveCacheMaxSize>>20)
+	}
+	add(CheckCache, SeverityOK, "", "archive cache has %d files, %dMB", files, size>>20)
+}
+
+// checkWritable checks that a file can be created in dir, or the nearest
+// existing parent directory if dir does not exist.
+func checkWritable(dir string) error {
+	p := existingParent(dir)
+	if fi, err := os.Stat(p); err != nil {
+		return err
+	} else if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", p)
+	}
+	f, err := os.CreateTemp(p, ".goreleases-diagnose-")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// existingParent returns dir, or its nearest parent directory that exists.
+func existingParent(dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,57 @@
+package goreleases
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mjl-/goreleases/goreleasestest"
+)
+
+func TestDiagnose(t *testing.T) {
+	srv := goreleasestest.NewServer("go1.22.3")
+	defer srv.Close()
+
+	archiveDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(archiveDir, "sha256-a"), make([]byte, 100), 0666); err != nil {
+		t.Fatal(err)
+	}
+	c := Client{BaseURL: srv.BaseURL(), ArchiveCacheDir: archiveDir}
+	dst := filepath.Join(t.TempDir(), "sdk", "go1.22.3")
+	findings := c.Diagnose(context.Background(), dst)
+	checks := map[string]bool{}
+	for _, f := range findings {
+		checks[f.Check] = true
+		if f.Check != CheckDiskSpace && f.Check != CheckTLS && f.Severity != SeverityOK {
+			t.Errorf("unexpected finding: %s", f)
+		}
+	}
+	for _, check := range []string{CheckConnectivity, CheckProxy, CheckCache, CheckDestination, CheckDiskSpace} {
+		if !checks[check] {
+			t.Errorf("no finding for check %s", check)
+		}
+	}
+	if srv.Requests("/") != 1 {
+		t.Errorf("got %d listing requests, expected 1", srv.Requests("/"))
+	}
+
+	// Unreachable server and destination that is a file.
+	srv.Close()
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	var errs []string
+	for _, f := range c.Diagnose(context.Background(), file) {
+		if f.Severity == SeverityError {
+			errs = append(errs, f.Check)
+			if f.Advice == "" {
+				t.Errorf("finding without advice: %s", f)
+			}
+		}
+	}
+	if len(errs) != 2 || errs[0] != CheckConnectivity || errs[1] != CheckDestination {
+		t.Fatalf("got errors for checks %v, expected connectivity and destination", errs)
+	}
+}
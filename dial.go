@@ -0,0 +1,102 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DialOptions configure connections made by the HTTP client of a Client
+// without HTTPClient, see Client.Dial. The HTTP client is created on first use
+// and kept with the options, which must not be copied after first use.
+type DialOptions struct {
+	// Network, "tcp4" or "tcp6" to only connect over IPv4 or IPv6. Default
+	// "tcp", both.
+	Network string
+
+	// For network "tcp", the IP family to try first, "tcp4" or "tcp6", e.g. for
+	// hosts with broken IPv6. Addresses of the other family are tried after
+	// all addresses of the preferred family failed.
+	Prefer string
+
+	// Local IP address to connect from, for multi-homed hosts.
+	LocalAddr string
+
+	// Called on the socket before connecting, e.g. to bind to a network
+	// interface with SO_BINDTODEVICE.
+	Control func(network, address string, c syscall.RawConn) error
+
+	// Resolver for host names. If nil, net.DefaultResolver is used.
+	Resolver *net.Resolver
+
+	// Timeout for establishing a connection, default 30s.
+	Timeout time.Duration
+
+	clientOnce sync.Once
+	client     *http.Client
+}
+
+// dialClient returns an HTTP client connecting with the options, reused for
+// the same options.
+func dialClient(o *DialOptions) *http.Client {
+	o.clientOnce.Do(func() {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.DialContext = o.dialContext
+		o.client = &http.Client{Transport: t}
+	})
+	return o.client
+}
+
+func (o *DialOptions) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := net.Dialer{Timeout: o.Timeout, KeepAlive: 30 * time.Second, Resolver: o.Resolver, Control: o.Control}
+	if d.Timeout == 0 {
+		d.Timeout = 30 * time.Second
+	}
+	if o.LocalAddr != "" {
+		ip := net.ParseIP(o.LocalAddr)
+		if ip == nil {
+			return nil, fmt.Errorf("bad local address %q", o.LocalAddr)
+		}
+		d.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+	if o.Network != "" {
+		network = o.Network
+	}
+	if network != "tcp" || o.Prefer == "" {
+		return d.DialContext(ctx, network, addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	resolver := o.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	ips, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var first, second []net.IPAddr
+	for _, ip := range ips {
+		if (ip.IP.To4() != nil) == (o.Prefer == "tcp4") {
+			first = append(first, ip)
+		} else {
+			second = append(second, ip)
+		}
+	}
+	err = fmt.Errorf("no addresses for %s", host)
+	for _, ip := range append(first, second...) {
+		var conn net.Conn
+		conn, err = d.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,30 @@
+package goreleases
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDial(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	file := File{Filename: "go1.22.3.linux-amd64.tar.gz"}
+	ctx := context.Background()
+
+	for _, o := range []*DialOptions{
+		{Network: "tcp4", LocalAddr: "127.0.0.1"},
+		{Prefer: "tcp6"},
+	} {
+		c := Client{BaseURL: srv.URL + "/", Dial: o}
+		if r, err := c.Probe(ctx, file); err != nil || !r.Exists {
+			t.Fatalf("probe with dial options %#v: %v %v", o, r, err)
+		}
+	}
+
+	c := Client{BaseURL: srv.URL + "/", Dial: &DialOptions{Network: "tcp6"}}
+	if _, err := c.Probe(ctx, file); err == nil {
+		t.Fatalf("probe of ipv4 address over ipv6 succeeded")
+	}
+}
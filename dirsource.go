@@ -0,0 +1,52 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DirSource is a ReleaseSource for a mirror in a local directory, as created by
+// Mirror or ImportBundle. Like a static mirror served over HTTP, List returns
+// all releases in the mirror, regardless of parameter all.
+type DirSource struct {
+	Dir string
+}
+
+var _ SignatureSource = DirSource{}
+
+// List returns the releases in MirrorIndex.
+func (s DirSource) List(ctx context.Context, all bool) ([]Release, error) {
+	return readMirrorIndex(s.Dir)
+}
+
+// Open opens the file in the directory.
+func (s DirSource) Open(ctx context.Context, file File) (io.ReadCloser, error) {
+	p, err := s.path(file.Filename)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+// Signature returns the .asc file for file, or nil if absent.
+func (s DirSource) Signature(ctx context.Context, file File) ([]byte, error) {
+	p, err := s.path(file.Filename)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := os.ReadFile(p + ".asc")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return buf, err
+}
+
+func (s DirSource) path(filename string) (string, error) {
+	if filename == "" || filepath.Base(filename) != filename || filename[0] == '.' {
+		return "", fmt.Errorf("bad filename %q", filename)
+	}
+	return filepath.Join(s.Dir, filename), nil
+}
@@ -0,0 +1,96 @@
+package goreleases
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Installation is a Go installation found on the system.
+type Installation struct {
+	GOROOT  string // Directory of the installation, with symlinks resolved.
+	Version string // From the VERSION file, e.g. "go1.22.3".
+	Found   string // Where it was found: "PATH", "default", "sdk" or "wrapper".
+}
+
+// ReadVersion returns the version of the Go installation in goroot, from the
+// first line of its VERSION file.
+func ReadVersion(goroot string) (string, error) {
+	buf, err := os.ReadFile(filepath.Join(goroot, "VERSION"))
+	if err != nil {
+		return "", err
+	}
+	s := string(buf)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// DiscoverInstalls looks for Go installations in common locations: The go
+// command in PATH, the default install location (/usr/local/go, or
+// C:\Program Files\Go on Windows), installs in the sdk directory (see
+// SDKDir), and golang.org/dl wrapper commands like go1.22.3 in $HOME/go/bin,
+// which use the sdk directory. Directories without VERSION file are skipped.
+// Installations are returned sorted by GOROOT, each at most once.
+func DiscoverInstalls() ([]Installation, error) {
+	seen := map[string]bool{}
+	var l []Installation
+	add := func(goroot, found string) {
+		goroot, err := filepath.EvalSymlinks(goroot)
+		if err != nil {
+			return
+		}
+		goroot, err = filepath.Abs(goroot)
+		if err != nil || seen[goroot] {
+			return
+		}
+		version, err := ReadVersion(goroot)
+		if err != nil {
+			return
+		}
+		seen[goroot] = true
+		l = append(l, Installation{goroot, version, found})
+	}
+
+	if p, err := exec.LookPath("go"); err == nil {
+		if p, err := filepath.EvalSymlinks(p); err == nil {
+			add(filepath.Dir(filepath.Dir(p)), "PATH")
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		add(`C:\Program Files\Go`, "default")
+	} else {
+		add("/usr/local/go", "default")
+	}
+
+	sdk, err := SDKDir()
+	if err == nil {
+		entries, _ := os.ReadDir(sdk)
+		for _, e := range entries {
+			if e.IsDir() && strings.HasPrefix(e.Name(), "go") {
+				add(filepath.Join(sdk, e.Name()), "sdk")
+			}
+		}
+
+		// Wrappers like $HOME/go/bin/go1.22.3 run $HOME/sdk/go1.22.3. Wrappers
+		// without a downloaded sdk are skipped.
+		home, _ := os.UserHomeDir()
+		entries, _ = os.ReadDir(filepath.Join(home, "go", "bin"))
+		for _, e := range entries {
+			name := strings.TrimSuffix(e.Name(), ".exe")
+			if _, err := ParseVersion(name); err == nil && strings.HasPrefix(name, "go") {
+				add(filepath.Join(sdk, name), "wrapper")
+			}
+		}
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].GOROOT < l[j].GOROOT
+	})
+	return l, nil
+}
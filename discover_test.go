@@ -0,0 +1,58 @@
+package goreleases
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDiscoverInstalls(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("home directory and executables set up for unix only")
+	}
+	tmp, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(p, s string, mode os.FileMode) {
+		t.Helper()
+		p = filepath.Join(tmp, p)
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(s), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("goroot/VERSION", "go1.21.0\ntime 2023-08-08\n", 0644)
+	write("goroot/bin/go", "#!/bin/sh\n", 0755)
+	write("home/sdk/go1.22.3/VERSION", "go1.22.3\n", 0644)
+	write("home/sdk/go1.20.1/README", "no version file\n", 0644)
+	// Wrapper for an sdk found above, and one without sdk.
+	write("home/go/bin/go1.22.3", "#!/bin/sh\n", 0755)
+	write("home/go/bin/go1.19.0", "#!/bin/sh\n", 0755)
+	t.Setenv("PATH", filepath.Join(tmp, "goroot", "bin"))
+	t.Setenv("HOME", filepath.Join(tmp, "home"))
+
+	l, err := DiscoverInstalls()
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	// The default location may have an install on this system.
+	var found []Installation
+	for _, inst := range l {
+		if strings.HasPrefix(inst.GOROOT, tmp) {
+			found = append(found, inst)
+		}
+	}
+	exp := []Installation{
+		{filepath.Join(tmp, "goroot"), "go1.21.0", "PATH"},
+		{filepath.Join(tmp, "home", "sdk", "go1.22.3"), "go1.22.3", "sdk"},
+	}
+	if !reflect.DeepEqual(found, exp) {
+		t.Fatalf("got %v, expected %v", found, exp)
+	}
+}
@@ -1,5 +1,13 @@
 // Package goreleases lists all or supported Go toolchain releases, and download/verify/extract them.
 //
 // A list of releases is retrieved from go.dev/dl/?mode=json, optionally with the include=all parameter.
+// Mirrors serving the same listing and files can be used, see Client.BaseURL.
+// For disconnected networks, releases can be moved in signed bundles, see
+// Client.ExportBundle and ImportBundle. Toolchains can also be fetched from
+// a module proxy, see ToolchainSource.
+// Listings can be cached on disk, see Client. CachingTransport caches HTTP
+// responses for use with other download code.
+// Defaults of the package-level functions can be set with environment
+// variables like GORELEASES_BASE_URL, see Client.ApplyEnv.
 // The released files are assumed to contain just a directory named "go" with a release.
 package goreleases
@@ -0,0 +1,69 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Download downloads file into directory dst, verifying its gpg signature and
+// sha256 checksum. The file is stored as dst/<filename>.
+//
+// Unlike Fetch, files of any kind can be downloaded, including installers
+// (.msi and .pkg) that cannot be extracted by this package.
+func Download(file File, dst string) error {
+	return DefaultClient().Download(context.Background(), file, dst)
+}
+
+// Download is like the package-level Download, but downloads from the client's
+// Source or BaseURL.
+func (c *Client) Download(ctx context.Context, file File, dst string) error {
+	if !validFilename(file.Filename) {
+		return fmt.Errorf("bad filename %q", file.Filename)
+	}
+
+	// Write to a temporary file in dst first, so we can rename it into place after
+	// verification. With the extension of the file, for verifying signatures.
+	f, err := os.CreateTemp(dst, ".goreleases-download*"+filepath.Ext(file.Filename))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if f != nil {
+			name := f.Name()
+			f.Close()
+			os.Remove(name)
+		}
+	}()
+
+	sum, _, err := c.download(ctx, file, f)
+	if err != nil {
+		return err
+	}
+	if err := c.checkSha256(file, sum); err != nil {
+		return err
+	}
+	if c.AuthenticodeSigner != "" && strings.HasSuffix(file.Filename, ".msi") {
+		if err := verifyAuthenticode(ctx, f.Name(), c.AuthenticodeSigner); err != nil {
+			return err
+		}
+	}
+	name := f.Name()
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close: %v", err)
+	}
+	f = nil
+	if err := os.Rename(name, filepath.Join(dst, file.Filename)); err != nil {
+		os.Remove(name)
+		return fmt.Errorf("rename: %v", err)
+	}
+	return nil
+}
+
+// validFilename returns whether name from a listing can be used as the name of
+// a file in a directory: Not empty, "." or "..", and without path separators.
+func validFilename(name string) bool {
+	return name != "" && name != "." && name != ".." && filepath.Base(name) == name
+}
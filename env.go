@@ -0,0 +1,42 @@
+package goreleases
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Shells for EnvScript.
+const (
+	ShellSh         = "sh" // POSIX shells, like bash and zsh.
+	ShellFish       = "fish"
+	ShellPowerShell = "powershell"
+)
+
+// EnvScript returns a snippet for shell that sets GOROOT to goroot and
+// prepends goroot/bin to PATH, for use like "eval $(tool env)", or
+// "tool env | Invoke-Expression" with PowerShell.
+func EnvScript(goroot, shell string) (string, error) {
+	bin := filepath.Join(goroot, "bin")
+	switch shell {
+	case ShellSh:
+		return fmt.Sprintf("export GOROOT=%s\nexport PATH=%s:\"$PATH\"\n", shQuote(goroot), shQuote(bin)), nil
+	case ShellFish:
+		return fmt.Sprintf("set -gx GOROOT %s\nset -gx PATH %s $PATH\n", fishQuote(goroot), fishQuote(bin)), nil
+	case ShellPowerShell:
+		return fmt.Sprintf("$env:GOROOT = %s\n$env:PATH = %s + [IO.Path]::PathSeparator + $env:PATH\n", psQuote(goroot), psQuote(bin)), nil
+	}
+	return "", fmt.Errorf("unknown shell %q", shell)
+}
+
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,52 @@
+package goreleases
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEnvScript(t *testing.T) {
+	goroot := filepath.Join(t.TempDir(), "it's go")
+	for _, shell := range []string{ShellSh, ShellFish, ShellPowerShell} {
+		if _, err := EnvScript(goroot, shell); err != nil {
+			t.Fatalf("env script for %s: %s", shell, err)
+		}
+	}
+	if _, err := EnvScript(goroot, "csh"); err == nil {
+		t.Fatalf("env script for unknown shell succeeded")
+	}
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	s, _ := EnvScript(goroot, ShellSh)
+	out, err := exec.Command("sh", "-c", s+`printf %s "$GOROOT"`).Output()
+	if err != nil || string(out) != goroot {
+		t.Fatalf("running sh script: got %q, %v, expected %q", out, err, goroot)
+	}
+}
+
+func TestPrependPath(t *testing.T) {
+	dir := filepath.Join("go", "bin")
+	if got, exp := prependPath("a;"+dir+";b;", dir), dir+";a;b"; got != exp {
+		t.Fatalf("got %q, expected %q", got, exp)
+	}
+	if got := prependPath("", dir); got != dir {
+		t.Fatalf("got %q, expected %q", got, dir)
+	}
+}
+
+func TestSetWindowsACL(t *testing.T) {
+	dir := t.TempDir()
+	if err := SetWindowsACL(dir, "bogus"); err == nil {
+		t.Fatalf("unknown acl accepted")
+	}
+	err := SetWindowsACL(dir, WindowsACLInherit)
+	if runtime.GOOS == "windows" && err != nil {
+		t.Fatalf("set acl: %s", err)
+	} else if runtime.GOOS != "windows" && err == nil {
+		t.Fatalf("set acl succeeded on %s", runtime.GOOS)
+	}
+}
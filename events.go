@@ -0,0 +1,43 @@
+package goreleases
+
+import (
+	"time"
+)
+
+// EventType is the type of an Event.
+type EventType string
+
+// Events emitted during a fetch or install, see Client.Events.
+const (
+	EventResolve  EventType = "resolve"  // Resolving version Spec, by Install and Manager.
+	EventResolved EventType = "resolved" // Spec resolved to File.
+	EventCached   EventType = "cached"   // File is read from the archive cache.
+	EventDownload EventType = "download" // Download of File from URL started, Total bytes, zero if unknown.
+	EventProgress EventType = "progress" // Bytes of Total downloaded.
+	EventExtract  EventType = "extract"  // Entry Name of Bytes extracted.
+	EventVerified EventType = "verified" // Checksum and signature of File verified, for streamed archives after extraction.
+	EventDone     EventType = "done"     // File extracted into Dir, the "go" directory.
+	EventRename   EventType = "rename"   // Install renamed into place as Dir, by FetchSDK, Install and Manager.
+)
+
+// Event describes a step of a fetch, for rendering detailed status, see
+// Client.Events.
+type Event struct {
+	Type  EventType
+	Time  time.Time
+	Spec  string // Version spec, for EventResolve and EventResolved.
+	File  File   // Except for EventResolve.
+	URL   string // For EventDownload, empty for a Client.Source without URLs.
+	Bytes int64  // Downloaded so far for EventProgress, entry size for EventExtract.
+	Total int64  // Expected download size, zero if unknown.
+	Name  string // Entry relative to the "go" directory, with slashes, for EventExtract.
+	Dir   string
+}
+
+// emit calls Client.Events with e, if set.
+func (c *Client) emit(e Event) {
+	if c.Events != nil {
+		e.Time = time.Now()
+		c.Events(e)
+	}
+}
@@ -0,0 +1,96 @@
+package goreleases
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ExportRelease is a release in the stable export schema of WriteJSON, with
+// fields derived from the version and files.
+type ExportRelease struct {
+	Version   string       `json:"version"`
+	Stable    bool         `json:"stable"`
+	Major     int          `json:"major"`
+	Minor     int          `json:"minor"`
+	Patch     int          `json:"patch"`
+	Pre       string       `json:"pre"`       // E.g. "rc2", empty for final releases.
+	Platforms int          `json:"platforms"` // Number of os/arch combinations with files.
+	Files     []ExportFile `json:"files"`
+}
+
+// ExportFile is a file in the stable export schema of WriteJSON.
+type ExportFile struct {
+	Filename string `json:"filename"`
+	Os       string `json:"os"`
+	Arch     string `json:"arch"`
+	Kind     string `json:"kind"`
+	Size     int64  `json:"size"`
+	Sha256   string `json:"sha256"`
+}
+
+// Export returns releases in the export schema. Versions that cannot be parsed
+// have zero version components.
+func Export(releases []Release) []ExportRelease {
+	l := []ExportRelease{}
+	for _, rel := range releases {
+		v, _ := ParseVersion(rel.Version)
+		xr := ExportRelease{
+			Version: rel.Version,
+			Stable:  rel.Stable,
+			Major:   v.Major,
+			Minor:   v.Minor,
+			Patch:   v.Patch,
+			Files:   []ExportFile{},
+		}
+		if v.Pre != "" {
+			xr.Pre = fmt.Sprintf("%s%d", v.Pre, v.PreN)
+		}
+		platforms := map[[2]string]bool{}
+		for _, f := range rel.Files {
+			if f.Os != "" || f.Arch != "" {
+				platforms[[2]string{f.Os, f.Arch}] = true
+			}
+			xr.Files = append(xr.Files, ExportFile{f.Filename, f.Os, f.Arch, f.Kind, f.Size, f.Sha256})
+		}
+		xr.Platforms = len(platforms)
+		l = append(l, xr)
+	}
+	return l
+}
+
+// WriteJSON writes releases in the export schema as indented JSON to w.
+func WriteJSON(w io.Writer, releases []Release) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(Export(releases))
+}
+
+// WriteCSV writes a CSV file with a header and a line for each file of each
+// release to w.
+func WriteCSV(w io.Writer, releases []Release) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"version", "stable", "major", "minor", "patch", "pre", "platforms", "filename", "os", "arch", "kind", "size", "sha256"})
+	for _, xr := range Export(releases) {
+		for _, f := range xr.Files {
+			cw.Write([]string{
+				xr.Version,
+				fmt.Sprint(xr.Stable),
+				fmt.Sprint(xr.Major),
+				fmt.Sprint(xr.Minor),
+				fmt.Sprint(xr.Patch),
+				xr.Pre,
+				fmt.Sprint(xr.Platforms),
+				f.Filename,
+				f.Os,
+				f.Arch,
+				f.Kind,
+				fmt.Sprint(f.Size),
+				f.Sha256,
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
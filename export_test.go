@@ -0,0 +1,48 @@
+package goreleases
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	rels := []Release{
+		{Version: "go1.23rc1", Files: []File{
+			{Filename: "go1.23rc1.src.tar.gz", Kind: KindSource, Size: 10, Sha256: "aa"},
+			{Filename: "go1.23rc1.linux-amd64.tar.gz", Os: "linux", Arch: "amd64", Kind: KindArchive, Size: 20, Sha256: "bb"},
+			{Filename: "go1.23rc1.windows-amd64.zip", Os: "windows", Arch: "amd64", Kind: KindArchive},
+		}},
+		{Version: "go1.22.3", Stable: true},
+	}
+	l := Export(rels)
+	exp := ExportRelease{Version: "go1.23rc1", Major: 1, Minor: 23, Pre: "rc1", Platforms: 2}
+	if x := l[0]; x.Version != exp.Version || x.Major != exp.Major || x.Minor != exp.Minor || x.Pre != exp.Pre || x.Platforms != exp.Platforms || len(x.Files) != 3 {
+		t.Fatalf("got %#v, expected %#v with 3 files", x, exp)
+	}
+	if x := l[1]; x.Patch != 3 || !x.Stable || x.Files == nil {
+		t.Fatalf("got %#v, expected patch 3, stable and empty files", x)
+	}
+
+	var b strings.Builder
+	if err := WriteJSON(&b, rels); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	var xl []ExportRelease
+	if err := json.Unmarshal([]byte(b.String()), &xl); err != nil || !reflect.DeepEqual(xl, l) {
+		t.Fatalf("json roundtrip: %v, %v", xl, err)
+	}
+	if !strings.Contains(b.String(), `"files": []`) {
+		t.Fatalf("release without files not written as empty list:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := WriteCSV(&b, rels); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "version,stable,") || lines[2] != "go1.23rc1,false,1,23,0,rc1,2,go1.23rc1.linux-amd64.tar.gz,linux,amd64,archive,20,bb" {
+		t.Fatalf("unexpected csv:\n%s", b.String())
+	}
+}
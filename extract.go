@@ -0,0 +1,197 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// extractor extracts files from an archive into dst.
+type extractor struct {
+	dst         string
+	perms       *Permissions
+	include     []string // Patterns, see Client.Include.
+	exclude     []string
+	entries     []ManifestEntry // Extracted entries, for the manifest.
+	buf         []byte          // Reused for copying file data, allocated on first use.
+	sync        bool            // Fsync each file after writing.
+	exact       bool            // Chmod to the exact mode from the archive, see Client.ExactModes.
+	xattrs      bool            // Set extended attributes from PAX records, see Client.Xattrs.
+	xattrFilter func(name, attr string, value []byte) ([]byte, bool)
+	signer      string                        // Required Authenticode signer of .msi files, see Client.AuthenticodeSigner.
+	strict      bool                          // Refuse unexpected entries, see Client.Strict.
+	onEntry     func(name string, size int64) // If set, called for each extracted entry, for Client.Events.
+	resume      *resumeJournal                // If set, continuing an earlier extraction into dst, see Client.ResumeExtract.
+	root        *extractRoot                  // For dst, all entries are created through it, see openRoot.
+
+	// Chown to uid/gid from tar headers, through ownerMap if set, see
+	// Client.ArchiveOwner.
+	owner    bool
+	ownerMap func(uid, gid int) (int, int)
+
+	// If not nil, directory modification times to set after extraction, for
+	// Client.Reproducible.
+	dirTimes map[string]time.Time
+}
+
+// openRoot opens the root for creating entries in dst. The caller must call
+// closeRoot.
+func (x *extractor) openRoot() error {
+	root, err := openExtractRoot(x.dst)
+	if err != nil {
+		return err
+	}
+	x.root = root
+	return nil
+}
+
+func (x *extractor) closeRoot() {
+	if x.root != nil {
+		x.root.Close()
+		x.root = nil
+	}
+}
+
+// path returns the path of name, relative to the root, on the file system.
+func (r *extractRoot) path(name string) string {
+	return filepath.Join(r.dir, filepath.FromSlash(name))
+}
+
+// copy copies r to w through the buffer of x. Writer w is wrapped so an
+// os.File does not use its ReadFrom, which allocates a buffer per call.
+func (x *extractor) copy(w io.Writer, r io.Reader) (int64, error) {
+	if x.buf == nil {
+		x.buf = make([]byte, DefaultBufferSize)
+	}
+	return io.CopyBuffer(struct{ io.Writer }{w}, r, x.buf)
+}
+
+// dirTime records the modification time for directory rel in the root, if
+// needed.
+func (x *extractor) dirTime(rel string, mtime time.Time) {
+	if x.dirTimes != nil {
+		x.dirTimes[rel] = mtime
+	}
+}
+
+// restoreDirTimes sets the recorded modification times of directories, which
+// were changed by extracting into them.
+func (x *extractor) restoreDirTimes() error {
+	if len(x.dirTimes) == 0 {
+		return nil
+	}
+	root, err := openExtractRoot(x.dst)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	for rel, mtime := range x.dirTimes {
+		if err := root.Chtimes(rel, mtime, mtime); err != nil {
+			return fmt.Errorf("chtimes: %v", err)
+		}
+	}
+	return nil
+}
+
+// buffered returns r with a read buffer of the size of the copy buffer, for
+// reading compressed archives.
+func (x *extractor) buffered(r io.Reader) io.Reader {
+	n := len(x.buf)
+	if n == 0 {
+		n = DefaultBufferSize
+	}
+	return bufio.NewReaderSize(r, n)
+}
+
+// add records an extracted entry. Name is the path in the archive, starting
+// with "go/", which is stripped.
+func (x *extractor) add(name, typ string, size int64, sha256, linkname string) {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "go/"), "/")
+	if name == "" || name == "go" {
+		return
+	}
+	x.entries = append(x.entries, ManifestEntry{name, typ, size, sha256, linkname})
+	if x.onEntry != nil {
+		x.onEntry(name, size)
+	}
+}
+
+// skip returns whether the entry with name in the archive, starting with "go/",
+// must not be extracted due to the include and exclude patterns. Directories
+// that can contain included paths are extracted.
+func (x *extractor) skip(name string, isdir bool) bool {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "go/"), "/")
+	if name == "" || name == "go" {
+		return false
+	}
+	ns := strings.Split(name, "/")
+	for _, p := range x.exclude {
+		if matchPath(strings.Split(p, "/"), ns, false) {
+			return true
+		}
+	}
+	if len(x.include) == 0 {
+		return false
+	}
+	for _, p := range x.include {
+		if matchPath(strings.Split(p, "/"), ns, isdir) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPath returns whether pattern segments ps match a leading part of path
+// segments ns, so a pattern matches a directory and everything in it. Segment
+// "**" matches zero or more segments, other segments are matched with
+// path.Match. With ancestor set, ns also matches if it is a directory that can
+// contain paths matching ps.
+func matchPath(ps, ns []string, ancestor bool) bool {
+	if len(ps) == 0 {
+		return true
+	}
+	if len(ns) == 0 {
+		return ancestor
+	}
+	if ps[0] == "**" {
+		return matchPath(ps[1:], ns, ancestor) || matchPath(ps, ns[1:], ancestor)
+	}
+	if ok, err := path.Match(ps[0], ns[0]); err != nil || !ok {
+		return false
+	}
+	return matchPath(ps[1:], ns[1:], ancestor)
+}
+
+// MinimalExclude is an extraction profile for Client.Exclude for a smaller
+// GOROOT, e.g. for CI images. It skips the api and doc directories, the
+// compiler and cgo test suites, test data and tests of the standard library.
+// Building, testing and vetting programs keeps working.
+var MinimalExclude = []string{
+	"api",
+	"doc",
+	"test",
+	"misc/cgo",
+	"**/testdata",
+	"src/**/*_test.go",
+}
+
+// chownHeader changes the owner of the extracted entry at rel in the root to the uid and
+// gid of tar header h, if configured and no owner is set in the permissions.
+func (x *extractor) chownHeader(rel string, h *tar.Header) error {
+	if !x.owner || x.perms != nil && (x.perms.Uid >= 0 || x.perms.Gid >= 0) {
+		return nil
+	}
+	uid, gid := h.Uid, h.Gid
+	if x.ownerMap != nil {
+		uid, gid = x.ownerMap(uid, gid)
+	}
+	if err := x.root.Lchown(rel, uid, gid); err != nil {
+		return fmt.Errorf("chown: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+//go:build go1.24 && !go1.25
+// +build go1.24,!go1.25
+
+package goreleases
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// extractRoot performs the writes of an extraction into directory dir, for
+// slash-separated names relative to dir, as in archives. Entries are opened and
+// created through an os.Root, so names with ".." and paths through symbolic
+// links cannot escape dir, regardless of what the archive created earlier. The
+// os.Root of Go 1.24 cannot create links or change metadata, those operations
+// check the parent directory through the root, then use the path. Go 1.25 and
+// later do all operations through the root.
+type extractRoot struct {
+	dir  string
+	root *os.Root
+}
+
+func openExtractRoot(dir string) (*extractRoot, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &extractRoot{dir, root}, nil
+}
+
+func (r *extractRoot) Close() error {
+	return r.root.Close()
+}
+
+func (r *extractRoot) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return r.root.OpenFile(filepath.FromSlash(name), flag, perm)
+}
+
+func (r *extractRoot) Mkdir(name string, perm os.FileMode) error {
+	return r.root.Mkdir(filepath.FromSlash(name), perm)
+}
+
+// MkdirAll creates directory name and its missing parents.
+func (r *extractRoot) MkdirAll(name string) error {
+	name = path.Clean(name)
+	if name == "." {
+		return nil
+	}
+	var p string
+	for _, elem := range strings.Split(name, "/") {
+		p = path.Join(p, elem)
+		err := r.root.Mkdir(filepath.FromSlash(p), 0777)
+		if err != nil && os.IsExist(err) {
+			var fi os.FileInfo
+			if fi, err = r.root.Stat(filepath.FromSlash(p)); err == nil && !fi.IsDir() {
+				err = fmt.Errorf("%s: not a directory", p)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *extractRoot) Remove(name string) error {
+	return r.root.Remove(filepath.FromSlash(name))
+}
+
+func (r *extractRoot) Lstat(name string) (os.FileInfo, error) {
+	return r.root.Lstat(filepath.FromSlash(name))
+}
+
+func (r *extractRoot) Chmod(name string, mode os.FileMode) error {
+	if err := r.checkParent(name); err != nil {
+		return err
+	}
+	return os.Chmod(r.path(name), mode)
+}
+
+func (r *extractRoot) Lchown(name string, uid, gid int) error {
+	if err := r.checkParent(name); err != nil {
+		return err
+	}
+	return os.Lchown(r.path(name), uid, gid)
+}
+
+func (r *extractRoot) Chtimes(name string, atime, mtime time.Time) error {
+	if err := r.checkParent(name); err != nil {
+		return err
+	}
+	return os.Chtimes(r.path(name), atime, mtime)
+}
+
+// Symlink creates symlink name to target, after checking its parent directory
+// through the root.
+func (r *extractRoot) Symlink(target, name string) error {
+	if err := r.checkParent(name); err != nil {
+		return err
+	}
+	return os.Symlink(target, r.path(name))
+}
+
+// Link creates hard link name to the regular file oldname. As with Symlink,
+// both are checked through the root first.
+func (r *extractRoot) Link(oldname, name string) error {
+	fi, err := r.root.Lstat(filepath.FromSlash(oldname))
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("hard link %s to %s: not a regular file", name, oldname)
+	}
+	if err := r.checkParent(name); err != nil {
+		return err
+	}
+	return os.Link(r.path(oldname), r.path(name))
+}
+
+// checkParent checks that the parent of name is a directory within the root,
+// not reached through a symbolic link.
+func (r *extractRoot) checkParent(name string) error {
+	parent := path.Dir(path.Clean(name))
+	if parent == "." {
+		return nil
+	}
+	fi, err := r.root.Lstat(filepath.FromSlash(parent))
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s: not a directory", parent)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+//go:build go1.24
+// +build go1.24
+
+package goreleases
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestExtractRoot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on windows")
+	}
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "go"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	// E.g. left in a directory kept for resuming, or created concurrently.
+	if err := os.Symlink(outside, filepath.Join(dir, "go", "bin")); err != nil {
+		t.Fatal(err)
+	}
+	r, err := openExtractRoot(dir)
+	if err != nil {
+		t.Fatalf("open root: %s", err)
+	}
+	defer r.Close()
+
+	if f, err := r.OpenFile("go/bin/go", os.O_RDWR|os.O_CREATE, 0666); err == nil {
+		f.Close()
+		t.Fatalf("created file through symlink out of root")
+	}
+	if err := r.MkdirAll("go/bin/x"); err == nil {
+		t.Fatalf("created directory through symlink out of root")
+	}
+	if err := r.Symlink("target", "go/bin/link"); err == nil {
+		t.Fatalf("created symlink through symlink out of root")
+	}
+	if err := os.WriteFile(filepath.Join(outside, "f"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Chmod("go/bin/f", 0600); err == nil {
+		t.Fatalf("chmod through symlink out of root")
+	}
+	if err := r.Chtimes("go/bin/f", time.Unix(0, 0), time.Unix(0, 0)); err == nil {
+		t.Fatalf("chtimes through symlink out of root")
+	}
+	if err := r.Lchown("go/bin/f", os.Getuid(), os.Getgid()); err == nil {
+		t.Fatalf("chown through symlink out of root")
+	}
+	if err := os.Remove(filepath.Join(outside, "f")); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := r.OpenFile("go/../../f", os.O_RDWR|os.O_CREATE, 0666); err == nil {
+		f.Close()
+		t.Fatalf("created file with .. out of root")
+	}
+	if entries, err := os.ReadDir(outside); err != nil || len(entries) != 0 {
+		t.Fatalf("entries outside root: %v %v", entries, err)
+	}
+
+	if err := r.MkdirAll("go/src/a"); err != nil {
+		t.Fatalf("mkdirall: %s", err)
+	}
+	f, err := r.OpenFile("go/src/a/b.go", os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		t.Fatalf("create: %s", err)
+	}
+	f.Close()
+	if err := r.Link("go/src/a/b.go", "go/src/c.go"); err != nil {
+		t.Fatalf("link: %s", err)
+	}
+}
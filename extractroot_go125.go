@@ -0,0 +1,87 @@
+//go:build go1.25
+// +build go1.25
+
+package goreleases
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// extractRoot performs the writes of an extraction into directory dir, for
+// slash-separated names relative to dir, as in archives. All operations go
+// through an os.Root, so names with ".." and paths through symbolic links
+// cannot escape dir, regardless of what the archive created earlier.
+type extractRoot struct {
+	dir  string
+	root *os.Root
+}
+
+func openExtractRoot(dir string) (*extractRoot, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &extractRoot{dir, root}, nil
+}
+
+func (r *extractRoot) Close() error {
+	return r.root.Close()
+}
+
+func (r *extractRoot) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return r.root.OpenFile(filepath.FromSlash(name), flag, perm)
+}
+
+func (r *extractRoot) Mkdir(name string, perm os.FileMode) error {
+	return r.root.Mkdir(filepath.FromSlash(name), perm)
+}
+
+// MkdirAll creates directory name and its missing parents.
+func (r *extractRoot) MkdirAll(name string) error {
+	name = path.Clean(name)
+	if name == "." {
+		return nil
+	}
+	return r.root.MkdirAll(filepath.FromSlash(name), 0777)
+}
+
+func (r *extractRoot) Remove(name string) error {
+	return r.root.Remove(filepath.FromSlash(name))
+}
+
+func (r *extractRoot) Lstat(name string) (os.FileInfo, error) {
+	return r.root.Lstat(filepath.FromSlash(name))
+}
+
+func (r *extractRoot) Chmod(name string, mode os.FileMode) error {
+	return r.root.Chmod(filepath.FromSlash(name), mode)
+}
+
+func (r *extractRoot) Lchown(name string, uid, gid int) error {
+	return r.root.Lchown(filepath.FromSlash(name), uid, gid)
+}
+
+func (r *extractRoot) Chtimes(name string, atime, mtime time.Time) error {
+	return r.root.Chtimes(filepath.FromSlash(name), atime, mtime)
+}
+
+// Symlink creates symlink name to target.
+func (r *extractRoot) Symlink(target, name string) error {
+	return r.root.Symlink(target, filepath.FromSlash(name))
+}
+
+// Link creates hard link name to the regular file oldname.
+func (r *extractRoot) Link(oldname, name string) error {
+	fi, err := r.root.Lstat(filepath.FromSlash(oldname))
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("hard link %s to %s: not a regular file", name, oldname)
+	}
+	return r.root.Link(filepath.FromSlash(oldname), filepath.FromSlash(name))
+}
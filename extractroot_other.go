@@ -0,0 +1,120 @@
+//go:build !go1.24
+// +build !go1.24
+
+package goreleases
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// extractRoot performs the writes of an extraction into directory dir, for
+// slash-separated names relative to dir, as in archives. Without os.Root,
+// before Go 1.24, names are only checked lexically, and symbolic links created
+// by the archive are followed.
+type extractRoot struct {
+	dir string
+}
+
+func openExtractRoot(dir string) (*extractRoot, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s: not a directory", dir)
+	}
+	return &extractRoot{dir}, nil
+}
+
+func (r *extractRoot) Close() error {
+	return nil
+}
+
+// check returns an error if name is not local to the root.
+func (r *extractRoot) check(name string) error {
+	n := path.Clean(name)
+	if path.IsAbs(n) || n == ".." || strings.HasPrefix(n, "../") || strings.Contains(name, `\`) || strings.Contains(name, ":") {
+		return fmt.Errorf("path %q escapes from parent", name)
+	}
+	return nil
+}
+
+func (r *extractRoot) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	if err := r.check(name); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(r.path(name), flag, perm)
+}
+
+func (r *extractRoot) Mkdir(name string, perm os.FileMode) error {
+	if err := r.check(name); err != nil {
+		return err
+	}
+	return os.Mkdir(r.path(name), perm)
+}
+
+// MkdirAll creates directory name and its missing parents.
+func (r *extractRoot) MkdirAll(name string) error {
+	if err := r.check(name); err != nil {
+		return err
+	}
+	return os.MkdirAll(r.path(name), 0777)
+}
+
+func (r *extractRoot) Remove(name string) error {
+	if err := r.check(name); err != nil {
+		return err
+	}
+	return os.Remove(r.path(name))
+}
+
+func (r *extractRoot) Lstat(name string) (os.FileInfo, error) {
+	if err := r.check(name); err != nil {
+		return nil, err
+	}
+	return os.Lstat(r.path(name))
+}
+
+func (r *extractRoot) Chmod(name string, mode os.FileMode) error {
+	if err := r.check(name); err != nil {
+		return err
+	}
+	return os.Chmod(r.path(name), mode)
+}
+
+func (r *extractRoot) Lchown(name string, uid, gid int) error {
+	if err := r.check(name); err != nil {
+		return err
+	}
+	return os.Lchown(r.path(name), uid, gid)
+}
+
+func (r *extractRoot) Chtimes(name string, atime, mtime time.Time) error {
+	if err := r.check(name); err != nil {
+		return err
+	}
+	return os.Chtimes(r.path(name), atime, mtime)
+}
+
+// Symlink creates symlink name to target.
+func (r *extractRoot) Symlink(target, name string) error {
+	if err := r.check(name); err != nil {
+		return err
+	}
+	return os.Symlink(target, r.path(name))
+}
+
+// Link creates hard link name to oldname.
+func (r *extractRoot) Link(oldname, name string) error {
+	if err := r.check(oldname); err != nil {
+		return err
+	}
+	if err := r.check(name); err != nil {
+		return err
+	}
+	return os.Link(r.path(oldname), r.path(name))
+}
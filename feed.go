@@ -0,0 +1,143 @@
+package goreleases
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// FeedOptions configure feeds written by WriteAtom and WriteRSS.
+type FeedOptions struct {
+	Title      string    // If empty, "Go releases".
+	Link       string    // If empty, "https://go.dev/dl/".
+	StableOnly bool      // Skip unstable releases.
+	Updated    time.Time // Time for the feed and its entries, listings have no dates. If zero, the current time.
+}
+
+func (o FeedOptions) defaults() FeedOptions {
+	if o.Title == "" {
+		o.Title = "Go releases"
+	}
+	if o.Link == "" {
+		o.Link = "https://go.dev/dl/"
+	}
+	if o.Updated.IsZero() {
+		o.Updated = time.Now()
+	}
+	return o
+}
+
+func feedReleases(releases []Release, stableOnly bool) []Release {
+	var l []Release
+	for _, rel := range releases {
+		if !stableOnly || rel.Stable {
+			l = append(l, rel)
+		}
+	}
+	return l
+}
+
+// releaseNotesURL returns the URL for the release history entry of a version.
+func releaseNotesURL(version string) string {
+	return "https://go.dev/doc/devel/release#" + version
+}
+
+// WriteAtom writes releases as an Atom feed to w.
+func WriteAtom(w io.Writer, releases []Release, opts FeedOptions) error {
+	opts = opts.defaults()
+
+	type link struct {
+		Href string `xml:"href,attr"`
+	}
+	type entry struct {
+		Title   string `xml:"title"`
+		ID      string `xml:"id"`
+		Link    link   `xml:"link"`
+		Updated string `xml:"updated"`
+		Summary string `xml:"summary"`
+	}
+	type feed struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Title   string   `xml:"title"`
+		ID      string   `xml:"id"`
+		Link    link     `xml:"link"`
+		Updated string   `xml:"updated"`
+		Entries []entry  `xml:"entry"`
+	}
+
+	updated := opts.Updated.UTC().Format(time.RFC3339)
+	f := feed{Title: opts.Title, ID: opts.Link, Link: link{opts.Link}, Updated: updated}
+	for _, rel := range feedReleases(releases, opts.StableOnly) {
+		f.Entries = append(f.Entries, entry{
+			Title:   rel.Version,
+			ID:      opts.Link + "#" + rel.Version,
+			Link:    link{releaseNotesURL(rel.Version)},
+			Updated: updated,
+			Summary: feedSummary(rel),
+		})
+	}
+	return writeXML(w, f)
+}
+
+// WriteRSS writes releases as an RSS 2.0 feed to w.
+func WriteRSS(w io.Writer, releases []Release, opts FeedOptions) error {
+	opts = opts.defaults()
+
+	type item struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		GUID        string `xml:"guid"`
+		PubDate     string `xml:"pubDate"`
+		Description string `xml:"description"`
+	}
+	type channel struct {
+		Title         string `xml:"title"`
+		Link          string `xml:"link"`
+		Description   string `xml:"description"`
+		LastBuildDate string `xml:"lastBuildDate"`
+		Items         []item `xml:"item"`
+	}
+	type rss struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Channel channel  `xml:"channel"`
+	}
+
+	date := opts.Updated.UTC().Format(time.RFC1123Z)
+	r := rss{Version: "2.0", Channel: channel{Title: opts.Title, Link: opts.Link, Description: opts.Title, LastBuildDate: date}}
+	for _, rel := range feedReleases(releases, opts.StableOnly) {
+		r.Channel.Items = append(r.Channel.Items, item{
+			Title:       rel.Version,
+			Link:        releaseNotesURL(rel.Version),
+			GUID:        opts.Link + "#" + rel.Version,
+			PubDate:     date,
+			Description: feedSummary(rel),
+		})
+	}
+	return writeXML(w, r)
+}
+
+func feedSummary(rel Release) string {
+	s := rel.Version
+	if !rel.Stable {
+		s += " (unstable)"
+	}
+	s += ", files:"
+	for _, f := range rel.Files {
+		s += " " + f.Filename
+	}
+	return s
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
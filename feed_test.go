@@ -0,0 +1,34 @@
+package goreleases
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeeds(t *testing.T) {
+	rels := []Release{
+		{Version: "go1.23rc1"},
+		{Version: "go1.22.3", Stable: true, Files: []File{{Filename: "go1.22.3.src.tar.gz"}}},
+	}
+	opts := FeedOptions{StableOnly: true, Updated: time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)}
+	for _, fn := range []func(b *bytes.Buffer) error{
+		func(b *bytes.Buffer) error { return WriteAtom(b, rels, opts) },
+		func(b *bytes.Buffer) error { return WriteRSS(b, rels, opts) },
+	} {
+		var b bytes.Buffer
+		if err := fn(&b); err != nil {
+			t.Fatalf("writing feed: %s", err)
+		}
+		s := b.String()
+		if !strings.Contains(s, "go1.22.3") || strings.Contains(s, "go1.23rc1") {
+			t.Fatalf("unexpected feed contents: %s", s)
+		}
+		var v struct{}
+		if err := xml.Unmarshal(b.Bytes(), &v); err != nil {
+			t.Fatalf("parsing feed: %s", err)
+		}
+	}
+}
@@ -2,12 +2,16 @@ package goreleases
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/openpgp"
 )
@@ -21,36 +25,98 @@ type Permissions struct {
 	Mode os.FileMode // Mode to use for extract files and directories. Files are masked with 0777 or 0666 depending on whether 0100 is set.
 }
 
+// SystemPermissions are the permissions of a system install, e.g. in
+// /usr/local/go, as by distribution packages: owned by root:root, with mode
+// 0755 for directories and executables and 0644 for other files, regardless
+// of the umask.
+var SystemPermissions = Permissions{Uid: 0, Gid: 0, Mode: 0755}
+
 // Fetch downloads a toolchain represented, downloads and verifies its gpg
 // signature, and extracts it into directory dst.
 //
 // After a successful fetch, dst contains a directory "go" with the specified release.
 // Directory dst must exist. It must not already contain a "go" subdirectory.
 //
-// Only files with filenames ending .tar.gz and .zip can be fetched. Tar.gz
+// Files with filenames ending .tar.gz and .zip can be fetched, both binary
+// archives and source files, which also contain a "go" directory. Tar.gz
 // files are extracted while fetched. Zip files are first read into memory,
-// then extracted.
+// then extracted. For macOS installers (.pkg), the Go installation in the
+// payload is extracted, without running the installer. Windows installers
+// (.msi) are extracted with an administrative install by msiexec on Windows,
+// and with msiextract from msitools elsewhere. To only download and verify
+// installers, e.g. for management tooling, use Download. Other formats can be
+// added with RegisterFormat.
 //
-// If permissions is not nil, it is applied to extracted files and directories.
-func Fetch(file File, dst string, permissions *Permissions) error {
-	// Fetch .asc file with signature.
-	resp, err := http.Get("https://go.dev/dl/" + file.Filename + ".asc")
+// If permissions is not nil, it is applied to extracted files and directories,
+// except for .msi files. Options, e.g. WithClient or WithProgress, configure
+// the fetch.
+func Fetch(file File, dst string, permissions *Permissions, opts ...Option) error {
+	ctx, c, permissions := makeOptions(permissions, opts)
+	return c.Fetch(ctx, file, dst, permissions)
+}
+
+// Fetch is like the package-level Fetch, but downloads from the client's
+// Source or BaseURL. Signatures are only verified if the source provides them,
+// the sha256 checksum is always verified.
+func (c *Client) Fetch(ctx context.Context, file File, dst string, permissions *Permissions) error {
+	_, err := c.FetchDetails(ctx, file, dst, permissions)
+	return err
+}
+
+// FetchResult describes a successful fetch, for logging and auditing.
+type FetchResult struct {
+	Dir        string // Directory with the extracted release, dst/go.
+	File       File
+	URL        string // Download URL, empty for a Client.Source without URLs.
+	Downloaded int64  // Bytes downloaded, including a partial download resumed with Client.KeepPartial. Zero if from the archive cache.
+	Cached     bool   // Whether the file came from the archive cache.
+	Sha256     string // Verified hex sha256 of the file.
+	Files      int    // Number of files extracted.
+	Duration   time.Duration
+}
+
+// FetchDetails is like Fetch, but also returns details about the fetch. The
+// result is also returned if a post-install hook fails, with the install
+// removed.
+func (c *Client) FetchDetails(ctx context.Context, file File, dst string, permissions *Permissions) (FetchResult, error) {
+	_, r, err := c.fetchResult(ctx, file, dst, permissions)
 	if err != nil {
-		return fmt.Errorf("getting .asc signature file: %v", err)
+		return FetchResult{}, err
+	}
+	return r, c.runHooks(ctx, r.Dir, file)
+}
+
+// fetch downloads and extracts file, returning a manifest with the extracted
+// entries and verified provenance.
+func (c *Client) fetch(ctx context.Context, file File, dst string, permissions *Permissions) (*Manifest, error) {
+	m, _, err := c.fetchResult(ctx, file, dst, permissions)
+	return m, err
+}
+
+// fetchResult is like fetch, also returning details about the fetch.
+func (c *Client) fetchResult(ctx context.Context, file File, dst string, permissions *Permissions) (*Manifest, FetchResult, error) {
+	start := time.Now()
+	r := FetchResult{Dir: filepath.Join(dst, "go"), File: file, URL: c.fileURL(file)}
+	if c.Source != nil {
+		if us, ok := c.Source.(URLSource); ok {
+			r.URL, _ = us.URL(ctx, file)
+		}
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("fetching .asc signature file, status %v, expected 200 OK", resp.Status)
+
+	if c.Preflight != nil {
+		if err := CheckFilesystem(dst, c.Preflight); err != nil {
+			return nil, r, err
+		}
 	}
-	sigbuf, err := io.ReadAll(resp.Body)
+	format, err := lookupFormat(file.Filename)
 	if err != nil {
-		return fmt.Errorf("read .asci signature file: %v", err)
+		return nil, r, err
 	}
 
 	// Temporary file to write release tgz/zip into.
-	f, err := os.CreateTemp("", "goreleases-download")
+	f, err := c.createTemp("goreleases-download")
 	if err != nil {
-		return err
+		return nil, r, err
 	}
 	defer func() {
 		// We only remove once we're done. Removing files that are in use doesn't work well
@@ -60,33 +126,221 @@ func Fetch(file File, dst string, permissions *Permissions) error {
 		os.Remove(name)
 	}()
 
-	resp, err = http.Get("https://go.dev/dl/" + file.Filename)
+	sum, n, err := c.download(ctx, file, f)
+	r.Downloaded = n
+	r.Cached = n == 0
 	if err != nil {
-		return fmt.Errorf("getting release file: %v", err)
+		return nil, r, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("fetching file, status %v, expected 200 OK", resp.Status)
+	if file.Sha256 == "" && (file.Checksum != "" || c.sourceVerified(file)) || c.InsecureSkipSha256 || c.Verifier != nil {
+		// Verified with Checksum, by the source, by the Verifier or not at all, the sha256 is
+		// checked during extraction and recorded.
+		file.Sha256 = sum
 	}
-	if _, err := io.Copy(f, resp.Body); err != nil {
-		return fmt.Errorf("copying release file: %v", err)
+	m := &Manifest{Version: file.Version, Filename: file.Filename, Sha256: file.Sha256, Permissions: permissions}
+	if c.VerifyProvenance != nil {
+		if err := c.checkSha256(file, sum); err != nil {
+			return nil, r, err
+		}
+		m.Provenance, err = c.VerifyProvenance(ctx, file)
+		if err != nil {
+			return nil, r, fmt.Errorf("verifying provenance: %w", err)
+		}
 	}
-	if _, err := f.Seek(0, 0); err != nil {
-		return fmt.Errorf("rewinding downloaded release file: %v", err)
+
+	x := &extractor{dst: dst, perms: permissions, include: c.Include, exclude: c.Exclude, buf: make([]byte, c.bufferSize()), sync: c.Sync, exact: c.ExactModes || c.Reproducible, xattrs: c.Xattrs, xattrFilter: c.XattrFilter, signer: c.AuthenticodeSigner, strict: c.Strict, owner: c.ArchiveOwner, ownerMap: c.OwnerMap}
+	if c.Reproducible {
+		x.dirTimes = map[string]time.Time{}
+	}
+	if c.Events != nil {
+		x.onEntry = func(name string, size int64) {
+			c.emit(Event{Type: EventExtract, File: file, Bytes: size, Name: name})
+		}
+	}
+
+	if df, ok := format.(directFormat); ok {
+		err = df.extract(ctx, f, file, x)
+	} else {
+		x.resume = c.resume
+		err = fetchTar(f, file, x, format)
+	}
+	if err != nil {
+		return nil, r, err
+	}
+	c.emit(Event{Type: EventVerified, File: file})
+	r.Sha256 = sum
+	if c.KeepArchiveDir != "" {
+		if err := keepArchive(c.KeepArchiveDir, file, f); err != nil {
+			return nil, r, fmt.Errorf("keeping release file: %v", err)
+		}
+	}
+	if c.WriteOrigin {
+		if err := writeOrigin(r, permissions); err != nil {
+			return nil, r, fmt.Errorf("writing origin: %v", err)
+		}
+	}
+	if err := x.restoreDirTimes(); err != nil {
+		return nil, r, err
+	}
+	if c.AuthenticodeSigner != "" && file.Os == "windows" {
+		if err := verifyAuthenticodeTree(ctx, filepath.Join(dst, "go"), c.AuthenticodeSigner); err != nil {
+			return nil, r, err
+		}
+	}
+	if c.WindowsACL != "" && runtime.GOOS == "windows" {
+		if err := SetWindowsACL(filepath.Join(dst, "go"), c.WindowsACL); err != nil {
+			return nil, r, fmt.Errorf("setting acls: %v", err)
+		}
+	}
+	if c.Sync {
+		if err := syncDirs(filepath.Join(dst, "go")); err != nil {
+			return nil, r, fmt.Errorf("sync: %v", err)
+		}
+	}
+	m.Entries = x.entries
+	for _, e := range m.Entries {
+		if e.Type == EntryFile || e.Type == EntryLink {
+			r.Files++
+		}
+	}
+	r.Duration = time.Since(start)
+	c.emit(Event{Type: EventDone, File: file, Dir: r.Dir})
+	return m, r, nil
+}
+
+// download fetches file and its signature, if available, writing the file to f
+// and verifying the signature. The hex sha256 of the data and the number of
+// bytes downloaded, zero for a file from the archive cache, are returned. On
+// success, f is rewound.
+//
+// The checksum in file is first checked against pins and cross-verified, if
+// configured.
+//
+// With an archive cache, a cached file with the expected checksum is used
+// instead, and downloaded files with the expected checksum are added to the
+// cache.
+func (c *Client) download(ctx context.Context, file File, f *os.File) (string, int64, error) {
+	if err := c.crossVerify(ctx, file); err != nil {
+		c.metrics.verifyFailed()
+		return "", 0, err
+	}
+	if c.ArchiveCacheDir != "" && validSha256(file.Sha256) {
+		// Another process may be downloading the same file into the cache.
+		unlock, err := c.lockCache(ctx, c.ArchiveCacheDir, "sha256-"+file.Sha256)
+		if err != nil {
+			return "", 0, fmt.Errorf("locking archive cache: %v", err)
+		}
+		defer unlock()
+		if ok, err := archiveCacheGet(c.ArchiveCacheDir, file.Sha256, f); err != nil {
+			return "", 0, err
+		} else if ok {
+			c.emit(Event{Type: EventCached, File: file, Total: file.Size})
+			return file.Sha256, 0, c.verifyFile(ctx, file, f)
+		}
+	}
+
+	sum, n, err := c.downloadVerify(ctx, file, f)
+	if err == nil && c.ArchiveCacheDir != "" && sum == file.Sha256 {
+		// Failing to cache is not a reason to fail the download.
+		if archiveCachePut(c.ArchiveCacheDir, sum, f) == nil {
+			c.CleanCache()
+		}
+		if _, err := f.Seek(0, 0); err != nil {
+			return "", 0, fmt.Errorf("rewinding downloaded release file: %v", err)
+		}
+	}
+	return sum, n, err
+}
+
+// ErrTruncated is returned when a download ends before all data has been
+// received, e.g. due to network problems. Retrying may help.
+var ErrTruncated = errors.New("truncated download")
+
+// downloadVerify downloads file from the source into f, verifying its
+// signature. The hex sha256 and the number of bytes are returned.
+func (c *Client) downloadVerify(ctx context.Context, file File, f *os.File) (string, int64, error) {
+	sigbuf, err := c.Signature(ctx, file)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var rc io.ReadCloser
+	if c.resumable(file) {
+		rc, err = c.openPartial(ctx, file)
+	} else {
+		rc, err = c.Open(ctx, file)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	defer rc.Close()
+	ck, err := c.newCheck(ctx, file)
+	if err != nil {
+		return "", 0, err
+	}
+	if c.Events != nil {
+		u := c.fileURL(file)
+		if us, ok := c.Source.(URLSource); ok {
+			u, _ = us.URL(ctx, file)
+		}
+		c.emit(Event{Type: EventDownload, File: file, URL: u, Total: file.Size})
+	}
+	hr := &hashReader{ck.tee(rc), sha256.New()}
+	var w io.Writer = struct{ io.Writer }{f}
+	if c.Progress != nil || c.Events != nil {
+		w = &progressWriter{w, file, 0, c.progress}
 	}
-	if _, err := openpgp.CheckArmoredDetachedSignature(signingKey, f, bytes.NewReader(sigbuf)); err != nil {
-		return fmt.Errorf("verifying pgp signature on go release: %v", err)
+	n, err := io.CopyBuffer(w, hr, make([]byte, c.bufferSize()))
+	c.metrics.upstream(n)
+	if err == io.ErrUnexpectedEOF {
+		// Body shorter than its Content-Length.
+		return "", 0, fmt.Errorf("%w: connection closed after %d bytes", ErrTruncated, n)
+	} else if err != nil {
+		return "", 0, fmt.Errorf("copying release file: %v", err)
+	}
+	if file.Size > 0 && n < file.Size {
+		return "", 0, fmt.Errorf("%w: got %d bytes, expected %d", ErrTruncated, n, file.Size)
+	}
+	if err := ck.verify(); err != nil {
+		c.metrics.verifyFailed()
+		return "", 0, err
 	}
 	if _, err := f.Seek(0, 0); err != nil {
-		return fmt.Errorf("rewinding downloaded release file after signature verification: %v", err)
+		return "", 0, fmt.Errorf("rewinding downloaded release file: %v", err)
+	}
+	if sigbuf != nil {
+		if _, err := openpgp.CheckArmoredDetachedSignature(signingKey, f, bytes.NewReader(sigbuf)); err != nil {
+			c.metrics.verifyFailed()
+			return "", 0, fmt.Errorf("verifying pgp signature on go release: %v", err)
+		}
+		if _, err := f.Seek(0, 0); err != nil {
+			return "", 0, fmt.Errorf("rewinding downloaded release file after signature verification: %v", err)
+		}
 	}
+	return fmt.Sprintf("%x", hr.h.Sum(nil)), n, nil
+}
 
-	if strings.HasSuffix(file.Filename, ".tar.gz") {
-		return fetchTgz(f, file, dst, permissions)
-	} else if strings.HasSuffix(file.Filename, ".zip") {
-		return fetchZip(f, file, dst, permissions)
+// progress calls Client.Progress and emits an EventProgress.
+func (c *Client) progress(file File, downloaded, total int64) {
+	if c.Progress != nil {
+		c.Progress(file, downloaded, total)
 	}
-	return fmt.Errorf("file extension not supported, only .tar.gz and .zip supported")
+	c.emit(Event{Type: EventProgress, File: file, Bytes: downloaded, Total: total})
+}
+
+// progressWriter calls fn after each write.
+type progressWriter struct {
+	w    io.Writer
+	file File
+	n    int64
+	fn   func(file File, downloaded, total int64)
+}
+
+func (pw *progressWriter) Write(buf []byte) (int, error) {
+	n, err := pw.w.Write(buf)
+	pw.n += int64(n)
+	pw.fn(pw.file, pw.n, pw.file.Size)
+	return n, err
 }
 
 func dstName(dst, name string) (string, error) {
@@ -95,8 +349,46 @@ func dstName(dst, name string) (string, error) {
 	}
 
 	r := filepath.Clean(filepath.Join(dst, name))
-	if !strings.HasPrefix(r, dst) {
+	if !strings.HasPrefix(r, strings.TrimSuffix(filepath.Clean(dst), string(filepath.Separator))+string(filepath.Separator)) {
 		return "", fmt.Errorf("bad path %q in archive, resulting in path %q outside dst %q", name, r, dst)
 	}
 	return r, nil
 }
+
+// keepArchive copies the verified release file in f to dir.
+func keepArchive(dir string, file File, f *os.File) error {
+	if file.Filename == "" || filepath.Base(file.Filename) != file.Filename {
+		return fmt.Errorf("bad filename %q", file.Filename)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	tf, err := os.CreateTemp(dir, "."+file.Filename+"-")
+	if err != nil {
+		return err
+	}
+	tmpname := tf.Name()
+	defer func() {
+		if tmpname != "" {
+			os.Remove(tmpname)
+		}
+	}()
+	if _, err := io.Copy(tf, f); err != nil {
+		tf.Close()
+		return err
+	}
+	if err := tf.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpname, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpname, filepath.Join(dir, file.Filename)); err != nil {
+		return err
+	}
+	tmpname = ""
+	return nil
+}
@@ -1,35 +1,1243 @@
 package goreleases
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"reflect"
 	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/mjl-/goreleases/goreleasestest"
 )
 
 func TestFetch(t *testing.T) {
-	// disabled, don't automatically bother production servers; especially for large downloads.
-	if true {
-		return
-	}
+	srv := goreleasestest.NewServer("go1.22.3")
+	defer srv.Close()
+	c := Client{BaseURL: srv.BaseURL(), NoSignatures: true}
 
-	rels, err := ListSupported()
+	rels, err := c.ListSupported(context.Background())
 	if err != nil {
 		t.Fatalf("fetching supported releases: %s", err)
 	}
 
 	rel := rels[0]
-	file, err := FindFile(rel, runtime.GOOS, runtime.GOARCH, "archive")
+	for _, p := range goreleasestest.Platforms {
+		goos, goarch, _ := strings.Cut(p, "/")
+		file, err := FindFile(rel, goos, goarch, "archive")
+		if err != nil {
+			t.Fatalf("finding %s archive: %s", p, err)
+		}
+		dst := t.TempDir()
+		if err := c.Fetch(context.Background(), file, dst, nil); err != nil {
+			t.Fatalf("fetch %s: %s", file.Filename, err)
+		}
+		if v, err := ReadVersion(filepath.Join(dst, "go")); err != nil || v != "go1.22.3" {
+			t.Fatalf("version of %s: %q %v", file.Filename, v, err)
+		}
+	}
+
+	// A modified file fails verification.
+	file, _ := FindFile(rel, "linux", "amd64", "archive")
+	srv.Files[file.Filename] = goreleasestest.Tgz(map[string]string{"go/VERSION": "bad\n"})
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err == nil {
+		t.Fatalf("fetch of modified file succeeded")
+	}
+}
+
+func TestFetchOptions(t *testing.T) {
+	srv := goreleasestest.NewServer("go1.22.3")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.BaseURL(), NoSignatures: true}
+
+	rels, err := ListSupported(WithClient(c), WithContext(context.Background()))
+	if err != nil {
+		t.Fatalf("list: %s", err)
+	}
+	file, err := rels[0].FindFile("linux", "amd64", KindArchive)
+	if err != nil {
+		t.Fatalf("find file: %s", err)
+	}
+
+	var last, total int64
+	progress := func(f File, downloaded, size int64) {
+		if f.Filename != file.Filename || downloaded < last {
+			t.Fatalf("bad progress %s %d after %d", f.Filename, downloaded, last)
+		}
+		last, total = downloaded, size
+	}
+	dst := t.TempDir()
+	perms := &Permissions{Uid: -1, Gid: -1, Mode: 0750}
+	if err := Fetch(file, dst, nil, WithClient(c), WithProgress(progress), WithPermissions(perms)); err != nil {
+		t.Fatalf("fetch: %s", err)
+	}
+	if last != file.Size || total != file.Size {
+		t.Fatalf("progress %d of %d, expected %d", last, total, file.Size)
+	}
+	if c.Progress != nil {
+		t.Fatalf("WithProgress changed client")
+	}
+	if fi, err := os.Stat(filepath.Join(dst, "go/bin")); err != nil || fi.Mode().Perm() != 0750 {
+		t.Fatalf("stat bin: %v %v", fi, err)
+	}
+}
+
+func TestDefaultClient(t *testing.T) {
+	srv := goreleasestest.NewServer("go1.22.3")
+	defer srv.Close()
+	other := goreleasestest.NewServer("go1.21.10")
+	defer other.Close()
+
+	SetDefaultClient(&Client{BaseURL: srv.BaseURL(), NoSignatures: true})
+	defer SetDefaultClient(nil)
+
+	rels, err := ListSupported()
+	if err != nil || rels[0].Version != "go1.22.3" {
+		t.Fatalf("list with default client: %v %v", rels, err)
+	}
+	rels, err = ListSupported(WithConfig(func(c *Client) { c.BaseURL = other.BaseURL() }))
+	if err != nil || rels[0].Version != "go1.21.10" {
+		t.Fatalf("list with config: %v %v", rels, err)
+	}
+	if c := DefaultClient(); c.BaseURL != srv.BaseURL() {
+		t.Fatalf("WithConfig changed default client, base url %q", c.BaseURL)
+	}
+	file, _ := rels[0].FindFile("linux", "amd64", KindArchive)
+	if err := Fetch(file, t.TempDir(), nil, WithConfig(func(c *Client) { c.BaseURL = other.BaseURL() })); err != nil {
+		t.Fatalf("fetch with config: %s", err)
+	}
+	if srv.Requests("/"+file.Filename) != 0 || other.Requests("/"+file.Filename) != 1 {
+		t.Fatalf("file not fetched from overridden base url")
+	}
+}
+
+func TestDefaultClientEnv(t *testing.T) {
+	srv := goreleasestest.NewServer("go1.22.3")
+	defer srv.Close()
+	other := goreleasestest.NewServer("go1.21.10")
+	defer other.Close()
+
+	cachedir := t.TempDir()
+	t.Setenv(EnvBaseURL, srv.BaseURL())
+	t.Setenv(EnvCacheDir, cachedir)
+	t.Setenv(EnvOffline, "bogus")
+	c := DefaultClient()
+	if c.BaseURL != srv.BaseURL() || c.CacheDir != cachedir || c.Offline || c.Source != nil {
+		t.Fatalf("default client from env: %#v", c)
+	}
+
+	// Explicit configuration takes precedence.
+	SetDefaultClient(&Client{BaseURL: other.BaseURL(), NoSignatures: true})
+	defer SetDefaultClient(nil)
+	rels, err := ListSupported()
+	if err != nil || rels[0].Version != "go1.21.10" {
+		t.Fatalf("list with default client: %v %v", rels, err)
+	}
+	if DefaultClient().CacheDir != cachedir {
+		t.Fatalf("cache dir from env not applied to default client")
+	}
+
+	t.Setenv(EnvMirrors, " "+srv.BaseURL()+" , "+other.BaseURL()[:len(other.BaseURL())-1])
+	if c := DefaultClient(); c.Source != nil || c.BaseURL != other.BaseURL() {
+		t.Fatalf("mirrors from env override explicit base url: %#v", c)
+	}
+	SetDefaultClient(nil)
+	ms, ok := DefaultClient().Source.(*MirrorSource)
+	if !ok || len(ms.BaseURLs) != 2 || ms.BaseURLs[0] != srv.BaseURL() || ms.BaseURLs[1] != other.BaseURL() {
+		t.Fatalf("mirrors from env: %#v", DefaultClient().Source)
+	}
+}
+
+func TestFetchRewriteURL(t *testing.T) {
+	srv := goreleasestest.NewServer("go1.22.3")
+	defer srv.Close()
+	rels, err := ListSupported(WithClient(&Client{BaseURL: srv.BaseURL(), NoSignatures: true}))
 	if err != nil {
-		t.Fatalf("finding current hosts archive: %s", err)
+		t.Fatalf("list: %s", err)
 	}
-	t.Logf("fetching release %q", rel.Version)
+	file, _ := rels[0].FindFile("linux", "amd64", KindArchive)
 
-	err = os.Mkdir("tmp", 0777)
+	var urls []string
+	c := Client{
+		BaseURL:      "http://dl.invalid/",
+		NoSignatures: true,
+		CrossVerify:  true,
+		RewriteURL: func(ctx context.Context, f File, u string) (string, error) {
+			urls = append(urls, u)
+			return srv.URL + strings.TrimPrefix(u, "http://dl.invalid") + "?sig=x", nil
+		},
+	}
+	r, err := c.FetchDetails(context.Background(), file, t.TempDir(), nil)
 	if err != nil {
-		t.Fatalf("mkdir tmp: %s", err)
+		t.Fatalf("fetch: %s", err)
+	}
+	exp := []string{"http://dl.invalid/" + file.Filename + ".sha256", "http://dl.invalid/" + file.Filename}
+	if !reflect.DeepEqual(urls, exp) || r.URL != exp[1] {
+		t.Fatalf("got urls %v, result url %s, expected %v", urls, r.URL, exp)
+	}
+
+	c.RewriteURL = func(ctx context.Context, f File, u string) (string, error) {
+		return "", errors.New("no")
 	}
-	err = Fetch(file, "tmp", nil)
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err == nil {
+		t.Fatalf("fetch with failing rewrite succeeded")
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	srv := goreleasestest.NewServer("go1.22.3")
+	defer srv.Close()
+	srv.Files["go1.4.3.linux-amd64.tar.gz"] = goreleasestest.Tgz(map[string]string{"go/VERSION": "go1.4.3"})
+	c := Client{BaseURL: srv.BaseURL(), NoSignatures: true}
+
+	file, err := c.NewFile(context.Background(), "1.4.3", "linux", "amd64", KindArchive)
 	if err != nil {
-		t.Fatalf("fetch into tmp: %s", err)
+		t.Fatalf("new file: %s", err)
+	}
+	if file.Filename != "go1.4.3.linux-amd64.tar.gz" || file.Version != "go1.4.3" || file.Os != "linux" || len(file.Sha256) != 64 {
+		t.Fatalf("unexpected file %#v", file)
+	}
+	dst := t.TempDir()
+	if err := c.Fetch(context.Background(), file, dst, nil); err != nil {
+		t.Fatalf("fetch: %s", err)
+	}
+	if v, err := ReadVersion(filepath.Join(dst, "go")); err != nil || v != "go1.4.3" {
+		t.Fatalf("version %q %v", v, err)
+	}
+
+	if _, err := c.NewFile(context.Background(), "go1.4.2", "linux", "amd64", KindArchive); err == nil {
+		t.Fatalf("new file without .sha256 succeeded")
+	}
+	for _, x := range [][5]string{
+		{"go1.4.3", "windows", "386", KindArchive, "go1.4.3.windows-386.zip"},
+		{"go1.4.3", "windows", "386", KindInstaller, "go1.4.3.windows-386.msi"},
+		{"go1.4.3", "darwin", "amd64", KindInstaller, "go1.4.3.darwin-amd64.pkg"},
+		{"1.4.3", "linux", "amd64", KindSource, "go1.4.3.src.tar.gz"},
+	} {
+		if name, err := FileName(x[0], x[1], x[2], x[3]); err != nil || name != x[4] {
+			t.Fatalf("filename for %v: got %s %v", x, name, err)
+		}
+	}
+}
+
+// memSource is a ReleaseSource with files in memory.
+type memSource struct {
+	sync.Mutex
+	releases []Release
+	files    map[string][]byte
+}
+
+func (s *memSource) setReleases(l []Release) {
+	s.Lock()
+	defer s.Unlock()
+	s.releases = l
+}
+
+func (s *memSource) List(ctx context.Context, all bool) ([]Release, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.releases, nil
+}
+
+// newTestSource returns a memSource with a stable release for each version,
+// listed newest first, see addRelease.
+func newTestSource(t *testing.T, versions ...string) *memSource {
+	t.Helper()
+	s := &memSource{files: map[string][]byte{}}
+	for _, v := range versions {
+		s.addRelease(t, v)
+	}
+	return s
+}
+
+// addRelease adds a stable release of version v as the newest release, with a
+// linux/amd64 archive with go/VERSION and go/bin/go, and returns its file.
+func (s *memSource) addRelease(t *testing.T, v string) File {
+	t.Helper()
+	tgz := makeTgz(t, map[string]string{"go/VERSION": v + "\n", "go/bin/go": "binary"})
+	f := testFile(v, tgz)
+	s.Lock()
+	defer s.Unlock()
+	if s.files == nil {
+		s.files = map[string][]byte{}
+	}
+	s.files[f.Filename] = tgz
+	s.releases = append([]Release{{Version: v, Stable: true, Files: []File{f}}}, s.releases...)
+	return f
+}
+
+func (s *memSource) Open(ctx context.Context, file File) (io.ReadCloser, error) {
+	s.Lock()
+	buf, ok := s.files[file.Filename]
+	s.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("file not found")
+	}
+	return io.NopCloser(bytes.NewReader(buf)), nil
+}
+
+// makeTgz returns a .tar.gz with the files, and directories for each parent.
+func makeTgz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	dirs := map[string]bool{}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for i := range name {
+			if name[i] == '/' && !dirs[name[:i]] {
+				dirs[name[:i]] = true
+				if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: name[:i] + "/", Mode: 0755, ModTime: time.Now()}); err != nil {
+					t.Fatalf("tar header: %s", err)
+				}
+			}
+		}
+		data := files[name]
+		mode := int64(0644)
+		if strings.HasSuffix(name, ".bash") {
+			mode = 0755
+		}
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: mode, Size: int64(len(data)), ModTime: time.Now()}); err != nil {
+			t.Fatalf("tar header: %s", err)
+		}
+		if _, err := tw.Write([]byte(data)); err != nil {
+			t.Fatalf("tar write: %s", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar close: %s", err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatalf("gzip close: %s", err)
+	}
+	return b.Bytes()
+}
+
+func TestFetchSource(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/go": "binary"})
+	file := File{
+		Filename: "go1.22.3.linux-amd64.tar.gz",
+		Os:       "linux",
+		Arch:     "amd64",
+		Version:  "go1.22.3",
+		Sha256:   fmt.Sprintf("%x", sha256.Sum256(tgz)),
+		Size:     int64(len(tgz)),
+		Kind:     KindArchive,
+	}
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}}
+
+	dst := t.TempDir()
+	if err := c.Fetch(context.Background(), file, dst, nil); err != nil {
+		t.Fatalf("fetch: %s", err)
+	}
+	buf, err := os.ReadFile(filepath.Join(dst, "go", "VERSION"))
+	if err != nil || string(buf) != "go1.22.3\n" {
+		t.Fatalf("reading extracted VERSION: %q, %v", buf, err)
+	}
+
+	// With an archive cache, the second fetch doesn't need the source.
+	c.ArchiveCacheDir = t.TempDir()
+	for i := 0; i < 2; i++ {
+		if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err != nil {
+			t.Fatalf("fetch with archive cache: %s", err)
+		}
+		c.Source = &memSource{}
+	}
+	c = Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}}
+
+	// Checksum mismatches fail and leave nothing behind.
+	dst = t.TempDir()
+	file.Sha256 = fmt.Sprintf("%x", sha256.Sum256(nil))
+	if err := c.Fetch(context.Background(), file, dst, nil); err == nil {
+		t.Fatalf("fetch with bad checksum succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "go")); !os.IsNotExist(err) {
+		t.Fatalf("go directory present after failed fetch: %v", err)
+	}
+}
+
+func TestFetchFilter(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{
+		"go/VERSION":                       "go1.22.3\n",
+		"go/bin/go":                        "binary",
+		"go/pkg/tool/linux_amd64/compile":  "binary",
+		"go/pkg/include/textflag.h":        "header",
+		"go/src/fmt/print.go":              "source",
+		"go/src/fmt/testdata/x.txt":        "testdata",
+		"go/src/cmd/go/testdata/script.sh": "testdata",
+	})
+	file := testFile("go1.22.3", tgz)
+	c := Client{
+		Source:  &memSource{files: map[string][]byte{file.Filename: tgz}},
+		Include: []string{"bin", "pkg/tool", "src"},
+		Exclude: []string{"**/testdata"},
+	}
+	dst := t.TempDir()
+	if err := c.Fetch(context.Background(), file, dst, nil); err != nil {
+		t.Fatalf("fetch: %s", err)
+	}
+	for name, exp := range map[string]bool{
+		"bin/go":                       true,
+		"pkg/tool/linux_amd64/compile": true,
+		"src/fmt/print.go":             true,
+		"VERSION":                      false,
+		"pkg/include":                  false,
+		"src/fmt/testdata":             false,
+		"src/cmd/go/testdata":          false,
+	} {
+		_, err := os.Stat(filepath.Join(dst, "go", filepath.FromSlash(name)))
+		if exp && err != nil || !exp && !os.IsNotExist(err) {
+			t.Errorf("%s: got stat error %v, expected present %v", name, err, exp)
+		}
+	}
+}
+
+func TestFetchMinimal(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{
+		"go/VERSION":                  "go1.22.3\n",
+		"go/api/go1.txt":              "api",
+		"go/doc/go_spec.html":         "doc",
+		"go/test/fixedbugs/bug0.go":   "test",
+		"go/src/fmt/print.go":         "source",
+		"go/src/fmt/fmt_test.go":      "test",
+		"go/src/fmt/testdata/x.txt":   "testdata",
+		"go/misc/wasm/wasm_exec.js":   "wasm",
+		"go/misc/cgo/test/cthread.go": "test",
+	})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}, Exclude: MinimalExclude}
+	dst := t.TempDir()
+	if err := c.Fetch(context.Background(), file, dst, nil); err != nil {
+		t.Fatalf("fetch: %s", err)
+	}
+	var have []string
+	filepath.Walk(filepath.Join(dst, "go"), func(p string, fi os.FileInfo, err error) error {
+		if err == nil && fi.Mode().IsRegular() {
+			rel, _ := filepath.Rel(filepath.Join(dst, "go"), p)
+			have = append(have, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	if exp := []string{"VERSION", "misc/wasm/wasm_exec.js", "src/fmt/print.go"}; !reflect.DeepEqual(have, exp) {
+		t.Fatalf("got files %v, expected %v", have, exp)
+	}
+}
+
+func TestFetchTruncated(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n"})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz[:len(tgz)/2]}}}
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("fetch of truncated file: got %v, expected ErrTruncated", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(tgz)))
+		w.Write(tgz[:10])
+	}))
+	defer srv.Close()
+	c = Client{BaseURL: srv.URL + "/", NoSignatures: true}
+	file.Size = 0
+	if err := c.Download(context.Background(), file, t.TempDir()); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("download with short body: got %v, expected ErrTruncated", err)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	src := newTestSource(t, "go1.22.3")
+	file := src.releases[0].Files[0]
+	c := Client{Source: src}
+	dst := t.TempDir()
+	if err := c.Download(context.Background(), file, dst); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if buf, err := os.ReadFile(filepath.Join(dst, file.Filename)); err != nil || !bytes.Equal(buf, src.files[file.Filename]) {
+		t.Fatalf("downloaded file: %v", err)
+	}
+
+	bad := file
+	bad.Sha256 = strings.Repeat("0", 64)
+	dst = t.TempDir()
+	if err := c.Download(context.Background(), bad, dst); err == nil {
+		t.Fatalf("download with bad checksum succeeded")
+	}
+	for _, name := range []string{"", "sub/" + file.Filename, "..", "."} {
+		bad := file
+		bad.Filename = name
+		src.files[name] = src.files[file.Filename]
+		// Rejected before downloading.
+		if err := c.Download(context.Background(), bad, dst); err == nil || !strings.Contains(err.Error(), "bad filename") {
+			t.Fatalf("download to %q: got %v, expected bad filename", name, err)
+		}
+	}
+	// Nothing is left behind.
+	if entries, err := os.ReadDir(dst); err != nil || len(entries) != 0 {
+		t.Fatalf("files after failed downloads: %v %v", entries, err)
+	}
+}
+
+func TestFetchTo(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n"})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}}
+	var b bytes.Buffer
+	if err := c.FetchTo(context.Background(), &b, file); err != nil || !bytes.Equal(b.Bytes(), tgz) {
+		t.Fatalf("fetch to writer: %v, %d bytes", err, b.Len())
+	}
+	file.Sha256 = fmt.Sprintf("%x", sha256.Sum256(nil))
+	if err := c.FetchTo(context.Background(), io.Discard, file); err == nil {
+		t.Fatalf("fetch with bad checksum succeeded")
+	}
+}
+
+func TestFetchReader(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/gofmt": "gofmt"})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}}
+
+	read := func(file File) (string, error) {
+		ar, err := c.FetchReader(context.Background(), file)
+		if err != nil {
+			return "", err
+		}
+		defer ar.Close()
+		var version string
+		for {
+			h, err := ar.Next()
+			if err == io.EOF {
+				return version, nil
+			} else if err != nil {
+				return "", err
+			}
+			if h.Name == "go/VERSION" {
+				buf, err := io.ReadAll(ar)
+				if err != nil {
+					return "", err
+				}
+				version = string(buf)
+			}
+		}
+	}
+	if version, err := read(file); err != nil || version != "go1.22.3\n" {
+		t.Fatalf("reading archive: %q, %v", version, err)
+	}
+	file.Sha256 = fmt.Sprintf("%x", sha256.Sum256(nil))
+	if _, err := read(file); err == nil {
+		t.Fatalf("reading archive with bad checksum succeeded")
+	}
+}
+
+func TestFetchReaderTrailing(t *testing.T) {
+	e, err := openpgp.NewEntity("test", "", "test@example.org", nil)
+	if err != nil {
+		t.Fatalf("new pgp key: %s", err)
+	}
+	orig := signingKey
+	signingKey = openpgp.EntityList{e}
+	t.Cleanup(func() { signingKey = orig })
+
+	// Padding after the end of the gzip stream, signed along with the rest.
+	tgz := append(makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n"}), make([]byte, 64*1024)...)
+	file := testFile("go1.22.3", tgz)
+	var sig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&sig, e, bytes.NewReader(tgz), nil); err != nil {
+		t.Fatalf("signing: %s", err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, file.Filename), tgz, 0644); err != nil {
+		t.Fatalf("write: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, file.Filename+".asc"), sig.Bytes(), 0644); err != nil {
+		t.Fatalf("write: %s", err)
+	}
+	c := Client{Source: DirSource{dir}}
+	ar, err := c.FetchReader(context.Background(), file)
+	if err != nil {
+		t.Fatalf("fetch reader: %s", err)
+	}
+	defer ar.Close()
+	for {
+		if _, err := ar.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("next: %s", err)
+		}
+	}
+}
+
+func TestFetchBufferSize(t *testing.T) {
+	data := strings.Repeat("x", 100*1024)
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/src/big.go": data})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}, BufferSize: 100}
+	dst := t.TempDir()
+	if err := c.Fetch(context.Background(), file, dst, nil); err != nil {
+		t.Fatalf("fetch: %s", err)
+	}
+	if buf, err := os.ReadFile(filepath.Join(dst, "go/src/big.go")); err != nil || string(buf) != data {
+		t.Fatalf("reading extracted file: %v, %d bytes", err, len(buf))
+	}
+}
+
+func TestFetchSync(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/src/fmt/print.go": "source"})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}, Sync: true}
+	dir := filepath.Join(t.TempDir(), "go1.22.3")
+	if err := c.install(context.Background(), file, dir, nil); err != nil {
+		t.Fatalf("install: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src/fmt/print.go")); err != nil {
+		t.Fatalf("stat extracted file: %v", err)
+	}
+}
+
+func TestResumeExtract(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/go": "binary"})
+	file := testFile("go1.22.3", tgz)
+	dir := filepath.Join(t.TempDir(), "go1.22.3")
+	interrupt := true
+	var extracted []string
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}, ResumeExtract: true, Events: func(e Event) {
+		if e.Type != EventExtract {
+			return
+		}
+		extracted = append(extracted, e.Name)
+		if interrupt && e.Name == "VERSION" {
+			panic("interrupted")
+		}
+	}}
+
+	func() {
+		defer func() {
+			if x := recover(); x != "interrupted" {
+				t.Fatalf("recovered %v", x)
+			}
+		}()
+		c.install(context.Background(), file, dir, nil)
+	}()
+	tmpdir := resumeDir(dir, file)
+	// Mode is set again when the file is not extracted again.
+	if err := os.Chmod(filepath.Join(tmpdir, "go", "VERSION"), 0600); err != nil {
+		t.Fatalf("chmod partial extraction: %v", err)
+	}
+	// An abandoned extraction of another file is removed.
+	other := filepath.Join(t.TempDir(), "go1.22.3")
+	otherdir := filepath.Join(filepath.Dir(dir), filepath.Base(resumeDir(dir, File{Sha256: strings.Repeat("0", 64)})))
+	if err := os.Mkdir(otherdir, 0777); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	fresh := c
+	fresh.ResumeExtract = false
+	fresh.Events = nil
+	if err := fresh.install(context.Background(), file, other, nil); err != nil {
+		t.Fatalf("fresh install: %v", err)
+	}
+	freshfi, err := os.Stat(filepath.Join(other, "VERSION"))
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+
+	interrupt = false
+	extracted = nil
+	if err := c.install(context.Background(), file, dir, nil); err != nil {
+		t.Fatalf("resumed install: %v", err)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "VERSION")); err != nil || fi.Mode() != freshfi.Mode() || !fi.ModTime().Equal(freshfi.ModTime()) {
+		t.Fatalf("VERSION after resume: %v, %v, expected %v", fi, err, freshfi)
+	}
+	if buf, err := os.ReadFile(filepath.Join(dir, "bin", "go")); err != nil || string(buf) != "binary" {
+		t.Fatalf("bin/go: %q, %v", buf, err)
+	}
+	if !reflect.DeepEqual(extracted, []string{"VERSION", "bin", "bin/go"}) {
+		t.Fatalf("extracted %v", extracted)
+	}
+	if _, err := os.Stat(tmpdir); !os.IsNotExist(err) {
+		t.Fatalf("temporary directory not removed: %v", err)
+	}
+	if _, err := os.Stat(otherdir); !os.IsNotExist(err) {
+		t.Fatalf("abandoned temporary directory not removed: %v", err)
+	}
+
+	// A checksum that would place the temporary directory elsewhere is not
+	// used, the install is not resumable.
+	bad := file
+	bad.Sha256 = "/../../escape012" + strings.Repeat("0", 48)
+	bc := c
+	bc.InsecureSkipSha256 = true
+	bc.Events = nil
+	baddir := filepath.Join(t.TempDir(), "a", "go1.22.3")
+	if err := os.Mkdir(filepath.Dir(baddir), 0777); err != nil {
+		t.Fatal(err)
+	}
+	escape := resumeDir(baddir, bad)
+	if err := os.MkdirAll(escape, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(escape, "keep"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.install(context.Background(), bad, baddir, nil); err != nil {
+		t.Fatalf("install with bad checksum: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(escape, "keep")); err != nil {
+		t.Fatalf("directory outside parent of install used: %v", err)
+	}
+
+	// A journal of an extraction with other options is discarded.
+	jdir := t.TempDir()
+	j, err := openResumeJournal(jdir, resumeOptions{})
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	if err := j.record("go/VERSION", 9, "x", 0644); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	j.close()
+	if j, err = openResumeJournal(jdir, resumeOptions{}); err != nil || len(j.done) != 1 {
+		t.Fatalf("reopened journal: %v, %v", j, err)
+	}
+	j.close()
+	if j, err = openResumeJournal(jdir, resumeOptions{Exclude: []string{"test"}}); err != nil || len(j.done) != 0 {
+		t.Fatalf("journal with other options: %v, %v", j, err)
+	}
+	j.close()
+}
+
+func TestFetchResume(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/src/big.go": strings.Repeat("x", 10*1024)})
+	file := testFile("go1.22.3", tgz)
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if len(ranges) == 1 {
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(tgz)))
+			w.Write(tgz[:100])
+			return
+		}
+		http.ServeContent(w, r, file.Filename, time.Time{}, bytes.NewReader(tgz))
+	}))
+	defer srv.Close()
+
+	c := Client{BaseURL: srv.URL + "/", NoSignatures: true, TempDir: t.TempDir(), KeepPartial: true}
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("fetch with short body: got %v, expected ErrTruncated", err)
+	}
+	if fi, err := os.Stat(c.partialPath(file)); err != nil || fi.Size() != 100 {
+		t.Fatalf("partial download: %v", err)
+	}
+	// Downloaded includes the bytes from the first attempt.
+	if r, err := c.FetchDetails(context.Background(), file, t.TempDir(), nil); err != nil || r.Downloaded != file.Size || r.Cached {
+		t.Fatalf("resumed fetch: %v, downloaded %d of %d", err, r.Downloaded, file.Size)
+	}
+	if len(ranges) != 2 || ranges[1] != "bytes=100-" {
+		t.Fatalf("got requests with ranges %q, expected resume from byte 100", ranges)
+	}
+	if _, err := os.Stat(c.partialPath(file)); !os.IsNotExist(err) {
+		t.Fatalf("partial download not removed: %v", err)
+	}
+}
+
+func TestFetchResumeBadChecksum(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n"})
+	file := testFile("go1.22.3", tgz)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(tgz)
+	}))
+	defer srv.Close()
+
+	// A checksum from the listing that would name a partial file outside the
+	// temp dir.
+	tmp := t.TempDir()
+	victim := filepath.Join(tmp, "victim.partial")
+	if err := os.WriteFile(victim, []byte("keep"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	file.Sha256 = "x/../../victim"
+	c := Client{BaseURL: srv.URL + "/", NoSignatures: true, TempDir: filepath.Join(tmp, "temp"), KeepPartial: true}
+	if err := os.Mkdir(c.TempDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if c.resumable(file) {
+		t.Fatalf("download with bad checksum is resumable")
+	}
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err == nil {
+		t.Fatalf("fetch with bad checksum succeeded")
+	}
+	if buf, err := os.ReadFile(victim); err != nil || string(buf) != "keep" {
+		t.Fatalf("file outside temp dir changed: %q %v", buf, err)
+	}
+}
+
+func TestFetchChecksum(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n"})
+	file := testFile("go1.22.3", tgz)
+	file.Sha256 = ""
+	file.Checksum = fmt.Sprintf("sha512:%x", sha512.Sum512(tgz))
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}}
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch with sha512 checksum: %v", err)
+	}
+	if err := c.FetchTo(context.Background(), io.Discard, file); err != nil {
+		t.Fatalf("fetch to writer with sha512 checksum: %v", err)
+	}
+
+	file.Checksum = fmt.Sprintf("sha512:%x", sha512.Sum512(nil))
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err == nil {
+		t.Fatalf("fetch with bad sha512 checksum succeeded")
+	}
+	file.Checksum = "md4:00"
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err == nil {
+		t.Fatalf("fetch with unknown checksum algorithm succeeded")
+	}
+}
+
+func TestFetchExactModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix modes on windows")
+	}
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	for _, h := range []*tar.Header{
+		{Typeflag: tar.TypeDir, Name: "go/", Mode: 0775},
+		{Typeflag: tar.TypeReg, Name: "go/VERSION", Mode: 0666},
+	} {
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file := testFile("go1.22.3", b.Bytes())
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: b.Bytes()}}, ExactModes: true}
+	dst := t.TempDir()
+	if err := c.Fetch(context.Background(), file, dst, nil); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	for name, exp := range map[string]os.FileMode{"go": 0775, "go/VERSION": 0666} {
+		if fi, err := os.Stat(filepath.Join(dst, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if fi.Mode().Perm() != exp {
+			t.Errorf("%s: got mode %v, expected %v", name, fi.Mode().Perm(), exp)
+		}
+	}
+}
+
+func TestFetchCrossVerify(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n"})
+	file := testFile("go1.22.3", tgz)
+	sha256file := file.Sha256
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + file.Filename:
+			w.Write(tgz)
+		case "/" + file.Filename + ".sha256":
+			fmt.Fprintf(w, "%s  %s\n", sha256file, file.Filename)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := Client{BaseURL: srv.URL + "/", NoSignatures: true, CrossVerify: true}
+	if err := c.Fetch(ctx, file, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch with matching .sha256 file: %v", err)
+	}
+	sha256file = fmt.Sprintf("%x", sha256.Sum256(nil))
+	if err := c.Fetch(ctx, file, t.TempDir(), nil); err == nil {
+		t.Fatalf("fetch with mismatching .sha256 file succeeded")
+	}
+
+	c = Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}, Pins: map[string]string{file.Filename: file.Sha256}}
+	if err := c.Fetch(ctx, file, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch with matching pin: %v", err)
+	}
+	c.Pins[file.Filename] = sha256file
+	if err := c.Fetch(ctx, file, t.TempDir(), nil); err == nil {
+		t.Fatalf("fetch with mismatching pin succeeded")
+	}
+}
+
+func TestFetchDetails(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/go": "binary"})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}, ArchiveCacheDir: t.TempDir()}
+	dst := t.TempDir()
+	r, err := c.FetchDetails(context.Background(), file, dst, nil)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if r.Dir != filepath.Join(dst, "go") || r.Downloaded != int64(len(tgz)) || r.Cached || r.Sha256 != file.Sha256 || r.Files != 2 {
+		t.Fatalf("got result %#v", r)
+	}
+	r, err = c.FetchDetails(context.Background(), file, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("fetch from cache: %v", err)
+	}
+	if r.Downloaded != 0 || !r.Cached {
+		t.Fatalf("fetch from cache: got result %#v", r)
+	}
+}
+
+func TestFetchSkipSha256(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n"})
+	file := testFile("go1.22.3", tgz)
+	file.Sha256 = fmt.Sprintf("%x", sha256.Sum256(nil))
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}}
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err == nil {
+		t.Fatalf("fetch with bad checksum succeeded")
+	}
+	c.InsecureSkipSha256 = true
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch without verifying checksum: %v", err)
+	}
+	if err := c.FetchTo(context.Background(), io.Discard, file); err != nil {
+		t.Fatalf("fetch to writer without verifying checksum: %v", err)
+	}
+}
+
+func TestFetchOrigin(t *testing.T) {
+	src := newTestSource(t, "go1.22.3")
+	f := src.releases[0].Files[0]
+	c := Client{Source: src, WriteOrigin: true}
+	dir := filepath.Join(t.TempDir(), "go1.22.3")
+	if _, err := c.Install(context.Background(), "go1.22.3", dir, &InstallOptions{Os: "linux", Arch: "amd64"}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	o, err := ReadOrigin(dir)
+	if err != nil {
+		t.Fatalf("reading origin: %v", err)
+	}
+	if o.Version != "go1.22.3" || o.Filename != f.Filename || o.Sha256 != f.Sha256 || o.Time.IsZero() {
+		t.Fatalf("got origin %#v", o)
+	}
+	if err := Remove(dir); err != nil {
+		t.Fatalf("remove install with origin: %v", err)
+	}
+}
+
+func TestListArchive(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/go.bash": "binary"})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}, TempDir: t.TempDir()}
+	l, err := c.ListArchive(context.Background(), file)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	sizes := map[string]int64{}
+	for _, e := range l {
+		sizes[e.Name] = e.Size
+		if e.Name == "go/bin/go.bash" && (e.Type != EntryFile || e.Mode&0100 == 0) {
+			t.Fatalf("got entry %#v", e)
+		}
+	}
+	if len(sizes) != len(l) || sizes["go/VERSION"] != 9 || sizes["go/bin/go.bash"] != 6 {
+		t.Fatalf("got entries %v", l)
+	}
+	if entries, err := os.ReadDir(c.TempDir); err != nil || len(entries) != 0 {
+		t.Fatalf("files written while listing: %v %v", entries, err)
+	}
+
+	// Zip file, made from an install.
+	dir, err := c.FetchSDK(context.Background(), file, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	var zb bytes.Buffer
+	if err := Repack(dir, &zb, "zip"); err != nil {
+		t.Fatalf("repack: %v", err)
+	}
+	zfile := testFile("go1.22.3", zb.Bytes())
+	zfile.Filename = "go1.22.3.windows-amd64.zip"
+	zc := Client{Source: &memSource{files: map[string][]byte{zfile.Filename: zb.Bytes()}}}
+	zl, err := zc.ListArchive(context.Background(), zfile)
+	if err != nil || len(zl) != 4 || zl[0].Type != EntryDir || zl[1].Name != "go/VERSION" || zl[1].Size != 9 {
+		t.Fatalf("list zip: %v %v", zl, err)
+	}
+
+	file.Sha256 = fmt.Sprintf("%x", sha256.Sum256(nil))
+	if _, err := c.ListArchive(context.Background(), file); err == nil {
+		t.Fatalf("list with bad checksum succeeded")
+	}
+}
+
+func TestFetchKeepArchive(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n"})
+	file := testFile("go1.22.3", tgz)
+	dst := t.TempDir()
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}, KeepArchiveDir: dst}
+	if err := c.Fetch(context.Background(), file, dst, nil); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	buf, err := os.ReadFile(filepath.Join(dst, file.Filename))
+	if err != nil || !bytes.Equal(buf, tgz) {
+		t.Fatalf("kept release file: %v", err)
+	}
+
+	// Extract again from the kept file.
+	c = Client{Source: DirSource{dst}}
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch from kept file: %v", err)
+	}
+}
+
+func TestFetchPAX(t *testing.T) {
+	long := "go/src/" + strings.Repeat("d", 120) + "/x.go"
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	for _, h := range []*tar.Header{
+		{Typeflag: tar.TypeDir, Name: "go/", Mode: 0755},
+		{Typeflag: tar.TypeDir, Name: "go/src/", Mode: 0755},
+		{Typeflag: tar.TypeDir, Name: "go/src/" + strings.Repeat("d", 120) + "/", Mode: 0755},
+		{Typeflag: tar.TypeReg, Name: long, Mode: 0644, Size: 1, PAXRecords: map[string]string{"SCHILY.xattr.user.goreleases": "test"}},
+	} {
+		h.ModTime = time.Now()
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if h.Size > 0 {
+			tw.Write([]byte("x"))
+		}
+	}
+	tw.Close()
+	gzw.Close()
+	tgz := b.Bytes()
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}}
+
+	// Long names from PAX records, extended attributes ignored.
+	dst := t.TempDir()
+	if err := c.Fetch(context.Background(), file, dst, nil); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, filepath.FromSlash(long))); err != nil {
+		t.Fatalf("file with long name: %v", err)
+	}
+
+	// Attributes skipped by a filter are not set, on any system.
+	c.Xattrs = true
+	var filtered []string
+	c.XattrFilter = func(name, attr string, value []byte) ([]byte, bool) {
+		filtered = append(filtered, name+" "+attr)
+		// As if the attribute were the quarantine attribute.
+		return NoQuarantine(name, QuarantineXattr, value)
+	}
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch with xattr filter: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0] != long+" user.goreleases" {
+		t.Fatalf("filter called for %v", filtered)
+	}
+	c.XattrFilter = nil
+
+	dst = t.TempDir()
+	err := c.Fetch(context.Background(), file, dst, nil)
+	if runtime.GOOS != "linux" {
+		if err == nil {
+			t.Fatalf("fetch with xattrs succeeded on %s", runtime.GOOS)
+		}
+		return
+	}
+	if err != nil && errors.Is(err, syscall.ENOTSUP) {
+		t.Skipf("extended attributes not supported: %v", err)
+	} else if err != nil {
+		t.Fatalf("fetch with xattrs: %v", err)
+	}
+}
+
+func TestFetchStrict(t *testing.T) {
+	makeTar := func(headers ...*tar.Header) []byte {
+		var b bytes.Buffer
+		gzw := gzip.NewWriter(&b)
+		tw := tar.NewWriter(gzw)
+		for _, h := range append([]*tar.Header{{Typeflag: tar.TypeDir, Name: "go/", Mode: 0755}}, headers...) {
+			if h.Typeflag != tar.TypeXGlobalHeader {
+				h.ModTime = time.Now()
+			}
+			if err := tw.WriteHeader(h); err != nil {
+				t.Fatal(err)
+			}
+			tw.Write(make([]byte, h.Size))
+		}
+		tw.Close()
+		gzw.Close()
+		return b.Bytes()
+	}
+	fetch := func(strict bool, tgz []byte) error {
+		file := testFile("go1.22.3", tgz)
+		c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}, Strict: strict}
+		return c.Fetch(context.Background(), file, t.TempDir(), nil)
+	}
+
+	ok := makeTar(&tar.Header{Typeflag: tar.TypeReg, Name: "go/VERSION", Mode: 0644, Size: 1}, &tar.Header{Typeflag: tar.TypeSymlink, Name: "go/v", Linkname: "VERSION"})
+	if err := fetch(true, ok); err != nil {
+		t.Fatalf("strict fetch: %v", err)
+	}
+	for _, h := range []*tar.Header{
+		{Typeflag: tar.TypeReg, Name: "go/setuid", Mode: 04755, Size: 1},
+		{Typeflag: tar.TypeReg, Name: "other/file", Mode: 0644, Size: 1},
+		{Typeflag: tar.TypeSymlink, Name: "go/passwd", Linkname: "/etc/passwd"},
+		{Typeflag: tar.TypeFifo, Name: "go/fifo", Mode: 0644},
+		{Typeflag: tar.TypeXGlobalHeader, Name: "go/global", PAXRecords: map[string]string{"comment": "x"}},
+	} {
+		name := h.Name
+		tgz := makeTar(h)
+		var serr *StrictError
+		if err := fetch(true, tgz); !errors.As(err, &serr) || serr.Name != name {
+			t.Fatalf("strict fetch with %s: got %v, expected StrictError", name, err)
+		}
+		if h.Mode&04000 != 0 {
+			if err := fetch(false, tgz); err != nil {
+				t.Fatalf("fetch with %s: %v", name, err)
+			}
+		}
+	}
+}
+
+func TestFetchArchiveOwner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no chown on windows")
+	}
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	for _, h := range []*tar.Header{
+		{Typeflag: tar.TypeDir, Name: "go/", Mode: 0755, Uid: 1234, Gid: 1234},
+		{Typeflag: tar.TypeReg, Name: "go/VERSION", Mode: 0644, Uid: 1234, Gid: 1234},
+		{Typeflag: tar.TypeSymlink, Name: "go/v", Linkname: "VERSION", Uid: 1234, Gid: 1234},
+	} {
+		h.ModTime = time.Now()
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gzw.Close()
+	tgz := b.Bytes()
+	file := testFile("go1.22.3", tgz)
+
+	var mapped int
+	c := Client{
+		Source:       &memSource{files: map[string][]byte{file.Filename: tgz}},
+		ArchiveOwner: true,
+		OwnerMap: func(uid, gid int) (int, int) {
+			if uid != 1234 || gid != 1234 {
+				t.Fatalf("owner map called with %d %d", uid, gid)
+			}
+			mapped++
+			return os.Getuid(), os.Getgid()
+		},
+	}
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if mapped != 3 {
+		t.Fatalf("owner map called %d times, expected 3", mapped)
+	}
+
+	// An owner in the permissions takes precedence.
+	mapped = 0
+	perms := &Permissions{os.Getuid(), os.Getgid(), 0755}
+	if err := c.Fetch(context.Background(), file, t.TempDir(), perms); err != nil {
+		t.Fatalf("fetch with permissions: %v", err)
+	}
+	if mapped != 0 {
+		t.Fatalf("owner map called with owner in permissions")
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	gzr, err := gzip.NewReader(bytes.NewReader(makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/go": "binary"})))
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	data, err := io.ReadAll(gzr)
+	if err != nil {
+		t.Fatalf("gunzip: %v", err)
+	}
+	f := testFile("go1.22.3", data)
+	f.Filename = "go1.22.3.linux-amd64.tar.test"
+	src := &memSource{files: map[string][]byte{f.Filename: data}}
+	src.setReleases([]Release{{Version: "go1.22.3", Stable: true, Files: []File{f}}})
+	c := Client{Source: src}
+	ctx := context.Background()
+
+	if err := c.Fetch(ctx, f, t.TempDir(), nil); err == nil || !strings.Contains(err.Error(), ".tar.gz") {
+		t.Fatalf("fetch of unregistered format: got %v, expected error listing formats", err)
+	}
+	RegisterFormat(".tar.test", ArchiveFormatFunc(func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(r), nil
+	}))
+	dst := t.TempDir()
+	if err := c.Fetch(ctx, f, dst, nil); err != nil {
+		t.Fatalf("fetch of registered format: %v", err)
+	}
+	if v, err := ReadVersion(filepath.Join(dst, "go")); err != nil || v != "go1.22.3" {
+		t.Fatalf("version %q %v", v, err)
+	}
+	l, err := c.ListArchive(ctx, f)
+	if err != nil || len(l) != 4 {
+		t.Fatalf("list archive of registered format: %v %v", l, err)
+	}
+}
+
+func TestFetchVerifier(t *testing.T) {
+	src := newTestSource(t, "go1.22.3")
+	f := src.releases[0].Files[0]
+	ctx := context.Background()
+
+	c := Client{Source: src, Verifier: AllVerifiers(Sha256Verifier, AllowlistVerifier(strings.ToUpper(f.Sha256)))}
+	if err := c.Fetch(ctx, f, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch with allowlisted file: %v", err)
+	}
+
+	c.Verifier = AllVerifiers(Sha256Verifier, AllowlistVerifier("00"))
+	if err := c.Fetch(ctx, f, t.TempDir(), nil); err == nil || !strings.Contains(err.Error(), "allowlist") {
+		t.Fatalf("fetch of file not in allowlist: got %v, expected allowlist error", err)
+	}
+	if err := c.FetchTo(ctx, io.Discard, f); err == nil {
+		t.Fatalf("fetchto of file not in allowlist: expected error")
+	}
+
+	bad := f
+	bad.Sha256 = strings.Repeat("0", 64)
+	c.Verifier = Sha256Verifier
+	if err := c.Fetch(ctx, bad, t.TempDir(), nil); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("fetch with bad sha256: got %v, expected checksum mismatch", err)
+	}
+
+	// The verifier replaces the default sha256 check.
+	c.Verifier = AllowlistVerifier(f.Sha256)
+	if err := c.Fetch(ctx, bad, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch with allowlist verifier: %v", err)
 	}
 }
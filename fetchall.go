@@ -0,0 +1,143 @@
+package goreleases
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FetchAllResult summarizes a FetchAll.
+type FetchAllResult struct {
+	Downloaded []File
+	Present    []File           // Already present with the expected checksum.
+	Failed     map[string]error // By filename.
+}
+
+// FetchAll downloads all files of release into directory dst, verifying them,
+// with at most concurrency downloads at a time (at least 1). Files already
+// present with the expected checksum are not downloaded again. Signatures, if
+// the source provides them, are stored as <filename>.asc, and checksums as
+// <filename>.sha256, like the upstream server provides them. Aggregate
+// progress is reported to Client.BulkProgress, if set.
+//
+// A result is always returned. If any file failed, an error is returned as
+// well.
+func (c *Client) FetchAll(ctx context.Context, release Release, dst string, concurrency int) (FetchAllResult, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	r := FetchAllResult{Failed: map[string]error{}}
+	var bulk *bulkTracker
+	fc := c
+	if c.BulkProgress != nil {
+		bulk = newBulkTracker(c.BulkProgress, release.Files)
+		fc = bulk.client(c)
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, f := range release.Files {
+		f := f
+		if err := acquire(ctx, sem); err != nil {
+			if bulk != nil {
+				bulk.finish(f, false)
+			}
+			mu.Lock()
+			r.Failed[f.Filename] = err
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			present, err := fc.fetchAllFile(ctx, f, dst)
+			if bulk != nil {
+				bulk.finish(f, err == nil && !present)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				r.Failed[f.Filename] = err
+			} else if present {
+				r.Present = append(r.Present, f)
+			} else {
+				r.Downloaded = append(r.Downloaded, f)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(r.Failed) > 0 {
+		return r, fmt.Errorf("%d of %d files failed", len(r.Failed), len(release.Files))
+	}
+	return r, nil
+}
+
+// fetchAllFile downloads f into dst unless already present, returning whether
+// it was present.
+func (c *Client) fetchAllFile(ctx context.Context, f File, dst string) (bool, error) {
+	p := filepath.Join(dst, f.Filename)
+	if sum, err := fileSha256(p); err == nil && sum == f.Sha256 {
+		return true, nil
+	}
+	// Fetch the signature once, for both verifying and storing it.
+	sig, err := c.Signature(ctx, f)
+	if err != nil {
+		return false, err
+	}
+	dc := *c
+	dc.NoSignatures = true
+	if err := dc.Download(ctx, f, dst); err != nil {
+		return false, err
+	}
+	if sig != nil {
+		if err := os.WriteFile(p+".asc", sig, 0666); err != nil {
+			return false, err
+		}
+		if err := verifyFileSignature(p); err != nil {
+			c.metrics.verifyFailed()
+			os.Remove(p)
+			os.Remove(p + ".asc")
+			return false, err
+		}
+	}
+	if err := os.WriteFile(p+".sha256", []byte(f.Sha256), 0666); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// acquire waits for a slot in sem, released by the caller. If ctx is done, its
+// error is returned instead, also if a slot is available.
+func acquire(ctx context.Context, sem chan struct{}) error {
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		<-sem
+		return err
+	}
+	return nil
+}
+
+// fileSha256 returns the hex sha256 of the file at path p.
+func fileSha256(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
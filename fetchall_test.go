@@ -0,0 +1,71 @@
+package goreleases
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// countSource counts opens and signature requests, and calls fn on open.
+type countSource struct {
+	*memSource
+	mu         sync.Mutex
+	opens      int
+	signatures int
+	fn         func()
+}
+
+func (s *countSource) Open(ctx context.Context, file File) (io.ReadCloser, error) {
+	s.mu.Lock()
+	s.opens++
+	s.mu.Unlock()
+	if s.fn != nil {
+		s.fn()
+	}
+	return s.memSource.Open(ctx, file)
+}
+
+func (s *countSource) Signature(ctx context.Context, file File) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signatures++
+	return nil, nil
+}
+
+func TestFetchAll(t *testing.T) {
+	ms := newTestSource(t, "go1.22.3")
+	f := ms.releases[0].Files[0]
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n"})
+	f2 := testFile("go1.22.3", tgz)
+	f2.Filename = "go1.22.3.linux-arm64.tar.gz"
+	ms.files[f2.Filename] = tgz
+	rel := Release{Version: "go1.22.3", Stable: true, Files: []File{f, f2}}
+
+	src := &countSource{memSource: ms}
+	c := Client{Source: src}
+	dst := t.TempDir()
+	r, err := c.FetchAll(context.Background(), rel, dst, 2)
+	if err != nil || len(r.Downloaded) != 2 {
+		t.Fatalf("fetchall: %#v %v", r, err)
+	}
+	if src.opens != 2 || src.signatures != 2 {
+		t.Fatalf("got %d opens and %d signature requests, expected 2 each", src.opens, src.signatures)
+	}
+	if buf, err := os.ReadFile(filepath.Join(dst, f.Filename+".sha256")); err != nil || string(buf) != f.Sha256 {
+		t.Fatalf("checksum file: %q %v", buf, err)
+	}
+
+	// Canceling stops starting downloads.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src = &countSource{memSource: ms, fn: cancel}
+	c = Client{Source: src}
+	r, _ = c.FetchAll(ctx, rel, t.TempDir(), 1)
+	if src.opens != 1 || !errors.Is(r.Failed[f2.Filename], context.Canceled) {
+		t.Fatalf("fetchall after cancel: %d opens, failed %v", src.opens, r.Failed)
+	}
+}
@@ -0,0 +1,138 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// InstallTarget is a release to install with FetchMany.
+type InstallTarget struct {
+	Spec string // Version spec, see Install.
+	Dir  string
+	Os   string // Default runtime.GOOS.
+	Arch string // Default runtime.GOARCH.
+}
+
+// FetchManyResult summarizes a FetchMany.
+type FetchManyResult struct {
+	Installed []InstallResult  // Of successful targets, also with action InstallNone.
+	Failed    map[string]error // By target Dir.
+}
+
+// FetchMany installs the targets like Install, e.g. several releases or
+// several platforms of a release, with at most concurrency installs at a time
+// (at least 1). Releases are listed once for all targets. Requests of all
+// installs share the rate limiter of the client, if any. Options other than
+// Os and Arch from opts apply to all targets. Aggregate progress is reported
+// to Client.BulkProgress, if set.
+//
+// A result is always returned. If any target failed, an error is returned as
+// well.
+func (c *Client) FetchMany(ctx context.Context, targets []InstallTarget, concurrency int, opts *InstallOptions) (FetchManyResult, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	r := FetchManyResult{Failed: map[string]error{}}
+	seen := map[string]bool{}
+	for _, t := range targets {
+		if seen[t.Dir] {
+			return r, fmt.Errorf("duplicate target dir %s", t.Dir)
+		}
+		seen[t.Dir] = true
+	}
+
+	var rels []Release
+	for _, t := range targets {
+		if t.Spec != "tip" && !strings.HasPrefix(t.Spec, "tip@") {
+			var err error
+			rels, err = c.ListAll(ctx)
+			if err != nil {
+				return r, err
+			}
+			break
+		}
+	}
+
+	var o InstallOptions
+	if opts != nil {
+		o = *opts
+	}
+	var bulk *bulkTracker
+	var bulkFiles map[string]File
+	ic := c
+	if c.BulkProgress != nil {
+		bulkFiles = c.fetchManyFiles(ctx, rels, targets, o)
+		var files []File
+		for _, t := range targets {
+			if f, ok := bulkFiles[t.Dir]; ok {
+				files = append(files, f)
+			}
+		}
+		bulk = newBulkTracker(c.BulkProgress, files)
+		ic = bulk.client(c)
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, t := range targets {
+		t := t
+		if err := acquire(ctx, sem); err != nil {
+			if bulk != nil {
+				bulk.finish(bulkFiles[t.Dir], false)
+			}
+			mu.Lock()
+			r.Failed[t.Dir] = err
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			to := o
+			to.Os, to.Arch = t.Os, t.Arch
+			ir, err := ic.installWith(ctx, rels, t.Spec, t.Dir, &to)
+			if bulk != nil {
+				bulk.finish(bulkFiles[t.Dir], err == nil && ir.Action != InstallNone)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				r.Failed[t.Dir] = err
+			} else {
+				r.Installed = append(r.Installed, ir)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(r.Failed) > 0 {
+		return r, fmt.Errorf("%d of %d targets failed", len(r.Failed), len(targets))
+	}
+	return r, nil
+}
+
+// fetchManyFiles returns the files that FetchMany will download, by target
+// dir, determined with dry runs, for the total of BulkProgress. Targets for
+// tip are not included, their size is not known in advance.
+func (c *Client) fetchManyFiles(ctx context.Context, rels []Release, targets []InstallTarget, o InstallOptions) map[string]File {
+	dc := *c
+	dc.Events = nil
+	o.DryRun = true
+	files := map[string]File{}
+	for _, t := range targets {
+		if t.Spec == "tip" || strings.HasPrefix(t.Spec, "tip@") {
+			continue
+		}
+		to := o
+		to.Os, to.Arch = t.Os, t.Arch
+		ir, err := dc.installWith(ctx, rels, t.Spec, t.Dir, &to)
+		if err == nil && ir.Action != InstallNone && !ir.Cached {
+			files[t.Dir] = ir.File
+		}
+	}
+	return files
+}
@@ -0,0 +1,75 @@
+package goreleases
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchMany(t *testing.T) {
+	tmp := t.TempDir()
+	src := newTestSource(t, "go1.22.2", "go1.22.3")
+	c := Client{Source: src}
+	ctx := context.Background()
+
+	targets := []InstallTarget{
+		{Spec: "1.22.2", Dir: filepath.Join(tmp, "a"), Os: "linux", Arch: "amd64"},
+		{Spec: "1.22.3", Dir: filepath.Join(tmp, "b"), Os: "linux", Arch: "amd64"},
+		{Spec: "1.22.3", Dir: filepath.Join(tmp, "c"), Os: "windows", Arch: "amd64"},
+		{Spec: "1.21", Dir: filepath.Join(tmp, "d"), Os: "linux", Arch: "amd64"},
+	}
+	r, err := c.FetchMany(ctx, targets, 2, nil)
+	if err == nil {
+		t.Fatalf("fetchmany: expected error")
+	}
+	if len(r.Installed) != 2 || len(r.Failed) != 2 || r.Failed[targets[2].Dir] == nil || r.Failed[targets[3].Dir] == nil {
+		t.Fatalf("fetchmany: got %d installed, failed %v", len(r.Installed), r.Failed)
+	}
+	for i, v := range []string{"go1.22.2", "go1.22.3"} {
+		if xv, err := ReadVersion(targets[i].Dir); err != nil || xv != v {
+			t.Fatalf("version of %s: %q %v, expected %s", targets[i].Dir, xv, err, v)
+		}
+	}
+
+	r, err = c.FetchMany(ctx, targets[:2], 0, nil)
+	if err != nil || len(r.Installed) != 2 || r.Installed[0].Action != InstallNone {
+		t.Fatalf("fetchmany again: %v %v", r, err)
+	}
+
+	if _, err := c.FetchMany(ctx, []InstallTarget{targets[0], targets[0]}, 1, nil); err == nil {
+		t.Fatalf("fetchmany with duplicate dir: expected error")
+	}
+}
+
+func TestFetchManyBulkProgress(t *testing.T) {
+	tmp := t.TempDir()
+	src := &memSource{}
+	var total int64
+	for _, v := range []string{"go1.22.2", "go1.22.3"} {
+		total += src.addRelease(t, v).Size
+	}
+	var last BulkProgress
+	var calls int
+	c := Client{Source: src, BulkProgress: func(p BulkProgress) {
+		calls++
+		if p.Total != total || p.FilesTotal != 2 || p.Downloaded > p.Total {
+			t.Fatalf("bulk progress %+v, expected total %d of 2 files", p, total)
+		}
+		last = p
+	}}
+	targets := []InstallTarget{
+		{Spec: "1.22.2", Dir: filepath.Join(tmp, "a"), Os: "linux", Arch: "amd64"},
+		{Spec: "1.22.3", Dir: filepath.Join(tmp, "b"), Os: "linux", Arch: "amd64"},
+	}
+	if _, err := c.FetchMany(context.Background(), targets, 2, nil); err != nil {
+		t.Fatalf("fetchmany: %v", err)
+	}
+	if last.Downloaded != total || last.Files != 2 || last.ETA != 0 {
+		t.Fatalf("last bulk progress %+v", last)
+	}
+
+	calls = 0
+	if _, err := c.FetchMany(context.Background(), targets, 2, nil); err != nil || calls != 0 {
+		t.Fatalf("fetchmany again: %v, %d progress calls", err, calls)
+	}
+}
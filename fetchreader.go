@@ -0,0 +1,163 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// ArchiveReader reads the entries of a .tar.gz release file while it is being
+// downloaded. Call Next and Read like on a tar.Reader. Once Next returns io.EOF,
+// the rest of the download has been read and the checksum and signature have
+// been verified. If verification fails, Next returns an error instead of
+// io.EOF. Data read before that point is not yet verified, callers must only
+// act on it after Next returned io.EOF.
+type ArchiveReader struct {
+	rc   io.ReadCloser
+	cr   *countReader
+	r    io.Reader // Over cr, feeding the signature check if any.
+	hr   *hashReader
+	ck   *checksumCheck
+	c    *Client
+	tarr io.ReadCloser // Tar stream of the format.
+	tr   *tar.Reader
+	file File
+
+	// For signature verification, set if the source provides signatures.
+	pw     *io.PipeWriter
+	sigerr chan error
+
+	done error
+}
+
+// FetchReader starts downloading file, which must be a .tar.gz or of another
+// format read as tar stream (see RegisterFormat), and returns an ArchiveReader
+// over its entries. The caller must call Close.
+func (c *Client) FetchReader(ctx context.Context, file File) (*ArchiveReader, error) {
+	format, err := lookupTarFormat(file.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.crossVerify(ctx, file); err != nil {
+		return nil, err
+	}
+	sigbuf, err := c.Signature(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	ck, err := c.newCheck(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := c.Open(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	ar := &ArchiveReader{rc: rc, file: file, ck: ck, c: c}
+	ar.hr = &hashReader{ck.tee(rc), sha256.New()}
+	ar.cr = &countReader{r: ar.hr}
+	var r io.Reader = ar.cr
+	if sigbuf != nil {
+		pr, pw := io.Pipe()
+		ar.pw = pw
+		ar.sigerr = make(chan error, 1)
+		go func() {
+			_, err := openpgp.CheckArmoredDetachedSignature(signingKey, pr, bytes.NewReader(sigbuf))
+			pr.CloseWithError(err)
+			ar.sigerr <- err
+		}()
+		r = io.TeeReader(r, pw)
+	}
+	ar.r = r
+	tarr, err := format.Tar(r)
+	if err != nil {
+		ar.Close()
+		return nil, err
+	}
+	ar.tarr = tarr
+	ar.tr = tar.NewReader(tarr)
+	return ar, nil
+}
+
+// Next advances to the next entry. At the end of the archive, it returns
+// io.EOF if the download verified correctly.
+func (ar *ArchiveReader) Next() (*tar.Header, error) {
+	if ar.done != nil {
+		return nil, ar.done
+	}
+	h, err := ar.tr.Next()
+	if err == io.EOF {
+		err = ar.verify()
+		if err == nil {
+			err = io.EOF
+		}
+	} else if err != nil {
+		err = ar.readErr(err)
+	}
+	if err != nil {
+		ar.done = err
+	}
+	return h, err
+}
+
+// Read reads from the current entry.
+func (ar *ArchiveReader) Read(buf []byte) (int, error) {
+	if ar.done != nil {
+		return 0, ar.done
+	}
+	n, err := ar.tr.Read(buf)
+	if err != nil && err != io.EOF {
+		err = ar.readErr(err)
+		ar.done = err
+	}
+	return n, err
+}
+
+// Close stops the download.
+func (ar *ArchiveReader) Close() error {
+	if ar.pw != nil {
+		ar.pw.CloseWithError(io.ErrClosedPipe)
+		ar.pw = nil
+	}
+	if ar.tarr != nil {
+		ar.tarr.Close()
+	}
+	return ar.rc.Close()
+}
+
+func (ar *ArchiveReader) readErr(err error) error {
+	if ar.cr.err == io.ErrUnexpectedEOF || ar.cr.err == io.EOF && ar.file.Size > 0 && ar.cr.n < ar.file.Size {
+		return fmt.Errorf("%w: connection closed after %d bytes", ErrTruncated, ar.cr.n)
+	}
+	return fmt.Errorf("reading release file: %v", err)
+}
+
+// verify reads the remainder of the download, e.g. gzip padding, and checks
+// the size, checksum and signature.
+func (ar *ArchiveReader) verify() error {
+	// Data after the end of the compressed stream is part of the checksum and
+	// signature.
+	if _, err := io.Copy(io.Discard, ar.r); err != nil {
+		return ar.readErr(err)
+	}
+	if ar.file.Size > 0 && ar.cr.n < ar.file.Size {
+		return fmt.Errorf("%w: got %d bytes, expected %d", ErrTruncated, ar.cr.n, ar.file.Size)
+	}
+	if ar.pw != nil {
+		ar.pw.Close()
+		ar.pw = nil
+		if err := <-ar.sigerr; err != nil {
+			return fmt.Errorf("verifying pgp signature on go release: %v", err)
+		}
+	}
+	if err := ar.ck.verify(); err != nil {
+		return err
+	}
+	return ar.c.checkSha256(ar.file, fmt.Sprintf("%x", ar.hr.h.Sum(nil)))
+}
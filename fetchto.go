@@ -0,0 +1,77 @@
+package goreleases
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// FetchTo downloads file and writes its raw contents to w while downloading,
+// without temporary files. The sha256 checksum, and the pgp signature if the
+// source provides one, are verified once all data has been written, so on
+// error, the data written to w must be discarded. The archive cache is not
+// used.
+func (c *Client) FetchTo(ctx context.Context, w io.Writer, file File) error {
+	if err := c.crossVerify(ctx, file); err != nil {
+		return err
+	}
+	sigbuf, err := c.Signature(ctx, file)
+	if err != nil {
+		return err
+	}
+	rc, err := c.Open(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	ck, err := c.newCheck(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	hr := &hashReader{ck.tee(rc), sha256.New()}
+	cr := &countReader{r: hr}
+	tr := io.TeeReader(cr, w)
+	if sigbuf != nil {
+		_, err = openpgp.CheckArmoredDetachedSignature(signingKey, tr, bytes.NewReader(sigbuf))
+	} else {
+		_, err = io.Copy(io.Discard, tr)
+	}
+	if err == io.ErrUnexpectedEOF && cr.err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: connection closed after %d bytes", ErrTruncated, cr.n)
+	} else if cr.err != nil && cr.err != io.EOF {
+		return fmt.Errorf("copying release file: %v", cr.err)
+	} else if err != nil && sigbuf != nil {
+		return fmt.Errorf("verifying pgp signature on go release: %v", err)
+	} else if err != nil {
+		return fmt.Errorf("copying release file: %v", err)
+	}
+	if file.Size > 0 && cr.n < file.Size {
+		return fmt.Errorf("%w: got %d bytes, expected %d", ErrTruncated, cr.n, file.Size)
+	}
+	if err := ck.verify(); err != nil {
+		return err
+	}
+	return c.checkSha256(file, fmt.Sprintf("%x", hr.h.Sum(nil)))
+}
+
+// countReader counts the bytes read and remembers the read error, to tell
+// read errors from errors of the consumer.
+type countReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (cr *countReader) Read(buf []byte) (int, error) {
+	n, err := cr.r.Read(buf)
+	cr.n += int64(n)
+	if err != nil {
+		cr.err = err
+	}
+	return n, err
+}
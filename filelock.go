@@ -0,0 +1,89 @@
+package goreleases
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockFile takes an exclusive lock on the file at path, shared with other
+// processes, for Client.SharedCache. The file and its directory are created if
+// needed. A lock file is only removed while locked, see removeLockFile, and a
+// lock on a file that was removed while waiting is taken again on a new file.
+// The lock is retried until ctx is done. The returned function releases the
+// lock. Where file locks are not supported, locking always succeeds.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	delay := 10 * time.Millisecond
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		} else if ok && !sameFile(f, path) {
+			unlock(f)
+			f.Close()
+			if f, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666); err != nil {
+				return nil, err
+			}
+			continue
+		} else if ok {
+			return func() {
+				unlock(f)
+				f.Close()
+			}, nil
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			f.Close()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+		if delay < time.Second {
+			delay *= 2
+		}
+	}
+}
+
+// sameFile returns whether path still refers to open file f.
+func sameFile(f *os.File, path string) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	pfi, err := os.Stat(path)
+	return err == nil && os.SameFile(fi, pfi)
+}
+
+// removeLockFile removes the lock file at path if it is not locked, returning
+// whether it was removed.
+func removeLockFile(path string) bool {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if ok, err := tryLock(f); err != nil || !ok {
+		return false
+	}
+	defer unlock(f)
+	return sameFile(f, path) && os.Remove(path) == nil
+}
+
+// lockCache locks name in cache directory dir if c.SharedCache is set, and
+// returns a function releasing the lock.
+func (c *Client) lockCache(ctx context.Context, dir, name string) (func(), error) {
+	if !c.SharedCache {
+		return func() {}, nil
+	}
+	return lockFile(ctx, filepath.Join(dir, ".lock-"+name))
+}
@@ -0,0 +1,21 @@
+//go:build !linux && !darwin && !freebsd && !openbsd && !netbsd && !dragonfly && !windows
+// +build !linux,!darwin,!freebsd,!openbsd,!netbsd,!dragonfly,!windows
+
+package goreleases
+
+import (
+	"os"
+)
+
+// fileLocks is whether file locking is supported.
+const fileLocks = false
+
+// tryLock always succeeds, file locks are not supported. Cache files are still
+// written atomically, concurrent processes may only duplicate work.
+func tryLock(f *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlock(f *os.File) error {
+	return nil
+}
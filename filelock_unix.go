@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd || dragonfly
+// +build linux darwin freebsd openbsd netbsd dragonfly
+
+package goreleases
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileLocks is whether file locking is supported.
+const fileLocks = true
+
+// tryLock takes an exclusive flock on f without waiting, returning false if
+// another process holds it.
+func tryLock(f *os.File) (bool, error) {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == syscall.EINTR {
+			continue
+		} else if err == syscall.EWOULDBLOCK {
+			return false, nil
+		}
+		return err == nil, err
+	}
+}
+
+func unlock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
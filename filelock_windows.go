@@ -0,0 +1,46 @@
+//go:build windows
+// +build windows
+
+package goreleases
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+	errorLockViolation      = syscall.Errno(33)
+)
+
+// fileLocks is whether file locking is supported.
+const fileLocks = true
+
+// tryLock locks f exclusively with LockFileEx without waiting, returning
+// false if another process holds the lock.
+func tryLock(f *os.File) (bool, error) {
+	var ol syscall.Overlapped
+	r, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r != 0 {
+		return true, nil
+	} else if err == errorLockViolation {
+		return false, nil
+	}
+	return false, err
+}
+
+func unlock(f *os.File) error {
+	var ol syscall.Overlapped
+	r, _, err := procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
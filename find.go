@@ -1,23 +1,313 @@
 package goreleases
 
 import (
+	"context"
 	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // FindFile finds the file in a release for a given os, arch, kind.
 // For empty values of os, arch, kind parameters, any file in the release matches.
+//
+// Upstream publishes 32-bit ARM files with arch "armv6l". For arch "arm", such
+// files match as well. A GOARM hint can be passed as arch "armv5", "armv6" or
+// "armv7", only files with an ARM variant that runs on that GOARM then match. An
+// exact match of arch is preferred, then the highest matching ARM variant.
+//
+// Source files are not specific to an os and arch, so for kind KindSource, os
+// and arch are ignored.
+//
+// If no file matches, a *FileNotFoundError is returned.
 func FindFile(release Release, os, arch, kind string) (File, error) {
+	if kind == KindSource {
+		os, arch = "", ""
+	}
+	goarm, isarm := parseARM(arch)
+	var best File
+	bestarm := -1
 	for _, f := range release.Files {
 		if os != "" && f.Os != os {
 			continue
 		}
-		if arch != "" && f.Arch != arch {
+		if kind != "" && f.Kind != kind {
 			continue
 		}
-		if kind != "" && f.Kind != kind {
+		if arch == "" || f.Arch == arch {
+			return f, nil
+		}
+		if !isarm {
+			continue
+		}
+		if v, ok := parseARM(f.Arch); ok && v <= goarm && v > bestarm {
+			best = f
+			bestarm = v
+		}
+	}
+	if bestarm >= 0 {
+		return best, nil
+	}
+	var kinds []string
+	if kind != "" {
+		kinds = []string{kind}
+	}
+	return File{}, newFileNotFoundError(release, os, arch, kinds)
+}
+
+// FindFile is like the package-level FindFile, for release r.
+func (r Release) FindFile(goos, goarch, kind string) (File, error) {
+	return FindFile(r, goos, goarch, kind)
+}
+
+// FindFilePreferred finds the file in release for os and arch with the first
+// of kinds that is available, e.g. KindArchive, then KindInstaller. Matching
+// is as with FindFile, as is the *FileNotFoundError if no file matches.
+func FindFilePreferred(release Release, os, arch string, kinds ...string) (File, error) {
+	for _, kind := range kinds {
+		if f, err := FindFile(release, os, arch, kind); err == nil {
+			return f, nil
+		}
+	}
+	return File{}, newFileNotFoundError(release, os, arch, kinds)
+}
+
+// FileNotFoundError is returned by FindFile and FindFilePreferred if a
+// release has no file for the platform, e.g. for ports without binary
+// releases like some versions for riscv64 or loong64. It has the nearest
+// alternatives. See SuggestSourceBuild for building from source instead.
+type FileNotFoundError struct {
+	Version  string
+	Os, Arch string
+	Kinds    []string // Requested kinds, empty for any.
+
+	// Files of the requested kinds for the os with other arches, the closest
+	// arch first: Of the same family (e.g. arm64 for arm), then with the same
+	// word size.
+	Alternatives []File
+
+	// Source file of the release, if any.
+	Source *File
+}
+
+func (e *FileNotFoundError) Error() string {
+	s := fmt.Sprintf("release %s has no file for %s/%s", e.Version, e.Os, e.Arch)
+	if len(e.Kinds) > 0 {
+		s += " of kind " + strings.Join(e.Kinds, " or ")
+	}
+	if len(e.Alternatives) > 0 {
+		var arches []string
+		seen := map[string]bool{}
+		for _, f := range e.Alternatives {
+			if !seen[f.Arch] {
+				seen[f.Arch] = true
+				arches = append(arches, f.Arch)
+			}
+		}
+		s += fmt.Sprintf(", available for arch %s", strings.Join(arches, ", "))
+	}
+	if e.Source != nil {
+		s += fmt.Sprintf(", can be built from source %s", e.Source.Filename)
+	}
+	return s
+}
+
+func newFileNotFoundError(release Release, goos, goarch string, kinds []string) *FileNotFoundError {
+	e := &FileNotFoundError{Version: release.Version, Os: goos, Arch: goarch, Kinds: kinds}
+	for _, f := range release.Files {
+		if f.Kind == KindSource {
+			if e.Source == nil {
+				f := f
+				e.Source = &f
+			}
+			continue
+		}
+		if goos != "" && f.Os != goos || goarch != "" && f.Arch == goarch {
 			continue
 		}
-		return f, nil
+		ok := len(kinds) == 0
+		for _, k := range kinds {
+			ok = ok || f.Kind == k
+		}
+		if ok {
+			e.Alternatives = append(e.Alternatives, f)
+		}
+	}
+	sort.SliceStable(e.Alternatives, func(i, j int) bool {
+		return archDistance(goarch, e.Alternatives[i].Arch) < archDistance(goarch, e.Alternatives[j].Arch)
+	})
+	return e
+}
+
+// archDistance returns how far arch b is from arch a: 0 for the same family
+// and word size, e.g. armv6l for arm, 1 for the same family, 2 for the same
+// word size, 3 otherwise.
+func archDistance(a, b string) int {
+	family := func(arch string) string {
+		if _, ok := parseARM(arch); ok {
+			return "arm"
+		}
+		switch arch {
+		case "arm64":
+			return "arm"
+		case "amd64", "386":
+			return "x86"
+		case "ppc64", "ppc64le":
+			return "ppc"
+		case "mips", "mipsle", "mips64", "mips64le":
+			return "mips"
+		}
+		return arch
+	}
+	bits := func(arch string) int {
+		switch arch {
+		case "amd64", "arm64", "loong64", "mips64", "mips64le", "ppc64", "ppc64le", "riscv64", "s390x", "wasm":
+			return 64
+		}
+		return 32
+	}
+	switch {
+	case family(a) == family(b) && bits(a) == bits(b):
+		return 0
+	case family(a) == family(b):
+		return 1
+	case bits(a) == bits(b):
+		return 2
+	}
+	return 3
+}
+
+// SourceBuild describes how to build a release from source for a platform
+// without binary release files, as returned by SuggestSourceBuild.
+type SourceBuild struct {
+	Release  string // E.g. "go1.22.3".
+	Source   File
+	Os, Arch string // Target platform.
+
+	// Minor of the Go release used to build, e.g. "1.20", see BootstrapMinor.
+	Bootstrap string
+
+	// Whether the target is not the host platform. The toolchain is then built
+	// on the host with bootstrap.bash, for copying to the target.
+	Cross bool
+}
+
+// SuggestSourceBuild returns how release can be built from its source for
+// goos and goarch, or the host platform if empty, e.g. after FindFile
+// returned a *FileNotFoundError. On the host, BuildFromSource does the build.
+func SuggestSourceBuild(release Release, goos, goarch string) (SourceBuild, error) {
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	src, err := FindSource(release)
+	if err != nil {
+		return SourceBuild{}, fmt.Errorf("release %s has no source file", release.Version)
+	}
+	v, err := ParseVersion(release.Version)
+	if err != nil {
+		return SourceBuild{}, err
+	}
+	minor, err := BootstrapMinor(v)
+	if err != nil {
+		return SourceBuild{}, err
+	}
+	cross := goos != runtime.GOOS || goarch != runtime.GOARCH
+	return SourceBuild{release.Version, src, goos, goarch, minor, cross}, nil
+}
+
+// String returns the steps of the build, for users.
+func (b SourceBuild) String() string {
+	s := fmt.Sprintf("extract %s, install go%s or newer as bootstrap toolchain, and ", b.Source.Filename, b.Bootstrap)
+	if b.Cross {
+		return s + fmt.Sprintf("run \"GOROOT_BOOTSTRAP=<bootstrap> GOOS=%s GOARCH=%s ./bootstrap.bash\" in go/src, and copy the resulting go-%s-%s tree to the target", b.Os, b.Arch, b.Os, b.Arch)
+	}
+	return s + "run \"GOROOT_BOOTSTRAP=<bootstrap> ./make.bash\" in go/src, or use BuildFromSource"
+}
+
+// FindFileForHost finds the file in release for runtime.GOOS and
+// runtime.GOARCH, preferring an archive (KindArchive) over an installer. On
+// 32-bit ARM, the GOARM of the running binary is used as hint, see HostArch.
+func FindFileForHost(release Release) (File, error) {
+	return FindFilePreferred(release, runtime.GOOS, HostArch(), KindArchive, KindInstaller)
+}
+
+// HostArch returns the arch of the host for FindFile: runtime.GOARCH, except
+// on 32-bit ARM, where a GOARM hint like "armv7" is returned from the build
+// settings of the running binary, if known, so "armv6l" files match.
+func HostArch() string {
+	if runtime.GOARCH != "arm" {
+		return runtime.GOARCH
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			// E.g. "7" or "6,softfloat".
+			if v, _, _ := strings.Cut(s.Value, ","); s.Key == "GOARM" && v != "" {
+				if _, ok := parseARM("armv" + v); ok {
+					return "armv" + v
+				}
+			}
+		}
+	}
+	return "arm"
+}
+
+// parseARM parses an arch like "arm", "armv7" or "armv6l" into its ARM variant.
+// For plain "arm", any variant is allowed and a high value is returned.
+func parseARM(arch string) (int, bool) {
+	if arch == "arm" {
+		return 1 << 30, true
+	}
+	if !strings.HasPrefix(arch, "armv") {
+		return 0, false
+	}
+	s := strings.TrimSuffix(strings.TrimPrefix(arch, "armv"), "l")
+	v, err := strconv.Atoi(s)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// FindSource returns the source file, goX.Y.Z.src.tar.gz, of a release. Like
+// binary archives, it can be extracted with Fetch.
+func FindSource(release Release) (File, error) {
+	return FindFile(release, "", "", KindSource)
+}
+
+// ReleaseNotFoundError is returned by FindRelease for a version that is not
+// in the releases.
+type ReleaseNotFoundError struct {
+	Version string
+}
+
+func (e *ReleaseNotFoundError) Error() string {
+	return fmt.Sprintf("release %s not found", e.Version)
+}
+
+// FindRelease returns the release with exactly version, e.g. "go1.21.8", also
+// without "go" prefix. If there is no such release, a *ReleaseNotFoundError
+// is returned.
+func FindRelease(releases []Release, version string) (Release, error) {
+	version = "go" + strings.TrimPrefix(version, "go")
+	for _, rel := range releases {
+		if rel.Version == version {
+			return rel, nil
+		}
+	}
+	return Release{}, &ReleaseNotFoundError{version}
+}
+
+// FindRelease lists all releases and returns the release with exactly
+// version, see the package-level FindRelease.
+func (c *Client) FindRelease(ctx context.Context, version string) (Release, error) {
+	rels, err := c.ListAll(ctx)
+	if err != nil {
+		return Release{}, err
 	}
-	return File{}, fmt.Errorf("file not found")
+	return FindRelease(rels, version)
 }
@@ -0,0 +1,152 @@
+package goreleases
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFindFile(t *testing.T) {
+	rel := Release{
+		Version: "go1.22.3",
+		Files: []File{
+			{Filename: "go1.22.3.linux-amd64.tar.gz", Os: "linux", Arch: "amd64", Kind: "archive"},
+			{Filename: "go1.22.3.linux-armv6l.tar.gz", Os: "linux", Arch: "armv6l", Kind: "archive"},
+			{Filename: "go1.22.3.linux-arm64.tar.gz", Os: "linux", Arch: "arm64", Kind: "archive"},
+			{Filename: "go1.22.3.src.tar.gz", Kind: "source"},
+		},
+	}
+
+	test := func(arch, expFilename string) {
+		t.Helper()
+		f, err := FindFile(rel, "linux", arch, "archive")
+		if expFilename == "" {
+			if err == nil {
+				t.Fatalf("arch %q: got file %q, expected error", arch, f.Filename)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("arch %q: %s", arch, err)
+		}
+		if f.Filename != expFilename {
+			t.Fatalf("arch %q: got %q, expected %q", arch, f.Filename, expFilename)
+		}
+	}
+
+	test("amd64", "go1.22.3.linux-amd64.tar.gz")
+	test("arm64", "go1.22.3.linux-arm64.tar.gz")
+	test("arm", "go1.22.3.linux-armv6l.tar.gz")
+	test("armv6l", "go1.22.3.linux-armv6l.tar.gz")
+	test("armv7", "go1.22.3.linux-armv6l.tar.gz")
+	test("armv5", "")
+	test("386", "")
+
+	f, err := FindFile(rel, "linux", "amd64", KindSource)
+	if err != nil || f.Filename != "go1.22.3.src.tar.gz" {
+		t.Fatalf("finding source, got %q, err %v", f.Filename, err)
+	}
+}
+
+func TestFindFilePreferred(t *testing.T) {
+	rel := Release{
+		Version: "go1.22.3",
+		Files: []File{
+			{Filename: "go1.22.3.darwin-arm64.pkg", Os: "darwin", Arch: "arm64", Kind: KindInstaller},
+			{Filename: "go1.22.3.darwin-arm64.tar.gz", Os: "darwin", Arch: "arm64", Kind: KindArchive},
+			{Filename: "go1.22.3.windows-amd64.msi", Os: "windows", Arch: "amd64", Kind: KindInstaller},
+		},
+	}
+	if f, err := FindFilePreferred(rel, "darwin", "arm64", KindArchive, KindInstaller); err != nil || f.Kind != KindArchive {
+		t.Fatalf("got %v %v, expected archive", f, err)
+	}
+	if f, err := FindFilePreferred(rel, "windows", "amd64", KindArchive, KindInstaller); err != nil || f.Kind != KindInstaller {
+		t.Fatalf("got %v %v, expected installer", f, err)
+	}
+	if _, err := FindFilePreferred(rel, "linux", "amd64", KindArchive, KindInstaller); err == nil {
+		t.Fatalf("found file for missing platform")
+	}
+}
+
+func TestFindFileForHost(t *testing.T) {
+	host := runtime.GOOS + "-" + runtime.GOARCH
+	if runtime.GOARCH == "arm" {
+		host = runtime.GOOS + "-armv6l"
+	}
+	rel := Release{
+		Version: "go1.22.3",
+		Files: []File{
+			{Filename: "go1.22.3.plan9-mips.tar.gz", Os: "plan9", Arch: "mips", Kind: KindArchive},
+			{Filename: "go1.22.3." + host + ".pkg", Os: runtime.GOOS, Arch: strings.TrimPrefix(host, runtime.GOOS+"-"), Kind: KindInstaller},
+			{Filename: "go1.22.3." + host + ".tar.gz", Os: runtime.GOOS, Arch: strings.TrimPrefix(host, runtime.GOOS+"-"), Kind: KindArchive},
+		},
+	}
+	if f, err := FindFileForHost(rel); err != nil || f.Filename != "go1.22.3."+host+".tar.gz" {
+		t.Fatalf("find file for host: %v %v", f, err)
+	}
+	rel.Files = rel.Files[:2]
+	if f, err := FindFileForHost(rel); err != nil || f.Kind != KindInstaller {
+		t.Fatalf("find file for host without archive: %v %v", f, err)
+	}
+	rel.Files = rel.Files[:1]
+	if _, err := FindFileForHost(rel); err == nil {
+		t.Fatalf("found file for host in release without host files")
+	}
+}
+
+func TestFindRelease(t *testing.T) {
+	rels := []Release{{Version: "go1.22.3"}, {Version: "go1.21.8"}}
+	for _, v := range []string{"go1.21.8", "1.21.8"} {
+		if rel, err := FindRelease(rels, v); err != nil || rel.Version != "go1.21.8" {
+			t.Fatalf("find %s: got %v %v", v, rel, err)
+		}
+	}
+	_, err := FindRelease(rels, "go1.21.9")
+	var nf *ReleaseNotFoundError
+	if !errors.As(err, &nf) || nf.Version != "go1.21.9" {
+		t.Fatalf("find missing release: got %v, expected ReleaseNotFoundError", err)
+	}
+}
+
+func TestFileNotFoundError(t *testing.T) {
+	rel := Release{
+		Version: "go1.22.3",
+		Files: []File{
+			{Filename: "go1.22.3.src.tar.gz", Kind: KindSource},
+			{Filename: "go1.22.3.linux-amd64.tar.gz", Os: "linux", Arch: "amd64", Kind: KindArchive},
+			{Filename: "go1.22.3.linux-386.tar.gz", Os: "linux", Arch: "386", Kind: KindArchive},
+			{Filename: "go1.22.3.linux-arm64.tar.gz", Os: "linux", Arch: "arm64", Kind: KindArchive},
+			{Filename: "go1.22.3.darwin-arm64.tar.gz", Os: "darwin", Arch: "arm64", Kind: KindArchive},
+		},
+	}
+	_, err := FindFile(rel, "linux", "riscv64", KindArchive)
+	var ferr *FileNotFoundError
+	if !errors.As(err, &ferr) {
+		t.Fatalf("got %v, expected FileNotFoundError", err)
+	}
+	var arches []string
+	for _, f := range ferr.Alternatives {
+		arches = append(arches, f.Arch)
+	}
+	if strings.Join(arches, ",") != "amd64,arm64,386" {
+		t.Fatalf("got alternatives %v, expected amd64,arm64,386", arches)
+	}
+	if ferr.Source == nil || ferr.Source.Filename != "go1.22.3.src.tar.gz" {
+		t.Fatalf("got source %v", ferr.Source)
+	}
+	if _, err := FindFilePreferred(rel, "linux", "arm", KindArchive); !errors.As(err, &ferr) || ferr.Alternatives[0].Arch != "arm64" {
+		t.Fatalf("got %v, expected arm64 first", err)
+	}
+
+	b, err := SuggestSourceBuild(rel, "linux", "riscv64")
+	if err != nil {
+		t.Fatalf("suggest source build: %v", err)
+	}
+	if b.Bootstrap != "1.20" || b.Source.Filename != "go1.22.3.src.tar.gz" || b.Cross != (runtime.GOOS != "linux" || runtime.GOARCH != "riscv64") {
+		t.Fatalf("got %#v", b)
+	}
+	if _, err := SuggestSourceBuild(Release{Version: "go1.22.3"}, "", ""); err == nil {
+		t.Fatalf("suggested build without source")
+	}
+}
@@ -0,0 +1,107 @@
+package goreleases
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ArchiveFormat reads release files of an archive format as a tar stream, so
+// they are extracted like .tar.gz files, with the same checks. See
+// RegisterFormat.
+type ArchiveFormat interface {
+	// Tar returns the tar stream with the entries of the release file read
+	// from r, e.g. by decompressing it. Entries must be in a "go" directory.
+	Tar(r io.Reader) (io.ReadCloser, error)
+}
+
+// ArchiveFormatFunc is an ArchiveFormat implemented by a function, e.g. a
+// decompressor for ".tar.xz".
+type ArchiveFormatFunc func(r io.Reader) (io.ReadCloser, error)
+
+// Tar calls fn.
+func (fn ArchiveFormatFunc) Tar(r io.Reader) (io.ReadCloser, error) {
+	return fn(r)
+}
+
+// directFormat is a built-in format that is extracted from the downloaded file
+// directly instead of through a tar stream.
+type directFormat struct {
+	name    string
+	extract func(ctx context.Context, f *os.File, file File, x *extractor) error
+}
+
+func (df directFormat) Tar(r io.Reader) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("%s files cannot be read as tar stream", df.name)
+}
+
+var gzipFormat = ArchiveFormatFunc(func(r io.Reader) (io.ReadCloser, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("gzip reader: %s", err)
+	}
+	return gzr, nil
+})
+
+var formats = struct {
+	sync.RWMutex
+	m map[string]ArchiveFormat // By filename suffix.
+}{m: map[string]ArchiveFormat{
+	".tar.gz": gzipFormat,
+	".zip": directFormat{"zip", func(ctx context.Context, f *os.File, file File, x *extractor) error {
+		return fetchZip(f, file, x)
+	}},
+	".pkg": directFormat{"pkg", func(ctx context.Context, f *os.File, file File, x *extractor) error {
+		return fetchPkg(f, file, x)
+	}},
+	".msi": directFormat{"msi", fetchMSI},
+}}
+
+// RegisterFormat registers format for release files with filenames ending in
+// suffix, e.g. ".tar.xz" or ".tar.zst", for fetches, FetchReader, FetchFS and
+// ListArchive. Of multiple matching suffixes, the longest is used. A format
+// for a registered suffix replaces the earlier format, including the
+// built-in formats: ".tar.gz", and ".zip", ".pkg" and ".msi", which are
+// extracted directly instead of as tar stream.
+func RegisterFormat(suffix string, format ArchiveFormat) {
+	formats.Lock()
+	defer formats.Unlock()
+	formats.m[suffix] = format
+}
+
+// lookupFormat returns the format for filename.
+func lookupFormat(filename string) (ArchiveFormat, error) {
+	formats.RLock()
+	defer formats.RUnlock()
+	var best string
+	for suffix := range formats.m {
+		if strings.HasSuffix(filename, suffix) && len(suffix) > len(best) {
+			best = suffix
+		}
+	}
+	if best == "" {
+		var l []string
+		for suffix := range formats.m {
+			l = append(l, suffix)
+		}
+		sort.Strings(l)
+		return nil, fmt.Errorf("file extension not supported, only %s supported", strings.Join(l, ", "))
+	}
+	return formats.m[best], nil
+}
+
+// lookupTarFormat returns the format for filename if it is read as tar stream.
+func lookupTarFormat(filename string) (ArchiveFormat, error) {
+	format, err := lookupFormat(filename)
+	if err != nil {
+		return nil, err
+	} else if df, ok := format.(directFormat); ok {
+		return nil, fmt.Errorf("%s files not supported, only formats read as tar stream, e.g. .tar.gz", df.name)
+	}
+	return format, nil
+}
@@ -0,0 +1,228 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// FS is a writable filesystem that a release can be extracted into with
+// FetchFS, e.g. in memory or remote storage. Names are slash-separated, start
+// with "go", and are clean. Parent directories are created before their
+// contents.
+type FS interface {
+	// Mkdir creates a directory.
+	Mkdir(name string, perm os.FileMode) error
+
+	// Create creates a file. The contents are written before the next call.
+	Create(name string, perm os.FileMode) (io.WriteCloser, error)
+
+	// Symlink creates name as a symbolic link to target, which is as in the
+	// archive.
+	Symlink(target, name string) error
+}
+
+// FetchFS is like Fetch, but extracts into fsys instead of a directory on the
+// OS filesystem. The release file is downloaded and verified before
+// extraction starts. Hard links in the archive are written as copies. The
+// extracted entries are returned, with names relative to the "go" directory,
+// as in a manifest.
+func (c *Client) FetchFS(ctx context.Context, file File, fsys FS) ([]ManifestEntry, error) {
+	f, err := c.createTemp("goreleases-download")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		name := f.Name()
+		f.Close()
+		os.Remove(name)
+	}()
+	sum, _, err := c.download(ctx, file, f)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkSha256(file, sum); err != nil {
+		return nil, err
+	}
+
+	x := &extractor{include: c.Include, exclude: c.Exclude, buf: make([]byte, c.bufferSize()), strict: c.Strict}
+	if err := fsys.Mkdir("go", 0777); err != nil {
+		return nil, err
+	}
+	format, err := lookupFormat(file.Filename)
+	if err != nil {
+		return nil, err
+	}
+	df, direct := format.(directFormat)
+	switch {
+	case !direct:
+		err = x.fsTar(f, fsys, format)
+	case df.name == "zip":
+		err = x.fsZipFile(f, fsys)
+	default:
+		return nil, fmt.Errorf("%s files not supported, only .zip and formats read as tar stream, e.g. .tar.gz", df.name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return x.entries, nil
+}
+
+// fsName checks and returns the cleaned name from an archive.
+func fsName(name string) (string, error) {
+	n := path.Clean(strings.TrimSuffix(name, "/"))
+	if n != "go" && !strings.HasPrefix(n, "go/") || strings.Contains(n, "/../") {
+		return "", fmt.Errorf("bad path %q in archive", name)
+	}
+	return n, nil
+}
+
+func (x *extractor) fsTar(f *os.File, fsys FS, format ArchiveFormat) error {
+	tarr, err := format.Tar(x.buffered(f))
+	if err != nil {
+		return err
+	}
+	defer tarr.Close()
+	tr := tar.NewReader(tarr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("reading next header from tar file: %s", err)
+		}
+		if err := x.checkTar(h); err != nil {
+			return err
+		}
+		name, err := fsName(h.Name)
+		if err != nil {
+			return err
+		}
+		if name == "go" || x.skip(h.Name, h.Typeflag == tar.TypeDir) {
+			continue
+		}
+		mode := os.FileMode(h.Mode) & 0777
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err := fsys.Mkdir(name, mode); err != nil {
+				return err
+			}
+			x.add(h.Name, EntryDir, 0, "", "")
+		case tar.TypeReg:
+			sum, n, err := x.fsWrite(fsys, name, mode, tr)
+			if err != nil {
+				return err
+			}
+			x.add(h.Name, EntryFile, n, sum, "")
+		case tar.TypeSymlink:
+			if err := fsys.Symlink(h.Linkname, name); err != nil {
+				return err
+			}
+			x.add(h.Name, EntrySymlink, 0, "", h.Linkname)
+		case tar.TypeLink:
+			sum, n, err := x.fsLink(f, fsys, format, name, h.Linkname, mode)
+			if err != nil {
+				return err
+			}
+			x.add(h.Name, EntryFile, n, sum, "")
+		case tar.TypeXGlobalHeader, tar.TypeGNUSparse:
+		default:
+			return fmt.Errorf("unsupported tar header typeflag %v", h.Typeflag)
+		}
+	}
+}
+
+// fsLink writes name with the contents of the earlier file target in the
+// archive in f, reading the archive again from the start. Hard links are rare.
+func (x *extractor) fsLink(f *os.File, fsys FS, format ArchiveFormat, name, target string, mode os.FileMode) (string, int64, error) {
+	target, err := fsName(target)
+	if err != nil {
+		return "", 0, err
+	}
+	tarr, err := format.Tar(io.NewSectionReader(f, 0, 1<<62))
+	if err != nil {
+		return "", 0, err
+	}
+	defer tarr.Close()
+	tr := tar.NewReader(tarr)
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			return "", 0, fmt.Errorf("hard link %s to unknown file %s", name, target)
+		}
+		if n, err := fsName(h.Name); err == nil && n == target && h.Typeflag == tar.TypeReg {
+			return x.fsWrite(fsys, name, mode, tr)
+		}
+	}
+}
+
+func (x *extractor) fsZipFile(f *os.File, fsys FS) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	r, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		return fmt.Errorf("reading zip file: %v", err)
+	}
+	return x.fsZip(r, fsys)
+}
+
+func (x *extractor) fsZip(r *zip.Reader, fsys FS) error {
+	for _, zf := range r.File {
+		if err := x.checkZip(zf); err != nil {
+			return err
+		}
+		name, err := fsName(zf.Name)
+		if err != nil {
+			return err
+		}
+		isdir := strings.HasSuffix(zf.Name, "/")
+		if name == "go" || x.skip(zf.Name, isdir) {
+			continue
+		}
+		if isdir {
+			if err := fsys.Mkdir(name, 0775); err != nil {
+				return err
+			}
+			x.add(zf.Name, EntryDir, 0, "", "")
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return fmt.Errorf("opening file in zip: %v", err)
+		}
+		sum, n, err := x.fsWrite(fsys, name, zf.Mode()&0777, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		x.add(zf.Name, EntryFile, n, sum, "")
+	}
+	return nil
+}
+
+// fsWrite creates name in fsys with the contents of r, returning the hex
+// sha256 and size.
+func (x *extractor) fsWrite(fsys FS, name string, mode os.FileMode, r io.Reader) (string, int64, error) {
+	w, err := fsys.Create(name, mode)
+	if err != nil {
+		return "", 0, err
+	}
+	hr := &hashReader{r, sha256.New()}
+	n, err := x.copy(w, hr)
+	if err != nil {
+		w.Close()
+		return "", 0, fmt.Errorf("extracting %s: %v", name, err)
+	}
+	if err := w.Close(); err != nil {
+		return "", 0, err
+	}
+	return fmt.Sprintf("%x", hr.h.Sum(nil)), n, nil
+}
@@ -0,0 +1,75 @@
+package goreleases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/mjl-/goreleases/goreleasestest"
+)
+
+// memFS is an FS in memory.
+type memFS struct {
+	dirs  map[string]bool
+	files map[string]*bytes.Buffer
+}
+
+func (fs *memFS) Mkdir(name string, perm os.FileMode) error {
+	fs.dirs[name] = true
+	return nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func (fs *memFS) Create(name string, perm os.FileMode) (io.WriteCloser, error) {
+	b := &bytes.Buffer{}
+	fs.files[name] = b
+	return nopWriteCloser{b}, nil
+}
+
+func (fs *memFS) Symlink(target, name string) error {
+	return nil
+}
+
+func TestFetchFS(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/go": "binary"})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}}
+	fs := &memFS{map[string]bool{}, map[string]*bytes.Buffer{}}
+	entries, err := c.FetchFS(context.Background(), file, fs)
+	if err != nil {
+		t.Fatalf("fetch: %s", err)
+	}
+	if len(entries) != 3 || !fs.dirs["go/bin"] || fs.files["go/VERSION"].String() != "go1.22.3\n" || fs.files["go/bin/go"].String() != "binary" {
+		t.Fatalf("unexpected extraction, entries %v, dirs %v, files %v", entries, fs.dirs, fs.files)
+	}
+}
+
+// failFS is an FS that fails to write files.
+type failFS struct{ memFS }
+
+type failWriter struct{}
+
+func (failWriter) Write(buf []byte) (int, error) { return 0, errors.New("disk full") }
+func (failWriter) Close() error                  { return nil }
+
+func (fs *failFS) Create(name string, perm os.FileMode) (io.WriteCloser, error) {
+	return failWriter{}, nil
+}
+
+func TestFetchFSZipError(t *testing.T) {
+	zipdata := goreleasestest.Zip(map[string]string{"go/VERSION": "go1.22.3\n"})
+	file := testFile("go1.22.3", zipdata)
+	file.Filename = "go1.22.3.windows-amd64.zip"
+	file.Os = "windows"
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: zipdata}}}
+	fs := &failFS{memFS{map[string]bool{}, map[string]*bytes.Buffer{}}}
+	if _, err := c.FetchFS(context.Background(), file, fs); err == nil {
+		t.Fatalf("fetch with failing write succeeded")
+	}
+}
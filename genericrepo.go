@@ -0,0 +1,136 @@
+package goreleases
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// GenericRepo is a generic repository in an artifact manager, e.g. a generic
+// repository in Artifactory or a raw repository in Nexus, with files at the
+// repository URL with their name added.
+//
+// GenericRepo is a BlobStore, to populate the repository with MirrorBlobs,
+// and a ReleaseSource, listing the releases in MirrorIndex, for use as
+// Client.Source.
+type GenericRepo struct {
+	// URL of the repository, e.g.
+	// "https://artifactory.example.com/artifactory/go-releases/" or
+	// "https://nexus.example.com/repository/go-releases/".
+	BaseURL string
+
+	// Credentials for HTTP basic authentication, e.g. an Artifactory user with
+	// API key, or a Nexus user or user token.
+	Username string
+	Password string
+
+	// If set, sent as bearer token instead of basic authentication, e.g. an
+	// Artifactory access token.
+	Token string
+
+	// HTTPClient is used for requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+var _ BlobStore = &GenericRepo{}
+var _ SignatureSource = &GenericRepo{}
+var _ URLSource = &GenericRepo{}
+
+// Get returns the file name in the repository.
+func (r *GenericRepo) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	resp, err := r.do(ctx, "GET", name, nil, -1, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, &os.PathError{Op: "get", Path: r.url() + name, Err: os.ErrNotExist}
+	} else if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("getting %s, status %v, expected 200 OK", name, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// Put uploads a file to the repository, replacing an existing file. If data
+// can be seeked, its sha256 is sent in an X-Checksum-Sha256 header, which
+// Artifactory verifies.
+func (r *GenericRepo) Put(ctx context.Context, name string, data io.Reader, size int64) error {
+	var sum string
+	if rs, ok := data.(io.ReadSeeker); ok {
+		h := sha256.New()
+		if _, err := io.Copy(h, rs); err != nil {
+			return err
+		}
+		if _, err := rs.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		sum = fmt.Sprintf("%x", h.Sum(nil))
+	}
+	resp, err := r.do(ctx, "PUT", name, data, size, sum)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("uploading %s, status %v", name, resp.Status)
+	}
+	return nil
+}
+
+// List returns the releases in MirrorIndex in the repository. Like a static
+// mirror, all releases in the repository are returned, regardless of
+// parameter all.
+func (r *GenericRepo) List(ctx context.Context, all bool) ([]Release, error) {
+	return (&BlobSource{r}).List(ctx, all)
+}
+
+// Open returns the contents of file in the repository.
+func (r *GenericRepo) Open(ctx context.Context, file File) (io.ReadCloser, error) {
+	return r.Get(ctx, file.Filename)
+}
+
+// Signature returns the .asc file for file, or nil if absent.
+func (r *GenericRepo) Signature(ctx context.Context, file File) ([]byte, error) {
+	return (&BlobSource{r}).Signature(ctx, file)
+}
+
+// URL returns the URL of file in the repository. Downloading requires the
+// credentials.
+func (r *GenericRepo) URL(ctx context.Context, file File) (string, error) {
+	return r.url() + file.Filename, nil
+}
+
+func (r *GenericRepo) url() string {
+	return strings.TrimSuffix(r.BaseURL, "/") + "/"
+}
+
+func (r *GenericRepo) do(ctx context.Context, method, name string, body io.Reader, size int64, sum string) (*http.Response, error) {
+	if strings.HasPrefix(name, "/") || strings.Contains("/"+name+"/", "/../") {
+		return nil, fmt.Errorf("bad name %q", name)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.url()+name, body)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	if sum != "" {
+		req.Header.Set("X-Checksum-Sha256", sum)
+	}
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	} else if r.Username != "" || r.Password != "" {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+	hc := r.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return hc.Do(req)
+}
@@ -2,4 +2,7 @@ module github.com/mjl-/goreleases
 
 go 1.14
 
-require golang.org/x/crypto v0.21.0
+require (
+	golang.org/x/crypto v0.21.0
+	golang.org/x/mod v0.8.0
+)
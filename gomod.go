@@ -0,0 +1,121 @@
+package goreleases
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ParseGoMod returns the values of the "go" and "toolchain" directives in a
+// go.mod file. Either can be empty if absent.
+func ParseGoMod(data []byte) (goVersion, toolchain string, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		t := strings.Fields(line)
+		if len(t) != 2 {
+			continue
+		}
+		switch t[0] {
+		case "go":
+			goVersion = t[1]
+		case "toolchain":
+			toolchain = t[1]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+	return goVersion, toolchain, nil
+}
+
+// ResolveGoMod returns the release the go command would select for a module
+// with the "go" and "toolchain" directives from its go.mod, following the
+// rules of the go command for switching toolchains:
+//
+// A toolchain directive newer than the go version selects exactly that
+// release. Otherwise, a go version with a patch or prerelease, e.g. "1.21.3"
+// or "1.22rc1", selects that release. A go version naming only a minor
+// version, e.g. "1.22", selects the latest release of that minor: the latest
+// stable release, or the latest prerelease if there are no stable releases
+// yet. Go versions before 1.21 have no ".0" patch release, "1.20" selects that
+// release.
+//
+// A toolchain directive of "default" or "local" is ignored.
+func ResolveGoMod(releases []Release, goVersion, toolchain string) (Release, error) {
+	gv, err := ParseVersion(goVersion)
+	if err != nil {
+		return Release{}, fmt.Errorf("go directive: %v", err)
+	}
+	if toolchain != "" && toolchain != "default" && toolchain != "local" {
+		// Names with a suffix, like "go1.22.3-custom", are not releases and fail to parse.
+		tv, err := ParseVersion(strings.TrimPrefix(toolchain, "go"))
+		if err != nil {
+			return Release{}, fmt.Errorf("toolchain directive: %v", err)
+		}
+		if tv.Compare(gv) > 0 {
+			return findVersion(releases, tv)
+		}
+	}
+
+	minorOnly := gv.Pre == "" && strings.Count(goVersion, ".") == 1
+	if !minorOnly || gv.Major == 1 && gv.Minor < 21 {
+		return findVersion(releases, gv)
+	}
+
+	var best *Release
+	var bestv Version
+	for i, rel := range releases {
+		rv, err := ParseVersion(rel.Version)
+		if err != nil || !rv.SameMinor(gv) {
+			continue
+		}
+		better := best == nil || rel.Stable && !best.Stable || rel.Stable == best.Stable && rv.Compare(bestv) > 0
+		if better {
+			best = &releases[i]
+			bestv = rv
+		}
+	}
+	if best == nil {
+		return Release{}, fmt.Errorf("no release found for go %s", goVersion)
+	}
+	return *best, nil
+}
+
+// findVersion returns the release for version v.
+func findVersion(releases []Release, v Version) (Release, error) {
+	for _, rel := range releases {
+		if rv, err := ParseVersion(rel.Version); err == nil && rv == v {
+			return rel, nil
+		}
+	}
+	return Release{}, fmt.Errorf("release %s not found", v)
+}
+
+// ResolveGoModFile reads the go.mod file at path, lists all releases and
+// returns the release selected by ResolveGoMod.
+func (c *Client) ResolveGoModFile(ctx context.Context, path string) (Release, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Release{}, err
+	}
+	goVersion, toolchain, err := ParseGoMod(data)
+	if err != nil {
+		return Release{}, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	if goVersion == "" {
+		// The go command assumes go 1.16 for modules without go directive.
+		goVersion = "1.16"
+	}
+	rels, err := c.ListAll(ctx)
+	if err != nil {
+		return Release{}, err
+	}
+	return ResolveGoMod(rels, goVersion, toolchain)
+}
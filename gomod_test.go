@@ -0,0 +1,43 @@
+package goreleases
+
+import (
+	"testing"
+)
+
+func TestResolveGoMod(t *testing.T) {
+	var rels []Release
+	for _, v := range []string{"go1.23rc1", "go1.22.3", "go1.22.2", "go1.22.0", "go1.22rc1", "go1.21.10", "go1.20.14", "go1.20"} {
+		rels = append(rels, Release{Version: v, Stable: v != "go1.23rc1" && v != "go1.22rc1"})
+	}
+
+	gv, tc, err := ParseGoMod([]byte("module example.com/m\n\ngo 1.22 // comment\n\ntoolchain go1.22.3\n\nrequire (\n\texample.com/x v1.0.0\n)\n"))
+	if err != nil || gv != "1.22" || tc != "go1.22.3" {
+		t.Fatalf("parse go.mod: got %q, %q, %v", gv, tc, err)
+	}
+
+	test := func(goVersion, toolchain, exp string) {
+		t.Helper()
+		rel, err := ResolveGoMod(rels, goVersion, toolchain)
+		if exp == "" {
+			if err == nil {
+				t.Fatalf("go %s, toolchain %s: got %s, expected error", goVersion, toolchain, rel.Version)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("go %s, toolchain %s: %s", goVersion, toolchain, err)
+		}
+		if rel.Version != exp {
+			t.Fatalf("go %s, toolchain %s: got %s, expected %s", goVersion, toolchain, rel.Version, exp)
+		}
+	}
+	test("1.22", "", "go1.22.3")
+	test("1.22.0", "", "go1.22.0")
+	test("1.22.0", "go1.22.2", "go1.22.2")
+	test("1.22.3", "go1.22.2", "go1.22.3")
+	test("1.22rc1", "", "go1.22rc1")
+	test("1.23", "", "go1.23rc1")
+	test("1.20", "", "go1.20")
+	test("1.22", "local", "go1.22.3")
+	test("1.19", "", "")
+}
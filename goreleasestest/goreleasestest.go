@@ -0,0 +1,298 @@
+// Package goreleasestest provides a fake Go download server for hermetic
+// tests of code that lists and fetches releases.
+//
+// The server serves a JSON listing like the upstream server, and small
+// generated release files with correct checksums. It does not serve
+// signatures, clients must be configured to not verify them, e.g. with
+// goreleases.Client.NoSignatures. The package does not import goreleases, so
+// it can be used by the tests of goreleases as well.
+package goreleasestest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Release and File have the JSON format of the upstream listing.
+type Release struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+	Files   []File `json:"files"`
+}
+
+// File is a release file in the listing.
+type File struct {
+	Filename string `json:"filename"`
+	Os       string `json:"os"`
+	Arch     string `json:"arch"`
+	Version  string `json:"version"`
+	Sha256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Kind     string `json:"kind"`
+}
+
+// Platforms are the os/arch combinations for which NewServer generates binary
+// archives, a .zip for windows, a .tar.gz for others.
+var Platforms = []string{"linux/amd64", "linux/arm64", "darwin/arm64", "windows/amd64"}
+
+// Server is a fake download server. Use URL + "/" as base URL.
+type Server struct {
+	*httptest.Server
+
+	// Releases in the listing, newest first. Supported releases are those of
+	// the two newest minor versions with stable releases, and newer
+	// prereleases.
+	Releases []Release
+
+	// Contents of release files, by filename. Can be changed to test checksum
+	// mismatches, or files can be removed to test errors.
+	Files map[string][]byte
+
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+// NewServer starts a server with releases for versions, e.g. "go1.22.3" or
+// "go1.23rc1". Each release has a source file and binary archives for
+// Platforms, each with a go/VERSION file with the version, and a go/bin/go
+// file. The caller must call Close.
+func NewServer(versions ...string) *Server {
+	s := &Server{Files: map[string][]byte{}, requests: map[string]int{}}
+	for _, v := range versions {
+		rel := Release{Version: v, Stable: !strings.Contains(v, "rc") && !strings.Contains(v, "beta")}
+		add := func(name, goos, goarch, kind string, data []byte) {
+			s.Files[name] = data
+			rel.Files = append(rel.Files, File{name, goos, goarch, v, fmt.Sprintf("%x", sha256.Sum256(data)), int64(len(data)), kind})
+		}
+		add(v+".src.tar.gz", "", "", "source", Tgz(map[string]string{
+			"go/VERSION":    v + "\n",
+			"go/src/go.mod": "module std\n",
+		}))
+		for _, p := range Platforms {
+			goos, goarch, _ := strings.Cut(p, "/")
+			files := map[string]string{
+				"go/VERSION": v + "\n",
+				"go/bin/go":  "fake go command for " + v + " " + p + "\n",
+			}
+			if goos == "windows" {
+				add(v+"."+goos+"-"+goarch+".zip", goos, goarch, "archive", Zip(files))
+			} else {
+				add(v+"."+goos+"-"+goarch+".tar.gz", goos, goarch, "archive", Tgz(files))
+			}
+		}
+		s.Releases = append(s.Releases, rel)
+	}
+	sort.SliceStable(s.Releases, func(i, j int) bool {
+		return compareVersions(s.Releases[i].Version, s.Releases[j].Version) > 0
+	})
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// BaseURL returns the URL of the server for goreleases.Client.BaseURL.
+func (s *Server) BaseURL() string {
+	return s.URL + "/"
+}
+
+// Requests returns the number of requests for path, e.g. "/" for listings or
+// "/go1.22.3.linux-amd64.tar.gz".
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	s.mu.Unlock()
+
+	if r.URL.Path == "/" {
+		if r.URL.Query().Get("mode") != "json" {
+			http.NotFound(w, r)
+			return
+		}
+		l := s.Releases
+		if r.URL.Query().Get("include") != "all" {
+			l = s.supported()
+		}
+		if l == nil {
+			l = []Release{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(l)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if strings.HasSuffix(name, ".sha256") {
+		if data, ok := s.Files[strings.TrimSuffix(name, ".sha256")]; ok {
+			fmt.Fprintf(w, "%x", sha256.Sum256(data))
+			return
+		}
+	}
+	data, ok := s.Files[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
+
+// supported returns the releases of the two newest minors with stable
+// releases, and prereleases of newer minors.
+func (s *Server) supported() []Release {
+	var minors []string
+	for _, rel := range s.Releases {
+		m := minor(rel.Version)
+		if rel.Stable && (len(minors) == 0 || minors[len(minors)-1] != m) {
+			minors = append(minors, m)
+		}
+	}
+	if len(minors) > 2 {
+		minors = minors[:2]
+	}
+	var l []Release
+	for _, rel := range s.Releases {
+		m := minor(rel.Version)
+		if len(minors) > 0 && !rel.Stable && compareVersions(m, minors[0]) > 0 {
+			l = append(l, rel)
+			continue
+		}
+		for _, sm := range minors {
+			if m == sm {
+				l = append(l, rel)
+			}
+		}
+	}
+	return l
+}
+
+// minor returns the minor version, e.g. "go1.22" for "go1.22.3" or "go1.23rc1".
+func minor(v string) string {
+	for _, pre := range []string{"rc", "beta"} {
+		if i := strings.Index(v, pre); i > 0 {
+			v = v[:i]
+		}
+	}
+	t := strings.Split(v, ".")
+	if len(t) > 2 {
+		t = t[:2]
+	}
+	return strings.Join(t, ".")
+}
+
+// compareVersions compares versions like "go1.22.3" and "go1.23rc1".
+func compareVersions(a, b string) int {
+	parse := func(s string) []int {
+		s = strings.TrimPrefix(s, "go")
+		pre := 1000
+		for _, p := range []string{"beta", "rc"} {
+			if i := strings.Index(s, p); i > 0 {
+				n, _ := strconv.Atoi(s[i+len(p):])
+				if p == "rc" {
+					n += 100
+				}
+				pre = n
+				s = s[:i]
+			}
+		}
+		l := make([]int, 3, 4)
+		for i, t := range strings.SplitN(s, ".", 3) {
+			l[i], _ = strconv.Atoi(t)
+		}
+		return append(l, pre)
+	}
+	x, y := parse(a), parse(b)
+	for i := range x {
+		if x[i] != y[i] {
+			if x[i] < y[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Tgz returns a .tar.gz with the files, by name with slashes, with parent
+// directories. Files ending in "/bin/go" or ".bash" are executable.
+func Tgz(files map[string]string) []byte {
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	mtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, name := range entries(files) {
+		h := &tar.Header{Name: name, ModTime: mtime, Mode: 0755}
+		if strings.HasSuffix(name, "/") {
+			h.Typeflag = tar.TypeDir
+		} else {
+			h.Typeflag = tar.TypeReg
+			h.Mode = mode(name)
+			h.Size = int64(len(files[name]))
+		}
+		tw.WriteHeader(h)
+		tw.Write([]byte(files[name]))
+	}
+	tw.Close()
+	gzw.Close()
+	return b.Bytes()
+}
+
+// Zip returns a .zip with the files, like Tgz.
+func Zip(files map[string]string) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	mtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, name := range entries(files) {
+		h := &zip.FileHeader{Name: name, Modified: mtime, Method: zip.Deflate}
+		h.SetMode(0755)
+		if !strings.HasSuffix(name, "/") {
+			h.SetMode(0644)
+			if mode(name) == 0755 {
+				h.SetMode(0755)
+			}
+		}
+		fw, _ := zw.CreateHeader(h)
+		fw.Write([]byte(files[name]))
+	}
+	zw.Close()
+	return b.Bytes()
+}
+
+// entries returns the sorted names of files and their parent directories, the
+// latter with trailing slash.
+func entries(files map[string]string) []string {
+	seen := map[string]bool{}
+	var l []string
+	for name := range files {
+		l = append(l, name)
+		for i := range name {
+			if name[i] == '/' && !seen[name[:i+1]] {
+				seen[name[:i+1]] = true
+				l = append(l, name[:i+1])
+			}
+		}
+	}
+	sort.Strings(l)
+	return l
+}
+
+func mode(name string) int64 {
+	if strings.HasSuffix(name, "/bin/go") || strings.HasSuffix(name, ".bash") {
+		return 0755
+	}
+	return 0644
+}
@@ -0,0 +1,268 @@
+package goreleases
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GoVersion is a toolchain version as printed by "go version", or for
+// binaries by "go version -m".
+type GoVersion struct {
+	// E.g. "go1.22.3", or "devel go1.24-2b3c4d5e Tue Jan 2 15:04:05 2024 +0000"
+	// for development versions.
+	Version string
+
+	Os, Arch    string   // Platform, empty if unknown.
+	Experiments []string // GOEXPERIMENT settings from "X:" in the version, e.g. "rangefunc".
+}
+
+// ParseGoVersion parses the output of "go version", e.g. "go version go1.22.3
+// linux/amd64".
+func ParseGoVersion(s string) (GoVersion, error) {
+	t := strings.Fields(s)
+	if len(t) < 4 || t[0] != "go" || t[1] != "version" {
+		return GoVersion{}, fmt.Errorf("bad go version output %q", s)
+	}
+	var v GoVersion
+	var ok bool
+	v.Os, v.Arch, ok = strings.Cut(t[len(t)-1], "/")
+	if !ok {
+		return GoVersion{}, fmt.Errorf("bad platform %q in go version output", t[len(t)-1])
+	}
+	v.Version, v.Experiments = splitExperiments(strings.Join(t[2:len(t)-1], " "))
+	return v, nil
+}
+
+// splitExperiments splits the X: field from a version as returned by
+// runtime.Version, e.g. "go1.22.3 X:rangefunc,loopvar".
+func splitExperiments(s string) (string, []string) {
+	i := strings.Index(s, " X:")
+	if i < 0 {
+		return s, nil
+	}
+	t := strings.Fields(s[i+len(" X:"):])
+	if len(t) == 0 {
+		return s[:i], nil
+	}
+	return s[:i], strings.Split(t[0], ",")
+}
+
+// Parsed returns the parsed Version. Development versions cannot be parsed.
+func (v GoVersion) Parsed() (Version, error) {
+	if strings.HasPrefix(v.Version, "devel ") {
+		return Version{}, fmt.Errorf("development version %s", v.Version)
+	}
+	return ParseVersion(v.Version)
+}
+
+// FindRelease returns the release in releases for the version, and the
+// archive file for its platform. If the platform is unknown or has no archive
+// file, a zero File is returned along with the release.
+func (v GoVersion) FindRelease(releases []Release) (Release, File, error) {
+	pv, err := v.Parsed()
+	if err != nil {
+		return Release{}, File{}, err
+	}
+	rel, err := findVersion(releases, pv)
+	if err != nil {
+		return Release{}, File{}, err
+	}
+	if v.Os == "" || v.Arch == "" {
+		return rel, File{}, nil
+	}
+	f, err := FindFile(rel, v.Os, v.Arch, KindArchive)
+	if err != nil {
+		return rel, File{}, nil
+	}
+	return rel, f, nil
+}
+
+// FindGoVersion lists all releases and calls FindRelease of v.
+func (c *Client) FindGoVersion(ctx context.Context, v GoVersion) (Release, File, error) {
+	rels, err := c.ListAll(ctx)
+	if err != nil {
+		return Release{}, File{}, err
+	}
+	return v.FindRelease(rels)
+}
+
+// BinaryInfo is the information about a Go binary printed by "go version -m".
+type BinaryInfo struct {
+	Path string // Of the binary.
+
+	// Toolchain the binary was built with. The platform is from the GOOS and
+	// GOARCH build settings.
+	GoVersion
+
+	Package       string            // Path of the main package, e.g. "golang.org/x/tools/gopls".
+	Module        string            // Main module, e.g. "golang.org/x/tools/gopls".
+	ModuleVersion string            // E.g. "v0.15.3" or "(devel)".
+	Settings      map[string]string // Build settings, e.g. "GOOS", "-trimpath" and "vcs.revision".
+}
+
+// ParseGoVersionM parses the output of "go version -m", with one or more
+// binaries. Build settings in "go version" output without -m are absent.
+func ParseGoVersionM(data []byte) ([]BinaryInfo, error) {
+	var l []BinaryInfo
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			// Paths can contain ": " on Windows, e.g. "C:\go\bin\go.exe: go1.22.3".
+			i := strings.LastIndex(line, ": ")
+			if i < 0 {
+				return nil, fmt.Errorf("bad binary line %q", line)
+			}
+			var b BinaryInfo
+			b.Path = line[:i]
+			b.Version, b.Experiments = splitExperiments(line[i+2:])
+			l = append(l, b)
+			continue
+		}
+		if len(l) == 0 {
+			return nil, fmt.Errorf("module line %q without binary", line)
+		}
+		b := &l[len(l)-1]
+		t := strings.Split(line[1:], "\t")
+		switch {
+		case t[0] == "path" && len(t) >= 2:
+			b.Package = t[1]
+		case t[0] == "mod" && len(t) >= 3:
+			b.Module, b.ModuleVersion = t[1], t[2]
+		case t[0] == "build" && len(t) >= 2:
+			k, v, _ := strings.Cut(strings.Join(t[1:], "\t"), "=")
+			if strings.HasPrefix(v, `"`) {
+				if s, err := strconv.Unquote(v); err == nil {
+					v = s
+				}
+			}
+			if b.Settings == nil {
+				b.Settings = map[string]string{}
+			}
+			b.Settings[k] = v
+			switch k {
+			case "GOOS":
+				b.Os = v
+			case "GOARCH":
+				b.Arch = v
+			}
+		}
+		// Dependency ("dep") and replacement ("=>") lines are ignored.
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// GoEnv has the variables printed by "go env" that are relevant for
+// toolchains.
+type GoEnv struct {
+	GOROOT      string
+	GOVERSION   string // E.g. "go1.22.3", since Go 1.16.
+	GOOS        string
+	GOARCH      string
+	GOTOOLCHAIN string // E.g. "auto" or "go1.22.3+auto", since Go 1.21.
+	GOPATH      string
+	GOBIN       string
+	GOPROXY     string
+
+	Vars map[string]string // All variables.
+}
+
+// ParseGoEnv parses the output of "go env" or "go env -json". The shell
+// formats of Unix (KEY='value') and Windows (set KEY=value) are recognized.
+func ParseGoEnv(data []byte) (GoEnv, error) {
+	vars := map[string]string{}
+	if s := bytes.TrimSpace(data); bytes.HasPrefix(s, []byte("{")) {
+		if err := json.Unmarshal(s, &vars); err != nil {
+			return GoEnv{}, fmt.Errorf("parsing go env json: %v", err)
+		}
+	} else {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimRight(line, "\r")
+			if line == "" {
+				continue
+			}
+			if s := strings.TrimPrefix(line, "set "); s != line {
+				k, v, _ := strings.Cut(s, "=")
+				vars[k] = v
+				continue
+			}
+			k, v, ok := strings.Cut(line, "=")
+			if !ok {
+				return GoEnv{}, fmt.Errorf("bad go env line %q", line)
+			}
+			v, err := shUnquote(v)
+			if err != nil {
+				return GoEnv{}, fmt.Errorf("value for %s: %v", k, err)
+			}
+			vars[k] = v
+		}
+	}
+	e := GoEnv{
+		GOROOT:      vars["GOROOT"],
+		GOVERSION:   vars["GOVERSION"],
+		GOOS:        vars["GOOS"],
+		GOARCH:      vars["GOARCH"],
+		GOTOOLCHAIN: vars["GOTOOLCHAIN"],
+		GOPATH:      vars["GOPATH"],
+		GOBIN:       vars["GOBIN"],
+		GOPROXY:     vars["GOPROXY"],
+		Vars:        vars,
+	}
+	return e, nil
+}
+
+// GoVersion returns the toolchain version of the environment, from GOVERSION,
+// GOOS and GOARCH.
+func (e GoEnv) GoVersion() GoVersion {
+	v := GoVersion{Os: e.GOOS, Arch: e.GOARCH}
+	v.Version, v.Experiments = splitExperiments(e.GOVERSION)
+	return v
+}
+
+// shUnquote returns the value of the shell word s, with single and double
+// quotes, as written by "go env" on Unix.
+func shUnquote(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'':
+			j := strings.IndexByte(s[i+1:], '\'')
+			if j < 0 {
+				return "", fmt.Errorf("unterminated quote in %q", s)
+			}
+			b.WriteString(s[i+1 : i+1+j])
+			i += 1 + j
+		case '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return "", fmt.Errorf("unterminated quote in %q", s)
+			}
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
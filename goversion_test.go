@@ -0,0 +1,87 @@
+package goreleases
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGoVersion(t *testing.T) {
+	v, err := ParseGoVersion("go version go1.22.3 X:rangefunc,loopvar linux/amd64\n")
+	if err != nil {
+		t.Fatalf("parse: %s", err)
+	}
+	if exp := (GoVersion{"go1.22.3", "linux", "amd64", []string{"rangefunc", "loopvar"}}); !reflect.DeepEqual(v, exp) {
+		t.Fatalf("got %#v, expected %#v", v, exp)
+	}
+	v, err = ParseGoVersion("go version devel go1.24-2b3c4d5e Tue Jan 2 15:04:05 2024 +0000 darwin/arm64")
+	if err != nil || v.Version != "devel go1.24-2b3c4d5e Tue Jan 2 15:04:05 2024 +0000" || v.Arch != "arm64" {
+		t.Fatalf("got %#v %v for devel", v, err)
+	}
+	if _, err := v.Parsed(); err == nil {
+		t.Fatalf("parsed development version")
+	}
+	if _, err := ParseGoVersion("gofmt version"); err == nil {
+		t.Fatalf("parsed bad output")
+	}
+
+	rels := []Release{{Version: "go1.22.3", Files: []File{{Filename: "go1.22.3.linux-amd64.tar.gz", Os: "linux", Arch: "amd64", Kind: KindArchive}}}}
+	v = GoVersion{Version: "go1.22.3", Os: "linux", Arch: "amd64"}
+	if rel, f, err := v.FindRelease(rels); err != nil || rel.Version != "go1.22.3" || f.Filename != "go1.22.3.linux-amd64.tar.gz" {
+		t.Fatalf("find release: %v %v %v", rel, f, err)
+	}
+}
+
+func TestParseGoVersionM(t *testing.T) {
+	out := `/home/user/go/bin/gopls: go1.22.3
+	path	golang.org/x/tools/gopls
+	mod	golang.org/x/tools/gopls	v0.15.3	h1:abc=
+	dep	golang.org/x/mod	v0.17.0	h1:def=
+	build	-buildmode=exe
+	build	-ldflags="-s -w"
+	build	GOARCH=arm64
+	build	GOOS=linux
+C:\Users\user\go\bin\hello.exe: go1.21.10 X:loopvar
+	path	example.com/hello
+	mod	example.com/hello	(devel)	
+`
+	l, err := ParseGoVersionM([]byte(out))
+	if err != nil {
+		t.Fatalf("parse: %s", err)
+	}
+	if len(l) != 2 {
+		t.Fatalf("got %d binaries, expected 2", len(l))
+	}
+	b := l[0]
+	if b.Path != "/home/user/go/bin/gopls" || b.Version != "go1.22.3" || b.Os != "linux" || b.Arch != "arm64" || b.Module != "golang.org/x/tools/gopls" || b.ModuleVersion != "v0.15.3" || b.Settings["-ldflags"] != "-s -w" {
+		t.Fatalf("got %#v", b)
+	}
+	b = l[1]
+	if b.Path != `C:\Users\user\go\bin\hello.exe` || b.Version != "go1.21.10" || !reflect.DeepEqual(b.Experiments, []string{"loopvar"}) || b.ModuleVersion != "(devel)" || b.Os != "" {
+		t.Fatalf("got %#v", b)
+	}
+}
+
+func TestParseGoEnv(t *testing.T) {
+	unix := `GOARCH='amd64'
+GOOS='linux'
+GOROOT='/usr/local/go'
+GOVERSION='go1.22.3'
+GOFLAGS='-tags=a'\''b'
+GOPATH="/home/user/go"
+`
+	windows := "set GOARCH=amd64\r\nset GOOS=linux\r\nset GOROOT=/usr/local/go\r\nset GOVERSION=go1.22.3\r\n"
+	json := `{"GOARCH": "amd64", "GOOS": "linux", "GOROOT": "/usr/local/go", "GOVERSION": "go1.22.3"}`
+	for _, s := range []string{unix, windows, json} {
+		e, err := ParseGoEnv([]byte(s))
+		if err != nil {
+			t.Fatalf("parse %q: %s", s, err)
+		}
+		if e.GOROOT != "/usr/local/go" || !reflect.DeepEqual(e.GoVersion(), GoVersion{Version: "go1.22.3", Os: "linux", Arch: "amd64"}) {
+			t.Fatalf("got %#v for %q", e, s)
+		}
+	}
+	e, _ := ParseGoEnv([]byte(unix))
+	if e.Vars["GOFLAGS"] != "-tags=a'b" || e.GOPATH != "/home/user/go" {
+		t.Fatalf("got %#v", e)
+	}
+}
@@ -0,0 +1,148 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// InstallHook is called after a release file has been extracted successfully,
+// with the directory of the install (the GOROOT) and the release file, see
+// Client.PostInstall. File.Version is the version of the release.
+type InstallHook func(ctx context.Context, goroot string, file File) error
+
+// runHooks calls the post-install hooks in order, stopping at the first error.
+// On error, goroot is removed, so a next attempt installs again instead of
+// finding a complete install.
+func (c *Client) runHooks(ctx context.Context, goroot string, file File) error {
+	for i, h := range c.PostInstall {
+		if err := h(ctx, goroot, file); err != nil {
+			err = fmt.Errorf("post-install hook %d: %v", i, err)
+			if rerr := os.RemoveAll(goroot); rerr != nil {
+				err = fmt.Errorf("%v (removing install: %v)", err, rerr)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// hostFile returns whether file is a binary release that runs on the host,
+// and its GOARCH.
+func hostFile(file File) (string, bool) {
+	goarch := file.Arch
+	if _, ok := parseARM(goarch); ok {
+		goarch = "arm"
+	}
+	return goarch, file.Kind != KindSource && file.Os == runtime.GOOS && goarch == runtime.GOARCH
+}
+
+// GoVersionError is returned by GoVersionHook if the go command of an install
+// fails to run, or reports another version or platform than the release.
+type GoVersionError struct {
+	Version  string // Of the release, e.g. "go1.22.3".
+	Platform string // Of the release, e.g. "linux/amd64".
+	Output   string // Of "go version".
+	Err      error  // From running the command, if it failed.
+}
+
+func (e *GoVersionError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("running go version: %v: %s", e.Err, e.Output)
+	}
+	return fmt.Sprintf("go version reports %q, expected %s %s", e.Output, e.Version, e.Platform)
+}
+
+func (e *GoVersionError) Unwrap() error {
+	return e.Err
+}
+
+// GoVersionHook is an InstallHook that runs "go version" of the install and
+// checks it reports the version and platform of the release, returning a
+// *GoVersionError otherwise. Nothing is done for source files and for files of
+// another os or arch than the host.
+func GoVersionHook(ctx context.Context, goroot string, file File) error {
+	goarch, ok := hostFile(file)
+	if !ok {
+		return nil
+	}
+	gocmd := filepath.Join(goroot, "bin", "go")
+	if runtime.GOOS == "windows" {
+		gocmd += ".exe"
+	}
+	cmd := exec.CommandContext(ctx, gocmd, "version")
+	cmd.Env = append(cmd.Environ(), "GOROOT="+goroot, "GOTOOLCHAIN=local")
+	buf, err := cmd.CombinedOutput()
+	out := strings.TrimSpace(string(buf))
+	platform := file.Os + "/" + goarch
+	if err != nil {
+		return &GoVersionError{file.Version, platform, out, err}
+	}
+	// E.g. "go version go1.22.3 linux/amd64".
+	t := strings.Fields(out)
+	if len(t) < 4 || t[2] != file.Version || t[3] != platform {
+		return &GoVersionError{file.Version, platform, out, nil}
+	}
+	return nil
+}
+
+// GoEnvHook returns an InstallHook that runs "go env -w" of the install with
+// settings, each "KEY=value", e.g. "GOTOOLCHAIN=local" or "GOPROXY=...". The
+// settings are written to the go env configuration file of the user (see "go
+// help environment"), and apply to all toolchains of the user. Like
+// GoVersionHook, nothing is done for source files and for files of another os
+// or arch than the host.
+func GoEnvHook(settings ...string) InstallHook {
+	return func(ctx context.Context, goroot string, file File) error {
+		if _, ok := hostFile(file); !ok || len(settings) == 0 {
+			return nil
+		}
+		// Prevent switching to another toolchain, keeping a GOTOOLCHAIN setting
+		// from conflicting with the environment.
+		toolchain := "GOTOOLCHAIN=local"
+		for _, s := range settings {
+			if !strings.Contains(s, "=") {
+				return fmt.Errorf("bad setting %q, must be KEY=value", s)
+			}
+			if strings.HasPrefix(s, "GOTOOLCHAIN=") {
+				toolchain = s
+			}
+		}
+		gocmd := filepath.Join(goroot, "bin", "go")
+		if runtime.GOOS == "windows" {
+			gocmd += ".exe"
+		}
+		cmd := exec.CommandContext(ctx, gocmd, append([]string{"env", "-w"}, settings...)...)
+		cmd.Env = append(cmd.Environ(), "GOROOT="+goroot, toolchain)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("running go env -w: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+}
+
+// RemoveQuarantineHook is an InstallHook that removes QuarantineXattr from all
+// files of the install on macOS, with the xattr command, so running the go
+// command does not trigger Gatekeeper prompts, e.g. for installs on developer
+// machines from downloads through a browser or a quarantining tool. Nothing
+// is done on other systems.
+func RemoveQuarantineHook(ctx context.Context, goroot string, file File) error {
+	if runtime.GOOS != "darwin" {
+		return nil
+	}
+	out, err := exec.CommandContext(ctx, "xattr", "-d", "-r", QuarantineXattr, goroot).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	// Files without the attribute are reported, but that is fine.
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if !strings.Contains(line, "No such xattr") {
+			return fmt.Errorf("removing quarantine attribute: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package goreleases
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestGoVersionHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell script as go command")
+	}
+	goroot := t.TempDir()
+	os.Mkdir(filepath.Join(goroot, "bin"), 0777)
+	writeGo := func(output string) {
+		script := "#!/bin/sh\necho '" + output + "'\n"
+		if err := os.WriteFile(filepath.Join(goroot, "bin", "go"), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	file := File{Version: "go1.22.3", Os: runtime.GOOS, Arch: runtime.GOARCH, Kind: KindArchive}
+	ctx := context.Background()
+
+	writeGo("go version go1.22.3 " + platform)
+	if err := GoVersionHook(ctx, goroot, file); err != nil {
+		t.Fatalf("hook: %v", err)
+	}
+
+	var verr *GoVersionError
+	writeGo("go version go1.22.2 " + platform)
+	if err := GoVersionHook(ctx, goroot, file); !errors.As(err, &verr) || verr.Err != nil {
+		t.Fatalf("hook with other version: got %v", err)
+	}
+	writeGo("go version go1.22.3 plan9/mips")
+	if err := GoVersionHook(ctx, goroot, file); !errors.As(err, &verr) {
+		t.Fatalf("hook with other platform: got %v", err)
+	}
+	os.Remove(filepath.Join(goroot, "bin", "go"))
+	if err := GoVersionHook(ctx, goroot, file); !errors.As(err, &verr) || verr.Err == nil {
+		t.Fatalf("hook without go command: got %v", err)
+	}
+
+	// Files for other platforms and source files are not checked.
+	file.Os = "plan9"
+	if err := GoVersionHook(ctx, goroot, file); err != nil {
+		t.Fatalf("hook for other os: %v", err)
+	}
+}
+
+func TestGoEnvHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell script as go command")
+	}
+	goroot := t.TempDir()
+	os.Mkdir(filepath.Join(goroot, "bin"), 0777)
+	args := filepath.Join(goroot, "args")
+	script := "#!/bin/sh\necho \"$GOTOOLCHAIN $@\" >'" + args + "'\n"
+	if err := os.WriteFile(filepath.Join(goroot, "bin", "go"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := File{Version: "go1.22.3", Os: runtime.GOOS, Arch: runtime.GOARCH, Kind: KindArchive}
+	hook := GoEnvHook("GOTOOLCHAIN=go1.22.3", "GOFLAGS=-mod=mod")
+	if err := hook(context.Background(), goroot, file); err != nil {
+		t.Fatalf("hook: %v", err)
+	}
+	buf, err := os.ReadFile(args)
+	if err != nil || string(buf) != "go1.22.3 env -w GOTOOLCHAIN=go1.22.3 GOFLAGS=-mod=mod\n" {
+		t.Fatalf("go command called with %q %v", buf, err)
+	}
+	if err := GoEnvHook("GOFLAGS")(context.Background(), goroot, file); err == nil {
+		t.Fatalf("hook with bad setting succeeded")
+	}
+}
+
+func TestCodesignHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell script as codesign command")
+	}
+	goroot := t.TempDir()
+	for _, p := range []string{"bin/go", "bin/gofmt", "pkg/tool/darwin_arm64/vet"} {
+		p = filepath.Join(goroot, filepath.FromSlash(p))
+		os.MkdirAll(filepath.Dir(p), 0777)
+		if err := os.WriteFile(p, nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Fake codesign that rejects vet.
+	codesign := filepath.Join(t.TempDir(), "codesign")
+	script := "#!/bin/sh\ncase \"$4\" in\n*/vet) echo invalid signature; exit 1;;\nesac\n"
+	if err := os.WriteFile(codesign, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var cerr *CodesignError
+	err := verifyCodesign(context.Background(), codesign, goroot, GoogleTeamID)
+	if !errors.As(err, &cerr) || filepath.Base(cerr.Path) != "vet" || cerr.Output != "invalid signature" {
+		t.Fatalf("got err %v, expected CodesignError for vet", err)
+	}
+
+	os.Remove(filepath.Join(goroot, "pkg/tool/darwin_arm64/vet"))
+	if err := verifyCodesign(context.Background(), codesign, goroot, GoogleTeamID); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IdentifyInstall returns the release in releases of the Go installation in
+// directory dir, which is a GOROOT, or contains a "go" directory that is. The
+// version is read from the VERSION file. The file the install was extracted
+// from is taken from its manifest if it was installed by this package.
+// Otherwise the platform is determined from the directories in pkg/tool, and
+// the archive file for it is returned. If the file cannot be determined, a
+// zero File is returned along with the release.
+func IdentifyInstall(releases []Release, dir string) (Release, File, error) {
+	goroot := dir
+	if _, err := os.Stat(filepath.Join(dir, "VERSION")); err != nil {
+		goroot = filepath.Join(dir, "go")
+	}
+	version, err := ReadVersion(goroot)
+	if err != nil {
+		return Release{}, File{}, fmt.Errorf("reading version: %v", err)
+	}
+	v, err := ParseVersion(version)
+	if err != nil {
+		return Release{}, File{}, fmt.Errorf("not a release: %v", err)
+	}
+	rel, err := findVersion(releases, v)
+	if err != nil {
+		return Release{}, File{}, err
+	}
+
+	if m, err := ReadManifest(goroot); err == nil && m.Version == rel.Version {
+		for _, f := range rel.Files {
+			if f.Filename == m.Filename && f.Sha256 == m.Sha256 {
+				return rel, f, nil
+			}
+		}
+	}
+
+	// Binary releases have tools for a single platform, in pkg/tool/<os>_<arch>.
+	entries, err := os.ReadDir(filepath.Join(goroot, "pkg", "tool"))
+	if err != nil || len(entries) != 1 {
+		return rel, File{}, nil
+	}
+	t := strings.SplitN(entries[0].Name(), "_", 2)
+	if len(t) != 2 {
+		return rel, File{}, nil
+	}
+	f, err := FindFile(rel, t[0], t[1], KindArchive)
+	if err != nil {
+		return rel, File{}, nil
+	}
+	return rel, f, nil
+}
+
+// IdentifyInstall lists all releases and calls IdentifyInstall.
+func (c *Client) IdentifyInstall(ctx context.Context, dir string) (Release, File, error) {
+	rels, err := c.ListAll(ctx)
+	if err != nil {
+		return Release{}, File{}, err
+	}
+	return IdentifyInstall(rels, dir)
+}
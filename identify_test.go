@@ -0,0 +1,30 @@
+package goreleases
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIdentifyInstall(t *testing.T) {
+	rels := []Release{{Version: "go1.22.3", Stable: true, Files: []File{
+		{Filename: "go1.22.3.linux-amd64.tar.gz", Os: "linux", Arch: "amd64", Version: "go1.22.3", Kind: KindArchive},
+		{Filename: "go1.22.3.linux-arm64.tar.gz", Os: "linux", Arch: "arm64", Version: "go1.22.3", Kind: KindArchive},
+	}}}
+	dir := t.TempDir()
+	goroot := filepath.Join(dir, "go")
+	os.MkdirAll(filepath.Join(goroot, "pkg", "tool", "linux_arm64"), 0777)
+	os.WriteFile(filepath.Join(goroot, "VERSION"), []byte("go1.22.3\ntime 2024-05-01T19:59:00Z\n"), 0666)
+
+	for _, d := range []string{dir, goroot} {
+		rel, f, err := IdentifyInstall(rels, d)
+		if err != nil || rel.Version != "go1.22.3" || f.Arch != "arm64" {
+			t.Fatalf("identify %s: got %s, %v, %v", d, rel.Version, f, err)
+		}
+	}
+
+	os.WriteFile(filepath.Join(goroot, "VERSION"), []byte("go1.21.0\n"), 0666)
+	if _, _, err := IdentifyInstall(rels, dir); err == nil {
+		t.Fatalf("identify unknown version succeeded")
+	}
+}
@@ -0,0 +1,290 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Actions taken by Client.Install.
+const (
+	InstallNone     = "none"     // Release already installed and valid.
+	InstallNew      = "new"      // Release installed, dir did not exist or was empty.
+	InstallReplaced = "replaced" // Another or damaged install in dir was replaced.
+)
+
+// InstallOptions are optional parameters for Client.Install.
+type InstallOptions struct {
+	Os          string // Default runtime.GOOS.
+	Arch        string // Default runtime.GOARCH.
+	Permissions *Permissions
+
+	// Go installation to build the development version with, for spec "tip",
+	// see FetchTip.
+	Bootstrap string
+
+	// If set, the release is resolved and the result describes what would be
+	// done, but nothing is downloaded or written.
+	DryRun bool
+
+	// System install, e.g. in /usr/local/go by root: Permissions default to
+	// SystemPermissions, and dir and its parent directory must not be
+	// symbolic links, which are refused instead of followed.
+	System bool
+
+	// If set and the release is for the host os, the release is refused with a
+	// *RequirementError if it needs a newer version of the host OS, see
+	// CheckRequirement.
+	CheckHost bool
+
+	// If set, the release file must have this checksum, like File.Checksum,
+	// e.g. "sha256:<hex>". A different checksum in the listing is an error,
+	// and the download is verified against it.
+	Checksum string
+}
+
+// InstallResult describes what Client.Install did.
+type InstallResult struct {
+	Action   string // InstallNone, InstallNew or InstallReplaced.
+	Release  Release
+	File     File
+	Dir      string
+	Previous string // For InstallReplaced, version previously installed, if known.
+
+	// For actions other than InstallNone.
+	URL    string // Download URL, empty for a Client.Source.
+	Cached bool   // Whether file is in the archive cache, and won't be downloaded.
+	Size   int64  // Download size, from the listing.
+}
+
+// Install makes directory dir contain the release selected by version spec
+// (see Resolve), for the os and arch in opts. Spec "tip" or "tip@<ref>"
+// selects the development version at the branch or commit ref (default
+// master), built for the host with FetchTip. Can be called repeatedly: If
+// dir already contains a valid install of the release file, as recorded in
+// its manifest, nothing is done. Otherwise the release is fetched, with the
+// layout of FetchSDK (dir is the GOROOT), and replaces any previous install
+// created by this package in dir after successful extraction. A non-empty dir
+// without manifest is not touched and an error is returned. With
+// InstallOptions.DryRun, the result describes what would be done.
+//
+// If dir is empty, the release is installed in <version> in UserInstallRoot,
+// e.g. ~/.local/share/goreleases/go1.22.3 on Linux, as with a Manager for it.
+// Only installs for the host os and arch can be made there.
+func (c *Client) Install(ctx context.Context, spec, dir string, opts *InstallOptions) (InstallResult, error) {
+	return c.installWith(ctx, nil, spec, dir, opts)
+}
+
+// installWith is Install, with rels as releases if not nil.
+func (c *Client) installWith(ctx context.Context, rels []Release, spec, dir string, opts *InstallOptions) (InstallResult, error) {
+	var o InstallOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Os == "" {
+		o.Os = runtime.GOOS
+	}
+	if o.Arch == "" {
+		o.Arch = runtime.GOARCH
+	}
+	var root string
+	if dir == "" {
+		if o.System {
+			return InstallResult{}, fmt.Errorf("system install needs a directory")
+		}
+		// The user root has a single install per version, for the host.
+		if o.Os != runtime.GOOS || o.Arch != runtime.GOARCH {
+			return InstallResult{}, fmt.Errorf("install for %s/%s needs a directory", o.Os, o.Arch)
+		}
+		var err error
+		root, err = userInstallRoot()
+		if err != nil {
+			return InstallResult{}, fmt.Errorf("per-user install root: %v", err)
+		}
+	}
+	if o.System {
+		if o.Permissions == nil {
+			o.Permissions = &SystemPermissions
+		}
+		if err := checkNoSymlink(dir); err != nil {
+			return InstallResult{}, err
+		}
+	}
+
+	if spec == "tip" || strings.HasPrefix(spec, "tip@") {
+		if root != "" {
+			// As Manager.InstallTip.
+			dir = filepath.Join(root, TipDir)
+		}
+		return c.installTip(ctx, spec, dir, o)
+	}
+
+	c.emit(Event{Type: EventResolve, Spec: spec})
+	if rels == nil {
+		var err error
+		rels, err = c.ListAll(ctx)
+		if err != nil {
+			return InstallResult{}, err
+		}
+	}
+	rel, err := Resolve(rels, spec)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	file, err := FindFile(rel, o.Os, o.Arch, KindArchive)
+	if err != nil {
+		return InstallResult{}, fmt.Errorf("finding file for %s/%s in %s: %v", o.Os, o.Arch, rel.Version, err)
+	}
+	if root != "" {
+		dir = filepath.Join(root, rel.Version)
+	}
+	c.emit(Event{Type: EventResolved, Spec: spec, File: file})
+	if o.Checksum != "" {
+		if err := pinChecksum(&file, o.Checksum); err != nil {
+			return InstallResult{}, err
+		}
+	}
+	if o.CheckHost && o.Os == runtime.GOOS {
+		if host, err := HostOSVersion(); err == nil {
+			if err := CheckRequirement(rel.Version, o.Os, host); err != nil {
+				return InstallResult{}, err
+			}
+		}
+	}
+	r := InstallResult{Action: InstallNew, Release: rel, File: file, Dir: dir}
+
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		m, err := ReadManifest(dir)
+		if err != nil {
+			return InstallResult{}, fmt.Errorf("%s exists and is not an install by this package: %v", dir, err)
+		}
+		if m.Sha256 == file.Sha256 && checkInstall(dir, m) == nil {
+			r.Action = InstallNone
+			return r, nil
+		}
+		r.Action = InstallReplaced
+		r.Previous = m.Version
+	} else if err != nil && !os.IsNotExist(err) {
+		return InstallResult{}, err
+	}
+
+	r.Size = file.Size
+	r.URL = c.fileURL(file)
+	if c.ArchiveCacheDir != "" && file.Sha256 != "" {
+		_, err := os.Stat(archiveCachePath(c.ArchiveCacheDir, file.Sha256))
+		r.Cached = err == nil
+	}
+	if o.DryRun {
+		return r, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0777); err != nil {
+		return InstallResult{}, err
+	}
+	if err := c.install(ctx, file, dir, o.Permissions); err != nil {
+		return InstallResult{}, err
+	}
+	return r, c.runHooks(ctx, dir, file)
+}
+
+func (c *Client) installTip(ctx context.Context, spec, dir string, o InstallOptions) (InstallResult, error) {
+	ref := "master"
+	if strings.HasPrefix(spec, "tip@") {
+		ref = spec[len("tip@"):]
+	}
+	commit, err := c.TipCommit(ctx, ref)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	version := tipVersion(commit)
+	r := InstallResult{Action: InstallNew, Release: Release{Version: version}, Dir: dir}
+	if c.Source == nil {
+		r.URL = c.tipURL() + "/+archive/" + commit + ".tar.gz"
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		m, err := ReadManifest(dir)
+		if err != nil {
+			return InstallResult{}, fmt.Errorf("%s exists and is not an install by this package: %v", dir, err)
+		}
+		if m.Version == version && checkInstall(dir, m) == nil {
+			r.Action = InstallNone
+			return r, nil
+		}
+		r.Action = InstallReplaced
+		r.Previous = m.Version
+	} else if err != nil && !os.IsNotExist(err) {
+		return InstallResult{}, err
+	}
+	if o.DryRun {
+		return r, nil
+	}
+	return r, c.FetchTip(ctx, commit, dir, o.Bootstrap)
+}
+
+// pinChecksum sets checksum, "<algorithm>:<hex>", on file, returning an error
+// if file has another checksum for the algorithm.
+func pinChecksum(file *File, checksum string) error {
+	alg, sum, ok := strings.Cut(checksum, ":")
+	if !ok || sum == "" {
+		return fmt.Errorf("bad checksum %q, expected <algorithm>:<hex>", checksum)
+	}
+	sum = strings.ToLower(sum)
+	if alg == "sha256" {
+		if file.Sha256 != "" && file.Sha256 != sum {
+			return fmt.Errorf("file %s has sha256 %s, expected %s", file.Filename, file.Sha256, sum)
+		}
+		file.Sha256 = sum
+		return nil
+	}
+	if file.Checksum != "" && !strings.EqualFold(file.Checksum, checksum) {
+		return fmt.Errorf("file %s has checksum %s, expected %s", file.Filename, file.Checksum, checksum)
+	}
+	file.Checksum = checksum
+	return nil
+}
+
+// checkNoSymlink returns an error if dir or its parent directory is a symbolic
+// link.
+func checkNoSymlink(dir string) error {
+	for _, p := range []string{dir, filepath.Dir(dir)} {
+		if fi, err := os.Lstat(p); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symbolic link, refusing system install", p)
+		} else if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkInstall checks that the entries in the manifest of the install in dir
+// are present with the expected type and size. File contents are not
+// verified.
+func checkInstall(dir string, m *Manifest) error {
+	if _, err := os.Stat(filepath.Join(dir, UnpackedMarker)); err != nil {
+		return err
+	}
+	for _, e := range m.Entries {
+		fi, err := os.Lstat(filepath.Join(dir, filepath.FromSlash(e.Name)))
+		if err != nil {
+			return err
+		}
+		var ok bool
+		switch e.Type {
+		case EntryDir:
+			ok = fi.IsDir()
+		case EntrySymlink:
+			ok = fi.Mode()&os.ModeSymlink != 0
+		case EntryLink:
+			ok = fi.Mode().IsRegular()
+		default:
+			ok = fi.Mode().IsRegular() && fi.Size() == e.Size
+		}
+		if !ok {
+			return fmt.Errorf("%s: unexpected type or size", e.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,352 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	rels := []Release{
+		{Version: "go1.23rc1"},
+		{Version: "go1.22.3", Stable: true},
+		{Version: "go1.22.2", Stable: true},
+		{Version: "go1.20.14", Stable: true},
+		{Version: "go1.20", Stable: true},
+	}
+	tests := []struct{ spec, exp string }{
+		{"latest", "go1.22.3"},
+		{"1.22", "go1.22.3"},
+		{"go1.22.2", "go1.22.2"},
+		{"1.20", "go1.20.14"},
+		{"1.23", "go1.23rc1"},
+		{"1.23rc1", "go1.23rc1"},
+		{"1.21", ""},
+		{"bogus", ""},
+	}
+	for _, tt := range tests {
+		rel, err := Resolve(rels, tt.spec)
+		if tt.exp == "" {
+			if err == nil {
+				t.Errorf("resolve %q: got %s, expected error", tt.spec, rel.Version)
+			}
+		} else if err != nil || rel.Version != tt.exp {
+			t.Errorf("resolve %q: got %q, %v, expected %s", tt.spec, rel.Version, err, tt.exp)
+		}
+	}
+}
+
+func TestIsLatestPatch(t *testing.T) {
+	rels := []Release{
+		{Version: "go1.23rc1"},
+		{Version: "go1.22.3", Stable: true},
+		{Version: "go1.22.2", Stable: true},
+		{Version: "go1.20.14", Stable: true},
+		{Version: "go1.20", Stable: true},
+	}
+	for i, exp := range []bool{false, true, false, true, false} {
+		if got := rels[i].IsLatestPatch(rels); got != exp {
+			t.Errorf("%s: latest patch %v, expected %v", rels[i].Version, got, exp)
+		}
+	}
+}
+
+func TestInstall(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "go")
+	src := newTestSource(t, "go1.22.2", "go1.22.3")
+	var hooked []string
+	hook := func(ctx context.Context, goroot string, file File) error {
+		hooked = append(hooked, file.Version)
+		if goroot != dir {
+			t.Fatalf("hook called with goroot %s, expected %s", goroot, dir)
+		}
+		return nil
+	}
+	c := Client{Source: src, PostInstall: []InstallHook{hook}}
+	ctx := context.Background()
+	opts := &InstallOptions{Os: "linux", Arch: "amd64"}
+
+	check := func(spec, action, previous string) {
+		t.Helper()
+		r, err := c.Install(ctx, spec, dir, opts)
+		if err != nil {
+			t.Fatalf("install %s: %s", spec, err)
+		}
+		if r.Action != action || r.Previous != previous {
+			t.Fatalf("install %s: got action %q, previous %q, expected %q, %q", spec, r.Action, r.Previous, action, previous)
+		}
+	}
+	dry := &InstallOptions{Os: "linux", Arch: "amd64", DryRun: true}
+	if r, err := c.Install(ctx, "1.22.2", dir, dry); err != nil || r.Action != InstallNew || r.Size == 0 {
+		t.Fatalf("dry-run install: %v %v", r, err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("dry-run install created directory: %v", err)
+	}
+	check("1.22.2", InstallNew, "")
+	check("1.22.2", InstallNone, "")
+	check("1.22", InstallReplaced, "go1.22.2")
+	if v, err := ReadVersion(dir); err != nil || v != "go1.22.3" {
+		t.Fatalf("version after replace: %q, %v", v, err)
+	}
+
+	// Damaged install is replaced.
+	if err := os.Remove(filepath.Join(dir, "bin", "go")); err != nil {
+		t.Fatalf("remove: %s", err)
+	}
+	check("latest", InstallReplaced, "go1.22.3")
+	if exp := []string{"go1.22.2", "go1.22.3", "go1.22.3"}; !reflect.DeepEqual(hooked, exp) {
+		t.Fatalf("hooks called for %v, expected %v", hooked, exp)
+	}
+
+	// Directories not created by us are left alone.
+	other := t.TempDir()
+	os.WriteFile(filepath.Join(other, "file"), nil, 0666)
+	if _, err := c.Install(ctx, "latest", other, opts); err == nil {
+		t.Fatalf("install over unmanaged directory succeeded")
+	}
+}
+
+func TestInstallHookError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "go")
+	fail := true
+	hook := func(ctx context.Context, goroot string, file File) error {
+		if fail {
+			fail = false
+			return fmt.Errorf("test failure")
+		}
+		return nil
+	}
+	c := Client{Source: newTestSource(t, "go1.22.3"), PostInstall: []InstallHook{hook}}
+	ctx := context.Background()
+	opts := &InstallOptions{Os: "linux", Arch: "amd64"}
+
+	if _, err := c.Install(ctx, "1.22", dir, opts); err == nil {
+		t.Fatalf("install with failing hook succeeded")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("install kept after failing hook: %v", err)
+	}
+	// The hook runs again for the new install.
+	if r, err := c.Install(ctx, "1.22", dir, opts); err != nil || r.Action != InstallNew {
+		t.Fatalf("install after failing hook: %v %v", r.Action, err)
+	}
+
+	fail = true
+	sdk := t.TempDir()
+	file := c.Source.(*memSource).releases[0].Files[0]
+	if _, err := c.FetchSDK(ctx, file, sdk, nil); err == nil {
+		t.Fatalf("fetch sdk with failing hook succeeded")
+	}
+	if _, err := c.FetchSDK(ctx, file, sdk, nil); err != nil {
+		t.Fatalf("fetch sdk after failing hook: %v", err)
+	}
+}
+
+func TestSetupGitHubActions(t *testing.T) {
+	tmp := t.TempDir()
+	toolCache := filepath.Join(tmp, "toolcache")
+	t.Setenv("RUNNER_TOOL_CACHE", toolCache)
+	t.Setenv("GITHUB_ENV", filepath.Join(tmp, "env"))
+	t.Setenv("GITHUB_PATH", filepath.Join(tmp, "path"))
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(tmp, "output"))
+
+	src := newTestSource(t, "go1.22.3")
+	c := Client{Source: src}
+	opts := &InstallOptions{Os: "linux", Arch: "amd64"}
+
+	dir := filepath.Join(toolCache, "go", "1.22.3", "x64")
+	for i, hit := range []bool{false, true} {
+		r, err := c.SetupGitHubActions(context.Background(), "1.22", opts)
+		if err != nil {
+			t.Fatalf("setup %d: %v", i, err)
+		}
+		if r.Dir != dir || r.Version != "1.22.3" || r.CacheHit != hit {
+			t.Fatalf("setup %d: dir %s, version %s, cache hit %v", i, r.Dir, r.Version, r.CacheHit)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bin", "go")); err != nil {
+		t.Fatalf("installed binary: %v", err)
+	}
+	if _, err := os.Stat(dir + ".complete"); err != nil {
+		t.Fatalf("marker: %v", err)
+	}
+	files := map[string]string{
+		"env":    "GOROOT=" + dir + "\n",
+		"path":   filepath.Join(dir, "bin") + "\n",
+		"output": fmt.Sprintf("go-version=1.22.3\ngoroot=%s\ncache-hit=true\n", dir),
+	}
+	for name, exp := range files {
+		buf, err := os.ReadFile(filepath.Join(tmp, name))
+		if err != nil {
+			t.Fatalf("reading %s: %v", name, err)
+		}
+		if !strings.HasSuffix(string(buf), exp) {
+			t.Fatalf("%s is %q, expected suffix %q", name, buf, exp)
+		}
+	}
+
+	if _, err := c.SetupGitHubActions(context.Background(), "tip", opts); err == nil {
+		t.Fatalf("setup with tip succeeded")
+	}
+	if v := actionsVersion(Version{Major: 1, Minor: 23, Pre: "rc", PreN: 1}); v != "1.23.0-rc.1" {
+		t.Fatalf("actions version %s", v)
+	}
+}
+
+func TestInstallSystem(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/go": "binary", "go/make.bash": "script"})
+	f := testFile("go1.22.3", tgz)
+	src := &memSource{files: map[string][]byte{f.Filename: tgz}}
+	src.setReleases([]Release{{Version: "go1.22.3", Stable: true, Files: []File{f}}})
+	c := Client{Source: src}
+	ctx := context.Background()
+	tmp := t.TempDir()
+
+	if err := os.Mkdir(filepath.Join(tmp, "real"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("real", filepath.Join(tmp, "go")); err != nil {
+		t.Skipf("symlink: %v", err)
+	}
+	opts := &InstallOptions{Os: "linux", Arch: "amd64", System: true}
+	if _, err := c.Install(ctx, "1.22.3", filepath.Join(tmp, "go"), opts); err == nil {
+		t.Fatalf("system install to symlink succeeded")
+	}
+
+	if os.Geteuid() != 0 {
+		// Only exercised when running as root, for the chown.
+		opts.Permissions = &Permissions{Uid: -1, Gid: -1, Mode: 0755}
+	}
+	dir := filepath.Join(tmp, "system")
+	if _, err := c.Install(ctx, "1.22.3", dir, opts); err != nil {
+		t.Fatalf("system install: %v", err)
+	}
+	for name, exp := range map[string]os.FileMode{"VERSION": 0644, "make.bash": 0755, "bin": 0755, ManifestFile: 0644} {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if fi.Mode().Perm() != exp {
+			t.Errorf("%s: got mode %v, expected %v", name, fi.Mode().Perm(), exp)
+		}
+	}
+}
+
+func TestInstallEvents(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "go")
+	src := newTestSource(t, "go1.22.3")
+
+	var types []EventType
+	var names []string
+	c := Client{Source: src, Events: func(e Event) {
+		if e.Time.IsZero() {
+			t.Fatalf("event without time")
+		}
+		if len(types) == 0 || types[len(types)-1] != e.Type {
+			types = append(types, e.Type)
+		}
+		if e.Type == EventExtract {
+			names = append(names, e.Name)
+		}
+		if e.Type == EventRename && e.Dir != dir {
+			t.Fatalf("rename event with dir %s, expected %s", e.Dir, dir)
+		}
+	}}
+	if _, err := c.Install(context.Background(), "1.22", dir, &InstallOptions{Os: "linux", Arch: "amd64"}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	exp := []EventType{EventResolve, EventResolved, EventDownload, EventProgress, EventExtract, EventVerified, EventDone, EventRename}
+	if !reflect.DeepEqual(types, exp) {
+		t.Fatalf("events %v, expected %v", types, exp)
+	}
+	if !reflect.DeepEqual(names, []string{"VERSION", "bin", "bin/go"}) {
+		t.Fatalf("extract events for %v", names)
+	}
+}
+
+func TestInstallUserRoot(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "root")
+	t.Setenv(EnvInstallRoot, root)
+	src := newTestSource(t, "go1.22.3")
+	// Only installs for the host go into the user root.
+	src.releases[0].Files[0].Os = runtime.GOOS
+	src.releases[0].Files[0].Arch = runtime.GOARCH
+	c := &Client{Source: src}
+
+	if _, err := c.Install(context.Background(), "1.22", "", &InstallOptions{Os: runtime.GOOS, Arch: "other"}); err == nil {
+		t.Fatalf("install for other platform without directory succeeded")
+	}
+	r, err := c.Install(context.Background(), "1.22", "", nil)
+	if err != nil {
+		t.Fatalf("install: %s", err)
+	}
+	if exp := filepath.Join(root, "go1.22.3"); r.Dir != exp {
+		t.Fatalf("installed in %s, expected %s", r.Dir, exp)
+	}
+	m, err := UserManager(c)
+	if err != nil {
+		t.Fatalf("user manager: %s", err)
+	}
+	if l, err := m.Installed(); err != nil || len(l) != 1 || l[0] != "go1.22.3" {
+		t.Fatalf("installed in user manager: %v %v", l, err)
+	}
+	if _, err := c.Install(context.Background(), "1.22", "", &InstallOptions{System: true}); err == nil {
+		t.Fatalf("system install without directory succeeded")
+	}
+}
+
+func TestInstallSymlink(t *testing.T) {
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	for _, h := range []*tar.Header{
+		{Typeflag: tar.TypeDir, Name: "go/", Mode: 0755},
+		{Typeflag: tar.TypeReg, Name: "go/VERSION", Mode: 0644, Size: 9},
+		{Typeflag: tar.TypeDir, Name: "go/bin/", Mode: 0755},
+		{Typeflag: tar.TypeSymlink, Name: "go/bin/VERSION", Linkname: "../VERSION"},
+	} {
+		h.ModTime = time.Now()
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte("go1.22.3\n")[:h.Size])
+	}
+	tw.Close()
+	gzw.Close()
+	tgz := b.Bytes()
+	f := testFile("go1.22.3", tgz)
+	rel := Release{Version: "go1.22.3", Stable: true, Files: []File{f}}
+	src := &memSource{files: map[string][]byte{f.Filename: tgz}}
+	src.setReleases([]Release{rel})
+	c := Client{Source: src}
+	dir := filepath.Join(t.TempDir(), "go")
+	if _, err := c.Install(context.Background(), "1.22", dir, &InstallOptions{Os: "linux", Arch: "amd64"}); err != nil {
+		if runtime.GOOS == "windows" {
+			t.Skipf("install with symlink: %v", err)
+		}
+		t.Fatalf("install: %v", err)
+	}
+	link := filepath.Join(dir, "bin", "VERSION")
+	if buf, err := os.ReadFile(link); err != nil || string(buf) != "go1.22.3\n" {
+		t.Fatalf("reading through symlink: %q, %v", buf, err)
+	}
+	if names, err := c.Repair(context.Background(), dir, rel); err != nil || len(names) != 0 {
+		t.Fatalf("repair of intact install: %v, %v", names, err)
+	}
+	os.Remove(link)
+	if names, err := c.Repair(context.Background(), dir, rel); err != nil || fmt.Sprint(names) != "[bin/VERSION]" {
+		t.Fatalf("repair: %v, %v", names, err)
+	}
+	if buf, err := os.ReadFile(link); err != nil || string(buf) != "go1.22.3\n" {
+		t.Fatalf("reading through repaired symlink: %q, %v", buf, err)
+	}
+}
@@ -0,0 +1,114 @@
+package goreleases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ReleaseIterator yields releases one at a time, see Client.Releases.
+type ReleaseIterator struct {
+	// Either a decoder for a streamed listing, or a slice.
+	dec  *json.Decoder
+	body io.Closer
+	l    []Release
+
+	rel  Release
+	err  error
+	done bool
+}
+
+// Releases returns an iterator over the releases, only the supported releases
+// if all is false. When listing from BaseURL without CacheDir or ListingCache,
+// releases are decoded while the listing is downloaded, and only the current
+// release is held in memory. Otherwise, the iterator is over the complete
+// listing. The iterator must be closed.
+//
+//	it, err := c.Releases(ctx, true)
+//	...
+//	defer it.Close()
+//	for it.Next() {
+//		rel := it.Release()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+func (c *Client) Releases(ctx context.Context, all bool) (*ReleaseIterator, error) {
+	if c.Source != nil || c.CacheDir != "" || c.ListingCache != nil || c.Offline {
+		l, err := c.List(ctx, all)
+		if err != nil {
+			return nil, err
+		}
+		return &ReleaseIterator{l: l}, nil
+	}
+
+	_, url := c.listURL(all)
+	resp, err := c.get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching releases: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching releases returned http status %d: %s", resp.StatusCode, resp.Status)
+	}
+	dec := json.NewDecoder(resp.Body)
+	if t, err := dec.Token(); err != nil || t != json.Delim('[') {
+		resp.Body.Close()
+		return nil, fmt.Errorf("parsing releases JSON: expected array, got %v (%v)", t, err)
+	}
+	return &ReleaseIterator{dec: dec, body: resp.Body}, nil
+}
+
+// Next advances to the next release, returning false at the end of the
+// listing or on error, see Err.
+func (it *ReleaseIterator) Next() bool {
+	if it.done {
+		return false
+	}
+	if it.dec == nil {
+		if len(it.l) == 0 {
+			it.done = true
+			return false
+		}
+		it.rel, it.l = it.l[0], it.l[1:]
+		return true
+	}
+	if !it.dec.More() {
+		it.done = true
+		if _, err := it.dec.Token(); err != nil {
+			it.err = fmt.Errorf("parsing releases JSON: %v", err)
+		}
+		return false
+	}
+	it.rel = Release{}
+	if err := it.dec.Decode(&it.rel); err != nil {
+		it.err = fmt.Errorf("parsing releases JSON: %v", err)
+		it.done = true
+		return false
+	}
+	return true
+}
+
+// Release returns the current release.
+func (it *ReleaseIterator) Release() Release {
+	return it.rel
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ReleaseIterator) Err() error {
+	return it.err
+}
+
+// Close releases the resources of the iterator, e.g. the HTTP response.
+func (it *ReleaseIterator) Close() error {
+	it.done = true
+	if it.body != nil {
+		err := it.body.Close()
+		it.body = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build go1.23
+// +build go1.23
+
+package goreleases
+
+import (
+	"iter"
+)
+
+// All returns the remaining releases as a sequence for range-over-func. Check
+// Err after the loop.
+func (it *ReleaseIterator) All() iter.Seq[Release] {
+	return func(yield func(Release) bool) {
+		for it.Next() {
+			if !yield(it.Release()) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package goreleases
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReleases(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"version":"go1.22.3","stable":true,"files":[]},{"version":"go1.21.10","stable":true,"files":[]}]`))
+	}))
+	defer srv.Close()
+
+	for _, c := range []*Client{
+		{BaseURL: srv.URL + "/"},
+		{BaseURL: srv.URL + "/", CacheDir: t.TempDir()},
+	} {
+		it, err := c.Releases(context.Background(), true)
+		if err != nil {
+			t.Fatalf("releases: %s", err)
+		}
+		var versions []string
+		for it.Next() {
+			versions = append(versions, it.Release().Version)
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("iterating: %s", err)
+		}
+		it.Close()
+		if len(versions) != 2 || versions[0] != "go1.22.3" || versions[1] != "go1.21.10" {
+			t.Fatalf("got versions %v", versions)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+)
+
+// Final returns the final release that prerelease v leads up to, the first
+// release of its minor, e.g. go1.23.0 for go1.23rc2. For final releases, v
+// itself is returned.
+func (v Version) Final() Version {
+	if v.Pre == "" {
+		return v
+	}
+	return Version{Major: v.Major, Minor: v.Minor}
+}
+
+// Lineage relates a version to the prereleases and final release of its minor
+// version, as returned by FindLineage.
+type Lineage struct {
+	Version     string   // E.g. "go1.23rc2".
+	Final       string   // Final release of the lineage, e.g. "go1.23.0", released or not.
+	Released    bool     // Whether Final has been released.
+	Prereleases []string // Betas and release candidates of the minor in releases, oldest first.
+
+	// Whether Version is a prerelease and Final has been released, so installs of
+	// Version can be retired.
+	Superseded bool
+
+	// Newest prerelease that is newer than Version, while Final is not
+	// released. Empty otherwise.
+	NewerPrerelease string
+}
+
+// FindLineage returns the lineage of version, based on releases, which should
+// include unstable releases, e.g. from ListAll.
+func FindLineage(releases []Release, version string) (Lineage, error) {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return Lineage{}, err
+	}
+	final := v.Final()
+	l := Lineage{Version: v.String(), Final: final.String()}
+	var newest *Version
+	for _, rel := range releases {
+		rv, err := ParseVersion(rel.Version)
+		if err != nil || !rv.SameMinor(v) {
+			continue
+		}
+		if rv.Pre == "" {
+			if rv == final {
+				l.Released = true
+			}
+			continue
+		}
+		l.Prereleases = append(l.Prereleases, rv.String())
+		if rv.Compare(v) > 0 && (newest == nil || rv.Compare(*newest) > 0) {
+			newest = &rv
+		}
+	}
+	sortVersions(l.Prereleases)
+	for i, j := 0, len(l.Prereleases)-1; i < j; i, j = i+1, j-1 {
+		l.Prereleases[i], l.Prereleases[j] = l.Prereleases[j], l.Prereleases[i]
+	}
+	l.Superseded = v.Pre != "" && l.Released
+	if !l.Released && newest != nil {
+		l.NewerPrerelease = newest.String()
+	}
+	return l, nil
+}
+
+// Lineage lists all releases and returns the lineage of version.
+func (c *Client) Lineage(ctx context.Context, version string) (Lineage, error) {
+	rels, err := c.ListAll(ctx)
+	if err != nil {
+		return Lineage{}, err
+	}
+	return FindLineage(rels, version)
+}
+
+// RetireSuperseded removes the installed prereleases whose final release has
+// been released, see Lineage. The active version is not removed. The removed
+// versions are returned.
+func (m *Manager) RetireSuperseded(ctx context.Context) ([]string, error) {
+	versions, err := m.Installed()
+	if err != nil {
+		return nil, err
+	}
+	current, err := m.Current()
+	if err != nil {
+		return nil, err
+	}
+	var rels []Release
+	var removed []string
+	for _, version := range versions {
+		v, err := ParseVersion(version)
+		if err != nil || v.Pre == "" || version == current {
+			continue
+		}
+		if rels == nil {
+			rels, err = m.client().ListAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+		l, err := FindLineage(rels, version)
+		if err != nil || !l.Superseded {
+			continue
+		}
+		if err := Remove(m.Path(version)); err != nil {
+			return removed, fmt.Errorf("removing %s: %v", version, err)
+		}
+		removed = append(removed, version)
+	}
+	return removed, nil
+}
@@ -1,9 +1,13 @@
 package goreleases
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // Release is a released Go toolchain version, with files for several Os/Arch combinations.
@@ -21,34 +25,216 @@ type File struct {
 	Version  string `json:"version"`
 	Sha256   string `json:"sha256"`
 	Size     int64  `json:"size"`
-	Kind     string `json:"kind"` // "source", "archive", "package"
+	Kind     string `json:"kind"` // KindSource, KindArchive or KindInstaller.
+
+	// Optional additional checksum, "<algorithm>:<hex>", e.g. "sha512:...",
+	// for custom sources that publish other checksums, see RegisterChecksum.
+	// It is verified when downloading. Sha256 can be empty if Checksum is set.
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// String returns the version, with " (unstable)" for unstable releases.
+func (r Release) String() string {
+	if r.Stable {
+		return r.Version
+	}
+	return r.Version + " (unstable)"
 }
 
-const urlCurrent = "https://go.dev/dl/?mode=json"
-const urlAll = "https://go.dev/dl/?mode=json&include=all"
+// String returns a description like "go1.22.3 linux/amd64 archive", or
+// "go1.22.3 source" for source files.
+func (f File) String() string {
+	l := []string{f.Version}
+	if f.Kind != KindSource && (f.Os != "" || f.Arch != "") {
+		l = append(l, f.Os+"/"+f.Arch)
+	}
+	if f.Kind != "" {
+		l = append(l, f.Kind)
+	}
+	return strings.Join(l, " ")
+}
+
+// Kinds of files in a release.
+const (
+	KindSource    = "source"    // Source code, .tar.gz.
+	KindArchive   = "archive"   // Binary release, .tar.gz or .zip.
+	KindInstaller = "installer" // Installer for macOS (.pkg) or Windows (.msi).
+)
+
+// ListSupported returns supported Go releases. Options, e.g. WithClient,
+// configure the request.
+func ListSupported(opts ...Option) ([]Release, error) {
+	ctx, c, _ := makeOptions(nil, opts)
+	return c.ListSupported(ctx)
+}
+
+// ListAll returns all Go releases, including historic.
+func ListAll(opts ...Option) ([]Release, error) {
+	ctx, c, _ := makeOptions(nil, opts)
+	return c.ListAll(ctx)
+}
 
 // ListSupported returns supported Go releases.
-func ListSupported() ([]Release, error) {
-	return list(urlCurrent)
+func (c *Client) ListSupported(ctx context.Context) ([]Release, error) {
+	return c.List(ctx, false)
 }
 
 // ListAll returns all Go releases, including historic.
-func ListAll() ([]Release, error) {
-	return list(urlAll)
+func (c *Client) ListAll(ctx context.Context) ([]Release, error) {
+	return c.List(ctx, true)
+}
+
+// ListOptions select releases for Client.ListFiltered and FilterReleases.
+type ListOptions struct {
+	All bool // Include historic releases, not only supported releases.
+
+	// If not empty, only releases with a file for the os, arch and kind are
+	// returned, matched as with FindFile. E.g. linux/riscv64 to find the first
+	// release for a port.
+	Os   string
+	Arch string
+	Kind string
+}
+
+// FilterReleases returns the releases that have a file matching the os, arch
+// and kind in opts. The files of returned releases are not filtered. All is
+// ignored.
+func FilterReleases(releases []Release, opts *ListOptions) []Release {
+	if opts == nil || opts.Os == "" && opts.Arch == "" && opts.Kind == "" {
+		return releases
+	}
+	var l []Release
+	for _, rel := range releases {
+		if _, err := FindFile(rel, opts.Os, opts.Arch, opts.Kind); err == nil {
+			l = append(l, rel)
+		}
+	}
+	return l
 }
 
-func list(url string) ([]Release, error) {
-	resp, err := http.Get(url)
+// ListFiltered lists supported releases, or all releases with opts.All, and
+// returns those matching opts, see FilterReleases.
+func (c *Client) ListFiltered(ctx context.Context, opts *ListOptions) ([]Release, error) {
+	rels, err := c.List(ctx, opts != nil && opts.All)
+	if err != nil {
+		return nil, err
+	}
+	return FilterReleases(rels, opts), nil
+}
+
+// list returns the releases at url. If a cache directory is configured, a
+// fresh cached listing stored under name is used, and new listings are stored.
+// A stale cached listing is revalidated with a conditional request, and reused
+// if the server reports it has not been modified. In offline mode, only the
+// cache is used. Otherwise, a ListingCache is consulted first.
+func (c *Client) list(ctx context.Context, name, url string) ([]Release, error) {
+	name = listCacheName(name, url)
+	if c.Offline {
+		if c.CacheDir == "" {
+			return nil, fmt.Errorf("%w: no cache directory configured", ErrNotCached)
+		}
+		lc, err := readListCache(c.CacheDir, name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNotCached, err)
+		}
+		if c.MaxStale > 0 && time.Since(lc.Time) > c.MaxStale {
+			return nil, fmt.Errorf("%w: cached listing from %s is older than %s", ErrNotCached, lc.Time.Format(time.RFC3339), c.MaxStale)
+		}
+		return parseReleases(lc.Data)
+	}
+
+	if c.ListingCache != nil {
+		return c.ListingCache.get(ctx, name, c.cacheTTL(), func() ([]Release, error) {
+			return c.listCacheDir(ctx, name, url)
+		})
+	}
+	return c.listCacheDir(ctx, name, url)
+}
+
+// listCacheDir lists from the cache in CacheDir if fresh, and from url
+// otherwise, storing the listing in CacheDir.
+func (c *Client) listCacheDir(ctx context.Context, name, url string) ([]Release, error) {
+	var cached *listCache
+	if c.CacheDir != "" {
+		lc, err := readListCache(c.CacheDir, name)
+		if err == nil && time.Since(lc.Time) < c.cacheTTL() {
+			return parseReleases(lc.Data)
+		}
+		if c.SharedCache {
+			// Another process may be refreshing the listing.
+			unlock, lerr := c.lockCache(ctx, c.CacheDir, "list-"+name)
+			if lerr != nil {
+				return nil, fmt.Errorf("locking listing cache: %v", lerr)
+			}
+			defer unlock()
+			lc, err = readListCache(c.CacheDir, name)
+			if err == nil && time.Since(lc.Time) < c.cacheTTL() {
+				return parseReleases(lc.Data)
+			}
+		}
+		if err == nil {
+			cached = lc
+		}
+	}
+
+	lc, err := c.fetchList(ctx, url, cached)
+	if err != nil {
+		return nil, err
+	}
+	rels, err := parseReleases(lc.Data)
+	if err != nil {
+		return nil, err
+	}
+	if c.CacheDir != "" {
+		// Failing to cache is not a reason to fail the listing.
+		writeListCache(c.CacheDir, name, lc)
+	}
+	return rels, nil
+}
+
+// fetchList fetches the listing at url. If cached is not nil, a conditional
+// request is made and cached is returned with an updated time if the listing
+// was not modified.
+func (c *Client) fetchList(ctx context.Context, url string, cached *listCache) (*listCache, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached != nil && cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("fetching releases: %w", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		lc := *cached
+		lc.Time = time.Now()
+		return &lc, nil
+	}
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("fetching releases returned http status %d: %s", resp.StatusCode, resp.Status)
 	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading releases: %w", err)
+	}
+	lc := &listCache{
+		Time:         time.Now(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Data:         data,
+	}
+	return lc, nil
+}
 
+func parseReleases(data []byte) ([]Release, error) {
 	var rels []Release
-	err = json.NewDecoder(resp.Body).Decode(&rels)
+	err := json.Unmarshal(data, &rels)
 	if err != nil {
 		return nil, fmt.Errorf("parsing releases JSON: %s", err)
 	}
@@ -1,25 +1,106 @@
 package goreleases
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/mjl-/goreleases/goreleasestest"
 )
 
 func TestList(t *testing.T) {
-	// disabled, don't bother real servers while testing
-	if true {
-		return
-	}
+	srv := goreleasestest.NewServer("go1.21.9", "go1.22.2", "go1.22.3", "go1.23rc1", "go1.20.14")
+	defer srv.Close()
+	c := Client{BaseURL: srv.BaseURL(), NoSignatures: true}
 
-	rels, err := ListSupported()
+	rels, err := c.ListSupported(context.Background())
 	if err != nil {
 		t.Fatalf("listing supported releases: %s", err)
 	}
-	fmt.Println(rels)
+	if len(rels) != 4 || rels[0].Version != "go1.23rc1" || rels[3].Version != "go1.21.9" {
+		t.Fatalf("unexpected supported releases %v", rels)
+	}
 
-	rels, err = ListAll()
+	rels, err = c.ListAll(context.Background())
 	if err != nil {
 		t.Fatalf("listing all releases: %s", err)
 	}
-	fmt.Println(rels)
+	if len(rels) != 5 || rels[4].Version != "go1.20.14" || len(rels[0].Files) != 1+len(goreleasestest.Platforms) {
+		t.Fatalf("unexpected releases %v", rels)
+	}
+}
+
+func TestListMirror(t *testing.T) {
+	const etag = `"v1"`
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("mode") != "json" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, `[{"version":"go1.22.3","stable":true,"files":[]}]`)
+	}))
+	defer srv.Close()
+
+	c := Client{BaseURL: srv.URL + "/dl/", CacheDir: t.TempDir(), CacheTTL: -1}
+	for i := 0; i < 2; i++ {
+		rels, err := c.ListSupported(context.Background())
+		if err != nil {
+			t.Fatalf("listing releases: %s", err)
+		}
+		if len(rels) != 1 || rels[0].Version != "go1.22.3" {
+			t.Fatalf("unexpected releases %v", rels)
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Fatalf("got %d requests with %d not modified, expected 2 and 1", requests, notModified)
+	}
+}
+
+func TestReleaseJSON(t *testing.T) {
+	// As served by go.dev/dl/?mode=json.
+	const upstream = `[{"version":"go1.22.3","stable":true,"files":[{"filename":"go1.22.3.src.tar.gz","os":"","arch":"","version":"go1.22.3","sha256":"80648ef34f903193d72a59c0dff019f5f98ae0c9aa13ade0b0ecbff991a76f68","size":27596452,"kind":"source"},{"filename":"go1.22.3.linux-amd64.tar.gz","os":"linux","arch":"amd64","version":"go1.22.3","sha256":"8920ea521bad8f6b7bc377b4824982e011c19af27df88a815e3586ea895f1b36","size":68958945,"kind":"archive"}]}]`
+	rels, err := parseReleases([]byte(upstream))
+	if err != nil {
+		t.Fatalf("parse: %s", err)
+	}
+	buf, err := json.Marshal(rels)
+	if err != nil || string(buf) != upstream {
+		t.Fatalf("round trip: got %s, %v", buf, err)
+	}
+	if s := rels[0].String(); s != "go1.22.3" {
+		t.Fatalf("release string %q", s)
+	}
+	for i, exp := range []string{"go1.22.3 source", "go1.22.3 linux/amd64 archive"} {
+		if s := rels[0].Files[i].String(); s != exp {
+			t.Fatalf("file string: got %q, expected %q", s, exp)
+		}
+	}
+}
+
+func TestFilterReleases(t *testing.T) {
+	rels := []Release{
+		{Version: "go1.22.3", Files: []File{{Os: "linux", Arch: "riscv64", Kind: KindArchive}, {Os: "linux", Arch: "amd64", Kind: KindArchive}}},
+		{Version: "go1.13", Files: []File{{Os: "linux", Arch: "amd64", Kind: KindArchive}}},
+	}
+	l := FilterReleases(rels, &ListOptions{Os: "linux", Arch: "riscv64"})
+	if len(l) != 1 || l[0].Version != "go1.22.3" || len(l[0].Files) != 2 {
+		t.Fatalf("filter for linux/riscv64: got %v", l)
+	}
+	if l := FilterReleases(rels, &ListOptions{Os: "linux", Kind: KindArchive}); len(l) != 2 {
+		t.Fatalf("filter for linux archives: got %v", l)
+	}
+	if l := FilterReleases(rels, nil); len(l) != 2 {
+		t.Fatalf("filter without options: got %v", l)
+	}
 }
@@ -0,0 +1,135 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ArchiveEntry is an entry in a release file, as returned by ListArchive.
+type ArchiveEntry struct {
+	Name     string // As in the archive, e.g. "go/bin/go".
+	Type     string // EntryFile, EntryDir, EntrySymlink or EntryLink.
+	Size     int64  // For files.
+	Mode     os.FileMode
+	Linkname string `json:",omitempty"` // For links.
+}
+
+// ListArchive returns the entries of release file file, a .tar.gz, .zip or of
+// another format read as tar stream (see RegisterFormat), without extracting.
+// The include and exclude patterns of the client do not apply. A .tar.gz is
+// read while downloading, see FetchReader, a .zip is first downloaded to a
+// temporary file. The entries are only returned after the file has been
+// verified.
+func (c *Client) ListArchive(ctx context.Context, file File) ([]ArchiveEntry, error) {
+	format, err := lookupFormat(file.Filename)
+	if err != nil {
+		return nil, err
+	}
+	df, ok := format.(directFormat)
+	if !ok {
+		return c.listTgz(ctx, file)
+	} else if df.name == "zip" {
+		return c.listZip(ctx, file)
+	}
+	return nil, fmt.Errorf("listing %s files not supported", df.name)
+}
+
+func (c *Client) listTgz(ctx context.Context, file File) ([]ArchiveEntry, error) {
+	ar, err := c.FetchReader(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	defer ar.Close()
+	var l []ArchiveEntry
+	for {
+		h, err := ar.Next()
+		if err == io.EOF {
+			return l, nil
+		} else if err != nil {
+			return nil, err
+		}
+		e := ArchiveEntry{Name: h.Name, Mode: h.FileInfo().Mode()}
+		switch h.Typeflag {
+		case tar.TypeDir:
+			e.Type = EntryDir
+		case tar.TypeReg:
+			e.Type = EntryFile
+			e.Size = h.Size
+		case tar.TypeSymlink:
+			e.Type = EntrySymlink
+			e.Linkname = h.Linkname
+		case tar.TypeLink:
+			e.Type = EntryLink
+			e.Linkname = h.Linkname
+		default:
+			// Like extraction, which ignores other types, e.g. pax headers.
+			continue
+		}
+		l = append(l, e)
+	}
+}
+
+func (c *Client) listZip(ctx context.Context, file File) ([]ArchiveEntry, error) {
+	f, err := c.createTemp("goreleases-list")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		name := f.Name()
+		f.Close()
+		os.Remove(name)
+	}()
+	sum, _, err := c.download(ctx, file, f)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkSha256(file, sum); err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	r, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		return nil, fmt.Errorf("reading zip file: %v", err)
+	}
+	var l []ArchiveEntry
+	for _, zf := range r.File {
+		mode := zf.Mode()
+		e := ArchiveEntry{Name: zf.Name, Mode: mode}
+		switch {
+		case mode.IsDir() || strings.HasSuffix(zf.Name, "/"):
+			e.Type = EntryDir
+		case mode&os.ModeSymlink != 0:
+			e.Type = EntrySymlink
+			target, err := readZipLink(zf)
+			if err != nil {
+				return nil, fmt.Errorf("reading link %s: %v", zf.Name, err)
+			}
+			e.Linkname = target
+		default:
+			e.Type = EntryFile
+			e.Size = int64(zf.UncompressedSize64)
+		}
+		l = append(l, e)
+	}
+	return l, nil
+}
+
+// readZipLink returns the target of a symlink in a zip file, stored as its
+// contents.
+func readZipLink(zf *zip.File) (string, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	buf, err := io.ReadAll(io.LimitReader(rc, 4096))
+	return string(buf), err
+}
@@ -0,0 +1,104 @@
+package goreleases
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ListingCache caches release listings in memory, for Client.ListingCache. It
+// is safe for concurrent use and can be shared by clients: Listings younger
+// than the CacheTTL of the client are returned without requests, and of
+// concurrent listings that need a refresh, only one fetches from upstream
+// while the others wait for its result. The zero value is ready to use.
+type ListingCache struct {
+	mu      sync.Mutex
+	entries map[string]*listingEntry // By cache name, with hash of url.
+}
+
+type listingEntry struct {
+	time time.Time // Of fetched rels, zero if none yet.
+	rels []Release
+
+	// While refreshing, closed when done, with the error of the refresh.
+	refresh chan struct{}
+	err     error
+}
+
+// Clear removes all cached listings.
+func (lc *ListingCache) Clear() {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.entries = nil
+}
+
+// get returns the listing for name, calling fetch if the cached listing is not
+// younger than ttl, unless another call is already fetching. If that call
+// fails because its context ended, the waiting calls try again, with their own
+// context.
+func (lc *ListingCache) get(ctx context.Context, name string, ttl time.Duration, fetch func() ([]Release, error)) ([]Release, error) {
+	for {
+		lc.mu.Lock()
+		if lc.entries == nil {
+			lc.entries = map[string]*listingEntry{}
+		}
+		e := lc.entries[name]
+		if e == nil {
+			e = &listingEntry{}
+			lc.entries[name] = e
+		}
+		if !e.time.IsZero() && time.Since(e.time) < ttl {
+			rels := copyReleases(e.rels)
+			lc.mu.Unlock()
+			return rels, nil
+		}
+		if ch := e.refresh; ch != nil {
+			lc.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-ch:
+			}
+			lc.mu.Lock()
+			rels, err := copyReleases(e.rels), e.err
+			lc.mu.Unlock()
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			return rels, nil
+		}
+		ch := make(chan struct{})
+		e.refresh = ch
+		lc.mu.Unlock()
+
+		rels, err := fetch()
+
+		lc.mu.Lock()
+		e.refresh = nil
+		e.err = err
+		if err == nil {
+			e.time = time.Now()
+			e.rels = rels
+		}
+		close(ch)
+		lc.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		return copyReleases(rels), nil
+	}
+}
+
+// copyReleases returns a copy of rels, with copies of their files, so callers
+// can modify them.
+func copyReleases(rels []Release) []Release {
+	l := make([]Release, len(rels))
+	for i, rel := range rels {
+		rel.Files = append([]File(nil), rel.Files...)
+		l[i] = rel
+	}
+	return l
+}
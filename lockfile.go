@@ -0,0 +1,186 @@
+package goreleases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// LockFileName is the conventional name of a lock file.
+const LockFileName = "goreleases.lock"
+
+// LockFile pins a release file for each target, "<os>/<arch>", for
+// reproducible installs, see Sync. It is stored as JSON, like:
+//
+//	{
+//		"linux/amd64": {"version": "go1.22.3", "checksum": "sha256:8920ea52..."},
+//		"darwin/arm64": {"version": "go1.22.3", "checksum": "sha256:02abeab3..."}
+//	}
+type LockFile map[string]LockEntry
+
+// LockEntry is the pinned release file for a target in a LockFile.
+type LockEntry struct {
+	Version  string `json:"version"`
+	Checksum string `json:"checksum"` // Of the archive file, like File.Checksum, e.g. "sha256:<hex>".
+}
+
+// ReadLockFile reads and checks the lock file at path.
+func ReadLockFile(path string) (LockFile, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lf LockFile
+	if err := json.Unmarshal(buf, &lf); err != nil {
+		return nil, fmt.Errorf("parsing lock file: %v", err)
+	}
+	for target, e := range lf {
+		if goos, goarch, ok := strings.Cut(target, "/"); !ok || goos == "" || goarch == "" {
+			return nil, fmt.Errorf("bad target %q in lock file, expected <os>/<arch>", target)
+		}
+		if _, err := ParseVersion(strings.TrimPrefix(e.Version, "go")); err != nil {
+			return nil, fmt.Errorf("bad version %q for %s in lock file: %v", e.Version, target, err)
+		}
+		if alg, sum, ok := strings.Cut(e.Checksum, ":"); !ok || alg == "" || sum == "" {
+			return nil, fmt.Errorf("bad checksum %q for %s in lock file, expected <algorithm>:<hex>", e.Checksum, target)
+		}
+	}
+	return lf, nil
+}
+
+// Write atomically writes the lock file to path.
+func (lf LockFile) Write(path string) error {
+	buf, err := json.MarshalIndent(lf, "", "\t")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(buf, '\n')); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	// CreateTemp makes the file only readable by us, a lock file is for sharing.
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// Entry returns the entry for goos and goarch.
+func (lf LockFile) Entry(goos, goarch string) (LockEntry, bool) {
+	e, ok := lf[goos+"/"+goarch]
+	return e, ok
+}
+
+// Targets returns the targets in the lock file, sorted.
+func (lf LockFile) Targets() []string {
+	l := make([]string, 0, len(lf))
+	for target := range lf {
+		l = append(l, target)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// Lock returns a lock file pinning the archive files of the release selected by
+// version spec (see Resolve) for targets, each "<os>/<arch>". Without
+// targets, the host is pinned.
+func (c *Client) Lock(ctx context.Context, spec string, targets ...string) (LockFile, error) {
+	if len(targets) == 0 {
+		targets = []string{runtime.GOOS + "/" + runtime.GOARCH}
+	}
+	rels, err := c.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := Resolve(rels, spec)
+	if err != nil {
+		return nil, err
+	}
+	lf := LockFile{}
+	for _, target := range targets {
+		goos, goarch, ok := strings.Cut(target, "/")
+		if !ok {
+			return nil, fmt.Errorf("bad target %q, expected <os>/<arch>", target)
+		}
+		file, err := FindFile(rel, goos, goarch, KindArchive)
+		if err != nil {
+			return nil, fmt.Errorf("finding file for %s in %s: %v", target, rel.Version, err)
+		}
+		checksum := file.Checksum
+		if file.Sha256 != "" {
+			checksum = "sha256:" + file.Sha256
+		}
+		if checksum == "" {
+			return nil, fmt.Errorf("file %s has no checksum to pin", file.Filename)
+		}
+		lf[target] = LockEntry{rel.Version, checksum}
+	}
+	return lf, nil
+}
+
+// Sync converges the Manager root to the lock file at lockPath: The release
+// pinned for the host is installed in root/<version> if not already, and made
+// the active version, see Use. The release file must have the pinned
+// checksum, both in the listing and when downloaded. Other installs are kept.
+func (m *Manager) Sync(ctx context.Context, lockPath string) (InstallResult, error) {
+	lf, err := ReadLockFile(lockPath)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	e, ok := lf.Entry(runtime.GOOS, runtime.GOARCH)
+	if !ok {
+		return InstallResult{}, fmt.Errorf("lock file has no entry for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	c := m.client()
+	rels, err := c.ListAll(ctx)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	rel, err := FindRelease(rels, e.Version)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	r, err := c.installWith(ctx, []Release{rel}, rel.Version, m.Path(rel.Version), &InstallOptions{Permissions: m.Permissions, Checksum: e.Checksum})
+	if err != nil {
+		return r, err
+	}
+	if m.Hardlink && r.Action != InstallNone {
+		if _, err := Dedup(m.Root); err != nil {
+			return r, fmt.Errorf("deduplicating: %v", err)
+		}
+	}
+	if cur, err := m.Current(); err != nil {
+		return r, err
+	} else if cur != rel.Version || m.Shims {
+		return r, m.Use(rel.Version)
+	}
+	return r, nil
+}
+
+// Sync converges root, a Manager root, to the lock file at lockPath, see
+// Manager.Sync.
+func Sync(lockPath, root string, opts ...Option) (InstallResult, error) {
+	ctx, c, permissions := makeOptions(nil, opts)
+	m := &Manager{Root: root, Client: c, Permissions: permissions}
+	return m.Sync(ctx, lockPath)
+}
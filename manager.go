@@ -0,0 +1,212 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Manager manages installs of multiple releases side by side in a root
+// directory, and the active version. Releases are installed in the layout of
+// FetchSDK, in root/<version>, so an sdk directory can be used as root. The
+// active version is recorded in root/.current, and root/current is a symlink
+// pointing to it where symlinks are available.
+type Manager struct {
+	Root string
+
+	// Client for fetching releases. If nil, a zero Client is used.
+	Client *Client
+
+	// Permissions for extracted files, passed to Fetch.
+	Permissions *Permissions
+
+	// If set, Use also writes shim scripts go and gofmt in root/bin (go.cmd and
+	// gofmt.cmd on Windows) that run the commands of the active version. Useful
+	// where symlinks are not available.
+	Shims bool
+
+	// If set, Install replaces files identical to files of other installs with
+	// hard links, see Dedup.
+	Hardlink bool
+}
+
+// CurrentLink is the name of the symlink to the active version in a
+// Manager root.
+const CurrentLink = "current"
+
+// CurrentFile is the name of the file with the active version in a Manager
+// root, written by Use, also when the current symlink cannot be created.
+const CurrentFile = ".current"
+
+func (m *Manager) client() *Client {
+	if m.Client != nil {
+		return m.Client
+	}
+	return &Client{}
+}
+
+// Path returns the directory of an install of version, whether installed or
+// not.
+func (m *Manager) Path(version string) string {
+	return filepath.Join(m.Root, version)
+}
+
+// Install fetches file into root/<version>. The path of the install is
+// returned.
+func (m *Manager) Install(ctx context.Context, file File) (string, error) {
+	dir, err := m.client().FetchSDK(ctx, file, m.Root, m.Permissions)
+	if err != nil {
+		return dir, err
+	}
+	if m.Hardlink {
+		if _, err := Dedup(m.Root); err != nil {
+			return dir, fmt.Errorf("deduplicating: %v", err)
+		}
+	}
+	return dir, nil
+}
+
+// TipDir is the directory in a Manager root for the development version.
+const TipDir = "gotip"
+
+// InstallTip installs the development version at ref (a branch or commit,
+// default master) into root/gotip, building it with bootstrap, see FetchTip.
+// If the commit is already installed, nothing is done. The path of the
+// install is returned. The install can be activated with Use("gotip").
+func (m *Manager) InstallTip(ctx context.Context, ref, bootstrap string) (string, error) {
+	spec := "tip"
+	if ref != "" {
+		spec += "@" + ref
+	}
+	dir := m.Path(TipDir)
+	_, err := m.client().Install(ctx, spec, dir, &InstallOptions{Bootstrap: bootstrap})
+	return dir, err
+}
+
+// Installed returns the versions successfully installed, newest first.
+func (m *Manager) Installed() ([]string, error) {
+	entries, err := os.ReadDir(m.Root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var l []string
+	for _, e := range entries {
+		if e.IsDir() && SDKInstalled(m.Root, e.Name()) {
+			l = append(l, e.Name())
+		}
+	}
+	sortVersions(l)
+	return l, nil
+}
+
+// sortVersions sorts versions newest first. Unparsable versions are sorted
+// last, by name.
+func sortVersions(l []string) {
+	sort.SliceStable(l, func(i, j int) bool {
+		return CompareVersions(l[i], l[j]) > 0
+	})
+}
+
+// Use makes version, which must be installed, the active version, by
+// atomically replacing the current symlink. With Shims, the shim scripts are
+// atomically replaced too. Finally, CurrentFile is atomically replaced.
+func (m *Manager) Use(version string) error {
+	if !SDKInstalled(m.Root, version) {
+		return fmt.Errorf("version %s not installed", version)
+	}
+
+	tmp := filepath.Join(m.Root, "."+CurrentLink+"-new")
+	os.Remove(tmp)
+	if err := os.Symlink(version, tmp); err != nil {
+		if !m.Shims {
+			return fmt.Errorf("creating symlink: %v", err)
+		}
+	} else if err := os.Rename(tmp, filepath.Join(m.Root, CurrentLink)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replacing symlink: %v", err)
+	}
+
+	if m.Shims {
+		if err := m.writeShims(version); err != nil {
+			return fmt.Errorf("writing shims: %v", err)
+		}
+	}
+
+	tmp = filepath.Join(m.Root, CurrentFile+".new")
+	if err := os.WriteFile(tmp, []byte(version+"\n"), 0666); err != nil {
+		return fmt.Errorf("writing active version: %v", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(m.Root, CurrentFile)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replacing active version: %v", err)
+	}
+	return nil
+}
+
+func (m *Manager) writeShims(version string) error {
+	bindir := filepath.Join(m.Root, "bin")
+	if err := os.MkdirAll(bindir, 0777); err != nil {
+		return err
+	}
+	for _, cmd := range []string{"go", "gofmt"} {
+		target := filepath.Join(m.Path(version), "bin", cmd)
+		var name, script string
+		if runtime.GOOS == "windows" {
+			name = cmd + ".cmd"
+			script = fmt.Sprintf("@echo off\r\n\"%s.exe\" %%*\r\n", target)
+		} else {
+			name = cmd
+			script = fmt.Sprintf("#!/bin/sh\nexec '%s' \"$@\"\n", strings.ReplaceAll(target, "'", `'\''`))
+		}
+		tmp := filepath.Join(bindir, "."+name+"-new")
+		if err := os.WriteFile(tmp, []byte(script), 0777); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, filepath.Join(bindir, name)); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove removes the install of version, see Remove. The active version
+// cannot be removed.
+func (m *Manager) Remove(version string) error {
+	if cur, err := m.Current(); err != nil {
+		return err
+	} else if cur == version {
+		return fmt.Errorf("cannot remove active version %s", version)
+	}
+	return Remove(m.Path(version))
+}
+
+// Prune removes all but the keep newest installs, see Prune.
+func (m *Manager) Prune(keep int) ([]string, error) {
+	return Prune(m.Root, keep)
+}
+
+// Current returns the active version, or an empty string if none. It is read
+// from CurrentFile, or for roots without it, from the current symlink.
+func (m *Manager) Current() (string, error) {
+	if buf, err := os.ReadFile(filepath.Join(m.Root, CurrentFile)); err == nil {
+		return strings.TrimSpace(string(buf)), nil
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	target, err := os.Readlink(filepath.Join(m.Root, CurrentLink))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return filepath.Base(target), nil
+}
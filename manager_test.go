@@ -0,0 +1,178 @@
+package goreleases
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mjl-/goreleases/goreleasestest"
+)
+
+func TestManager(t *testing.T) {
+	m := Manager{Root: t.TempDir()}
+	for _, v := range []string{"go1.9.2", "go1.22.3", "go1.10"} {
+		if err := os.MkdirAll(m.Path(v), 0777); err != nil {
+			t.Fatalf("mkdir: %s", err)
+		}
+		if err := os.WriteFile(filepath.Join(m.Path(v), UnpackedMarker), nil, 0666); err != nil {
+			t.Fatalf("write marker: %s", err)
+		}
+	}
+	os.Mkdir(m.Path("go1.21.0"), 0777) // Without marker.
+
+	l, err := m.Installed()
+	if err != nil {
+		t.Fatalf("installed: %s", err)
+	}
+	if exp := []string{"go1.22.3", "go1.10", "go1.9.2"}; !reflect.DeepEqual(l, exp) {
+		t.Fatalf("got installed %v, expected %v", l, exp)
+	}
+
+	if err := m.Use("go1.21.0"); err == nil {
+		t.Fatalf("using incomplete install succeeded")
+	}
+	for _, v := range []string{"go1.10", "go1.22.3"} {
+		if err := m.Use(v); err != nil {
+			t.Fatalf("use %s: %s", v, err)
+		}
+		if cur, err := m.Current(); err != nil || cur != v {
+			t.Fatalf("current: got %q, %v, expected %s", cur, err, v)
+		}
+	}
+}
+
+func TestManagerShims(t *testing.T) {
+	src := newTestSource(t, "go1.22.2", "go1.22.3")
+	m := Manager{Root: t.TempDir(), Client: &Client{Source: src}, Shims: true}
+	for _, rel := range src.releases {
+		if _, err := m.Install(context.Background(), rel.Files[0]); err != nil {
+			t.Fatalf("install: %s", err)
+		}
+	}
+	// Make creating the symlink fail, as on systems without symlinks.
+	if err := os.MkdirAll(filepath.Join(m.Root, "."+CurrentLink+"-new", "x"), 0777); err != nil {
+		t.Fatalf("mkdir: %s", err)
+	}
+	if err := m.Use("go1.22.2"); err != nil {
+		t.Fatalf("use: %s", err)
+	}
+	if _, err := os.Lstat(filepath.Join(m.Root, CurrentLink)); !os.IsNotExist(err) {
+		t.Fatalf("current symlink created: %v", err)
+	}
+	if cur, err := m.Current(); err != nil || cur != "go1.22.2" {
+		t.Fatalf("current with shims only: got %q, %v", cur, err)
+	}
+	if removed, err := m.Prune(0); err != nil || !reflect.DeepEqual(removed, []string{"go1.22.3"}) {
+		t.Fatalf("prune: removed %v, %v", removed, err)
+	}
+	if err := m.Remove("go1.22.2"); err == nil {
+		t.Fatalf("removing active version succeeded")
+	}
+}
+
+func TestSync(t *testing.T) {
+	host := runtime.GOOS + "/" + runtime.GOARCH
+	var ok bool
+	for _, p := range goreleasestest.Platforms {
+		ok = ok || p == host
+	}
+	if !ok {
+		t.Skipf("no test release for %s", host)
+	}
+	srv := goreleasestest.NewServer("go1.22.3", "go1.21.10")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.BaseURL(), NoSignatures: true}
+
+	lf, err := c.Lock(context.Background(), "1.21")
+	if err != nil {
+		t.Fatalf("lock: %s", err)
+	}
+	lockPath := filepath.Join(t.TempDir(), LockFileName)
+	if err := lf.Write(lockPath); err != nil {
+		t.Fatalf("writing lock file: %s", err)
+	}
+	if e, ok := lf.Entry(runtime.GOOS, runtime.GOARCH); !ok || e.Version != "go1.21.10" {
+		t.Fatalf("lock entry for host: %v %v", e, ok)
+	}
+
+	root := t.TempDir()
+	r, err := Sync(lockPath, root, WithClient(c))
+	if err != nil || r.Action != InstallNew {
+		t.Fatalf("sync: %v %v", r.Action, err)
+	}
+	m := Manager{Root: root}
+	if cur, err := m.Current(); err != nil || cur != "go1.21.10" {
+		t.Fatalf("current after sync: %q %v", cur, err)
+	}
+	r, err = Sync(lockPath, root, WithClient(c))
+	if err != nil || r.Action != InstallNone {
+		t.Fatalf("second sync: %v %v", r.Action, err)
+	}
+
+	// A checksum in the listing different from the lock file is refused.
+	lf[host] = LockEntry{"go1.22.3", "sha256:" + strings.Repeat("0", 64)}
+	if err := lf.Write(lockPath); err != nil {
+		t.Fatalf("writing lock file: %s", err)
+	}
+	if _, err := Sync(lockPath, root, WithClient(c)); err == nil {
+		t.Fatalf("sync with wrong checksum succeeded")
+	}
+	if SDKInstalled(root, "go1.22.3") {
+		t.Fatalf("release with wrong checksum was installed")
+	}
+}
+
+func TestPlanApply(t *testing.T) {
+	src := newTestSource(t, "go1.22.2")
+	m := &Manager{Root: t.TempDir(), Client: &Client{Source: src}}
+	ctx := context.Background()
+
+	desired := DesiredState{Current: "1.22", Exclusive: true, Os: "linux", Arch: "amd64"}
+	p, err := m.Plan(ctx, desired)
+	if err != nil {
+		t.Fatalf("plan: %s", err)
+	}
+	if exp := "+ install go1.22.2 (go1.22.2.linux-amd64.tar.gz)\n~ use go1.22.2\n"; p.String() != exp {
+		t.Fatalf("got plan %q, expected %q", p.String(), exp)
+	}
+	// E.g. a plan from JSON without file.
+	np := &Plan{Actions: []PlanAction{{Action: PlanInstall, Version: "go1.22.2"}}}
+	if s := np.String(); s != "+ install go1.22.2\n" {
+		t.Fatalf("got plan without file %q", s)
+	}
+	if err := m.Apply(ctx, p); err != nil {
+		t.Fatalf("apply: %s", err)
+	}
+	if err := m.Apply(ctx, p); err != ErrStalePlan {
+		t.Fatalf("applying again: got %v, expected ErrStalePlan", err)
+	}
+	if p, err := m.Plan(ctx, desired); err != nil || !p.Empty() {
+		t.Fatalf("plan after apply: %v %v", p, err)
+	}
+
+	src.addRelease(t, "go1.22.3")
+	p, err = m.Plan(ctx, desired)
+	if err != nil {
+		t.Fatalf("plan: %s", err)
+	}
+	var actions []string
+	for _, a := range p.Actions {
+		actions = append(actions, a.Action+" "+a.Version)
+	}
+	if exp := []string{"install go1.22.3", "use go1.22.3", "remove go1.22.2"}; !reflect.DeepEqual(actions, exp) {
+		t.Fatalf("got actions %v, expected %v", actions, exp)
+	}
+	if err := m.Apply(ctx, p); err != nil {
+		t.Fatalf("apply: %s", err)
+	}
+	if l, err := m.Installed(); err != nil || !reflect.DeepEqual(l, []string{"go1.22.3"}) {
+		t.Fatalf("installed after apply: %v %v", l, err)
+	}
+	if cur, err := m.Current(); err != nil || cur != "go1.22.3" {
+		t.Fatalf("current after apply: %q %v", cur, err)
+	}
+}
@@ -0,0 +1,141 @@
+package goreleases
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestFile is the name of the install manifest, written in the installs
+// created by FetchSDK and Manager.
+const ManifestFile = ".goreleases-manifest.json"
+
+// Manifest describes an install, with the release file it was extracted from
+// and the extracted entries.
+type Manifest struct {
+	Version  string
+	Filename string // Of the release file.
+	Sha256   string // Of the release file.
+	Entries  []ManifestEntry
+
+	// Provenance of the release file, if verified with Client.VerifyProvenance.
+	Provenance *Provenance `json:",omitempty"`
+
+	// Permissions the entries were extracted with, if any, e.g. for a system
+	// install. Used by Repair.
+	Permissions *Permissions `json:",omitempty"`
+}
+
+// Types of manifest entries.
+const (
+	EntryFile    = "file"
+	EntryDir     = "dir"
+	EntrySymlink = "symlink"
+	EntryLink    = "link" // Hard link.
+)
+
+// ManifestEntry is an extracted file, directory or link.
+type ManifestEntry struct {
+	Name     string // Relative to the install directory, with slashes, e.g. "bin/go".
+	Type     string // EntryFile, EntryDir, EntrySymlink, EntryLink.
+	Size     int64  `json:",omitempty"` // For files.
+	Sha256   string `json:",omitempty"` // For files.
+	Linkname string `json:",omitempty"` // For links, as in the archive.
+}
+
+// ReadManifest reads the manifest from install directory dir.
+func ReadManifest(dir string) (*Manifest, error) {
+	buf, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %v", err)
+	}
+	return &m, nil
+}
+
+func writeManifest(dir string, m *Manifest) error {
+	buf, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ManifestFile), buf, 0666)
+}
+
+// Remove removes an install created by FetchSDK or Manager from directory
+// dir. Only the entries listed in the manifest are removed, and directories
+// that are empty afterwards. If other files remain, an error is returned and
+// the manifest is kept. Directories without manifest are not touched.
+func Remove(dir string) error {
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return fmt.Errorf("reading manifest: %v", err)
+	}
+
+	var dirs []string
+	for _, e := range m.Entries {
+		if strings.HasPrefix(e.Name, "/") || strings.Contains("/"+e.Name+"/", "/../") {
+			return fmt.Errorf("bad name %q in manifest", e.Name)
+		}
+		p := filepath.Join(dir, filepath.FromSlash(e.Name))
+		if e.Type == EntryDir {
+			dirs = append(dirs, p)
+			continue
+		}
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	os.Remove(filepath.Join(dir, UnpackedMarker))
+	os.Remove(filepath.Join(dir, OriginFile))
+
+	// Remove deepest directories first.
+	sort.Slice(dirs, func(i, j int) bool {
+		return len(dirs[i]) > len(dirs[j])
+	})
+	for _, d := range dirs {
+		os.Remove(d)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 1 {
+		return fmt.Errorf("%d unknown files remain in %s, not removing", len(entries)-1, dir)
+	}
+	if err := os.Remove(filepath.Join(dir, ManifestFile)); err != nil {
+		return err
+	}
+	return os.Remove(dir)
+}
+
+// Prune removes all but the keep newest installs from directory root, with
+// the layout of FetchSDK and Manager. The active version of a Manager in root
+// is never removed. The removed versions are returned.
+func Prune(root string, keep int) ([]string, error) {
+	m := Manager{Root: root}
+	versions, err := m.Installed()
+	if err != nil {
+		return nil, err
+	}
+	current, err := m.Current()
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for i, v := range versions {
+		if i < keep || v == current {
+			continue
+		}
+		if err := Remove(m.Path(v)); err != nil {
+			return removed, fmt.Errorf("removing %s: %v", v, err)
+		}
+		removed = append(removed, v)
+	}
+	return removed, nil
+}
@@ -0,0 +1,190 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func testFile(version string, data []byte) File {
+	return File{
+		Filename: version + ".linux-amd64.tar.gz",
+		Os:       "linux",
+		Arch:     "amd64",
+		Version:  version,
+		Sha256:   fmt.Sprintf("%x", sha256.Sum256(data)),
+		Size:     int64(len(data)),
+		Kind:     KindArchive,
+	}
+}
+
+func TestRemovePrune(t *testing.T) {
+	src := &memSource{}
+	c := &Client{Source: src}
+	m := Manager{Root: t.TempDir(), Client: c, Hardlink: true}
+	for _, v := range []string{"go1.21.10", "go1.22.2", "go1.22.3"} {
+		file := src.addRelease(t, v)
+		dir, err := m.Install(context.Background(), file)
+		if err != nil {
+			t.Fatalf("install %s: %s", v, err)
+		}
+		man, err := ReadManifest(dir)
+		if err != nil {
+			t.Fatalf("reading manifest: %s", err)
+		}
+		if man.Version != v || len(man.Entries) != 3 {
+			t.Fatalf("unexpected manifest %#v", man)
+		}
+	}
+
+	fi0, err := os.Stat(filepath.Join(m.Path("go1.22.2"), "bin", "go"))
+	if err != nil {
+		t.Fatalf("stat: %s", err)
+	}
+	fi1, err := os.Stat(filepath.Join(m.Path("go1.22.3"), "bin", "go"))
+	if err != nil {
+		t.Fatalf("stat: %s", err)
+	}
+	if !os.SameFile(fi0, fi1) {
+		t.Fatalf("identical files not hard linked")
+	}
+
+	// Unknown files prevent removal.
+	extra := filepath.Join(m.Path("go1.22.2"), "bin", "extra")
+	if err := os.WriteFile(extra, nil, 0666); err != nil {
+		t.Fatalf("write: %s", err)
+	}
+	if err := m.Remove("go1.22.2"); err == nil {
+		t.Fatalf("remove with unknown file succeeded")
+	}
+	os.Remove(extra)
+	if err := m.Remove("go1.22.2"); err != nil {
+		t.Fatalf("remove: %s", err)
+	}
+	if _, err := os.Stat(m.Path("go1.22.2")); !os.IsNotExist(err) {
+		t.Fatalf("install still present after remove: %v", err)
+	}
+
+	if err := m.Use("go1.21.10"); err != nil {
+		t.Fatalf("use: %s", err)
+	}
+	removed, err := m.Prune(0)
+	if err != nil {
+		t.Fatalf("prune: %s", err)
+	}
+	if len(removed) != 1 || removed[0] != "go1.22.3" {
+		t.Fatalf("pruned %v, expected go1.22.3", removed)
+	}
+}
+
+func TestReproducible(t *testing.T) {
+	mtime := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	for _, h := range []*tar.Header{
+		{Typeflag: tar.TypeDir, Name: "go/", Mode: 0755, ModTime: mtime},
+		{Typeflag: tar.TypeDir, Name: "go/src/", Mode: 0755, ModTime: mtime},
+		{Typeflag: tar.TypeReg, Name: "go/src/x.go", Mode: 0644, ModTime: mtime},
+	} {
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tgz := b.Bytes()
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}, Reproducible: true}
+	var digests []string
+	for i := 0; i < 2; i++ {
+		dir := filepath.Join(t.TempDir(), "go")
+		if err := c.install(context.Background(), file, dir, nil); err != nil {
+			t.Fatalf("install: %v", err)
+		}
+		for _, name := range []string{".", "src", ManifestFile} {
+			if fi, err := os.Stat(filepath.Join(dir, name)); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			} else if !fi.ModTime().Equal(mtime) {
+				t.Fatalf("%s: got mtime %v, expected %v", name, fi.ModTime(), mtime)
+			}
+		}
+		digest, err := TreeDigest(dir)
+		if err != nil {
+			t.Fatalf("tree digest: %v", err)
+		}
+		digests = append(digests, digest)
+	}
+	if digests[0] != digests[1] {
+		t.Fatalf("reproducible installs have different digests %s and %s", digests[0], digests[1])
+	}
+}
+
+func TestRepair(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/go": "binary", "go/src/a.go": "package a\n"})
+	f := testFile("go1.22.3", tgz)
+	rel := Release{Version: "go1.22.3", Stable: true, Files: []File{f}}
+	src := &memSource{files: map[string][]byte{f.Filename: tgz}}
+	src.setReleases([]Release{rel})
+	c := Client{Source: src}
+	dir := filepath.Join(t.TempDir(), "go")
+	if _, err := c.Install(context.Background(), "1.22", dir, &InstallOptions{Os: "linux", Arch: "amd64"}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	// Same size, other contents.
+	if err := os.WriteFile(filepath.Join(dir, "bin", "go"), []byte("BINARY"), 0755); err != nil {
+		t.Fatalf("corrupting: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "src")); err != nil {
+		t.Fatalf("removing: %v", err)
+	}
+	names, err := c.Repair(context.Background(), dir, rel)
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if fmt.Sprint(names) != "[src bin/go src/a.go]" {
+		t.Fatalf("repaired %v", names)
+	}
+	for name, exp := range map[string]string{"bin/go": "binary", "src/a.go": "package a\n"} {
+		if buf, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name))); err != nil || string(buf) != exp {
+			t.Fatalf("%s after repair: %q, %v", name, buf, err)
+		}
+	}
+	if names, err := c.Repair(context.Background(), dir, rel); err != nil || len(names) != 0 {
+		t.Fatalf("second repair: %v, %v", names, err)
+	}
+
+	// Entries are restored with the permissions of the install.
+	if runtime.GOOS == "windows" {
+		return
+	}
+	perms := &Permissions{os.Getuid(), os.Getgid(), 0750}
+	pdir := filepath.Join(t.TempDir(), "go")
+	if _, err := c.Install(context.Background(), "1.22", pdir, &InstallOptions{Os: "linux", Arch: "amd64", Permissions: perms}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(pdir, "src")); err != nil {
+		t.Fatalf("removing: %v", err)
+	}
+	if _, err := c.Repair(context.Background(), pdir, rel); err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	for name, mode := range map[string]os.FileMode{"src": os.ModeDir | 0750, "src/a.go": 0640} {
+		if fi, err := os.Stat(filepath.Join(pdir, filepath.FromSlash(name))); err != nil || fi.Mode() != mode {
+			t.Fatalf("%s after repair: %v, %v, expected mode %v", name, fi, err, mode)
+		}
+	}
+}
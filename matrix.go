@@ -0,0 +1,74 @@
+package goreleases
+
+import (
+	"sort"
+)
+
+// MatrixOptions configure Matrix.
+type MatrixOptions struct {
+	// Number of minor versions, newest first. If zero, 2 is used, the number of
+	// supported minors.
+	Minors int
+
+	// If set, the latest prerelease of a minor newer than the latest stable
+	// minor is added, e.g. go1.23rc1.
+	Prerelease bool
+}
+
+// MatrixEntry is a version to test against.
+type MatrixEntry struct {
+	Version string `json:"version"` // Full version, e.g. "go1.22.3".
+	Go      string `json:"go"`      // Without "go" prefix, e.g. "1.22.3", as used by setup actions.
+	Minor   string `json:"minor"`   // E.g. "1.22".
+	Stable  bool   `json:"stable"`
+}
+
+// Matrix returns the versions a project should test against, for generating
+// CI matrices: The latest patch release of the newest opts.Minors minor
+// versions with stable releases, newest first, optionally preceded by a
+// prerelease. The result can be marshalled to JSON.
+func Matrix(releases []Release, opts MatrixOptions) []MatrixEntry {
+	minors := opts.Minors
+	if minors <= 0 {
+		minors = 2
+	}
+
+	// Latest patch per minor, and the latest prerelease.
+	best := map[string]Version{}
+	var pre *Version
+	for _, rel := range releases {
+		v, err := ParseVersion(rel.Version)
+		if err != nil {
+			continue
+		}
+		if v.Pre != "" {
+			if pre == nil || v.Compare(*pre) > 0 {
+				pre = &v
+			}
+		} else if rel.Stable {
+			if b, ok := best[v.MinorString()]; !ok || v.Compare(b) > 0 {
+				best[v.MinorString()] = v
+			}
+		}
+	}
+	var latest []Version
+	for _, v := range best {
+		latest = append(latest, v)
+	}
+	sort.Slice(latest, func(i, j int) bool {
+		return latest[i].Compare(latest[j]) > 0
+	})
+	if len(latest) > minors {
+		latest = latest[:minors]
+	}
+	if opts.Prerelease && pre != nil && (len(latest) == 0 || pre.Compare(latest[0]) > 0) {
+		latest = append([]Version{*pre}, latest...)
+	}
+
+	l := make([]MatrixEntry, len(latest))
+	for i, v := range latest {
+		s := v.String()
+		l[i] = MatrixEntry{s, s[len("go"):], v.MinorString(), v.Pre == ""}
+	}
+	return l
+}
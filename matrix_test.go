@@ -0,0 +1,39 @@
+package goreleases
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatrix(t *testing.T) {
+	rels := []Release{
+		{Version: "go1.23rc1"},
+		{Version: "go1.22.3", Stable: true},
+		{Version: "go1.22.2", Stable: true},
+		{Version: "go1.22rc2"},
+		{Version: "go1.21.10", Stable: true},
+		{Version: "go1.20.14", Stable: true},
+	}
+	versions := func(l []MatrixEntry) []string {
+		var r []string
+		for _, e := range l {
+			r = append(r, e.Version)
+		}
+		return r
+	}
+	if got, exp := versions(Matrix(rels, MatrixOptions{})), []string{"go1.22.3", "go1.21.10"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+	l := Matrix(rels, MatrixOptions{Minors: 3, Prerelease: true})
+	if got, exp := versions(l), []string{"go1.23rc1", "go1.22.3", "go1.21.10", "go1.20.14"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+	if e := l[1]; e.Go != "1.22.3" || e.Minor != "1.22" || !e.Stable {
+		t.Fatalf("unexpected entry %#v", e)
+	}
+
+	// No prerelease if the final release is out.
+	if got, exp := versions(Matrix(rels[1:], MatrixOptions{Minors: 1, Prerelease: true})), []string{"go1.22.3"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+}
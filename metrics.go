@@ -0,0 +1,114 @@
+package goreleases
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Metrics collects metrics of a Proxy and of SyncMirror, for monitoring
+// internal Go download infrastructure. It is an http.Handler serving the
+// metrics in the Prometheus text format, e.g. at /metrics. The zero value is
+// ready to use, and can be shared between a Proxy and SyncMirror calls.
+type Metrics struct {
+	mu sync.Mutex
+
+	cacheHits       int64
+	cacheMisses     int64
+	upstreamBytes   int64
+	verifyFailures  int64
+	syncs           int64
+	syncFailures    int64
+	lastSync        time.Time // Of last successful sync.
+	lastSyncFailed  int       // Files that failed in the last sync.
+	lastSyncChanged int       // Files downloaded or replaced in the last sync.
+}
+
+func (m *Metrics) add(p *int64, n int64) {
+	m.mu.Lock()
+	*p += n
+	m.mu.Unlock()
+}
+
+func (m *Metrics) cacheHit() {
+	if m != nil {
+		m.add(&m.cacheHits, 1)
+	}
+}
+
+func (m *Metrics) cacheMiss() {
+	if m != nil {
+		m.add(&m.cacheMisses, 1)
+	}
+}
+
+func (m *Metrics) upstream(n int64) {
+	if m != nil {
+		m.add(&m.upstreamBytes, n)
+	}
+}
+
+func (m *Metrics) verifyFailed() {
+	if m != nil {
+		m.add(&m.verifyFailures, 1)
+	}
+}
+
+// synced records the end of a SyncMirror.
+func (m *Metrics) synced(r SyncMirrorReport, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.syncs++
+	if err != nil {
+		m.syncFailures++
+	} else {
+		m.lastSync = time.Now()
+	}
+	m.lastSyncFailed = len(r.Failed)
+	m.lastSyncChanged = len(r.Downloaded) + len(r.Replaced)
+}
+
+// WriteTo writes the metrics in the Prometheus text format to w.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	var lastSync, lag float64
+	if !m.lastSync.IsZero() {
+		lastSync = float64(m.lastSync.UnixNano()) / 1e9
+		lag = time.Since(m.lastSync).Seconds()
+	}
+	metrics := []struct {
+		name, typ, help string
+		value           interface{}
+	}{
+		{"goreleases_proxy_cache_hits_total", "counter", "Release file requests to the proxy served from the cache.", m.cacheHits},
+		{"goreleases_proxy_cache_misses_total", "counter", "Release file requests to the proxy that required fetching from upstream.", m.cacheMisses},
+		{"goreleases_upstream_bytes_total", "counter", "Bytes of release files downloaded from upstream.", m.upstreamBytes},
+		{"goreleases_verification_failures_total", "counter", "Downloads that failed verification of checksum, signature, pin or cross-verification.", m.verifyFailures},
+		{"goreleases_mirror_syncs_total", "counter", "Mirror synchronizations.", m.syncs},
+		{"goreleases_mirror_sync_failures_total", "counter", "Mirror synchronizations that failed.", m.syncFailures},
+		{"goreleases_mirror_last_sync_timestamp_seconds", "gauge", "Time of the last successful mirror synchronization, zero if none.", lastSync},
+		{"goreleases_mirror_sync_lag_seconds", "gauge", "Seconds since the last successful mirror synchronization, zero if none.", lag},
+		{"goreleases_mirror_last_sync_failed_files", "gauge", "Files that failed in the last mirror synchronization.", m.lastSyncFailed},
+		{"goreleases_mirror_last_sync_changed_files", "gauge", "Files downloaded or replaced by the last mirror synchronization.", m.lastSyncChanged},
+	}
+	m.mu.Unlock()
+
+	var b bytes.Buffer
+	for _, x := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", x.name, x.help, x.name, x.typ, x.name, x.value)
+	}
+	n, err := w.Write(b.Bytes())
+	return int64(n), err
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
@@ -0,0 +1,177 @@
+package goreleases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// MicrosoftSource is a ReleaseSource for the Microsoft build of Go. For each
+// configured minor version, the latest release is listed from its
+// assets.json manifest. Files only have a sha256 checksum, no pgp signature.
+//
+// Use as Client.Source to list and fetch with a Client.
+type MicrosoftSource struct {
+	// Minor versions to list, e.g. "1.22".
+	Versions []string
+
+	// URL with the manifests, "go<version>.assets.json" is added. If empty,
+	// DefaultMicrosoftBaseURL is used.
+	BaseURL string
+
+	// HTTPClient is used for requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	mu   sync.Mutex
+	urls map[string]string // Filename to URL, from manifests.
+}
+
+// DefaultMicrosoftBaseURL is the location of the manifests of the latest
+// Microsoft builds of Go.
+const DefaultMicrosoftBaseURL = "https://aka.ms/golang/release/latest/"
+
+// msAssets is the assets.json manifest for a Microsoft Go build.
+type msAssets struct {
+	Version string `json:"version"` // E.g. "1.22.3-1".
+	Arches  []struct {
+		Env struct {
+			GOOS   string `json:"GOOS"`
+			GOARCH string `json:"GOARCH"`
+			GOARM  string `json:"GOARM"`
+		} `json:"env"`
+		URL    string `json:"url"`
+		Sha256 string `json:"sha256"`
+	} `json:"arches"`
+	GoSrcURL    string `json:"goSrcURL"`
+	GoSrcSha256 string `json:"goSrcSHA256"`
+}
+
+// List returns the latest release for each configured version. Parameter all is
+// ignored, older releases are not available in the manifests.
+func (s *MicrosoftSource) List(ctx context.Context, all bool) ([]Release, error) {
+	var rels []Release
+	urls := map[string]string{}
+	for _, v := range s.Versions {
+		a, err := s.assets(ctx, v)
+		if err != nil {
+			return nil, fmt.Errorf("fetching manifest for %s: %v", v, err)
+		}
+		rel := Release{Version: "go" + a.Version, Stable: true}
+		add := func(url, goos, goarch, sha256, kind string) {
+			f := File{
+				Filename: path.Base(url),
+				Os:       goos,
+				Arch:     goarch,
+				Version:  rel.Version,
+				Sha256:   sha256,
+				Kind:     kind,
+			}
+			rel.Files = append(rel.Files, f)
+			urls[f.Filename] = url
+		}
+		if a.GoSrcURL != "" {
+			add(a.GoSrcURL, "", "", a.GoSrcSha256, KindSource)
+		}
+		for _, arch := range a.Arches {
+			goarch := arch.Env.GOARCH
+			if goarch == "arm" && arch.Env.GOARM != "" {
+				// Match upstream naming, so FindFile works the same.
+				goarch = "armv" + arch.Env.GOARM + "l"
+			}
+			if !strings.HasSuffix(arch.URL, ".tar.gz") && !strings.HasSuffix(arch.URL, ".zip") {
+				continue
+			}
+			add(arch.URL, arch.Env.GOOS, goarch, arch.Sha256, KindArchive)
+		}
+		rels = append(rels, rel)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.urls == nil {
+		s.urls = map[string]string{}
+	}
+	for k, v := range urls {
+		s.urls[k] = v
+	}
+	return rels, nil
+}
+
+func (s *MicrosoftSource) assets(ctx context.Context, version string) (*msAssets, error) {
+	baseURL := s.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultMicrosoftBaseURL
+	}
+	resp, err := s.get(ctx, baseURL+"go"+version+".assets.json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var a msAssets
+	if err := json.NewDecoder(resp.Body).Decode(&a); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %v", err)
+	}
+	if a.Version == "" {
+		return nil, fmt.Errorf("manifest without version")
+	}
+	return &a, nil
+}
+
+// Open opens a file from a release returned by List. If the file is not known,
+// the releases are listed again.
+func (s *MicrosoftSource) Open(ctx context.Context, file File) (io.ReadCloser, error) {
+	url, err := s.URL(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// URL returns the download URL of file, from the manifests. If the file is
+// not known, the releases are listed again.
+func (s *MicrosoftSource) URL(ctx context.Context, file File) (string, error) {
+	s.mu.Lock()
+	url, ok := s.urls[file.Filename]
+	s.mu.Unlock()
+	if !ok {
+		if _, err := s.List(ctx, false); err != nil {
+			return "", err
+		}
+		s.mu.Lock()
+		url, ok = s.urls[file.Filename]
+		s.mu.Unlock()
+		if !ok {
+			return "", fmt.Errorf("file %q not found in manifests", file.Filename)
+		}
+	}
+	return url, nil
+}
+
+func (s *MicrosoftSource) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	hc := s.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s, status %v, expected 200 OK", url, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,59 @@
+package goreleases
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMicrosoftSource(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n"})
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest/go1.22.assets.json":
+			fmt.Fprintf(w, `{"version": "1.22.3-1", "goSrcURL": "%[1]s/files/go1.22.3-1.src.tar.gz", "goSrcSHA256": "abcd", "arches": [
+	{"env": {"GOOS": "linux", "GOARCH": "amd64"}, "url": "%[1]s/files/go1.22.3-1.linux-amd64.tar.gz", "sha256": "%[2]x"},
+	{"env": {"GOOS": "linux", "GOARCH": "arm", "GOARM": "6"}, "url": "%[1]s/files/go1.22.3-1.linux-armv6l.tar.gz", "sha256": "ef01"},
+	{"env": {"GOOS": "windows", "GOARCH": "amd64"}, "url": "%[1]s/files/go1.22.3-1.windows-amd64.msi", "sha256": "2345"}
+]}`, srv.URL, sha256.Sum256(tgz))
+		case "/files/go1.22.3-1.linux-amd64.tar.gz":
+			w.Write(tgz)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	src := &MicrosoftSource{Versions: []string{"1.22"}, BaseURL: srv.URL + "/latest/"}
+	ctx := context.Background()
+	rels, err := src.List(ctx, true)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(rels) != 1 || rels[0].Version != "go1.22.3-1" || len(rels[0].Files) != 3 {
+		t.Fatalf("unexpected releases %v", rels)
+	}
+	if _, err := rels[0].FindFile("linux", "armv6l", KindArchive); err != nil {
+		t.Fatalf("finding arm file: %v", err)
+	}
+	file, err := rels[0].FindFile("linux", "amd64", KindArchive)
+	if err != nil {
+		t.Fatalf("finding file: %v", err)
+	}
+
+	// A new source lists again to find the file.
+	c := Client{Source: &MicrosoftSource{Versions: []string{"1.22"}, BaseURL: srv.URL + "/latest/"}}
+	if err := c.Fetch(ctx, file, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if _, err := src.URL(ctx, File{Filename: "unknown.tar.gz"}); err == nil {
+		t.Fatalf("url for unknown file: expected error")
+	}
+	if _, err := (&MicrosoftSource{Versions: []string{"1.21"}, BaseURL: srv.URL + "/latest/"}).List(ctx, false); err == nil {
+		t.Fatalf("list with missing manifest: expected error")
+	}
+}
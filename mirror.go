@@ -0,0 +1,207 @@
+package goreleases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// MirrorIndex is the file in a mirror created by Mirror with the JSON
+// listing of the mirrored releases. Static web servers serve it for the
+// directory, ignoring the "?mode=json" query string, so a Client with BaseURL
+// set to the mirror lists the mirrored releases, for both ListSupported and
+// ListAll.
+const MirrorIndex = "index.html"
+
+// Mirror creates a mirror in directory dst with the files of releases,
+// laid out like the upstream server: Files with their .asc signatures (if
+// available) and .sha256 checksums, and the listing in MirrorIndex. Files
+// already present with the expected checksum are not downloaded again.
+// Releases already in the listing of the mirror that are not in releases are
+// kept in the listing.
+func (c *Client) Mirror(ctx context.Context, dst string, releases ...Release) error {
+	if err := os.MkdirAll(dst, 0777); err != nil {
+		return err
+	}
+	for _, rel := range releases {
+		if _, err := c.FetchAll(ctx, rel, dst, 4); err != nil {
+			return fmt.Errorf("mirroring %s: %v", rel.Version, err)
+		}
+	}
+	return mergeMirrorIndex(dst, releases)
+}
+
+// mergeMirrorIndex writes the listing of the mirror in dst with releases, and
+// the releases already in the listing.
+func mergeMirrorIndex(dst string, releases []Release) error {
+	l := append([]Release{}, releases...)
+	if old, err := readMirrorIndex(dst); err == nil {
+		have := map[string]bool{}
+		for _, rel := range releases {
+			have[rel.Version] = true
+		}
+		for _, rel := range old {
+			if !have[rel.Version] {
+				l = append(l, rel)
+			}
+		}
+	}
+	SortReleases(l)
+	return writeMirrorIndex(dst, l)
+}
+
+func readMirrorIndex(dir string) ([]Release, error) {
+	buf, err := os.ReadFile(filepath.Join(dir, MirrorIndex))
+	if err != nil {
+		return nil, err
+	}
+	return parseReleases(buf)
+}
+
+func writeMirrorIndex(dir string, releases []Release) error {
+	if releases == nil {
+		releases = []Release{}
+	}
+	buf, err := json.MarshalIndent(releases, "", " ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, "."+MirrorIndex+"-new")
+	if err := os.WriteFile(tmp, buf, 0666); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, MirrorIndex)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// SyncMirrorOptions are options for SyncMirror.
+type SyncMirrorOptions struct {
+	All bool // Mirror all releases, not only supported releases.
+
+	// Maximum number of concurrent downloads. If zero, 4.
+	Concurrency int
+
+	// If set, the checksum of all files present in the mirror is verified.
+	// Otherwise, files listed in the mirror listing with the same checksum and
+	// with the expected size are assumed to be unchanged.
+	Verify bool
+
+	// If set, the result of the sync, upstream bytes and verification failures
+	// are recorded, e.g. for the sync lag.
+	Metrics *Metrics
+}
+
+// SyncMirrorReport describes what SyncMirror changed.
+type SyncMirrorReport struct {
+	NewReleases []string         // Versions not in the mirror listing before.
+	Downloaded  []File           // Files that were missing.
+	Replaced    []File           // Files that were present with another checksum.
+	Unchanged   int              // Number of files already present.
+	Failed      map[string]error // By filename.
+}
+
+// SyncMirror updates the mirror in directory dst, as created by Mirror, with
+// the upstream releases. Only files that are missing or do not match are
+// downloaded, see SyncMirrorOptions.Verify. The listing of the mirror is only
+// updated if all files were synchronized successfully.
+//
+// A report is always returned. If any file failed, an error is returned as
+// well.
+func (c *Client) SyncMirror(ctx context.Context, dst string, opts *SyncMirrorOptions) (SyncMirrorReport, error) {
+	var o SyncMirrorOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Metrics != nil {
+		mc := *c
+		mc.metrics = o.Metrics
+		r, err := mc.syncMirror(ctx, dst, o)
+		o.Metrics.synced(r, err)
+		return r, err
+	}
+	return c.syncMirror(ctx, dst, o)
+}
+
+func (c *Client) syncMirror(ctx context.Context, dst string, o SyncMirrorOptions) (SyncMirrorReport, error) {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	r := SyncMirrorReport{Failed: map[string]error{}}
+	rels, err := c.List(ctx, o.All)
+	if err != nil {
+		return r, err
+	}
+	if err := os.MkdirAll(dst, 0777); err != nil {
+		return r, err
+	}
+
+	// Checksums of files in the current listing of the mirror.
+	old, _ := readMirrorIndex(dst)
+	listed := map[string]string{}
+	known := map[string]bool{}
+	for _, rel := range old {
+		known[rel.Version] = true
+		for _, f := range rel.Files {
+			listed[f.Filename] = f.Sha256
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, o.Concurrency)
+	var nfiles int
+	for _, rel := range rels {
+		if !known[rel.Version] {
+			r.NewReleases = append(r.NewReleases, rel.Version)
+		}
+		for _, f := range rel.Files {
+			nfiles++
+			p := filepath.Join(dst, f.Filename)
+			fi, err := os.Stat(p)
+			exists := err == nil
+			if exists && !o.Verify && listed[f.Filename] == f.Sha256 && (f.Size == 0 || fi.Size() == f.Size) {
+				r.Unchanged++
+				continue
+			}
+
+			f := f
+			if err := acquire(ctx, sem); err != nil {
+				mu.Lock()
+				r.Failed[f.Filename] = err
+				mu.Unlock()
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				present, err := c.fetchAllFile(ctx, f, dst)
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err != nil:
+					r.Failed[f.Filename] = err
+				case present:
+					r.Unchanged++
+				case exists:
+					r.Replaced = append(r.Replaced, f)
+				default:
+					r.Downloaded = append(r.Downloaded, f)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	if len(r.Failed) > 0 {
+		return r, fmt.Errorf("%d of %d files failed", len(r.Failed), nfiles)
+	}
+	return r, mergeMirrorIndex(dst, rels)
+}
@@ -0,0 +1,134 @@
+package goreleases
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	src := newTestSource(t, "go1.22.3")
+	rel := src.releases[0]
+
+	dir := t.TempDir()
+	c := Client{Source: src}
+	if err := c.Mirror(context.Background(), dir, rel); err != nil {
+		t.Fatalf("mirror: %s", err)
+	}
+
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+	mc := Client{BaseURL: srv.URL + "/", NoSignatures: true}
+	rels, err := mc.ListSupported(context.Background())
+	if err != nil {
+		t.Fatalf("listing mirror: %s", err)
+	}
+	if len(rels) != 1 || rels[0].Version != "go1.22.3" {
+		t.Fatalf("unexpected releases from mirror: %v", rels)
+	}
+	if err := mc.Fetch(context.Background(), rels[0].Files[0], t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch from mirror: %s", err)
+	}
+}
+
+func TestSyncMirror(t *testing.T) {
+	src := &memSource{}
+	f := src.addRelease(t, "go1.22.2")
+	dir := t.TempDir()
+	c := Client{Source: src}
+	ctx := context.Background()
+
+	r, err := c.SyncMirror(ctx, dir, nil)
+	if err != nil || len(r.NewReleases) != 1 || len(r.Downloaded) != 1 || r.Unchanged != 0 {
+		t.Fatalf("first sync: %#v %v", r, err)
+	}
+	src.addRelease(t, "go1.22.3")
+	r, err = c.SyncMirror(ctx, dir, nil)
+	if err != nil || len(r.NewReleases) != 1 || r.NewReleases[0] != "go1.22.3" || len(r.Downloaded) != 1 || r.Unchanged != 1 {
+		t.Fatalf("second sync: %#v %v", r, err)
+	}
+
+	// Damage a file keeping its size, only found when verifying.
+	p := filepath.Join(dir, f.Filename)
+	buf, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf[len(buf)-1] ^= 1
+	if err := os.WriteFile(p, buf, 0666); err != nil {
+		t.Fatal(err)
+	}
+	r, err = c.SyncMirror(ctx, dir, nil)
+	if err != nil || len(r.Replaced) != 0 || r.Unchanged != 2 {
+		t.Fatalf("sync without verify: %#v %v", r, err)
+	}
+	metrics := &Metrics{}
+	r, err = c.SyncMirror(ctx, dir, &SyncMirrorOptions{Verify: true, Metrics: metrics})
+	if err != nil || len(r.Replaced) != 1 || r.Unchanged != 1 {
+		t.Fatalf("sync with verify: %#v %v", r, err)
+	}
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, exp := range []string{"goreleases_mirror_syncs_total 1\n", "goreleases_mirror_last_sync_changed_files 1\n", "# TYPE goreleases_mirror_sync_lag_seconds gauge\n"} {
+		if !strings.Contains(rec.Body.String(), exp) {
+			t.Fatalf("metrics missing %q:\n%s", exp, rec.Body.String())
+		}
+	}
+	if l, err := readMirrorIndex(dir); err != nil || len(l) != 2 {
+		t.Fatalf("mirror listing: %v %v", l, err)
+	}
+}
+
+func TestVerifyMirror(t *testing.T) {
+	src := newTestSource(t, "go1.22.2", "go1.22.3")
+	rels := src.releases
+	dir := t.TempDir()
+	c := Client{Source: src}
+	ctx := context.Background()
+	if _, err := c.SyncMirror(ctx, dir, nil); err != nil {
+		t.Fatalf("sync: %s", err)
+	}
+
+	a, err := c.VerifyMirror(ctx, dir, nil)
+	if err != nil || !a.OK() || a.Checked != 2 {
+		t.Fatalf("verify: %#v %v", a, err)
+	}
+
+	// Damage one file, remove another, add an unknown file, and a new upstream
+	// release makes the listing stale.
+	p := filepath.Join(dir, rels[0].Files[0].Filename)
+	buf, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf[len(buf)-1] ^= 1
+	if err := os.WriteFile(p, buf, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, rels[1].Files[0].Filename)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "unknown.txt"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	f := src.addRelease(t, "go1.22.4")
+
+	a, err = c.VerifyMirror(ctx, dir, nil)
+	if err != nil || a.OK() || a.Checked != 1 {
+		t.Fatalf("verify damaged: %#v %v", a, err)
+	}
+	if len(a.Corrupted) != 1 || a.Corrupted[0].Filename != rels[0].Files[0].Filename {
+		t.Fatalf("got corrupted %v", a.Corrupted)
+	}
+	if len(a.Missing) != 2 || a.Missing[0].Filename != rels[1].Files[0].Filename || a.Missing[1].Filename != f.Filename {
+		t.Fatalf("got missing %v", a.Missing)
+	}
+	if !reflect.DeepEqual(a.Extra, []string{"unknown.txt"}) || !reflect.DeepEqual(a.StaleListing, []string{"go1.22.4"}) {
+		t.Fatalf("got extra %v, stale listing %v", a.Extra, a.StaleListing)
+	}
+}
@@ -0,0 +1,219 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultProbeSize is the number of bytes read from each mirror when probing,
+// if MirrorSource.ProbeSize is zero.
+const DefaultProbeSize = 64 * 1024
+
+// MirrorSource is a ReleaseSource for multiple mirrors, each serving the
+// listing, files and signatures like the upstream server, see
+// Client.BaseURL. Releases are listed from the first mirror that responds.
+// Files are downloaded from the first mirror that has them, or with Probe,
+// from the fastest mirror.
+//
+// Use as Client.Source to list and fetch with a Client.
+type MirrorSource struct {
+	// Base URLs of the mirrors, each ending with a slash.
+	BaseURLs []string
+
+	// If set, mirrors are probed before each download by concurrently reading
+	// the first ProbeSize bytes of the file with a range request, and the
+	// file is downloaded from the mirror that completed first, falling back
+	// to the others. Useful for CI runners in multiple regions.
+	Probe bool
+
+	// Bytes to read when probing, default DefaultProbeSize.
+	ProbeSize int64
+
+	// Maximum duration of a probe, default 10 seconds. Mirrors that have not
+	// completed are tried last.
+	ProbeTimeout time.Duration
+
+	// HTTPClient is used for requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	mu     sync.Mutex
+	probed map[string]mirrorOrder // By filename.
+}
+
+// mirrorOrder is the order of mirrors from a probe, reused for a minute, so
+// a fetch probes only once.
+type mirrorOrder struct {
+	time time.Time
+	urls []string
+}
+
+var _ SignatureSource = &MirrorSource{}
+var _ URLSource = &MirrorSource{}
+
+// MirrorProbe is the result of probing a mirror for a file.
+type MirrorProbe struct {
+	BaseURL  string
+	Duration time.Duration // Until the probe completed.
+	Bytes    int64         // Bytes read.
+	Err      error         // If the probe failed.
+}
+
+// List returns the releases from the first mirror that can list them.
+func (s *MirrorSource) List(ctx context.Context, all bool) ([]Release, error) {
+	if len(s.BaseURLs) == 0 {
+		return nil, fmt.Errorf("no mirrors configured")
+	}
+	var errs []string
+	for _, u := range s.BaseURLs {
+		c := Client{BaseURL: u, HTTPClient: s.HTTPClient}
+		rels, err := c.List(ctx, all)
+		if err == nil {
+			return rels, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", u, err))
+	}
+	return nil, fmt.Errorf("listing from mirrors: %s", strings.Join(errs, "; "))
+}
+
+// Open opens file from the first mirror that provides it, with Probe from
+// the fastest mirror first.
+func (s *MirrorSource) Open(ctx context.Context, file File) (io.ReadCloser, error) {
+	var errs []string
+	for _, u := range s.order(ctx, file) {
+		resp, err := s.get(ctx, u+file.Filename, "")
+		if err == nil {
+			return resp.Body, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return nil, fmt.Errorf("fetching from mirrors: %s", strings.Join(errs, "; "))
+}
+
+// Signature returns the .asc file for file from the first mirror that has it.
+func (s *MirrorSource) Signature(ctx context.Context, file File) ([]byte, error) {
+	var errs []string
+	for _, u := range s.BaseURLs {
+		resp, err := s.get(ctx, u+file.Filename+".asc", "")
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		buf, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024))
+		resp.Body.Close()
+		if err == nil {
+			return buf, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return nil, fmt.Errorf("fetching signature from mirrors: %s", strings.Join(errs, "; "))
+}
+
+// URL returns the URL of file at the first mirror, or with Probe, at the
+// fastest mirror.
+func (s *MirrorSource) URL(ctx context.Context, file File) (string, error) {
+	l := s.order(ctx, file)
+	if len(l) == 0 {
+		return "", fmt.Errorf("no mirrors configured")
+	}
+	return l[0] + file.Filename, nil
+}
+
+// order returns the base URLs to try for file. With Probe, the order from a
+// recent probe is reused.
+func (s *MirrorSource) order(ctx context.Context, file File) []string {
+	if !s.Probe || len(s.BaseURLs) < 2 {
+		return s.BaseURLs
+	}
+	s.mu.Lock()
+	o, ok := s.probed[file.Filename]
+	s.mu.Unlock()
+	if ok && time.Since(o.time) < time.Minute {
+		return o.urls
+	}
+	var l []string
+	for _, p := range s.ProbeMirrors(ctx, file) {
+		l = append(l, p.BaseURL)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.probed == nil {
+		s.probed = map[string]mirrorOrder{}
+	}
+	s.probed[file.Filename] = mirrorOrder{time.Now(), l}
+	return l
+}
+
+// ProbeMirrors reads the first ProbeSize bytes of file from all mirrors
+// concurrently and returns the results, fastest first, followed by failed
+// probes in the order of BaseURLs.
+func (s *MirrorSource) ProbeMirrors(ctx context.Context, file File) []MirrorProbe {
+	size := s.ProbeSize
+	if size <= 0 {
+		size = DefaultProbeSize
+	}
+	timeout := s.ProbeTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	probes := make([]MirrorProbe, len(s.BaseURLs))
+	var wg sync.WaitGroup
+	for i, u := range s.BaseURLs {
+		wg.Add(1)
+		go func(p *MirrorProbe, u string) {
+			defer wg.Done()
+			p.BaseURL = u
+			start := time.Now()
+			resp, err := s.get(ctx, u+file.Filename, fmt.Sprintf("bytes=0-%d", size-1))
+			if err == nil {
+				// Servers ignoring the range send the whole file.
+				p.Bytes, err = io.Copy(io.Discard, io.LimitReader(resp.Body, size))
+				resp.Body.Close()
+			}
+			p.Duration = time.Since(start)
+			p.Err = err
+		}(&probes[i], u)
+	}
+	wg.Wait()
+	sort.SliceStable(probes, func(i, j int) bool {
+		a, b := probes[i], probes[j]
+		if (a.Err == nil) != (b.Err == nil) {
+			return a.Err == nil
+		}
+		return a.Err == nil && a.Duration < b.Duration
+	})
+	return probes
+}
+
+// get requests url, with a range if not empty, and returns the response if
+// successful.
+func (s *MirrorSource) get(ctx context.Context, url, rng string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	if rng != "" {
+		req.Header.Set("Range", rng)
+	}
+	hc := s.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && (rng == "" || resp.StatusCode != http.StatusPartialContent) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s, status %v, expected 200 OK", url, resp.Status)
+	}
+	return resp, nil
+}
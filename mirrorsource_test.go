@@ -0,0 +1,70 @@
+package goreleases
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mjl-/goreleases/goreleasestest"
+)
+
+func TestMirrorSource(t *testing.T) {
+	fast := goreleasestest.NewServer("go1.22.3")
+	defer fast.Close()
+	other := goreleasestest.NewServer("go1.22.3")
+	defer other.Close()
+	u, _ := url.Parse(other.URL)
+	rp := httputil.NewSingleHostReverseProxy(u)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".tar.gz") {
+			time.Sleep(200 * time.Millisecond)
+		}
+		rp.ServeHTTP(w, r)
+	}))
+	defer slow.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	defer down.Close()
+
+	src := &MirrorSource{BaseURLs: []string{down.URL + "/", slow.URL + "/", fast.BaseURL()}}
+	c := Client{Source: src, NoSignatures: true}
+	ctx := context.Background()
+	rels, err := c.ListSupported(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	file, _ := rels[0].FindFile("linux", "amd64", KindArchive)
+
+	// In order, the second mirror is the first with the file.
+	if err := c.Fetch(ctx, file, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if other.Requests("/"+file.Filename) != 1 || fast.Requests("/"+file.Filename) != 0 {
+		t.Fatalf("file not fetched from first mirror with file")
+	}
+
+	src.Probe = true
+	probes := src.ProbeMirrors(ctx, file)
+	if len(probes) != 3 || probes[0].BaseURL != fast.BaseURL() || probes[0].Bytes != file.Size || probes[2].Err == nil {
+		t.Fatalf("unexpected probes %v", probes)
+	}
+	if u, err := src.URL(ctx, file); err != nil || u != fast.BaseURL()+file.Filename {
+		t.Fatalf("url %s %v", u, err)
+	}
+	dst := t.TempDir()
+	if err := c.Fetch(ctx, file, dst, nil); err != nil {
+		t.Fatalf("fetch with probe: %v", err)
+	}
+	if v, err := ReadVersion(filepath.Join(dst, "go")); err != nil || v != "go1.22.3" {
+		t.Fatalf("version %q %v", v, err)
+	}
+	// One probe and one download.
+	if n := fast.Requests("/" + file.Filename); n != 3 {
+		t.Fatalf("got %d requests at fast mirror, expected 3", n)
+	}
+}
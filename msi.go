@@ -0,0 +1,183 @@
+package goreleases
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// msiCommand returns the command extracting msi file into directory dir: An
+// administrative install with msiexec on Windows, and msiextract from msitools
+// elsewhere.
+var msiCommand = func(ctx context.Context, msi, dir string) (*exec.Cmd, error) {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "msiexec", "/a", msi, "/qn", "TARGETDIR="+dir), nil
+	}
+	if _, err := exec.LookPath("msiextract"); err != nil {
+		return nil, fmt.Errorf("extracting msi files requires msiextract from msitools: %v", err)
+	}
+	return exec.CommandContext(ctx, "msiextract", "-C", dir, msi), nil
+}
+
+// fetchMSI extracts the Go installation from a Windows installer, without
+// installing it. The msi file is extracted into a temporary directory with
+// msiCommand, and the directory with the installation (with VERSION file and
+// bin directory) is moved into dst/go.
+func fetchMSI(ctx context.Context, f *os.File, file File, x *extractor) error {
+	dst := filepath.Clean(x.dst)
+	if _, err := os.Stat(filepath.Join(dst, "go")); err == nil {
+		return fmt.Errorf(`directory "go" already exists`)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("reading msi file: %v", err)
+	}
+	if sum := fmt.Sprintf("%x", h.Sum(nil)); sum != file.Sha256 {
+		return fmt.Errorf("checksum mismatch, got %s, expected %s", sum, file.Sha256)
+	}
+
+	tmpdir, err := os.MkdirTemp(dst, ".msi-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+	// Tools recognize msi files by their extension, so give the file a fixed name
+	// with that extension, not the name from the listing. With a copy if it
+	// cannot be linked.
+	msi, err := filepath.Abs(filepath.Join(tmpdir, "go.msi"))
+	if err != nil {
+		return err
+	}
+	if err := os.Link(f.Name(), msi); err != nil {
+		if err := copyMSI(f, msi); err != nil {
+			return err
+		}
+	}
+	if x.signer != "" {
+		if err := verifyAuthenticode(ctx, msi, x.signer); err != nil {
+			return err
+		}
+	}
+	target, err := filepath.Abs(filepath.Join(tmpdir, "target"))
+	if err != nil {
+		return err
+	}
+	if err := os.Mkdir(target, 0777); err != nil {
+		return err
+	}
+	cmd, err := msiCommand(ctx, msi, target)
+	if err != nil {
+		return err
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("extracting msi: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	root, err := msiRoot(target)
+	if err != nil {
+		return err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			os.RemoveAll(filepath.Join(dst, "go"))
+		}
+	}()
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		name := "go"
+		if rel != "." {
+			name += "/" + filepath.ToSlash(rel)
+		}
+		if x.skip(name, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		np := filepath.Join(dst, filepath.FromSlash(name))
+		if d.IsDir() {
+			if err := os.Mkdir(np, 0777); err != nil {
+				return err
+			}
+			x.add(name, EntryDir, 0, "", "")
+			return nil
+		} else if !d.Type().IsRegular() {
+			return fmt.Errorf("%s: unexpected file type in msi", name)
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sum, err := fileSha256(p)
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(p, np); err != nil {
+			return err
+		}
+		x.add(name, EntryFile, fi.Size(), sum, "")
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("moving msi contents: %v", err)
+	}
+	success = true
+	return nil
+}
+
+// copyMSI writes the contents of f to new file p.
+func copyMSI(f *os.File, p string) error {
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+	nf, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(nf, f); err != nil {
+		nf.Close()
+		return fmt.Errorf("copying msi file: %v", err)
+	}
+	return nf.Close()
+}
+
+// msiRoot finds the directory with the Go installation extracted from an msi
+// file, the least deep directory with a VERSION file and a bin directory.
+func msiRoot(dir string) (string, error) {
+	l := []string{dir}
+	for len(l) > 0 {
+		var next []string
+		for _, d := range l {
+			fi, err := os.Stat(filepath.Join(d, "bin"))
+			if _, verr := os.Stat(filepath.Join(d, "VERSION")); err == nil && fi.IsDir() && verr == nil {
+				return d, nil
+			}
+			entries, err := os.ReadDir(d)
+			if err != nil {
+				return "", err
+			}
+			for _, e := range entries {
+				if e.IsDir() {
+					next = append(next, filepath.Join(d, e.Name()))
+				}
+			}
+		}
+		l = next
+	}
+	return "", fmt.Errorf("no go installation found in msi")
+}
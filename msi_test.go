@@ -0,0 +1,114 @@
+package goreleases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFetchMSI(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses sh")
+	}
+	// Simulate the layout of an administrative install.
+	defer func(fn func(ctx context.Context, msi, dir string) (*exec.Cmd, error)) { msiCommand = fn }(msiCommand)
+	msiCommand = func(ctx context.Context, msi, dir string) (*exec.Cmd, error) {
+		script := `set -e; cd "$1"; mkdir -p Go/bin Go/src; echo go1.22.3 >Go/VERSION; echo binary >Go/bin/go.exe`
+		return exec.CommandContext(ctx, "sh", "-c", script, "sh", dir), nil
+	}
+
+	data := []byte("msi")
+	file := testFile("go1.22.3", data)
+	file.Filename = "go1.22.3.windows-amd64.msi"
+	file.Os, file.Kind = "windows", KindInstaller
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: data}}}
+	dst := t.TempDir()
+	m, err := c.fetch(context.Background(), file, dst, nil)
+	if err != nil {
+		t.Fatalf("fetch msi: %v", err)
+	}
+	if len(m.Entries) != 4 {
+		t.Fatalf("got entries %v, expected 4", m.Entries)
+	}
+	if buf, err := os.ReadFile(filepath.Join(dst, "go/bin/go.exe")); err != nil || string(buf) != "binary\n" {
+		t.Fatalf("reading extracted file: %q %v", buf, err)
+	}
+	if entries, err := os.ReadDir(dst); err != nil || len(entries) != 1 {
+		t.Fatalf("temporary files left in dst: %v %v", entries, err)
+	}
+}
+
+func TestFetchAuthenticode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses sh")
+	}
+	defer func(fn func(ctx context.Context, msi, dir string) (*exec.Cmd, error)) { msiCommand = fn }(msiCommand)
+	msiCommand = func(ctx context.Context, msi, dir string) (*exec.Cmd, error) {
+		script := `set -e; cd "$1"; mkdir -p Go/bin; echo go1.22.3 >Go/VERSION; echo binary >Go/bin/go.exe`
+		return exec.CommandContext(ctx, "sh", "-c", script, "sh", dir), nil
+	}
+	var verified []string
+	subject := "/C=US/O=Google LLC/CN=Google LLC"
+	defer func(fn func(ctx context.Context, path string) (*exec.Cmd, error)) { authenticodeCommand = fn }(authenticodeCommand)
+	authenticodeCommand = func(ctx context.Context, path string) (*exec.Cmd, error) {
+		verified = append(verified, filepath.Base(path))
+		return exec.CommandContext(ctx, "printf", "Signer's certificate:\n\tSigner #0:\n\t\tSubject: %s\n", subject), nil
+	}
+
+	data := []byte("msi")
+	file := testFile("go1.22.3", data)
+	file.Filename = "go1.22.3.windows-amd64.msi"
+	file.Os, file.Kind = "windows", KindInstaller
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: data}}, AuthenticodeSigner: GoogleSigner}
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch msi: %v", err)
+	}
+	if len(verified) != 2 || verified[0] != "go.msi" || verified[1] != "go.exe" {
+		t.Fatalf("verified %v, expected msi and go.exe", verified)
+	}
+
+	subject = "/O=Other/CN=Other"
+	var aerr *AuthenticodeError
+	if err := c.Download(context.Background(), file, t.TempDir()); !errors.As(err, &aerr) || aerr.Signer != GoogleSigner {
+		t.Fatalf("download with other signer, got %v, expected AuthenticodeError", err)
+	}
+}
+
+func TestSignerSubject(t *testing.T) {
+	osslsigncode := `Signature Index: 0  (Primary Signature)
+Message digest algorithm  : SHA256
+
+Signer's certificate:
+	Signer #0:
+		Subject: /C=US/O=%s/CN=%s
+		Issuer : /C=US/O=DigiCert, Inc./CN=%s
+Number of certificates: 2
+	Cert #0:
+		Subject: /C=US/O=%[1]s/CN=%[2]s
+	Cert #1:
+		Subject: /C=US/O=DigiCert, Inc./CN=%[3]s
+
+Signature verification: ok
+`
+	tests := []struct {
+		output string
+		ok     bool
+	}{
+		{"CN=Google LLC, O=Google LLC, L=Mountain View, S=California, C=US", true},
+		{"CN=Other, O=Other", false},
+		{fmt.Sprintf(osslsigncode, "Google LLC", "Google LLC", "DigiCert CA"), true},
+		// Only the issuer or another certificate in the chain matches.
+		{fmt.Sprintf(osslsigncode, "Other", "Other", "Google LLC"), false},
+		{"Number of certificates: 1\n\tCert #0:\n\t\tSubject: /CN=Google LLC\n", false},
+	}
+	for _, tt := range tests {
+		if ok := hasCommonName(signerSubject(tt.output), GoogleSigner); ok != tt.ok {
+			t.Errorf("signer %q in %q: got %v, expected %v", GoogleSigner, tt.output, ok, tt.ok)
+		}
+	}
+}
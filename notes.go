@@ -0,0 +1,194 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultHistoryURL is the release history page, with a summary per release.
+const DefaultHistoryURL = "https://go.dev/doc/devel/release"
+
+// ReleaseNotes holds information about a release from the release history page.
+type ReleaseNotes struct {
+	Version    string
+	Released   time.Time // Release date, zero if unknown.
+	Summary    string    // Text of the history entry, e.g. "go1.22.3 (released 2024-05-07) includes security fixes to ...".
+	HistoryURL string    // History page with anchor for the version.
+	NotesURL   string    // Release notes of the minor version, e.g. https://go.dev/doc/go1.22.
+
+	// Whether the summary mentions security fixes, and the affected packages
+	// and commands, e.g. "net/http" or "go command".
+	Security         bool
+	SecurityAffected []string
+}
+
+// Age returns the time since the release, zero if the release date is
+// unknown.
+func (rn ReleaseNotes) Age() time.Duration {
+	if rn.Released.IsZero() {
+		return 0
+	}
+	return time.Since(rn.Released)
+}
+
+// OlderThan returns whether the release is older than d. False if the release
+// date is unknown.
+func (rn ReleaseNotes) OlderThan(d time.Duration) bool {
+	return !rn.Released.IsZero() && time.Since(rn.Released) > d
+}
+
+// Adoptable returns whether the release can be adopted under a policy of
+// adopting releases with security fixes immediately, and other releases once
+// they are older than minAge.
+func (rn ReleaseNotes) Adoptable(minAge time.Duration) bool {
+	return rn.Security || rn.OlderThan(minAge)
+}
+
+// ReleaseNotes fetches the release history page and returns the entry for
+// version, e.g. "go1.22.3". The history page is fetched from
+// Client.HistoryURL, or DefaultHistoryURL if empty.
+func (c *Client) ReleaseNotes(ctx context.Context, version string) (*ReleaseNotes, error) {
+	page, err := c.historyPage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseReleaseNotes(page, c.historyURL(), version)
+}
+
+func (c *Client) historyURL() string {
+	if c.HistoryURL != "" {
+		return c.HistoryURL
+	}
+	return DefaultHistoryURL
+}
+
+func (c *Client) historyPage(ctx context.Context) (string, error) {
+	resp, err := c.get(ctx, c.historyURL())
+	if err != nil {
+		return "", fmt.Errorf("fetching release history: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching release history returned http status %d: %s", resp.StatusCode, resp.Status)
+	}
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading release history: %w", err)
+	}
+	return string(buf), nil
+}
+
+var (
+	tagRegexp      = regexp.MustCompile(`<[^>]*>`)
+	releasedRegexp = regexp.MustCompile(`\(released (\d{4}-\d{2}-\d{2})\)`)
+)
+
+// History fetches the release history page and returns the entries for all
+// releases on it, in order of the page.
+func (c *Client) History(ctx context.Context) ([]ReleaseNotes, error) {
+	page, err := c.historyPage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseHistory(page, c.historyURL())
+}
+
+var historyIDRegexp = regexp.MustCompile(`id="(go[0-9][0-9a-z.]*)"`)
+
+func parseHistory(page, historyURL string) ([]ReleaseNotes, error) {
+	var l []ReleaseNotes
+	for _, m := range historyIDRegexp.FindAllStringSubmatch(page, -1) {
+		if _, err := ParseVersion(m[1]); err != nil {
+			// E.g. "go1.22.minor".
+			continue
+		}
+		rn, err := parseReleaseNotes(page, historyURL, m[1])
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, *rn)
+	}
+	return l, nil
+}
+
+// parseReleaseNotes finds the element with the version as id in the history
+// page. For headings, used for minor releases, the next paragraph is included.
+func parseReleaseNotes(page, historyURL, version string) (*ReleaseNotes, error) {
+	i := strings.Index(page, `id="`+version+`"`)
+	if i < 0 {
+		return nil, fmt.Errorf("version %q not found in release history", version)
+	}
+	start := strings.LastIndex(page[:i], "<")
+	if start < 0 {
+		return nil, fmt.Errorf("malformed release history")
+	}
+	fields := strings.Fields(page[start+1 : i])
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("malformed release history")
+	}
+	tag := fields[0]
+	end := strings.Index(page[start:], "</"+tag+">")
+	if end < 0 {
+		return nil, fmt.Errorf("malformed release history, no end tag for %q", tag)
+	}
+	end += start + len("</"+tag+">")
+	if strings.HasPrefix(tag, "h") {
+		if p := strings.Index(page[end:], "<p"); p >= 0 {
+			if pend := strings.Index(page[end+p:], "</p>"); pend >= 0 {
+				end += p + pend + len("</p>")
+			}
+		}
+	}
+	text := html.UnescapeString(tagRegexp.ReplaceAllString(page[start:end], ""))
+	text = strings.Join(strings.Fields(text), " ")
+
+	rn := &ReleaseNotes{
+		Version:    version,
+		Summary:    text,
+		HistoryURL: historyURL + "#" + version,
+	}
+	if m := releasedRegexp.FindStringSubmatch(text); m != nil {
+		rn.Released, _ = time.Parse("2006-01-02", m[1])
+	}
+	rn.SecurityAffected = parseSecurityAffected(text)
+	rn.Security = rn.SecurityAffected != nil
+	if v, err := ParseVersion(version); err == nil {
+		rn.NotesURL = fmt.Sprintf("https://go.dev/doc/go%s", v.MinorString())
+	}
+	return rn, nil
+}
+
+var securityRegexp = regexp.MustCompile(`security fix(?:es)? to (.*?)(?:, as well as|\. |\.$)`)
+
+// parseSecurityAffected parses the packages and commands from summaries like
+// "includes security fixes to the go command and the crypto/x509 and net/http
+// packages, as well as ...". If no security fixes are mentioned, nil is
+// returned. If they are, but the affected packages cannot be parsed, an
+// empty non-nil slice is returned.
+func parseSecurityAffected(summary string) []string {
+	if !strings.Contains(summary, "security fix") {
+		return nil
+	}
+	l := []string{}
+	m := securityRegexp.FindStringSubmatch(summary)
+	if m == nil {
+		return l
+	}
+	s := strings.ReplaceAll(m[1], " and ", ", ")
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		e = strings.TrimPrefix(e, "the ")
+		e = strings.TrimSuffix(e, " packages")
+		e = strings.TrimSuffix(e, " package")
+		if e != "" {
+			l = append(l, e)
+		}
+	}
+	return l
+}
@@ -0,0 +1,83 @@
+package goreleases
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testHistory = `<html><body>
+<h2 id="go1.22.0">go1.22.0 (released 2024-02-06)</h2>
+<p>
+Go 1.22.0 is a major release of Go.
+Read the <a href="/doc/go1.22">Go 1.22 Release Notes</a> for more information.
+</p>
+<h3 id="go1.22.minor">Minor revisions</h3>
+<p id="go1.22.1">
+go1.22.1 (released 2024-03-05) includes security fixes to the <code>crypto/x509</code>, <code>html/template</code>, <code>net/http</code>, <code>net/http/cookiejar</code>, and <code>net/mail</code> packages, as well as bug fixes to the compiler, the go command, the runtime, the <code>trace</code> command, and the <code>go/types</code> and <code>net/http</code> packages.
+See the <a href="https://github.com/golang/go/issues?q=milestone%3AGo1.22.1+label%3ACherryPickApproved">Go 1.22.1 milestone</a> on our issue tracker for details.
+</p>
+<p id="go1.22.2">
+go1.22.2 (released 2024-04-03) includes a security fix to the <code>net/http</code> package, as well as bug fixes to the compiler, the go command, the linker, and the <code>encoding/gob</code>, <code>go/types</code>, <code>net/http</code>, and <code>runtime/trace/v2</code> packages.
+</p>
+<p id="go1.21.9">
+go1.21.9 (released 2024-04-03) includes bug fixes to the linker.
+</p>
+</body></html>
+`
+
+func TestReleaseNotes(t *testing.T) {
+	rn, err := parseReleaseNotes(testHistory, DefaultHistoryURL, "go1.22.1")
+	if err != nil {
+		t.Fatalf("parsing release notes: %s", err)
+	}
+	if !rn.Released.Equal(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("got release date %v", rn.Released)
+	}
+	if rn.NotesURL != "https://go.dev/doc/go1.22" || rn.HistoryURL != DefaultHistoryURL+"#go1.22.1" {
+		t.Fatalf("unexpected urls %q %q", rn.NotesURL, rn.HistoryURL)
+	}
+
+	exp := []string{"crypto/x509", "html/template", "net/http", "net/http/cookiejar", "net/mail"}
+	if !rn.Security || !reflect.DeepEqual(rn.SecurityAffected, exp) {
+		t.Fatalf("got security %v, affected %v, expected %v", rn.Security, rn.SecurityAffected, exp)
+	}
+
+	rn, err = parseReleaseNotes(testHistory, DefaultHistoryURL, "go1.22.0")
+	if err != nil {
+		t.Fatalf("parsing release notes: %s", err)
+	}
+	const expSummary = "go1.22.0 (released 2024-02-06) Go 1.22.0 is a major release of Go. Read the Go 1.22 Release Notes for more information."
+	if rn.Summary != expSummary || rn.Security {
+		t.Fatalf("got summary %q, security %v, expected %q without security", rn.Summary, rn.Security, expSummary)
+	}
+
+	l, err := parseHistory(testHistory, DefaultHistoryURL)
+	if err != nil {
+		t.Fatalf("parsing history: %s", err)
+	}
+	if len(l) != 4 || !l[2].Security || !reflect.DeepEqual(l[2].SecurityAffected, []string{"net/http"}) || l[3].Security {
+		t.Fatalf("unexpected history %#v", l)
+	}
+
+	if _, err := parseReleaseNotes(testHistory, DefaultHistoryURL, "go1.99.0"); err == nil {
+		t.Fatalf("expected error for unknown version")
+	}
+}
+
+func TestReleaseAge(t *testing.T) {
+	week := 7 * 24 * time.Hour
+	old := ReleaseNotes{Released: time.Now().Add(-10 * 24 * time.Hour)}
+	recent := ReleaseNotes{Released: time.Now().Add(-2 * 24 * time.Hour)}
+	security := ReleaseNotes{Released: recent.Released, Security: true}
+	var unknown ReleaseNotes
+	if old.Age() < 10*24*time.Hour || unknown.Age() != 0 {
+		t.Fatalf("age %v, unknown %v", old.Age(), unknown.Age())
+	}
+	if !old.OlderThan(week) || recent.OlderThan(week) || unknown.OlderThan(week) {
+		t.Fatalf("older than week: old %v, recent %v, unknown %v", old.OlderThan(week), recent.OlderThan(week), unknown.OlderThan(week))
+	}
+	if !old.Adoptable(week) || recent.Adoptable(week) || !security.Adoptable(week) {
+		t.Fatalf("adoptable: old %v, recent %v, security %v", old.Adoptable(week), recent.Adoptable(week), security.Adoptable(week))
+	}
+}
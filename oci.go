@@ -0,0 +1,117 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// OCILayerMediaType is the media type of layers written by WriteOCILayer.
+const OCILayerMediaType = "application/vnd.oci.image.layer.v1.tar+gzip"
+
+// OCIDescriptor is an OCI content descriptor, for referencing a layer in an
+// image manifest.
+type OCIDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"` // E.g. "sha256:<hex>".
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// OCILayer describes a layer written by WriteOCILayer.
+type OCILayer struct {
+	Descriptor OCIDescriptor // Of the compressed layer.
+	DiffID     string        // Digest of the uncompressed layer, for rootfs.diff_ids in the image config.
+	Env        []string      // Suggested environment for the image config, with GOROOT and PATH.
+}
+
+// OCILayerOptions are options for WriteOCILayer.
+type OCILayerOptions struct {
+	// Path of the GOROOT in the image, without leading slash. If empty,
+	// PkgPrefix, "usr/local/go", is used, as in the official images.
+	Path string
+
+	// Annotations for the descriptor. The version from the manifest of the
+	// install, if any, is added as "org.opencontainers.image.version".
+	Annotations map[string]string
+}
+
+// WriteOCILayer writes the install in directory dir as an OCI image layer, a
+// gzipped tar file, to w. Parent directories of the path are included. Entries
+// are deterministic as with Repack, and owned by root. The descriptor and diff
+// ID of the layer are returned, to add it to an image manifest and config.
+func WriteOCILayer(dir string, w io.Writer, opts *OCILayerOptions) (OCILayer, error) {
+	var o OCILayerOptions
+	if opts != nil {
+		o = *opts
+	}
+	p := strings.Trim(o.Path, "/")
+	if p == "" {
+		p = PkgPrefix
+	}
+	if p != path.Clean(p) || strings.HasPrefix(p, "../") || p == ".." {
+		return OCILayer{}, fmt.Errorf("bad path %q", o.Path)
+	}
+
+	zh := sha256.New()
+	zw := &countWriter{w: io.MultiWriter(w, zh)}
+	gzw := gzip.NewWriter(zw)
+	h := sha256.New()
+	tw := tar.NewWriter(io.MultiWriter(gzw, h))
+	elems := strings.Split(p, "/")
+	for i := range elems[:len(elems)-1] {
+		name := strings.Join(elems[:i+1], "/") + "/"
+		hdr := &tar.Header{Typeflag: tar.TypeDir, Name: name, Mode: 0755, ModTime: repackTime, Format: tar.FormatPAX}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return OCILayer{}, err
+		}
+	}
+	err := repackWalk(dir, p, func(name string, fi fs.FileInfo, path string) error {
+		return repackTar(tw, name, fi, path)
+	})
+	if err != nil {
+		return OCILayer{}, err
+	}
+	if err := tw.Close(); err != nil {
+		return OCILayer{}, err
+	}
+	if err := gzw.Close(); err != nil {
+		return OCILayer{}, err
+	}
+
+	annotations := map[string]string{}
+	for k, v := range o.Annotations {
+		annotations[k] = v
+	}
+	if m, err := ReadManifest(dir); err == nil && m.Version != "" {
+		annotations["org.opencontainers.image.version"] = m.Version
+	}
+	l := OCILayer{
+		Descriptor: OCIDescriptor{
+			MediaType:   OCILayerMediaType,
+			Digest:      fmt.Sprintf("sha256:%x", zh.Sum(nil)),
+			Size:        zw.n,
+			Annotations: annotations,
+		},
+		DiffID: fmt.Sprintf("sha256:%x", h.Sum(nil)),
+		Env:    []string{"GOROOT=/" + p, "PATH=/" + p + "/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"},
+	}
+	return l, nil
+}
+
+// countWriter counts the bytes written to w.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(buf []byte) (int, error) {
+	n, err := w.w.Write(buf)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,69 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestWriteOCILayer(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/go.bash": "binary"})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}}
+	dir, err := c.FetchSDK(context.Background(), file, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	var b bytes.Buffer
+	l, err := WriteOCILayer(dir, &b, nil)
+	if err != nil {
+		t.Fatalf("write layer: %v", err)
+	}
+	if d := l.Descriptor; d.MediaType != OCILayerMediaType || d.Size != int64(b.Len()) || d.Digest != fmt.Sprintf("sha256:%x", sha256.Sum256(b.Bytes())) || d.Annotations["org.opencontainers.image.version"] != "go1.22.3" {
+		t.Fatalf("got descriptor %#v", d)
+	}
+	gzr, err := gzip.NewReader(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tar0, err := io.ReadAll(gzr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.DiffID != fmt.Sprintf("sha256:%x", sha256.Sum256(tar0)) {
+		t.Fatalf("bad diff id %s", l.DiffID)
+	}
+	var names []string
+	modes := map[string]int64{}
+	tr := tar.NewReader(bytes.NewReader(tar0))
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, h.Name)
+		modes[h.Name] = h.Mode
+		if h.Uid != 0 || h.Gid != 0 {
+			t.Fatalf("%s: owner %d:%d", h.Name, h.Uid, h.Gid)
+		}
+	}
+	expect := []string{"usr/", "usr/local/", "usr/local/go/", "usr/local/go/VERSION", "usr/local/go/bin/", "usr/local/go/bin/go.bash"}
+	if fmt.Sprint(names) != fmt.Sprint(expect) {
+		t.Fatalf("got entries %v, expected %v", names, expect)
+	}
+	if modes["usr/local/go/bin/go.bash"] != 0755 || modes["usr/local/go/VERSION"] != 0644 {
+		t.Fatalf("got modes %v", modes)
+	}
+
+	if _, err := WriteOCILayer(dir, io.Discard, &OCILayerOptions{Path: "../go"}); err == nil {
+		t.Fatalf("layer with bad path succeeded")
+	}
+}
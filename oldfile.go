@@ -0,0 +1,64 @@
+package goreleases
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// FileName returns the upstream filename of a release file, e.g.
+// "go1.4.3.linux-amd64.tar.gz". Source files are "<version>.src.tar.gz",
+// archives are .zip files for windows and .tar.gz files otherwise, installers
+// are .msi files for windows and .pkg files otherwise. Some ancient releases
+// used other names, e.g. with an "-osx10.8" suffix for darwin.
+func FileName(version, goos, goarch, kind string) (string, error) {
+	version = "go" + strings.TrimPrefix(version, "go")
+	switch kind {
+	case KindSource:
+		return version + ".src.tar.gz", nil
+	case KindArchive, KindInstaller:
+	default:
+		return "", fmt.Errorf("unknown kind %q", kind)
+	}
+	if goos == "" || goarch == "" {
+		return "", fmt.Errorf("os and arch required")
+	}
+	ext := ".tar.gz"
+	if kind == KindArchive && goos == "windows" {
+		ext = ".zip"
+	} else if kind == KindInstaller && goos == "windows" {
+		ext = ".msi"
+	} else if kind == KindInstaller {
+		ext = ".pkg"
+	}
+	return version + "." + goos + "-" + goarch + ext, nil
+}
+
+// NewFile returns a File for a release file that is not in the listing, as
+// for some ancient releases, so it can still be fetched. The filename is as
+// returned by FileName, the sha256 checksum is read from the .sha256 file
+// published next to the file at BaseURL, and the size is unknown. Not
+// supported with a Source.
+func (c *Client) NewFile(ctx context.Context, version, goos, goarch, kind string) (File, error) {
+	if c.Source != nil {
+		return File{}, fmt.Errorf("new file not supported with a source")
+	}
+	name, err := FileName(version, goos, goarch, kind)
+	if err != nil {
+		return File{}, err
+	}
+	file := File{Filename: name, Version: "go" + strings.TrimPrefix(version, "go"), Kind: kind}
+	if kind != KindSource {
+		file.Os, file.Arch = goos, goarch
+	}
+	sum, err := c.publishedSha256(ctx, file)
+	if err != nil {
+		return File{}, fmt.Errorf("%s: %w", name, err)
+	}
+	if buf, err := hex.DecodeString(sum); err != nil || len(buf) != 32 {
+		return File{}, fmt.Errorf("%s: bad sha256 %q in .sha256 file", name, sum)
+	}
+	file.Sha256 = strings.ToLower(sum)
+	return file, nil
+}
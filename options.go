@@ -0,0 +1,165 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Option configures the package-level Fetch, FetchSDK, ListSupported, ListAll
+// and Sync, which otherwise use the default client, see SetDefaultClient, and
+// context.Background.
+type Option func(*options)
+
+type options struct {
+	ctx         context.Context
+	client      *Client
+	configure   []func(c *Client)
+	progress    func(file File, downloaded, total int64)
+	permissions *Permissions
+}
+
+var defaultClient struct {
+	sync.Mutex
+	c Client
+}
+
+// SetDefaultClient sets the client used by the package-level functions, e.g.
+// once at program start with the base URL, caches and rate limit for all
+// call sites. A copy of c is stored, nil restores the zero Client. Fields of
+// the default client that are not set are taken from the environment, see
+// ApplyEnv. Calls can override it with options, e.g. WithClient or
+// WithConfig.
+func SetDefaultClient(c *Client) {
+	defaultClient.Lock()
+	defer defaultClient.Unlock()
+	if c == nil {
+		defaultClient.c = Client{}
+	} else {
+		defaultClient.c = *c
+	}
+}
+
+// DefaultClient returns a copy of the client used by the package-level
+// functions, see SetDefaultClient, with ApplyEnv applied. An error from
+// ApplyEnv is logged.
+func DefaultClient() *Client {
+	defaultClient.Lock()
+	c := defaultClient.c
+	defaultClient.Unlock()
+	if err := c.ApplyEnv(); err != nil {
+		log.Printf("goreleases: %v", err)
+	}
+	return &c
+}
+
+// Environment variables applied by ApplyEnv, so operators can configure all
+// tools using this package.
+const (
+	EnvBaseURL         = "GORELEASES_BASE_URL"          // Client.BaseURL.
+	EnvMirrors         = "GORELEASES_MIRRORS"           // Comma-separated base URLs, for a MirrorSource as Client.Source.
+	EnvCacheDir        = "GORELEASES_CACHE_DIR"         // Client.CacheDir.
+	EnvArchiveCacheDir = "GORELEASES_ARCHIVE_CACHE_DIR" // Client.ArchiveCacheDir.
+	EnvTempDir         = "GORELEASES_TEMP_DIR"          // Client.TempDir.
+	EnvOffline         = "GORELEASES_OFFLINE"           // Client.Offline, e.g. "1" or "true".
+	EnvSharedCache     = "GORELEASES_SHARED_CACHE"      // Client.UseDefaultCache, e.g. "1" or "true".
+)
+
+// ApplyEnv sets the fields of c that are not set from the Env* environment
+// variables that are set. Invalid values are ignored. Settings of c take
+// precedence, so explicit configuration, e.g. from command-line flags,
+// overrides the environment. EnvMirrors is not used if c has a BaseURL or
+// Source. Boolean fields that are false are indistinguishable from unset, and
+// are enabled by the environment, callers with an explicit false must set it
+// again after ApplyEnv. An error is returned if the default cache of
+// EnvSharedCache cannot be used, the other variables are still applied.
+func (c *Client) ApplyEnv() error {
+	// Explicit, before EnvBaseURL is applied.
+	mirrors := c.BaseURL == "" && c.Source == nil
+	set := func(field *string, name string) {
+		if v := os.Getenv(name); *field == "" && v != "" {
+			*field = v
+		}
+	}
+	set(&c.BaseURL, EnvBaseURL)
+	set(&c.CacheDir, EnvCacheDir)
+	set(&c.ArchiveCacheDir, EnvArchiveCacheDir)
+	set(&c.TempDir, EnvTempDir)
+	if v, err := strconv.ParseBool(os.Getenv(EnvOffline)); err == nil && !c.Offline {
+		c.Offline = v
+	}
+	var err error
+	if v, perr := strconv.ParseBool(os.Getenv(EnvSharedCache)); perr == nil && v {
+		if cerr := c.UseDefaultCache(); cerr != nil {
+			err = fmt.Errorf("using default cache for %s: %v", EnvSharedCache, cerr)
+		}
+	}
+	if v := os.Getenv(EnvMirrors); v != "" && mirrors {
+		ms := &MirrorSource{HTTPClient: c.HTTPClient}
+		for _, u := range strings.Split(v, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				ms.BaseURLs = append(ms.BaseURLs, strings.TrimSuffix(u, "/")+"/")
+			}
+		}
+		if len(ms.BaseURLs) > 0 {
+			c.Source = ms
+		}
+	}
+	return err
+}
+
+// WithContext sets the context for requests.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) { o.ctx = ctx }
+}
+
+// WithClient uses a copy of c instead of the default client.
+func WithClient(c *Client) Option {
+	return func(o *options) { o.client = c }
+}
+
+// WithConfig calls fn with a copy of the client for the call, the default
+// client or from WithClient, to change fields for just this call, e.g. the
+// BaseURL.
+func WithConfig(fn func(c *Client)) Option {
+	return func(o *options) { o.configure = append(o.configure, fn) }
+}
+
+// WithProgress sets Client.Progress, also when combined with WithClient.
+func WithProgress(fn func(file File, downloaded, total int64)) Option {
+	return func(o *options) { o.progress = fn }
+}
+
+// WithPermissions sets the permissions for extracted files and directories,
+// taking precedence over a permissions parameter.
+func WithPermissions(p *Permissions) Option {
+	return func(o *options) { o.permissions = p }
+}
+
+// makeOptions applies opts, returning the context, client and permissions to
+// use.
+func makeOptions(permissions *Permissions, opts []Option) (context.Context, *Client, *Permissions) {
+	o := options{ctx: context.Background(), permissions: permissions}
+	for _, fn := range opts {
+		if fn != nil {
+			fn(&o)
+		}
+	}
+	var c Client
+	if o.client != nil {
+		c = *o.client
+	} else {
+		c = *DefaultClient()
+	}
+	for _, fn := range o.configure {
+		fn(&c)
+	}
+	if o.progress != nil {
+		c.Progress = o.progress
+	}
+	return o.ctx, &c, o.permissions
+}
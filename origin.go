@@ -0,0 +1,89 @@
+package goreleases
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+)
+
+// OriginFile is the name of the file describing where an install came from,
+// written in the "go" directory when Client.WriteOrigin is set.
+const OriginFile = ".goreleases-origin.json"
+
+// Origin describes where an install came from, for audits.
+type Origin struct {
+	Version  string
+	Filename string // Of the release file.
+	URL      string // Download URL, empty for a Client.Source without URLs.
+	Sha256   string // Verified hex sha256 of the release file.
+	Cached   bool   // Whether the file came from the archive cache.
+	Time     time.Time
+	Tool     string // Module path and version of this package, e.g. "github.com/mjl-/goreleases v0.5.0", if known.
+}
+
+// ReadOrigin reads the OriginFile from directory dir, e.g. a GOROOT.
+func ReadOrigin(dir string) (*Origin, error) {
+	buf, err := os.ReadFile(filepath.Join(dir, OriginFile))
+	if err != nil {
+		return nil, err
+	}
+	var o Origin
+	if err := json.Unmarshal(buf, &o); err != nil {
+		return nil, fmt.Errorf("parsing origin: %v", err)
+	}
+	return &o, nil
+}
+
+// writeOrigin writes the OriginFile for a fetch into r.Dir.
+func writeOrigin(r FetchResult, permissions *Permissions) error {
+	o := Origin{
+		Version:  r.File.Version,
+		Filename: r.File.Filename,
+		URL:      r.URL,
+		Sha256:   r.Sha256,
+		Cached:   r.Cached,
+		Time:     time.Now().UTC(),
+		Tool:     toolVersion(),
+	}
+	buf, err := json.MarshalIndent(o, "", "\t")
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(r.Dir, OriginFile)
+	if err := os.WriteFile(p, buf, 0666); err != nil {
+		return err
+	}
+	if permissions != nil {
+		if err := os.Chmod(p, permissions.Mode&0666); err != nil {
+			return fmt.Errorf("chmod: %v", err)
+		}
+		if permissions.Uid >= 0 || permissions.Gid >= 0 {
+			if err := os.Lchown(p, permissions.Uid, permissions.Gid); err != nil {
+				return fmt.Errorf("chown: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// toolVersion returns the module path and version of this package from the
+// build info of the binary, or an empty string.
+func toolVersion() string {
+	const path = "github.com/mjl-/goreleases"
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if bi.Main.Path == path {
+		return path + " " + bi.Main.Version
+	}
+	for _, m := range bi.Deps {
+		if m.Path == path {
+			return path + " " + m.Version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// tempDir returns the directory for temporary download files.
+func (c *Client) tempDir() string {
+	if c.TempDir != "" {
+		return c.TempDir
+	}
+	return os.TempDir()
+}
+
+// createTemp creates a temporary file in the client's temp directory.
+func (c *Client) createTemp(pattern string) (*os.File, error) {
+	return os.CreateTemp(c.tempDir(), pattern)
+}
+
+// resumable returns whether a download of file can be kept when incomplete and
+// resumed later. Only downloads over HTTP from BaseURL can be resumed. The
+// checksum names the partial file, so it must be a valid one.
+func (c *Client) resumable(file File) bool {
+	return c.KeepPartial && c.Source == nil && validSha256(file.Sha256)
+}
+
+// partialPath returns the path of the partial download of file.
+func (c *Client) partialPath(file File) string {
+	return filepath.Join(c.tempDir(), "goreleases-"+file.Sha256+".partial")
+}
+
+// openPartial completes the partial download of file, requesting only the
+// missing data from the server, and returns it opened for reading. Closing it
+// removes the partial file. If the download is interrupted again, the partial
+// file is kept and an error returned.
+func (c *Client) openPartial(ctx context.Context, file File) (io.ReadCloser, error) {
+	p := c.partialPath(file)
+	f, err := os.OpenFile(p, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("opening partial download: %v", err)
+	}
+	pf := &partialFile{f}
+	fi, err := f.Stat()
+	if err != nil {
+		pf.Close()
+		return nil, err
+	}
+	offset := fi.Size()
+	if file.Size > 0 && offset >= file.Size {
+		// Possibly complete, verified by the caller.
+		return pf, pf.rewind()
+	}
+
+	u, err := c.downloadURL(ctx, file, "")
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("getting release file: %v", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		_, err = f.Seek(0, io.SeekEnd)
+	case resp.StatusCode == http.StatusOK:
+		// Range not supported, start over.
+		offset = 0
+		err = f.Truncate(0)
+	default:
+		f.Close()
+		return nil, fmt.Errorf("fetching file, status %v, expected 200 OK or 206 Partial Content", resp.Status)
+	}
+	if err != nil {
+		pf.Close()
+		return nil, err
+	}
+	n, err := io.Copy(f, resp.Body)
+	if err == io.ErrUnexpectedEOF || err == nil && file.Size > 0 && offset+n < file.Size {
+		f.Close()
+		return nil, fmt.Errorf("%w: got %d bytes, partial download kept for resuming", ErrTruncated, offset+n)
+	} else if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("copying release file: %v", err)
+	}
+	return pf, pf.rewind()
+}
+
+// partialFile is a complete partial download, removed when closed.
+type partialFile struct {
+	*os.File
+}
+
+func (pf *partialFile) rewind() error {
+	if _, err := pf.Seek(0, 0); err != nil {
+		pf.Close()
+		return fmt.Errorf("rewinding partial download: %v", err)
+	}
+	return nil
+}
+
+func (pf *partialFile) Close() error {
+	err := pf.File.Close()
+	os.Remove(pf.Name())
+	return err
+}
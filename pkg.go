@@ -0,0 +1,306 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PkgPrefix is the directory in the payload of macOS installer packages
+// (.pkg) that holds the Go installation. It is extracted as "go".
+const PkgPrefix = "usr/local/go"
+
+// fetchPkg extracts the Go installation from the payload of a macOS
+// installer package: A xar archive with a gzip-compressed cpio Payload file.
+// Files outside PkgPrefix, e.g. for /etc/paths.d, are skipped.
+func fetchPkg(f *os.File, file File, x *extractor) error {
+	dst := x.dst
+	fi, err := os.Stat(dst)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("dst is not a directory")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "go")); err == nil {
+		return fmt.Errorf(`directory "go" already exists`)
+	}
+	dst = filepath.Clean(dst)
+	if err := x.openRoot(); err != nil {
+		return err
+	}
+	defer x.closeRoot()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return fmt.Errorf("reading pkg file: %v", err)
+	}
+	if sum := fmt.Sprintf("%x", h.Sum(nil)); sum != file.Sha256 {
+		return fmt.Errorf("checksum mismatch, got %s, expected %s", sum, file.Sha256)
+	}
+
+	payload, err := xarPayload(io.NewSectionReader(f, 0, size))
+	if err != nil {
+		return err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			os.RemoveAll(filepath.Join(dst, "go"))
+		}
+	}()
+
+	gzr, err := gzip.NewReader(x.buffered(payload))
+	if err != nil {
+		return fmt.Errorf("payload: gzip reader: %v", err)
+	}
+	defer gzr.Close()
+	cr := &cpioReader{r: bufio.NewReader(gzr)}
+	for {
+		h, err := cr.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("payload: %v", err)
+		}
+		n := path.Clean(h.Name)
+		if n != PkgPrefix && !strings.HasPrefix(n, PkgPrefix+"/") {
+			continue
+		}
+		h.Name = "go" + strings.TrimPrefix(n, PkgPrefix)
+		if h.Typeflag == tar.TypeDir {
+			h.Name += "/"
+		}
+		name, err := dstName(dst, h.Name)
+		if err != nil {
+			return err
+		}
+		if x.skip(h.Name, h.Typeflag == tar.TypeDir) {
+			continue
+		}
+		if h.Typeflag == tar.TypeSymlink {
+			// Stored as data, absolute or relative to the directory of the link.
+			buf, err := io.ReadAll(cr)
+			if err != nil {
+				return fmt.Errorf("payload: %v", err)
+			}
+			t := string(buf)
+			if strings.HasPrefix(t, "/") {
+				t = strings.TrimPrefix(path.Clean(t), "/")
+				if t != PkgPrefix && !strings.HasPrefix(t, PkgPrefix+"/") {
+					return fmt.Errorf("symlink %s to %s outside installation", h.Name, buf)
+				}
+				target := "go" + strings.TrimPrefix(t, PkgPrefix)
+				rel, err := filepath.Rel(filepath.FromSlash(path.Dir(h.Name)), filepath.FromSlash(target))
+				if err != nil {
+					return fmt.Errorf("symlink %s to %s: %v", h.Name, buf, err)
+				}
+				t = filepath.ToSlash(rel)
+			}
+			h.Linkname = t
+		}
+		if err := x.checkTar(h); err != nil {
+			return err
+		}
+		if err := x.storeTar(cr, h, name); err != nil {
+			return err
+		}
+	}
+	success = true
+	return nil
+}
+
+// xarTOCFile is a file in the table of contents of a xar archive.
+type xarTOCFile struct {
+	Name string `xml:"name"`
+	Type string `xml:"type"`
+	Data struct {
+		Offset   int64 `xml:"offset"`
+		Length   int64 `xml:"length"`
+		Encoding struct {
+			Style string `xml:"style,attr"`
+		} `xml:"encoding"`
+	} `xml:"data"`
+	Files []xarTOCFile `xml:"file"`
+}
+
+// xarPayload returns the contents of the file named Payload in the xar archive
+// in r, e.g. "org.golang.go.pkg/Payload".
+func xarPayload(r *io.SectionReader) (io.Reader, error) {
+	var hdr struct {
+		Magic                uint32
+		HeaderSize           uint16
+		Version              uint16
+		TOCLengthCompressed  uint64
+		TOCLengthUncompresed uint64
+		ChecksumAlg          uint32
+	}
+	if err := binary.Read(io.NewSectionReader(r, 0, 28), binary.BigEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("reading xar header: %v", err)
+	}
+	if hdr.Magic != 0x78617221 {
+		return nil, fmt.Errorf("not a xar archive")
+	}
+	zr, err := zlib.NewReader(io.NewSectionReader(r, int64(hdr.HeaderSize), int64(hdr.TOCLengthCompressed)))
+	if err != nil {
+		return nil, fmt.Errorf("xar table of contents: %v", err)
+	}
+	var toc struct {
+		Files []xarTOCFile `xml:"toc>file"`
+	}
+	if err := xml.NewDecoder(zr).Decode(&toc); err != nil {
+		return nil, fmt.Errorf("parsing xar table of contents: %v", err)
+	}
+	heap := int64(hdr.HeaderSize) + int64(hdr.TOCLengthCompressed)
+
+	var find func(l []xarTOCFile) *xarTOCFile
+	find = func(l []xarTOCFile) *xarTOCFile {
+		for i, f := range l {
+			if f.Type == "file" && f.Name == "Payload" {
+				return &l[i]
+			}
+			if p := find(f.Files); p != nil {
+				return p
+			}
+		}
+		return nil
+	}
+	p := find(toc.Files)
+	if p == nil {
+		return nil, fmt.Errorf("no payload in pkg file")
+	}
+	data := io.NewSectionReader(r, heap+p.Data.Offset, p.Data.Length)
+	switch p.Data.Encoding.Style {
+	case "", "application/octet-stream":
+		return data, nil
+	case "application/x-gzip":
+		// Xar uses zlib for "gzip".
+		return zlib.NewReader(data)
+	}
+	return nil, fmt.Errorf("unsupported payload encoding %q", p.Data.Encoding.Style)
+}
+
+// cpioReader reads entries from a cpio archive in odc ("070707") or newc
+// ("070701") format, as used in the payload of pkg files.
+type cpioReader struct {
+	r       *bufio.Reader
+	data    io.Reader // Of current entry.
+	padding int64     // After data of current entry.
+}
+
+// next returns the header of the next entry, converted to a tar header.
+func (cr *cpioReader) next() (*tar.Header, error) {
+	if cr.data != nil {
+		if _, err := io.Copy(io.Discard, cr.data); err != nil {
+			return nil, err
+		}
+		if _, err := cr.r.Discard(int(cr.padding)); err != nil {
+			return nil, err
+		}
+	}
+
+	magic, err := cr.r.Peek(6)
+	if err != nil {
+		return nil, fmt.Errorf("reading cpio header: %v", err)
+	}
+	var mode, mtime, namesize, filesize int64
+	var align int64 = 1
+	var fields []int64
+	switch string(magic) {
+	case "070707":
+		// dev, ino, mode, uid, gid, nlink, rdev, mtime, namesize, filesize.
+		fields, err = cpioFields(cr.r, 76, 8, 6, 6, 6, 6, 6, 6, 6, 11, 6, 11)
+		if err == nil {
+			mode, mtime, namesize, filesize = fields[2], fields[7], fields[8], fields[9]
+		}
+	case "070701":
+		align = 4
+		// ino, mode, uid, gid, nlink, mtime, filesize, devmajor, devminor,
+		// rdevmajor, rdevminor, namesize, check.
+		fields, err = cpioFields(cr.r, 110, 16, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8)
+		if err == nil {
+			mode, mtime, namesize, filesize = fields[1], fields[5], fields[11], fields[6]
+		}
+	default:
+		return nil, fmt.Errorf("unsupported cpio format %q", magic)
+	}
+	if err != nil {
+		return nil, err
+	}
+	hdrsize := int64(76)
+	if align == 4 {
+		hdrsize = 110
+	}
+	name := make([]byte, namesize+(align-(hdrsize+namesize)%align)%align)
+	if _, err := io.ReadFull(cr.r, name); err != nil {
+		return nil, fmt.Errorf("reading cpio name: %v", err)
+	}
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	if string(name) == "TRAILER!!!" {
+		return nil, io.EOF
+	}
+	cr.data = io.LimitReader(cr.r, filesize)
+	cr.padding = (align - filesize%align) % align
+
+	h := &tar.Header{
+		Name:    strings.TrimPrefix(string(name), "./"),
+		Mode:    mode & 07777,
+		ModTime: time.Unix(mtime, 0),
+	}
+	switch mode & 0170000 {
+	case 0100000:
+		h.Typeflag = tar.TypeReg
+		h.Size = filesize
+	case 0040000:
+		h.Typeflag = tar.TypeDir
+	case 0120000:
+		h.Typeflag = tar.TypeSymlink
+	default:
+		return nil, fmt.Errorf("unsupported cpio file type %o for %s", mode&0170000, name)
+	}
+	return h, nil
+}
+
+func (cr *cpioReader) Read(buf []byte) (int, error) {
+	if cr.data == nil {
+		return 0, io.EOF
+	}
+	return cr.data.Read(buf)
+}
+
+// cpioFields reads a header of size bytes with fields of the given widths
+// after the magic, in the base.
+func cpioFields(r io.Reader, size int, base int, widths ...int) ([]int64, error) {
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("reading cpio header: %v", err)
+	}
+	var l []int64
+	o := 6
+	for _, w := range widths {
+		v, err := strconv.ParseInt(string(buf[o:o+w]), base, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing cpio header: %v", err)
+		}
+		l = append(l, v)
+		o += w
+	}
+	return l, nil
+}
@@ -0,0 +1,89 @@
+package goreleases
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makePkg returns a xar archive with a Payload with a gzip-compressed odc cpio
+// archive with the files, a mode and contents per name.
+func makePkg(t *testing.T, files []struct {
+	name string
+	mode int64
+	data string
+}) []byte {
+	t.Helper()
+	var cpio bytes.Buffer
+	add := func(name string, mode int64, data string) {
+		fmt.Fprintf(&cpio, "070707%06o%06o%06o%06o%06o%06o%06o%011o%06o%011o%s\x00%s", 0, 0, mode, 0, 0, 1, 0, 1715040000, len(name)+1, len(data), name, data)
+	}
+	for _, f := range files {
+		add(f.name, f.mode, f.data)
+	}
+	add("TRAILER!!!", 0, "")
+	var payload bytes.Buffer
+	gzw := gzip.NewWriter(&payload)
+	gzw.Write(cpio.Bytes())
+	gzw.Close()
+
+	toc := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<xar><toc><file id="1"><name>org.golang.go.pkg</name><type>directory</type>
+<file id="2"><name>Payload</name><type>file</type><data><offset>0</offset><length>%d</length><size>%d</size><encoding style="application/octet-stream"/></data></file>
+</file></toc></xar>`, payload.Len(), payload.Len())
+	var ztoc bytes.Buffer
+	zw := zlib.NewWriter(&ztoc)
+	zw.Write([]byte(toc))
+	zw.Close()
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, struct {
+		Magic                          uint32
+		HeaderSize, Version            uint16
+		TOCCompressed, TOCUncompressed uint64
+		ChecksumAlg                    uint32
+	}{0x78617221, 28, 1, uint64(ztoc.Len()), uint64(len(toc)), 0})
+	b.Write(ztoc.Bytes())
+	b.Write(payload.Bytes())
+	return b.Bytes()
+}
+
+func TestFetchPkg(t *testing.T) {
+	pkg := makePkg(t, []struct {
+		name string
+		mode int64
+		data string
+	}{
+		{"./usr", 040755, ""},
+		{"./usr/local", 040755, ""},
+		{"./usr/local/go", 040755, ""},
+		{"./usr/local/go/VERSION", 0100644, "go1.22.3\n"},
+		{"./usr/local/go/bin", 040755, ""},
+		{"./usr/local/go/bin/go", 0100755, "binary"},
+		{"./etc/paths.d/go", 0100644, "/usr/local/go/bin\n"},
+	})
+	file := testFile("go1.22.3", pkg)
+	file.Filename = "go1.22.3.darwin-arm64.pkg"
+	file.Os, file.Arch, file.Kind = "darwin", "arm64", KindInstaller
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: pkg}}}
+	dst := t.TempDir()
+	m, err := c.fetch(context.Background(), file, dst, nil)
+	if err != nil {
+		t.Fatalf("fetch pkg: %v", err)
+	}
+	if len(m.Entries) != 3 {
+		t.Fatalf("got entries %v, expected 3", m.Entries)
+	}
+	if buf, err := os.ReadFile(filepath.Join(dst, "go/bin/go")); err != nil || string(buf) != "binary" {
+		t.Fatalf("reading extracted file: %q %v", buf, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "go/etc")); !os.IsNotExist(err) {
+		t.Fatalf("file outside go installation extracted")
+	}
+}
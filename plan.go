@@ -0,0 +1,229 @@
+package goreleases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// DesiredState is the state of a Manager root to converge to with Plan and
+// Apply.
+type DesiredState struct {
+	// Version specs to have installed, see Resolve, e.g. "1.22" for the latest
+	// patch release of the minor, or "go1.21.10".
+	Versions []string
+
+	// Version spec to make the active version, installed if not in Versions. If
+	// empty, the active version is not changed.
+	Current string
+
+	// If set, installed versions that are not in Versions or Current are
+	// removed. The active version is only removed when Current changes it.
+	// Installs that are not releases, like TipDir, are kept.
+	Exclusive bool
+
+	// Platform of the installs, default runtime.GOOS and runtime.GOARCH.
+	Os   string
+	Arch string
+}
+
+// Actions in a Plan.
+const (
+	PlanInstall = "install" // Install the release file.
+	PlanUse     = "use"     // Make the version active.
+	PlanRemove  = "remove"  // Remove the install.
+)
+
+// PlanAction is a single change in a Plan.
+type PlanAction struct {
+	Action   string `json:"action"` // PlanInstall, PlanUse or PlanRemove.
+	Version  string `json:"version"`
+	File     *File  `json:"file,omitempty"`     // For PlanInstall.
+	Previous string `json:"previous,omitempty"` // For PlanUse, the active version before, if any.
+}
+
+// Plan is the list of changes to converge a Manager root to a DesiredState,
+// as returned by Manager.Plan and executed by Manager.Apply. Installs come
+// first, then the change of active version, then removals. A Plan can be
+// stored as JSON, e.g. for review before applying.
+type Plan struct {
+	Root    string       `json:"root"`
+	Actions []PlanAction `json:"actions"`
+
+	// State of the root the plan was made for. Apply refuses to execute the plan
+	// if the root has changed.
+	Installed []string `json:"installed"`
+	Current   string   `json:"current"`
+}
+
+// ErrStalePlan is returned by Apply if the installs or active version of a
+// Manager root changed since the plan was made.
+var ErrStalePlan = errors.New("manager root changed since plan was made")
+
+// Empty returns whether the plan has no actions, i.e. the root is in the
+// desired state.
+func (p *Plan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+// String returns the actions of the plan, one per line, for review.
+func (p *Plan) String() string {
+	var b strings.Builder
+	for _, a := range p.Actions {
+		switch a.Action {
+		case PlanInstall:
+			if a.File != nil {
+				fmt.Fprintf(&b, "+ install %s (%s)\n", a.Version, a.File.Filename)
+			} else {
+				fmt.Fprintf(&b, "+ install %s\n", a.Version)
+			}
+		case PlanUse:
+			if a.Previous != "" {
+				fmt.Fprintf(&b, "~ use %s, was %s\n", a.Version, a.Previous)
+			} else {
+				fmt.Fprintf(&b, "~ use %s\n", a.Version)
+			}
+		case PlanRemove:
+			fmt.Fprintf(&b, "- remove %s\n", a.Version)
+		}
+	}
+	return b.String()
+}
+
+// Plan resolves the desired state against the releases, and returns the
+// actions needed to converge the root to it. Nothing is changed, see Apply.
+func (m *Manager) Plan(ctx context.Context, desired DesiredState) (*Plan, error) {
+	goos, goarch := desired.Os, desired.Arch
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	installed, err := m.Installed()
+	if err != nil {
+		return nil, err
+	}
+	current, err := m.Current()
+	if err != nil {
+		return nil, err
+	}
+	p := &Plan{Root: m.Root, Installed: installed, Current: current}
+	if p.Installed == nil {
+		p.Installed = []string{}
+	}
+	p.Actions = []PlanAction{}
+
+	rels, err := m.client().ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	have := map[string]bool{}
+	for _, v := range installed {
+		have[v] = true
+	}
+	want := map[string]bool{}
+	add := func(spec string) (string, error) {
+		rel, err := Resolve(rels, spec)
+		if err != nil {
+			return "", err
+		}
+		if want[rel.Version] {
+			return rel.Version, nil
+		}
+		want[rel.Version] = true
+		if have[rel.Version] {
+			return rel.Version, nil
+		}
+		f, err := FindFile(rel, goos, goarch, KindArchive)
+		if err != nil {
+			return "", fmt.Errorf("finding file for %s/%s in %s: %w", goos, goarch, rel.Version, err)
+		}
+		p.Actions = append(p.Actions, PlanAction{Action: PlanInstall, Version: rel.Version, File: &f})
+		return rel.Version, nil
+	}
+	for _, spec := range desired.Versions {
+		if _, err := add(spec); err != nil {
+			return nil, err
+		}
+	}
+	active := current
+	if desired.Current != "" {
+		v, err := add(desired.Current)
+		if err != nil {
+			return nil, err
+		}
+		if v != current {
+			p.Actions = append(p.Actions, PlanAction{Action: PlanUse, Version: v, Previous: current})
+		}
+		active = v
+	}
+	if desired.Exclusive {
+		for _, v := range installed {
+			if _, err := ParseVersion(v); err != nil || want[v] || v == active {
+				continue
+			}
+			p.Actions = append(p.Actions, PlanAction{Action: PlanRemove, Version: v})
+		}
+	}
+	return p, nil
+}
+
+// Apply executes the actions of plan, as returned by Plan for the same root.
+// If the installs or active version of the root changed since the plan was
+// made, ErrStalePlan is returned and nothing is done. Installs are verified
+// against the checksums in the plan. If an action fails, the remaining actions
+// are not executed and the error is returned.
+func (m *Manager) Apply(ctx context.Context, plan *Plan) error {
+	if plan.Root != m.Root {
+		return fmt.Errorf("plan is for root %s, not %s", plan.Root, m.Root)
+	}
+	installed, err := m.Installed()
+	if err != nil {
+		return err
+	}
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+	if installed == nil {
+		installed = []string{}
+	}
+	if current != plan.Current || !reflect.DeepEqual(installed, plan.Installed) {
+		return ErrStalePlan
+	}
+
+	c := m.client()
+	var dedup bool
+	for _, a := range plan.Actions {
+		var err error
+		switch a.Action {
+		case PlanInstall:
+			if a.File == nil {
+				return fmt.Errorf("install of %s without file", a.Version)
+			}
+			rel := Release{Version: a.Version, Files: []File{*a.File}}
+			opts := &InstallOptions{Os: a.File.Os, Arch: a.File.Arch, Permissions: m.Permissions}
+			_, err = c.installWith(ctx, []Release{rel}, a.Version, m.Path(a.Version), opts)
+			dedup = true
+		case PlanUse:
+			err = m.Use(a.Version)
+		case PlanRemove:
+			err = m.Remove(a.Version)
+		default:
+			err = fmt.Errorf("unknown action %q", a.Action)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", a.Action, a.Version, err)
+		}
+	}
+	if m.Hardlink && dedup {
+		if _, err := Dedup(m.Root); err != nil {
+			return fmt.Errorf("deduplicating: %v", err)
+		}
+	}
+	return nil
+}
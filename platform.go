@@ -0,0 +1,293 @@
+package goreleases
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Platform is a combination of os, arch and kind for which a release has a file.
+// For source files, Os and Arch are empty.
+type Platform struct {
+	Os   string
+	Arch string
+	Kind string
+}
+
+// Platforms returns the distinct combinations of os, arch and kind for which
+// release has files, sorted by os, arch, kind.
+func Platforms(release Release) []Platform {
+	seen := map[Platform]bool{}
+	var l []Platform
+	for _, f := range release.Files {
+		p := Platform{f.Os, f.Arch, f.Kind}
+		if !seen[p] {
+			seen[p] = true
+			l = append(l, p)
+		}
+	}
+	sort.Slice(l, func(i, j int) bool {
+		a, b := l[i], l[j]
+		if a.Os != b.Os {
+			return a.Os < b.Os
+		}
+		if a.Arch != b.Arch {
+			return a.Arch < b.Arch
+		}
+		return a.Kind < b.Kind
+	})
+	return l
+}
+
+// HasPlatform returns whether release has a file for os, arch and kind, with
+// the same matching rules as FindFile.
+func HasPlatform(release Release, os, arch, kind string) bool {
+	_, err := FindFile(release, os, arch, kind)
+	return err == nil
+}
+
+// firstClassPorts are the first-class ports, as listed by "go tool dist list
+// -json".
+var firstClassPorts = map[string]bool{
+	"darwin/amd64":  true,
+	"darwin/arm64":  true,
+	"linux/386":     true,
+	"linux/amd64":   true,
+	"linux/arm":     true,
+	"linux/arm64":   true,
+	"windows/386":   true,
+	"windows/amd64": true,
+}
+
+// FirstClass returns whether p is for a first-class port, for which broken
+// builds block releases, e.g. to filter the result of Platforms. Arm variants
+// like "armv6l" are of port arm. Source files are not for a port.
+func (p Platform) FirstClass() bool {
+	arch := p.Arch
+	if _, ok := parseARM(arch); ok {
+		arch = "arm"
+	}
+	return p.Kind != KindSource && firstClassPorts[p.Os+"/"+arch]
+}
+
+// FileComparison is the result of CompareFiles.
+type FileComparison struct {
+	OnlyA []File     // Platforms only release A has files for.
+	OnlyB []File     // Platforms only release B has files for.
+	Both  []FilePair // Platforms both releases have files for.
+}
+
+// FilePair is a file for the same platform in two releases.
+type FilePair struct {
+	A, B         File
+	SameSize     bool
+	SameChecksum bool
+}
+
+// CompareFiles compares the files of releases a and b, per platform, e.g. to
+// notice ports that were added or dropped, or to audit a mirror against
+// upstream by comparing the same release from both. Empty goos and goarch
+// match all files, source files are always included. Results are sorted by
+// platform.
+func CompareFiles(a, b Release, goos, goarch string) FileComparison {
+	files := func(r Release) map[Platform]File {
+		m := map[Platform]File{}
+		for _, f := range r.Files {
+			if f.Kind != KindSource && (goos != "" && f.Os != goos || goarch != "" && f.Arch != goarch) {
+				continue
+			}
+			m[Platform{f.Os, f.Arch, f.Kind}] = f
+		}
+		return m
+	}
+	fa, fb := files(a), files(b)
+
+	var c FileComparison
+	for _, p := range Platforms(Release{Files: append(append([]File{}, a.Files...), b.Files...)}) {
+		x, okA := fa[p]
+		y, okB := fb[p]
+		switch {
+		case okA && okB:
+			c.Both = append(c.Both, FilePair{x, y, x.Size == y.Size, x.Sha256 == y.Sha256})
+		case okA:
+			c.OnlyA = append(c.OnlyA, x)
+		case okB:
+			c.OnlyB = append(c.OnlyB, y)
+		}
+	}
+	return c
+}
+
+// Port is an os and arch with binary release files.
+type Port struct {
+	Os   string
+	Arch string
+}
+
+// PortChange describes the ports added and removed in a release, compared to
+// the previous release.
+type PortChange struct {
+	Version  string
+	Previous string
+	Added    []Port
+	Removed  []Port
+}
+
+// PortChanges returns the ports with binary release files (archives or
+// installers) that were added or removed between consecutive releases from
+// version from up to and including version to, e.g. "go1.14" and "go1.22.3".
+// Empty from or to means no lower or upper bound. Releases that cannot be
+// parsed are skipped. Only releases with changes are returned, oldest first.
+func PortChanges(releases []Release, from, to string) ([]PortChange, error) {
+	var fv, tv Version
+	var err error
+	if from != "" {
+		if fv, err = ParseVersion(from); err != nil {
+			return nil, err
+		}
+	}
+	if to != "" {
+		if tv, err = ParseVersion(to); err != nil {
+			return nil, err
+		}
+	}
+
+	var l []Release
+	for _, rel := range releases {
+		v, err := ParseVersion(rel.Version)
+		if err != nil || from != "" && v.Compare(fv) < 0 || to != "" && v.Compare(tv) > 0 {
+			continue
+		}
+		l = append(l, rel)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return CompareReleases(l[i], l[j]) < 0
+	})
+
+	ports := func(rel Release) map[Port]bool {
+		m := map[Port]bool{}
+		for _, f := range rel.Files {
+			if f.Kind == KindArchive || f.Kind == KindInstaller {
+				m[Port{f.Os, f.Arch}] = true
+			}
+		}
+		return m
+	}
+	sorted := func(m map[Port]bool) []Port {
+		var l []Port
+		for p := range m {
+			l = append(l, p)
+		}
+		sort.Slice(l, func(i, j int) bool {
+			return l[i].Os < l[j].Os || l[i].Os == l[j].Os && l[i].Arch < l[j].Arch
+		})
+		return l
+	}
+
+	var changes []PortChange
+	for i := 1; i < len(l); i++ {
+		prev, cur := ports(l[i-1]), ports(l[i])
+		added, removed := map[Port]bool{}, map[Port]bool{}
+		for p := range cur {
+			if !prev[p] {
+				added[p] = true
+			}
+		}
+		for p := range prev {
+			if !cur[p] {
+				removed[p] = true
+			}
+		}
+		if len(added) > 0 || len(removed) > 0 {
+			changes = append(changes, PortChange{l[i].Version, l[i-1].Version, sorted(added), sorted(removed)})
+		}
+	}
+	return changes, nil
+}
+
+// ParseContainerPlatform parses a container platform string as used by OCI
+// images and docker, e.g. "linux/arm64" or "linux/arm/v7", into os and arch as
+// expected by FindFile. ARM variants v5 to v7 become a GOARM hint, e.g.
+// "armv7", other variants (like "amd64/v2" or "arm64/v8") are ignored.
+func ParseContainerPlatform(s string) (goos, goarch string, err error) {
+	t := strings.Split(s, "/")
+	if len(t) < 2 || len(t) > 3 || t[0] == "" || t[1] == "" {
+		return "", "", fmt.Errorf("bad platform %q, must be os/arch or os/arch/variant", s)
+	}
+	goos, goarch = t[0], t[1]
+	if len(t) == 3 && goarch == "arm" {
+		switch t[2] {
+		case "v5", "v6", "v7":
+			goarch += t[2]
+		default:
+			return "", "", fmt.Errorf("unknown arm variant %q", t[2])
+		}
+	}
+	return goos, goarch, nil
+}
+
+// ContainerPlatform returns the container platform string for os and arch of a
+// File, e.g. "linux/arm/v6" for arch "armv6l".
+func ContainerPlatform(goos, goarch string) string {
+	if v, ok := parseARM(goarch); ok && goarch != "arm" {
+		return fmt.Sprintf("%s/arm/v%d", goos, v)
+	}
+	return goos + "/" + goarch
+}
+
+// NormalizeOS returns the GOOS for an operating system name as printed by
+// "uname -s" or in the OS environment variable on Windows, e.g. "Linux",
+// "Darwin" or "Windows_NT". Names that already are a GOOS are returned as is.
+func NormalizeOS(s string) (string, error) {
+	ls := strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case ls == "linux", ls == "darwin", ls == "windows", ls == "freebsd", ls == "netbsd", ls == "openbsd", ls == "dragonfly", ls == "aix", ls == "illumos", ls == "solaris", ls == "plan9":
+		return ls, nil
+	case ls == "sunos":
+		return "solaris", nil
+	case ls == "windows_nt", strings.HasPrefix(ls, "mingw"), strings.HasPrefix(ls, "msys_nt"), strings.HasPrefix(ls, "cygwin_nt"):
+		return "windows", nil
+	}
+	return "", fmt.Errorf("unknown operating system %q", s)
+}
+
+// NormalizeArch returns the GOARCH for a machine name as printed by "uname -m"
+// or in the PROCESSOR_ARCHITECTURE environment variable on Windows, e.g.
+// "x86_64", "aarch64" or "AMD64". For 32-bit ARM, a GOARM hint accepted by
+// FindFile is returned, e.g. "armv7" for "armv7l". Names that already are a
+// GOARCH are returned as is.
+func NormalizeArch(s string) (string, error) {
+	ls := strings.ToLower(strings.TrimSpace(s))
+	switch ls {
+	case "amd64", "x86_64", "x64", "em64t":
+		return "amd64", nil
+	case "386", "i386", "i486", "i586", "i686", "x86":
+		return "386", nil
+	case "arm64", "aarch64", "arm64e":
+		return "arm64", nil
+	case "arm":
+		return "arm", nil
+	case "armv8l", "armv8":
+		// 32-bit userland on a 64-bit ARM processor.
+		return "armv7", nil
+	case "mipsel":
+		return "mipsle", nil
+	case "mips64el":
+		return "mips64le", nil
+	case "ppc64le", "ppc64", "s390x", "riscv64", "loong64", "mips", "mipsle", "mips64", "mips64le":
+		return ls, nil
+	case "loongarch64":
+		return "loong64", nil
+	}
+	if strings.HasPrefix(ls, "armv") {
+		// E.g. "armv7l", "armv6l", "armv5tel".
+		n := len("armv")
+		for n < len(ls) && ls[n] >= '0' && ls[n] <= '9' {
+			n++
+		}
+		if v, ok := parseARM(ls[:n]); ok && v >= 5 && v <= 7 {
+			return ls[:n], nil
+		}
+	}
+	return "", fmt.Errorf("unknown architecture %q", s)
+}
@@ -0,0 +1,166 @@
+package goreleases
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlatforms(t *testing.T) {
+	rel := Release{Version: "go1.22.3", Files: []File{
+		{Filename: "go1.22.3.windows-amd64.zip", Os: "windows", Arch: "amd64", Kind: KindArchive},
+		{Filename: "go1.22.3.src.tar.gz", Kind: KindSource},
+		{Filename: "go1.22.3.linux-armv6l.tar.gz", Os: "linux", Arch: "armv6l", Kind: KindArchive},
+		{Filename: "go1.22.3.windows-amd64.msi", Os: "windows", Arch: "amd64", Kind: KindInstaller},
+		{Filename: "go1.22.3.freebsd-riscv64.tar.gz", Os: "freebsd", Arch: "riscv64", Kind: KindArchive},
+		{Filename: "go1.22.3.windows-amd64.zip", Os: "windows", Arch: "amd64", Kind: KindArchive},
+	}}
+	expect := []Platform{
+		{"", "", KindSource},
+		{"freebsd", "riscv64", KindArchive},
+		{"linux", "armv6l", KindArchive},
+		{"windows", "amd64", KindArchive},
+		{"windows", "amd64", KindInstaller},
+	}
+	l := Platforms(rel)
+	if !reflect.DeepEqual(l, expect) {
+		t.Fatalf("got platforms %v, expected %v", l, expect)
+	}
+	var firstClass []Platform
+	for _, p := range l {
+		if p.FirstClass() {
+			firstClass = append(firstClass, p)
+		}
+	}
+	if exp := expect[2:]; !reflect.DeepEqual(firstClass, exp) {
+		t.Fatalf("got first-class platforms %v, expected %v", firstClass, exp)
+	}
+
+	for _, x := range []struct {
+		os, arch, kind string
+		exp            bool
+	}{
+		{"windows", "amd64", KindArchive, true},
+		{"windows", "amd64", KindInstaller, true},
+		{"linux", "armv6l", KindArchive, true},
+		{"freebsd", "riscv64", KindArchive, true},
+		{"", "", KindSource, true},
+		{"darwin", "amd64", KindInstaller, false},
+		{"linux", "amd64", KindArchive, false},
+		{"plan9", "mips", KindArchive, false},
+		{"bogus", "armv6l", KindArchive, false},
+	} {
+		if got := HasPlatform(rel, x.os, x.arch, x.kind); got != x.exp {
+			t.Errorf("has platform %s/%s %s: got %v, expected %v", x.os, x.arch, x.kind, got, x.exp)
+		}
+	}
+}
+
+func TestCompareFiles(t *testing.T) {
+	a := Release{Version: "go1.22.3", Files: []File{
+		{Filename: "go1.22.3.src.tar.gz", Kind: KindSource, Sha256: "aa", Size: 1},
+		{Filename: "go1.22.3.linux-amd64.tar.gz", Os: "linux", Arch: "amd64", Kind: KindArchive, Sha256: "bb", Size: 2},
+		{Filename: "go1.22.3.windows-386.zip", Os: "windows", Arch: "386", Kind: KindArchive},
+	}}
+	b := Release{Version: "go1.22.3", Files: []File{
+		{Filename: "go1.22.3.src.tar.gz", Kind: KindSource, Sha256: "aa", Size: 1},
+		{Filename: "go1.22.3.linux-amd64.tar.gz", Os: "linux", Arch: "amd64", Kind: KindArchive, Sha256: "cc", Size: 2},
+		{Filename: "go1.22.3.linux-loong64.tar.gz", Os: "linux", Arch: "loong64", Kind: KindArchive},
+	}}
+
+	c := CompareFiles(a, b, "", "")
+	if len(c.OnlyA) != 1 || c.OnlyA[0].Arch != "386" {
+		t.Fatalf("only in a: got %v", c.OnlyA)
+	}
+	if len(c.OnlyB) != 1 || c.OnlyB[0].Arch != "loong64" {
+		t.Fatalf("only in b: got %v", c.OnlyB)
+	}
+	if len(c.Both) != 2 || c.Both[0].A.Kind != KindSource || !c.Both[0].SameChecksum || c.Both[1].SameChecksum || !c.Both[1].SameSize {
+		t.Fatalf("in both: got %v", c.Both)
+	}
+
+	c = CompareFiles(a, b, "linux", "")
+	if len(c.OnlyA) != 0 || len(c.OnlyB) != 1 || len(c.Both) != 2 {
+		t.Fatalf("comparing linux files: got %v", c)
+	}
+}
+
+func TestPortChanges(t *testing.T) {
+	rel := func(v string, ports ...string) Release {
+		r := Release{Version: v, Files: []File{{Filename: v + ".src.tar.gz", Kind: KindSource}}}
+		for _, p := range ports {
+			os, arch, _ := strings.Cut(p, "/")
+			r.Files = append(r.Files, File{Filename: v + "." + os + "-" + arch + ".tar.gz", Os: os, Arch: arch, Kind: KindArchive})
+		}
+		return r
+	}
+	rels := []Release{
+		rel("go1.15", "linux/amd64", "darwin/amd64"),
+		rel("go1.14", "linux/amd64", "darwin/amd64", "darwin/386"),
+		rel("go1.16", "linux/amd64", "darwin/amd64", "darwin/arm64"),
+		rel("go1.16.1", "linux/amd64", "darwin/amd64", "darwin/arm64"),
+	}
+	changes, err := PortChanges(rels, "1.14", "go1.16.1")
+	if err != nil {
+		t.Fatalf("port changes: %v", err)
+	}
+	expect := []PortChange{
+		{"go1.15", "go1.14", nil, []Port{{"darwin", "386"}}},
+		{"go1.16", "go1.15", []Port{{"darwin", "arm64"}}, nil},
+	}
+	if !reflect.DeepEqual(changes, expect) {
+		t.Fatalf("got changes %v, expected %v", changes, expect)
+	}
+
+	if changes, err := PortChanges(rels, "go1.15", ""); err != nil || len(changes) != 1 || changes[0].Version != "go1.16" {
+		t.Fatalf("port changes from go1.15: %v %v", changes, err)
+	}
+	if _, err := PortChanges(rels, "bogus", ""); err == nil {
+		t.Fatalf("port changes with bad version succeeded")
+	}
+}
+
+func TestContainerPlatform(t *testing.T) {
+	for _, x := range []struct{ platform, goos, goarch, back string }{
+		{"linux/amd64", "linux", "amd64", "linux/amd64"},
+		{"linux/amd64/v2", "linux", "amd64", "linux/amd64"},
+		{"linux/arm64/v8", "linux", "arm64", "linux/arm64"},
+		{"linux/arm/v7", "linux", "armv7", "linux/arm/v7"},
+		{"linux/arm", "linux", "arm", "linux/arm"},
+		{"windows/386", "windows", "386", "windows/386"},
+	} {
+		goos, goarch, err := ParseContainerPlatform(x.platform)
+		if err != nil || goos != x.goos || goarch != x.goarch {
+			t.Fatalf("parse %s: got %s %s %v, expected %s %s", x.platform, goos, goarch, err, x.goos, x.goarch)
+		}
+		if s := ContainerPlatform(goos, goarch); s != x.back {
+			t.Fatalf("platform for %s/%s: got %s, expected %s", goos, goarch, s, x.back)
+		}
+	}
+	if s := ContainerPlatform("linux", "armv6l"); s != "linux/arm/v6" {
+		t.Fatalf("platform for armv6l: got %s", s)
+	}
+	for _, s := range []string{"linux", "linux/arm/v9", "/amd64", "linux/amd64/v2/x"} {
+		if _, _, err := ParseContainerPlatform(s); err == nil {
+			t.Fatalf("parse %q succeeded", s)
+		}
+	}
+}
+
+func TestNormalizePlatform(t *testing.T) {
+	for s, exp := range map[string]string{"Linux": "linux", "Darwin": "darwin", "Windows_NT": "windows", "MINGW64_NT-10.0-19045": "windows", "SunOS": "solaris", "freebsd": "freebsd", "Haiku": ""} {
+		if got, err := NormalizeOS(s); got != exp || (err != nil) != (exp == "") {
+			t.Errorf("normalize os %q: got %q, %v, expected %q", s, got, err, exp)
+		}
+	}
+	for s, exp := range map[string]string{"x86_64": "amd64", "AMD64": "amd64", "i686": "386", "x86": "386", "aarch64": "arm64", "ARM64": "arm64", "armv7l": "armv7", "armv6l": "armv6", "armv5tel": "armv5", "armv8l": "armv7", "ppc64le": "ppc64le", "mips64el": "mips64le", "IA64": "", "armv4": ""} {
+		if got, err := NormalizeArch(s); got != exp || (err != nil) != (exp == "") {
+			t.Errorf("normalize arch %q: got %q, %v, expected %q", s, got, err, exp)
+		}
+	}
+	rel := Release{Files: []File{{Os: "linux", Arch: "armv6l", Kind: KindArchive}}}
+	arch, _ := NormalizeArch("armv7l")
+	if _, err := FindFile(rel, "linux", arch, KindArchive); err != nil {
+		t.Errorf("find file for normalized arch %q: %v", arch, err)
+	}
+}
@@ -0,0 +1,125 @@
+package goreleases
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Errors for problems found by CheckFilesystem, wrapped in a *FilesystemError.
+var (
+	ErrNoExec          = errors.New("file system mounted noexec, installed binaries cannot run")
+	ErrCaseInsensitive = errors.New("file system is not case-sensitive")
+	ErrPathTooLong     = errors.New("file system does not support the path lengths of a release")
+	ErrNoInodes        = errors.New("file system has too few free inodes for a release")
+)
+
+// DefaultPreflightInodes is the number of free inodes required by
+// CheckFilesystem if PreflightOptions.MinInodes is zero. A release has about
+// 15000 files and directories.
+const DefaultPreflightInodes = 20000
+
+// releasePathLen is the length of paths that must be supported in a
+// destination, longer than the longest path of a release, relative to the
+// destination, of about 150 characters.
+const releasePathLen = 200
+
+// fsInfo is information about a file system, from statFS.
+type fsInfo struct {
+	Free       int64 // Bytes available to unprivileged users.
+	FreeInodes int64 // -1 if unknown or unlimited.
+	NoExec     bool
+}
+
+// PreflightOptions configure CheckFilesystem, see Client.Preflight.
+type PreflightOptions struct {
+	// Free inodes required, default DefaultPreflightInodes. Negative disables
+	// the check. Not checked on file systems that allocate inodes dynamically.
+	MinInodes int64
+
+	// If set, the file system must be case-sensitive. Releases install fine on
+	// case-insensitive file systems, the default on macOS and Windows, but
+	// e.g. sharing the install with Linux containers may need this.
+	CaseSensitive bool
+}
+
+// FilesystemError is returned by CheckFilesystem, and by fetches with
+// Client.Preflight, if the file system of a destination is not suitable. Err
+// is one of the Err* errors of CheckFilesystem, or the error of a failed
+// check.
+type FilesystemError struct {
+	Dir string
+	Err error
+}
+
+func (e *FilesystemError) Error() string {
+	return fmt.Sprintf("destination %s: %v", e.Dir, e.Err)
+}
+
+func (e *FilesystemError) Unwrap() error {
+	return e.Err
+}
+
+// CheckFilesystem checks whether the file system of dir, or its nearest
+// existing parent directory, is suitable for extracting a release: It must not
+// be mounted noexec (ErrNoExec), it must support the path lengths of a
+// release (ErrPathTooLong), and must have enough free inodes (ErrNoInodes).
+// With opts.CaseSensitive it must be case-sensitive (ErrCaseInsensitive). The
+// error is a *FilesystemError. Temporary files are created and removed to test
+// the file system.
+func CheckFilesystem(dir string, opts *PreflightOptions) error {
+	var o PreflightOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MinInodes == 0 {
+		o.MinInodes = DefaultPreflightInodes
+	}
+	p := existingParent(dir)
+	fail := func(err error) error {
+		return &FilesystemError{dir, err}
+	}
+
+	if fi, err := statFS(p); err == nil {
+		if fi.NoExec {
+			return fail(ErrNoExec)
+		}
+		if o.MinInodes > 0 && fi.FreeInodes >= 0 && fi.FreeInodes < o.MinInodes {
+			return fail(fmt.Errorf("%w: %d free, need %d", ErrNoInodes, fi.FreeInodes, o.MinInodes))
+		}
+	}
+
+	tmpdir, err := os.MkdirTemp(p, ".goreleases-preflight-")
+	if err != nil {
+		return fail(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	if o.CaseSensitive {
+		name := filepath.Join(tmpdir, "case")
+		if err := os.WriteFile(name, nil, 0666); err != nil {
+			return fail(err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpdir, "CASE")); err == nil {
+			return fail(ErrCaseInsensitive)
+		}
+	}
+
+	// Paths up to releasePathLen relative to dir, with 50-character elements,
+	// longer than any element in a release.
+	n := len(filepath.Clean(dir)) + releasePathLen - len(tmpdir)
+	for q := tmpdir; n > 0; n -= 51 {
+		q = filepath.Join(q, strings.Repeat("p", 50))
+		if n <= 51 {
+			err = os.WriteFile(q, nil, 0666)
+		} else {
+			err = os.Mkdir(q, 0777)
+		}
+		if err != nil {
+			return fail(fmt.Errorf("%w: %v", ErrPathTooLong, err))
+		}
+	}
+	return nil
+}
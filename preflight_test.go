@@ -0,0 +1,35 @@
+package goreleases
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCheckFilesystem(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sdk", "go1.22.3")
+	if err := CheckFilesystem(dir, nil); err != nil {
+		t.Fatalf("check: %s", err)
+	}
+	if runtime.GOOS == "linux" {
+		if err := CheckFilesystem(dir, &PreflightOptions{CaseSensitive: true}); err != nil {
+			t.Fatalf("check case-sensitive: %s", err)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Dir(filepath.Dir(dir)))
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("temporary files left behind: %v %v", entries, err)
+	}
+
+	fi, err := statFS(t.TempDir())
+	if err != nil {
+		t.Skipf("no file system information: %v", err)
+	}
+	var fserr *FilesystemError
+	err = CheckFilesystem(dir, &PreflightOptions{MinInodes: 1 << 62})
+	if fi.FreeInodes >= 0 && (!errors.As(err, &fserr) || !errors.Is(err, ErrNoInodes)) {
+		t.Fatalf("check with many inodes, got %v, expected ErrNoInodes", err)
+	}
+}
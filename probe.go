@@ -0,0 +1,110 @@
+package goreleases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrNoURL is returned by ResolveURL and Probe for files from a Client.Source
+// that does not download over HTTP.
+var ErrNoURL = errors.New("source has no download urls")
+
+// ResolveURL returns the URL that file is downloaded from: BaseURL with the
+// filename, or the URL from a Client.Source implementing URLSource. Useful
+// for display, or for external downloaders, which must verify the checksum.
+func (c *Client) ResolveURL(ctx context.Context, file File) (string, error) {
+	if c.Source == nil {
+		return c.fileURL(file), nil
+	}
+	if us, ok := c.Source.(URLSource); ok {
+		return us.URL(ctx, file)
+	}
+	return "", ErrNoURL
+}
+
+// ProbeResult is the result of Probe.
+type ProbeResult struct {
+	URL    string
+	Exists bool
+	Size   int64 // From Content-Length, -1 if unknown.
+
+	// Whether Size matches the size in the listing. False if either is unknown.
+	SizeMatches bool
+}
+
+// Probe checks with a HEAD request whether file is available for download,
+// and its size, without downloading. A missing file (status 404) is not an
+// error, other failures are.
+func (c *Client) Probe(ctx context.Context, file File) (ProbeResult, error) {
+	url, err := c.ResolveURL(ctx, file)
+	if err != nil {
+		return ProbeResult{}, err
+	}
+	r := ProbeResult{URL: url, Size: -1}
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
+	if err != nil {
+		return r, fmt.Errorf("new request: %w", err)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return r, fmt.Errorf("probing %s: %w", url, err)
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		r.Exists = true
+		r.Size = resp.ContentLength
+		r.SizeMatches = r.Size >= 0 && file.Size > 0 && r.Size == file.Size
+		return r, nil
+	case http.StatusNotFound:
+		return r, nil
+	}
+	return r, fmt.Errorf("probing %s: status %v", url, resp.Status)
+}
+
+// DownloadSize is the result of Client.DownloadSize.
+type DownloadSize struct {
+	Total   int64 // Bytes, of the files with a known size.
+	Files   int
+	Probed  int // Files without size in the listing, sized with a HEAD request.
+	Unknown int // Files whose size could not be determined.
+}
+
+// DownloadSize sums the sizes of files, e.g. to show the size of a mirror
+// operation before starting. For files without size in the listing, the size
+// is determined with Probe if the source has URLs. Files with an unknown size
+// are counted in Unknown. Probe failures other than ErrNoURL are returned.
+func (c *Client) DownloadSize(ctx context.Context, files []File) (DownloadSize, error) {
+	s := DownloadSize{Files: len(files)}
+	for _, f := range files {
+		if f.Size > 0 {
+			s.Total += f.Size
+			continue
+		}
+		r, err := c.Probe(ctx, f)
+		if errors.Is(err, ErrNoURL) {
+			s.Unknown++
+			continue
+		} else if err != nil {
+			return s, err
+		}
+		if !r.Exists || r.Size < 0 {
+			s.Unknown++
+			continue
+		}
+		s.Probed++
+		s.Total += r.Size
+	}
+	return s, nil
+}
+
+// ReleaseFiles returns the files of releases, e.g. for DownloadSize.
+func ReleaseFiles(releases ...Release) []File {
+	var l []File
+	for _, rel := range releases {
+		l = append(l, rel.Files...)
+	}
+	return l
+}
@@ -0,0 +1,60 @@
+package goreleases
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/go1.22.3.linux-amd64.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("12345"))
+	}))
+	defer srv.Close()
+
+	c := Client{BaseURL: srv.URL + "/"}
+	file := File{Filename: "go1.22.3.linux-amd64.tar.gz", Size: 5}
+	if u, err := c.ResolveURL(context.Background(), file); err != nil || u != srv.URL+"/"+file.Filename {
+		t.Fatalf("resolve url: %q, %v", u, err)
+	}
+	r, err := c.Probe(context.Background(), file)
+	if err != nil || !r.Exists || r.Size != 5 || !r.SizeMatches {
+		t.Fatalf("probe: %#v, %v", r, err)
+	}
+	r, err = c.Probe(context.Background(), File{Filename: "go1.22.3.linux-arm64.tar.gz"})
+	if err != nil || r.Exists {
+		t.Fatalf("probe missing file: %#v, %v", r, err)
+	}
+
+	c = Client{Source: &memSource{}}
+	if _, err := c.ResolveURL(context.Background(), file); err != ErrNoURL {
+		t.Fatalf("resolve url for memory source: %v, expected ErrNoURL", err)
+	}
+}
+
+func TestDownloadSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/go1.22.3.linux-amd64.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("12345"))
+	}))
+	defer srv.Close()
+
+	c := Client{BaseURL: srv.URL + "/"}
+	rel := Release{Version: "go1.22.3", Files: []File{
+		{Filename: "go1.22.3.linux-amd64.tar.gz"},
+		{Filename: "go1.22.3.linux-arm64.tar.gz"},
+		{Filename: "go1.22.3.src.tar.gz", Size: 100},
+	}}
+	s, err := c.DownloadSize(context.Background(), ReleaseFiles(rel))
+	if err != nil || s != (DownloadSize{Total: 105, Files: 3, Probed: 1, Unknown: 1}) {
+		t.Fatalf("download size: %#v, %v", s, err)
+	}
+}
@@ -0,0 +1,23 @@
+package goreleases
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Provenance describes how a release file was built, as verified from an
+// attestation, e.g. a SLSA provenance statement.
+type Provenance struct {
+	Builder      string          // ID of the builder, e.g. a URL.
+	SourceURI    string          // Source repository, e.g. "https://go.googlesource.com/go".
+	SourceDigest string          // Commit hash of the source, if known.
+	Statement    json.RawMessage `json:",omitempty"` // Verified attestation, in its original form.
+}
+
+// ProvenanceVerifier verifies the provenance of a downloaded release file.
+// Its sha256 checksum has already been verified against file.Sha256, so
+// attestations can be checked against it. The verified provenance is
+// returned. Upstream does not publish provenance for release files at the
+// time of writing, so verifiers get attestations from elsewhere, e.g. an
+// internal attestation store.
+type ProvenanceVerifier func(ctx context.Context, file File) (*Provenance, error)
@@ -0,0 +1,40 @@
+package goreleases
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestProvenance(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n"})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}}
+
+	errBad := errors.New("no attestation")
+	c.VerifyProvenance = func(ctx context.Context, f File) (*Provenance, error) {
+		return nil, errBad
+	}
+	sdk := t.TempDir()
+	if _, err := c.FetchSDK(context.Background(), file, sdk, nil); !errors.Is(err, errBad) {
+		t.Fatalf("fetch with failing provenance verification: got %v, expected %v", err, errBad)
+	}
+	if SDKInstalled(sdk, file.Version) {
+		t.Fatalf("installed despite failed provenance verification")
+	}
+
+	c.VerifyProvenance = func(ctx context.Context, f File) (*Provenance, error) {
+		return &Provenance{Builder: "https://builder.example", SourceDigest: "abc"}, nil
+	}
+	dir, err := c.FetchSDK(context.Background(), file, sdk, nil)
+	if err != nil {
+		t.Fatalf("fetch: %s", err)
+	}
+	m, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatalf("read manifest: %s", err)
+	}
+	if m.Provenance == nil || m.Provenance.Builder != "https://builder.example" {
+		t.Fatalf("provenance not recorded: %v", m.Provenance)
+	}
+}
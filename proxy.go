@@ -0,0 +1,185 @@
+package goreleases
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Proxy is an http.Handler serving the release listing and release files like
+// the upstream server, to be used as Client.BaseURL. Listings and files are
+// fetched through Client. Release files are verified and cached in Dir,
+// keyed by checksum, and served from there.
+//
+// Requests for the listing are for "/?mode=json", optionally with
+// "&include=all". Files are requested as "/<filename>", signatures and checksums
+// as "/<filename>.asc" and "/<filename>.sha256". Use http.StripPrefix to serve
+// under a path.
+//
+// Each file request lists all releases to find the file, so Client should
+// have a CacheDir or ListingCache.
+type Proxy struct {
+	Client  *Client  // If nil, a zero Client is used.
+	Dir     string   // Directory for cached release files.
+	Metrics *Metrics // If set, cache hits, upstream bytes and verification failures are recorded.
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex // Per filename, for single downloads.
+}
+
+func (p *Proxy) client() Client {
+	var c Client
+	if p.Client != nil {
+		c = *p.Client
+	}
+	c.ArchiveCacheDir = p.Dir
+	c.metrics = p.Metrics
+	return c
+}
+
+func (p *Proxy) lock(name string) func() {
+	p.mu.Lock()
+	if p.locks == nil {
+		p.locks = map[string]*sync.Mutex{}
+	}
+	l, ok := p.locks[name]
+	if !ok {
+		l = &sync.Mutex{}
+		p.locks[name] = l
+	}
+	p.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "405 - method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	c := p.client()
+	ctx := r.Context()
+
+	name := path.Base(r.URL.Path)
+	if r.URL.Path == "/" || r.URL.Path == "" {
+		if r.URL.Query().Get("mode") != "json" {
+			http.NotFound(w, r)
+			return
+		}
+		rels, err := c.List(ctx, r.URL.Query().Get("include") == "all")
+		if err != nil {
+			p.serverError(w, "listing releases", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(rels)
+		return
+	}
+
+	filename := strings.TrimSuffix(strings.TrimSuffix(name, ".asc"), ".sha256")
+	rels, err := c.ListAll(ctx)
+	if err != nil {
+		p.serverError(w, "listing releases", err)
+		return
+	}
+	file, ok := findFilename(rels, filename)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(name, ".sha256"):
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, file.Sha256)
+		return
+	case strings.HasSuffix(name, ".asc"):
+		sig, err := c.Signature(ctx, file)
+		if err != nil {
+			p.serverError(w, "fetching signature", err)
+			return
+		} else if sig == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(sig)
+		return
+	}
+
+	cachePath := archiveCachePath(p.Dir, file.Sha256)
+	if _, err := os.Stat(cachePath); err == nil {
+		p.Metrics.cacheHit()
+	} else {
+		p.Metrics.cacheMiss()
+		unlock := p.lock(file.Filename)
+		err := p.fetch(r, c, file)
+		unlock()
+		if err != nil {
+			p.serverError(w, "fetching file", err)
+			return
+		}
+	}
+	f, err := os.Open(cachePath)
+	if err != nil {
+		p.serverError(w, "opening cached file", err)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		p.serverError(w, "stat cached file", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, file.Filename, fi.ModTime(), f)
+}
+
+// fetch downloads file into the cache, unless another request already did.
+func (p *Proxy) fetch(r *http.Request, c Client, file File) error {
+	if _, err := os.Stat(archiveCachePath(p.Dir, file.Sha256)); err == nil {
+		return nil
+	}
+	f, err := c.createTemp("goreleases-proxy")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		name := f.Name()
+		f.Close()
+		os.Remove(name)
+	}()
+	sum, _, err := c.download(r.Context(), file, f)
+	if err != nil {
+		return err
+	}
+	if err := c.checkSha256(file, sum); err != nil {
+		return err
+	}
+	if _, err := os.Stat(archiveCachePath(p.Dir, file.Sha256)); err != nil {
+		return fmt.Errorf("file not in cache after download: %v", err)
+	}
+	return nil
+}
+
+func (p *Proxy) serverError(w http.ResponseWriter, msg string, err error) {
+	log.Printf("goreleases proxy: %s: %v", msg, err)
+	http.Error(w, "500 - internal server error - "+msg, http.StatusInternalServerError)
+}
+
+// findFilename returns the file with filename from releases.
+func findFilename(releases []Release, filename string) (File, bool) {
+	for _, rel := range releases {
+		for _, f := range rel.Files {
+			if f.Filename == filename {
+				return f, true
+			}
+		}
+	}
+	return File{}, false
+}
@@ -0,0 +1,50 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProxy(t *testing.T) {
+	src := newTestSource(t, "go1.22.3")
+	file := src.releases[0].Files[0]
+	metrics := &Metrics{}
+	proxy := &Proxy{Client: &Client{Source: src}, Dir: t.TempDir(), Metrics: metrics}
+	srv := httptest.NewServer(proxy)
+	defer srv.Close()
+
+	// Fetch through the proxy twice, the second time without the source having the file.
+	c := Client{BaseURL: srv.URL + "/", NoSignatures: true}
+	for i := 0; i < 2; i++ {
+		rels, err := c.ListAll(context.Background())
+		if err != nil {
+			t.Fatalf("listing through proxy: %s", err)
+		}
+		dst := t.TempDir()
+		if err := c.Fetch(context.Background(), rels[0].Files[0], dst, nil); err != nil {
+			t.Fatalf("fetch through proxy: %s", err)
+		}
+		if _, err := os.Stat(filepath.Join(dst, "go", "VERSION")); err != nil {
+			t.Fatalf("stat extracted file: %s", err)
+		}
+		src.files = nil
+	}
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	for _, exp := range []string{
+		"goreleases_proxy_cache_hits_total 1\n",
+		"goreleases_proxy_cache_misses_total 1\n",
+		fmt.Sprintf("goreleases_upstream_bytes_total %d\n", file.Size),
+		"goreleases_verification_failures_total 0\n",
+	} {
+		if !strings.Contains(b.String(), exp) {
+			t.Fatalf("metrics missing %q:\n%s", exp, b.String())
+		}
+	}
+}
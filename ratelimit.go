@@ -0,0 +1,69 @@
+package goreleases
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the rate of requests, with a token bucket. A RateLimiter
+// can be shared between clients, e.g. to stay polite to a server for all
+// operations of a program. It is safe for concurrent use.
+type RateLimiter struct {
+	Interval time.Duration // Minimum average time between requests.
+	Burst    int           // Requests allowed without delay after idle time. At least 1.
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing perSecond requests per second
+// on average, with bursts of up to burst requests.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	return &RateLimiter{Interval: time.Duration(float64(time.Second) / perSecond), Burst: burst}
+}
+
+// Wait blocks until a request may be made, or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	burst := float64(l.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	now := time.Now()
+	if l.last.IsZero() {
+		l.tokens = burst
+	} else if l.Interval > 0 {
+		l.tokens += float64(now.Sub(l.last)) / float64(l.Interval)
+		if l.tokens > burst {
+			l.tokens = burst
+		}
+	} else {
+		l.tokens = burst
+	}
+	l.last = now
+	// Take a token, possibly going negative, the deficit is the time to wait.
+	l.tokens--
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens * float64(l.Interval))
+	}
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		// Return the token.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,30 @@
+package goreleases
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := &RateLimiter{Interval: 20 * time.Millisecond, Burst: 2}
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("wait: %s", err)
+		}
+	}
+	// Two requests in the burst, two more after 20ms each.
+	if d := time.Since(start); d < 35*time.Millisecond {
+		t.Fatalf("4 requests took %s, expected at least 40ms", d)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	l = &RateLimiter{Interval: time.Hour}
+	l.Wait(ctx)
+	if err := l.Wait(ctx); err != context.Canceled {
+		t.Fatalf("wait with canceled context: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// repackTime is the modification time of all entries in archives created by
+// Repack. The zip format cannot represent earlier times.
+var repackTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Repack writes the install in directory dir, e.g. an install by Install or
+// FetchSDK with only some paths included, as an archive with format "tar.gz"
+// or "zip" to w. Entries are prefixed with "go/", as in release files, and can
+// be extracted with Fetch.
+//
+// The archive is deterministic: Entries are sorted by name, have a fixed
+// modification time, no owner, and mode 0755 for directories and executable
+// files, 0644 for other files. Hard links are stored as regular files. The
+// manifest, marker and origin files are not included.
+func Repack(dir string, w io.Writer, format string) error {
+	var add func(name string, fi fs.FileInfo, path string) error
+	var closeArchive func() error
+	switch format {
+	case "tar.gz":
+		gzw := gzip.NewWriter(w)
+		tw := tar.NewWriter(gzw)
+		add = func(name string, fi fs.FileInfo, path string) error {
+			return repackTar(tw, name, fi, path)
+		}
+		closeArchive = func() error {
+			if err := tw.Close(); err != nil {
+				return err
+			}
+			return gzw.Close()
+		}
+	case "zip":
+		zw := zip.NewWriter(w)
+		add = func(name string, fi fs.FileInfo, path string) error {
+			return repackZip(zw, name, fi, path)
+		}
+		closeArchive = zw.Close
+	default:
+		return fmt.Errorf("unknown format %q, must be tar.gz or zip", format)
+	}
+
+	if err := repackWalk(dir, "go", add); err != nil {
+		return err
+	}
+	return closeArchive()
+}
+
+// repackWalk calls add for all files in the install in dir, sorted by name,
+// with names under prefix. The manifest, marker and origin files are skipped.
+func repackWalk(dir, prefix string, add func(name string, fi fs.FileInfo, path string) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := prefix
+		if rel != "." {
+			name += "/" + filepath.ToSlash(rel)
+		}
+		switch name {
+		case prefix + "/" + ManifestFile, prefix + "/" + UnpackedMarker, prefix + "/" + OriginFile:
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := add(name, fi, path); err != nil {
+			return fmt.Errorf("adding %s: %v", name, err)
+		}
+		return nil
+	})
+}
+
+// repackMode returns the normalized mode for an entry in a repacked archive.
+func repackMode(fi fs.FileInfo) fs.FileMode {
+	if fi.IsDir() || fi.Mode()&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+func repackTar(tw *tar.Writer, name string, fi fs.FileInfo, path string) error {
+	h := &tar.Header{Name: name, Mode: int64(repackMode(fi)), ModTime: repackTime, Format: tar.FormatPAX}
+	switch {
+	case fi.IsDir():
+		h.Typeflag = tar.TypeDir
+		h.Name += "/"
+		return tw.WriteHeader(h)
+	case fi.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		h.Typeflag = tar.TypeSymlink
+		h.Linkname = target
+		h.Mode = 0777
+		return tw.WriteHeader(h)
+	case fi.Mode().IsRegular():
+		h.Typeflag = tar.TypeReg
+		h.Size = fi.Size()
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		return repackCopy(tw, path)
+	}
+	return fmt.Errorf("unsupported file type %v", fi.Mode().Type())
+}
+
+func repackZip(zw *zip.Writer, name string, fi fs.FileInfo, path string) error {
+	h := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: repackTime}
+	switch {
+	case fi.IsDir():
+		h.Name += "/"
+		h.Method = zip.Store
+		h.SetMode(fs.ModeDir | repackMode(fi))
+		_, err := zw.CreateHeader(h)
+		return err
+	case fi.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		h.SetMode(fs.ModeSymlink | 0777)
+		fw, err := zw.CreateHeader(h)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(fw, target)
+		return err
+	case fi.Mode().IsRegular():
+		h.SetMode(repackMode(fi))
+		fw, err := zw.CreateHeader(h)
+		if err != nil {
+			return err
+		}
+		return repackCopy(fw, path)
+	}
+	return fmt.Errorf("unsupported file type %v", fi.Mode().Type())
+}
+
+func repackCopy(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,62 @@
+package goreleases
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepack(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/go.bash": "binary", "go/src/x.go": "package x"})
+	file := testFile("go1.22.3", tgz)
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}, Exclude: []string{"src"}}
+	ctx := context.Background()
+	dir, err := c.FetchSDK(ctx, file, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	for _, format := range []string{"tar.gz", "zip"} {
+		var a, b bytes.Buffer
+		if err := Repack(dir, &a, format); err != nil {
+			t.Fatalf("repack %s: %v", format, err)
+		}
+		if err := Repack(dir, &b, format); err != nil {
+			t.Fatalf("repack %s: %v", format, err)
+		}
+		if !bytes.Equal(a.Bytes(), b.Bytes()) {
+			t.Fatalf("repack %s not deterministic", format)
+		}
+
+		// Repacked archives are like release files.
+		rf := testFile("go1.22.3", a.Bytes())
+		rf.Filename = strings.TrimSuffix(rf.Filename, ".tar.gz") + "." + format
+		rc := Client{Source: &memSource{files: map[string][]byte{rf.Filename: a.Bytes()}}}
+		dst := t.TempDir()
+		if err := rc.Fetch(ctx, rf, dst, nil); err != nil {
+			t.Fatalf("fetch repacked %s: %v", format, err)
+		}
+		if v, err := ReadVersion(filepath.Join(dst, "go")); err != nil || v != "go1.22.3" {
+			t.Fatalf("version of repacked %s: %q %v", format, v, err)
+		}
+		fi, err := os.Stat(filepath.Join(dst, "go", "bin", "go.bash"))
+		if err != nil {
+			t.Fatalf("stat repacked %s: %v", format, err)
+		}
+		if fi.Mode()&0100 == 0 {
+			t.Fatalf("repacked %s: executable lost, mode %v", format, fi.Mode())
+		}
+		for _, name := range []string{"src", ManifestFile, UnpackedMarker} {
+			if _, err := os.Stat(filepath.Join(dst, "go", name)); err == nil {
+				t.Fatalf("repacked %s: unexpected %s", format, name)
+			}
+		}
+	}
+
+	if err := Repack(dir, &bytes.Buffer{}, "rar"); err == nil {
+		t.Fatalf("repack with unknown format succeeded")
+	}
+}
@@ -0,0 +1,199 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Repair checks the install in directory dir, as created by FetchSDK, Manager
+// or Install, of release against its manifest and restores the entries that
+// are missing, have another type, or files with another size or checksum.
+// Only the damaged files are extracted, from the release file from the archive
+// cache or downloaded, which is verified as with Fetch. Directories and links
+// are recreated from the manifest. Entries get the permissions the install was
+// made with, as recorded in the manifest. Entries not in the manifest are left
+// alone.
+// The include and exclude patterns of the client are not used. The names of
+// the repaired entries are returned, none if the install is intact.
+func (c *Client) Repair(ctx context.Context, dir string, release Release) ([]string, error) {
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %v", err)
+	}
+	if m.Version != release.Version {
+		return nil, fmt.Errorf("install is of %s, not %s", m.Version, release.Version)
+	}
+	damaged, err := damagedEntries(dir, m)
+	if err != nil {
+		return nil, err
+	}
+	_, err = os.Stat(filepath.Join(dir, UnpackedMarker))
+	if len(damaged) == 0 && err == nil {
+		return nil, nil
+	}
+
+	var files []ManifestEntry
+	var include []string
+	for _, e := range damaged {
+		if e.Type == EntryFile {
+			files = append(files, e)
+			include = append(include, escapePattern(e.Name))
+		}
+	}
+	if len(files) > 0 {
+		var file File
+		var ok bool
+		for _, f := range release.Files {
+			if f.Filename == m.Filename && (m.Sha256 == "" || f.Sha256 == "" || f.Sha256 == m.Sha256) {
+				file, ok = f, true
+				break
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("release file %s of install not in release", m.Filename)
+		}
+
+		tmpdir, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+"-repair-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmpdir)
+		rc := *c
+		rc.Include = include
+		rc.Exclude = nil
+		rc.WriteOrigin = false
+		if _, err := rc.fetch(ctx, file, tmpdir, m.Permissions); err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", file.Filename, err)
+		}
+		for _, e := range files {
+			err := repairFile(filepath.Join(tmpdir, "go", filepath.FromSlash(e.Name)), filepath.Join(dir, filepath.FromSlash(e.Name)), e)
+			if err != nil {
+				return nil, fmt.Errorf("repairing %s: %v", e.Name, err)
+			}
+		}
+	}
+
+	// Directories first, for links in them. Hard links after the files they
+	// link to.
+	var names []string
+	for _, typ := range []string{EntryDir, EntryFile, EntrySymlink, EntryLink} {
+		for _, e := range damaged {
+			if e.Type != typ {
+				continue
+			}
+			names = append(names, e.Name)
+			p := filepath.Join(dir, filepath.FromSlash(e.Name))
+			var err error
+			switch e.Type {
+			case EntryDir:
+				if fi, err := os.Lstat(p); err == nil && !fi.IsDir() {
+					os.Remove(p)
+				}
+				err = os.MkdirAll(p, 0777)
+				if perms := m.Permissions; err == nil && perms != nil {
+					err = os.Chmod(p, perms.Mode)
+				}
+			case EntrySymlink:
+				os.RemoveAll(p)
+				err = os.Symlink(e.Linkname, p)
+			case EntryLink:
+				os.RemoveAll(p)
+				err = os.Link(filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(e.Linkname, "go/"))), p)
+			}
+			if perms := m.Permissions; err == nil && perms != nil && e.Type != EntryLink && (perms.Uid >= 0 || perms.Gid >= 0) {
+				err = os.Lchown(p, perms.Uid, perms.Gid)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("repairing %s: %v", e.Name, err)
+			}
+		}
+	}
+	if c.Sync {
+		if err := syncDirs(dir); err != nil {
+			return nil, fmt.Errorf("sync: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, UnpackedMarker), nil, 0666); err != nil {
+		return nil, fmt.Errorf("writing marker: %v", err)
+	}
+	return names, nil
+}
+
+// damagedEntries returns the entries of manifest m that are missing from the
+// install in dir, have another type, or are files with another size or
+// checksum.
+func damagedEntries(dir string, m *Manifest) ([]ManifestEntry, error) {
+	var damaged []ManifestEntry
+	for _, e := range m.Entries {
+		if strings.HasPrefix(e.Name, "/") || strings.Contains("/"+e.Name+"/", "/../") || e.Type == EntryLink && strings.Contains("/"+e.Linkname+"/", "/../") {
+			return nil, fmt.Errorf("bad name %q in manifest", e.Name)
+		}
+		if e.Type == EntrySymlink {
+			// Symlink targets are relative to the directory of the link.
+			if target, err := symlinkTarget("go/"+e.Name, e.Linkname); err != nil || strictName(target) != nil {
+				return nil, fmt.Errorf("bad symlink target %q for %q in manifest", e.Linkname, e.Name)
+			}
+		}
+		p := filepath.Join(dir, filepath.FromSlash(e.Name))
+		fi, err := os.Lstat(p)
+		var ok bool
+		switch {
+		case err != nil:
+		case e.Type == EntryDir:
+			ok = fi.IsDir()
+		case e.Type == EntrySymlink:
+			target, err := os.Readlink(p)
+			ok = err == nil && target == e.Linkname
+		case e.Type == EntryLink:
+			ok = fi.Mode().IsRegular()
+		default:
+			if fi.Mode().IsRegular() && fi.Size() == e.Size {
+				sum, err := fileSha256(p)
+				ok = err == nil && sum == e.Sha256
+			}
+		}
+		if !ok {
+			damaged = append(damaged, e)
+		}
+	}
+	return damaged, nil
+}
+
+// repairFile replaces dst with the extracted file src, after checking it
+// against e.
+func repairFile(src, dst string, e ManifestEntry) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	err = deltaCheck(f, e)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if fi, err := os.Lstat(dst); err == nil && fi.IsDir() {
+		if err := os.RemoveAll(dst); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+// escapePattern escapes the special characters of path.Match in name, for
+// Client.Include.
+func escapePattern(name string) string {
+	var b strings.Builder
+	for _, c := range name {
+		if strings.ContainsRune(`*?[\`, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package goreleases
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Requirement is the minimum operating system version a release runs on.
+type Requirement struct {
+	Os      string // GOOS, e.g. "darwin".
+	Version string // Minimum version of the OS, e.g. "10.15". For linux the kernel version, for windows the NT version, e.g. "10.0" for Windows 10.
+	Name    string // Human-readable, e.g. "macOS 10.15 Catalina".
+}
+
+// requirements lists the minimum OS versions of releases, from the Go wiki
+// MinimumRequirements page and release notes, per os, newest first.
+var requirements = map[string][]struct {
+	since string // First Go minor with this requirement.
+	req   Requirement
+}{
+	"linux": {
+		{"1.24", Requirement{"linux", "3.2", "Linux kernel 3.2"}},
+		{"1.0", Requirement{"linux", "2.6.32", "Linux kernel 2.6.32"}},
+	},
+	"darwin": {
+		{"1.23", Requirement{"darwin", "11", "macOS 11 Big Sur"}},
+		{"1.21", Requirement{"darwin", "10.15", "macOS 10.15 Catalina"}},
+		{"1.17", Requirement{"darwin", "10.13", "macOS 10.13 High Sierra"}},
+		{"1.15", Requirement{"darwin", "10.12", "macOS 10.12 Sierra"}},
+		{"1.13", Requirement{"darwin", "10.11", "macOS 10.11 El Capitan"}},
+		{"1.11", Requirement{"darwin", "10.10", "macOS 10.10 Yosemite"}},
+	},
+	"windows": {
+		{"1.21", Requirement{"windows", "10.0", "Windows 10 or Windows Server 2016"}},
+		{"1.11", Requirement{"windows", "6.1", "Windows 7 or Windows Server 2008 R2"}},
+	},
+}
+
+// MinimumRequirement returns the known minimum OS version for running release
+// version, e.g. "go1.22.3", on goos. Requirements are known for linux, darwin
+// and windows, for other systems and old releases false is returned.
+func MinimumRequirement(version, goos string) (Requirement, bool) {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return Requirement{}, false
+	}
+	for _, r := range requirements[goos] {
+		since, err := ParseVersion(r.since)
+		if err == nil && (v.Major > since.Major || v.Major == since.Major && v.Minor >= since.Minor) {
+			return r.req, true
+		}
+	}
+	return Requirement{}, false
+}
+
+// RequirementError is returned for a release that requires a newer OS version
+// than the host has.
+type RequirementError struct {
+	Release     string // E.g. "go1.23.0".
+	Requirement Requirement
+	Host        string // Version of the host OS.
+}
+
+func (e *RequirementError) Error() string {
+	return fmt.Sprintf("%s requires %s (%s), host has version %s", e.Release, e.Requirement.Name, e.Requirement.Version, e.Host)
+}
+
+// CheckRequirement checks whether release version can run on goos with OS
+// version host, e.g. from HostOSVersion, returning a *RequirementError if not.
+// If the requirement or host version is unknown, nil is returned.
+func CheckRequirement(version, goos, host string) error {
+	r, ok := MinimumRequirement(version, goos)
+	if !ok {
+		return nil
+	}
+	hv, ok := parseOSVersion(host)
+	if !ok {
+		return nil
+	}
+	mv, _ := parseOSVersion(r.Version)
+	for i := 0; i < len(mv); i++ {
+		var n int
+		if i < len(hv) {
+			n = hv[i]
+		}
+		if n > mv[i] {
+			return nil
+		} else if n < mv[i] {
+			version = "go" + strings.TrimPrefix(version, "go")
+			return &RequirementError{version, r, host}
+		}
+	}
+	return nil
+}
+
+var osVersionRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*`)
+
+// parseOSVersion parses the leading dotted numbers of an OS version, e.g.
+// "6.8.0-31-generic".
+func parseOSVersion(s string) ([]int, bool) {
+	m := osVersionRegexp.FindString(strings.TrimSpace(s))
+	if m == "" {
+		return nil, false
+	}
+	var l []int
+	for _, t := range strings.Split(m, ".") {
+		n, err := strconv.Atoi(t)
+		if err != nil {
+			return nil, false
+		}
+		l = append(l, n)
+	}
+	return l, true
+}
+
+var windowsVerRegexp = regexp.MustCompile(`Version ([0-9.]+)`)
+
+// HostOSVersion returns the version of the host OS, as used in Requirement:
+// The kernel release on linux, the product version on macOS, the NT version
+// on windows.
+func HostOSVersion() (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		buf, err := os.ReadFile("/proc/sys/kernel/osrelease")
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(buf)), nil
+	case "darwin":
+		buf, err := exec.Command("sw_vers", "-productVersion").Output()
+		if err != nil {
+			return "", fmt.Errorf("running sw_vers: %v", err)
+		}
+		return strings.TrimSpace(string(buf)), nil
+	case "windows":
+		buf, err := exec.Command("cmd", "/c", "ver").Output()
+		if err != nil {
+			return "", fmt.Errorf("running ver: %v", err)
+		}
+		m := windowsVerRegexp.FindSubmatch(buf)
+		if m == nil {
+			return "", fmt.Errorf("parsing output of ver: %q", buf)
+		}
+		return string(m[1]), nil
+	}
+	return "", fmt.Errorf("os version not known for %s", runtime.GOOS)
+}
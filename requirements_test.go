@@ -0,0 +1,37 @@
+package goreleases
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRequirement(t *testing.T) {
+	if r, ok := MinimumRequirement("go1.22.3", "darwin"); !ok || r.Version != "10.15" {
+		t.Fatalf("darwin requirement for go1.22.3: %v %v", r, ok)
+	}
+	if r, ok := MinimumRequirement("1.24rc1", "linux"); !ok || r.Version != "3.2" {
+		t.Fatalf("linux requirement for go1.24rc1: %v %v", r, ok)
+	}
+	if _, ok := MinimumRequirement("go1.22.3", "plan9"); ok {
+		t.Fatalf("unexpected requirement for plan9")
+	}
+
+	var rerr *RequirementError
+	if err := CheckRequirement("go1.23.0", "darwin", "10.15.7"); !errors.As(err, &rerr) || rerr.Requirement.Version != "11" {
+		t.Fatalf("check on old macOS: got %v", err)
+	}
+	for _, host := range []string{"11.0.1", "14.4", "unknown"} {
+		if err := CheckRequirement("go1.23.0", "darwin", host); err != nil {
+			t.Fatalf("check on macOS %s: %v", host, err)
+		}
+	}
+	if err := CheckRequirement("go1.24.0", "linux", "3.2.0-generic"); err != nil {
+		t.Fatalf("check on linux 3.2: %v", err)
+	}
+	if err := CheckRequirement("go1.24.0", "linux", "2.6.32-754.el6"); !errors.As(err, &rerr) {
+		t.Fatalf("check on linux 2.6.32: got %v", err)
+	}
+	if err := CheckRequirement("go1.21.0", "windows", "6.1.7601"); !errors.As(err, &rerr) {
+		t.Fatalf("check on windows 7: got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package goreleases
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Resolve returns the release in releases selected by version spec:
+//
+//	"latest"            the newest stable release
+//	"go1.22.3", "1.22.3" exactly that release, also for prereleases like "1.23rc1"
+//	"1.22", "go1.22"    the latest stable patch release of that minor, or the
+//	                    latest prerelease if there are no stable releases yet
+//
+// Minor versions before 1.21 also select their latest patch release, e.g.
+// "1.20" selects go1.20.14, not go1.20.
+func Resolve(releases []Release, spec string) (Release, error) {
+	if spec == "latest" {
+		var best *Release
+		var bestv Version
+		for i, rel := range releases {
+			v, err := ParseVersion(rel.Version)
+			if err != nil || !rel.Stable {
+				continue
+			}
+			if best == nil || v.Compare(bestv) > 0 {
+				best, bestv = &releases[i], v
+			}
+		}
+		if best == nil {
+			return Release{}, fmt.Errorf("no stable release found")
+		}
+		return *best, nil
+	}
+
+	s := strings.TrimPrefix(spec, "go")
+	v, err := ParseVersion(s)
+	if err != nil {
+		return Release{}, fmt.Errorf("parsing version spec %q: %v", spec, err)
+	}
+	if v.Pre != "" || strings.Count(s, ".") != 1 {
+		return findVersion(releases, v)
+	}
+
+	var best *Release
+	var bestv Version
+	for i, rel := range releases {
+		rv, err := ParseVersion(rel.Version)
+		if err != nil || !rv.SameMinor(v) {
+			continue
+		}
+		better := best == nil || rel.Stable && !best.Stable || rel.Stable == best.Stable && rv.Compare(bestv) > 0
+		if better {
+			best, bestv = &releases[i], rv
+		}
+	}
+	if best == nil {
+		return Release{}, fmt.Errorf("no release found for %s", spec)
+	}
+	return *best, nil
+}
+
+// IsLatestPatch returns whether r is the latest stable patch release of its
+// minor version in releases, e.g. go1.22.3 but not go1.22.2 if releases has
+// both. Prereleases are never the latest patch.
+func (r Release) IsLatestPatch(releases []Release) bool {
+	v, err := ParseVersion(r.Version)
+	if err != nil || v.Pre != "" {
+		return false
+	}
+	latest, err := Resolve(releases, v.MinorString())
+	return err == nil && latest.Stable && latest.Version == r.Version
+}
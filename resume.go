@@ -0,0 +1,156 @@
+package goreleases
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resumeJournalFile is the name of the journal in the kept temporary
+// directory of an install, next to the "go" directory, see
+// Client.ResumeExtract.
+const resumeJournalFile = ".goreleases-extracted"
+
+// errResumeStale is returned when data of the release file does not match a
+// file of the earlier extraction, which is then discarded.
+var errResumeStale = errors.New("data differs from earlier extraction, starting over")
+
+// resumeJournal records the files completely extracted into a temporary
+// directory, so an interrupted extraction can be continued. The first line has
+// the options of the extraction, followed by one JSON-encoded resumeEntry per
+// line with the name in the archive.
+type resumeJournal struct {
+	f    *os.File
+	done map[string]resumeEntry // By name in archive.
+}
+
+// resumeEntry is a file in the journal, with the mode it was created with.
+type resumeEntry struct {
+	ManifestEntry
+	Mode os.FileMode
+}
+
+// resumeOptions are the options that determine the result of an extraction. An
+// earlier extraction with other options is not continued. Functions like
+// Client.OwnerMap and Client.XattrFilter cannot be compared and are assumed
+// unchanged.
+type resumeOptions struct {
+	Permissions  *Permissions
+	Include      []string
+	Exclude      []string
+	ExactModes   bool
+	Xattrs       bool
+	ArchiveOwner bool
+}
+
+// resumeDir returns the temporary directory next to dir for a resumable
+// install of file, which must have a valid sha256.
+func resumeDir(dir string, file File) string {
+	return filepath.Join(filepath.Dir(dir), "."+filepath.Base(dir)+"-"+file.Sha256[:16]+".partial")
+}
+
+// removeResumeDirs removes the temporary directories of resumable installs
+// into dir, e.g. of other release files, that were abandoned.
+func removeResumeDirs(dir string) {
+	entries, err := os.ReadDir(filepath.Dir(dir))
+	if err != nil {
+		return
+	}
+	prefix := "." + filepath.Base(dir) + "-"
+	for _, e := range entries {
+		if name := e.Name(); e.IsDir() && strings.HasPrefix(name, prefix) && strings.HasSuffix(name, ".partial") {
+			os.RemoveAll(filepath.Join(filepath.Dir(dir), name))
+		}
+	}
+}
+
+// openResumeJournal opens the journal in tmpdir, reading the files recorded by
+// an earlier extraction. An incomplete last line, from an interrupted write, is
+// ignored. If the earlier extraction had other options, its files are removed
+// and the extraction starts over.
+func openResumeJournal(tmpdir string, opts resumeOptions) (*resumeJournal, error) {
+	p := filepath.Join(tmpdir, resumeJournalFile)
+	header, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	j := &resumeJournal{done: map[string]resumeEntry{}}
+	if f, err := os.Open(p); err == nil {
+		scanner := bufio.NewScanner(f)
+		same := scanner.Scan() && scanner.Text() == string(header)
+		for same && scanner.Scan() {
+			var e resumeEntry
+			if json.Unmarshal(scanner.Bytes(), &e) == nil && e.Type == EntryFile {
+				j.done[e.Name] = e
+			}
+		}
+		f.Close()
+		if !same {
+			if err := os.RemoveAll(filepath.Join(tmpdir, "go")); err != nil {
+				return nil, err
+			}
+			if err := os.Remove(p); err != nil {
+				return nil, err
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	j.f = f
+	if fi, err := f.Stat(); err == nil && fi.Size() == 0 {
+		if _, err := f.Write(append(header, '\n')); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// completed returns the entry for the file with name in the archive if an
+// earlier extraction completed it, and it is still intact at path p.
+func (j *resumeJournal) completed(name, p string, size int64) (resumeEntry, bool) {
+	if j == nil {
+		return resumeEntry{}, false
+	}
+	e, ok := j.done[name]
+	if !ok || e.Size != size {
+		return resumeEntry{}, false
+	}
+	fi, err := os.Lstat(p)
+	if err != nil || !fi.Mode().IsRegular() || fi.Size() != size {
+		return resumeEntry{}, false
+	}
+	if sum, err := fileSha256(p); err != nil || sum != e.Sha256 {
+		return resumeEntry{}, false
+	}
+	return e, true
+}
+
+// record adds a completely extracted file, created with mode, to the journal.
+// Writes are not synced: A lost record only causes the file to be extracted
+// again.
+func (j *resumeJournal) record(name string, size int64, sha256 string, mode os.FileMode) error {
+	if j == nil {
+		return nil
+	}
+	buf, err := json.Marshal(resumeEntry{ManifestEntry{Name: name, Type: EntryFile, Size: size, Sha256: sha256}, mode})
+	if err != nil {
+		return err
+	}
+	_, err = j.f.Write(append(buf, '\n'))
+	return err
+}
+
+func (j *resumeJournal) close() error {
+	if j == nil {
+		return nil
+	}
+	return j.f.Close()
+}
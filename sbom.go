@@ -0,0 +1,148 @@
+package goreleases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ScanInstall returns a manifest for the Go installation in goroot: its
+// manifest if it was installed by this package, otherwise one with the version
+// from the VERSION file and all files in goroot, without release file.
+func ScanInstall(goroot string) (*Manifest, error) {
+	if m, err := ReadManifest(goroot); err == nil {
+		return m, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	version, err := ReadVersion(goroot)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := walkManifest(goroot)
+	if err != nil {
+		return nil, err
+	}
+	return &Manifest{Version: version, Entries: entries}, nil
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxComponent struct {
+	Type               string         `json:"type"`
+	BOMRef             string         `json:"bom-ref,omitempty"`
+	Name               string         `json:"name"`
+	Version            string         `json:"version,omitempty"`
+	Supplier           *cdxSupplier   `json:"supplier,omitempty"`
+	Hashes             []cdxHash      `json:"hashes,omitempty"`
+	Licenses           []cdxLicense   `json:"licenses,omitempty"`
+	ExternalReferences []cdxExtRef    `json:"externalReferences,omitempty"`
+	Components         []cdxComponent `json:"components,omitempty"`
+}
+
+type cdxSupplier struct {
+	Name string `json:"name"`
+}
+
+type cdxLicense struct {
+	License struct {
+		ID string `json:"id"`
+	} `json:"license"`
+}
+
+type cdxExtRef struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// WriteCycloneDX writes a CycloneDX 1.5 JSON SBOM for the install described by
+// m, e.g. from ReadManifest or ScanInstall, to w. The toolchain is the main
+// component, with the sha256 of the release file and url as distribution
+// location if known. Each regular file is a nested component with its sha256.
+func WriteCycloneDX(w io.Writer, m *Manifest, url string) error {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return err
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	var lic cdxLicense
+	lic.License.ID = "BSD-3-Clause"
+	main := cdxComponent{
+		Type:     "application",
+		BOMRef:   "go",
+		Name:     "go",
+		Version:  m.Version,
+		Supplier: &cdxSupplier{"Google LLC"},
+		Licenses: []cdxLicense{lic},
+	}
+	if m.Sha256 != "" {
+		main.Hashes = []cdxHash{{"SHA-256", m.Sha256}}
+	}
+	if url != "" {
+		main.ExternalReferences = []cdxExtRef{{"distribution", url}}
+	}
+	for _, e := range m.Entries {
+		if e.Type == EntryFile {
+			main.Components = append(main.Components, cdxComponent{Type: "file", Name: e.Name, Hashes: []cdxHash{{"SHA-256", e.Sha256}}})
+		}
+	}
+
+	doc := struct {
+		BOMFormat    string `json:"bomFormat"`
+		SpecVersion  string `json:"specVersion"`
+		SerialNumber string `json:"serialNumber"`
+		Version      int    `json:"version"`
+		Metadata     struct {
+			Timestamp string `json:"timestamp"`
+			Tools     []struct {
+				Name string `json:"name"`
+			} `json:"tools"`
+			Component cdxComponent `json:"component"`
+		} `json:"metadata"`
+	}{BOMFormat: "CycloneDX", SpecVersion: "1.5", Version: 1}
+	doc.SerialNumber = fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+	doc.Metadata.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	doc.Metadata.Tools = append(doc.Metadata.Tools, struct {
+		Name string `json:"name"`
+	}{"goreleases"})
+	doc.Metadata.Component = main
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(doc)
+}
+
+// SBOMHook returns an InstallHook that writes a CycloneDX SBOM for each
+// install to dir/<filename>.cdx.json, with the download URL of the release
+// file if fetched from BaseURL. The SBOM is not written into the install, so
+// that Remove keeps working.
+func (c *Client) SBOMHook(dir string) InstallHook {
+	return func(ctx context.Context, goroot string, file File) error {
+		m, err := ScanInstall(goroot)
+		if err != nil {
+			return err
+		}
+		if m.Sha256 == "" {
+			m.Sha256 = file.Sha256
+		}
+		f, err := os.Create(filepath.Join(dir, file.Filename+".cdx.json"))
+		if err != nil {
+			return err
+		}
+		if err := WriteCycloneDX(f, m, c.fileURL(file)); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+}
@@ -0,0 +1,45 @@
+package goreleases
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSBOM(t *testing.T) {
+	tgz := makeTgz(t, map[string]string{"go/VERSION": "go1.22.3\n", "go/bin/go": "binary"})
+	file := testFile("go1.22.3", tgz)
+	sbomdir := t.TempDir()
+	c := Client{Source: &memSource{files: map[string][]byte{file.Filename: tgz}}}
+	c.PostInstall = []InstallHook{c.SBOMHook(sbomdir)}
+	if err := c.Fetch(context.Background(), file, t.TempDir(), nil); err != nil {
+		t.Fatalf("fetch: %s", err)
+	}
+
+	buf, err := os.ReadFile(filepath.Join(sbomdir, file.Filename+".cdx.json"))
+	if err != nil {
+		t.Fatalf("reading sbom: %s", err)
+	}
+	var doc struct {
+		BOMFormat string
+		Metadata  struct {
+			Component struct {
+				Version    string
+				Hashes     []cdxHash
+				Components []cdxComponent
+			}
+		}
+	}
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		t.Fatalf("parsing sbom: %s", err)
+	}
+	comp := doc.Metadata.Component
+	if doc.BOMFormat != "CycloneDX" || comp.Version != "go1.22.3" || len(comp.Hashes) != 1 || comp.Hashes[0].Content != file.Sha256 {
+		t.Fatalf("unexpected sbom %s", buf)
+	}
+	if len(comp.Components) != 2 {
+		t.Fatalf("got %d file components, expected 2", len(comp.Components))
+	}
+}
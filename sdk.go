@@ -0,0 +1,194 @@
+package goreleases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// UnpackedMarker is the file created in an sdk install after a successful
+// extraction, as used by the golang.org/dl wrappers.
+const UnpackedMarker = ".unpacked-success"
+
+// SDKDir returns the directory with sdk installs used by the golang.org/dl
+// wrappers, $HOME/sdk.
+func SDKDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "sdk"), nil
+}
+
+// FetchSDK is like Client.FetchSDK, with the default client unless configured
+// with opts, see SetDefaultClient.
+func FetchSDK(file File, sdk string, permissions *Permissions, opts ...Option) (string, error) {
+	ctx, c, permissions := makeOptions(permissions, opts)
+	return c.FetchSDK(ctx, file, sdk, permissions)
+}
+
+// FetchSDK fetches file and installs it in directory sdk in the layout used
+// by the golang.org/dl wrappers: The release is placed in sdk/<version>,
+// e.g. $HOME/sdk/go1.22.3 (without "go" subdirectory), and the
+// UnpackedMarker file is created in it, along with a manifest, see Remove.
+// Directory sdk is created if needed.
+//
+// The release is first extracted into a temporary directory in sdk, and
+// renamed into place when complete. The path of the install is returned, also
+// if a post-install hook fails and the install was removed.
+func (c *Client) FetchSDK(ctx context.Context, file File, sdk string, permissions *Permissions) (string, error) {
+	if file.Version == "" || filepath.Base(file.Version) != file.Version {
+		return "", fmt.Errorf("bad version %q", file.Version)
+	}
+	dir := filepath.Join(sdk, file.Version)
+	if _, err := os.Stat(dir); err == nil {
+		return "", fmt.Errorf("%s already exists", dir)
+	}
+
+	if err := os.MkdirAll(sdk, 0777); err != nil {
+		return "", err
+	}
+	if err := c.install(ctx, file, dir, permissions); err != nil {
+		return "", err
+	}
+	return dir, c.runHooks(ctx, dir, file)
+}
+
+// install extracts file into a temporary directory next to dir, writes the
+// manifest and UnpackedMarker, and renames it to dir. An existing dir is
+// replaced, only after successful extraction. With Client.ResumeExtract, the
+// temporary directory of an interrupted install is reused.
+func (c *Client) install(ctx context.Context, file File, dir string, permissions *Permissions) error {
+	var tmpdir string
+	var err error
+	_, tarerr := lookupTarFormat(file.Filename)
+	if c.ResumeExtract && validSha256(file.Sha256) && tarerr == nil {
+		tmpdir = resumeDir(dir, file)
+		if err := os.MkdirAll(tmpdir, 0777); err != nil {
+			return err
+		}
+		// An old install moved away by an earlier attempt.
+		os.RemoveAll(filepath.Join(tmpdir, "old"))
+		rc := *c
+		opts := resumeOptions{permissions, c.Include, c.Exclude, c.ExactModes || c.Reproducible, c.Xattrs, c.ArchiveOwner}
+		rc.resume, err = openResumeJournal(tmpdir, opts)
+		if err != nil {
+			return fmt.Errorf("opening resume journal: %v", err)
+		}
+		c = &rc
+	} else {
+		tmpdir, err = os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+"-")
+		if err != nil {
+			return err
+		}
+	}
+	// Kept for a next attempt if resumable.
+	keep := c.resume != nil
+	defer func() {
+		c.resume.close()
+		if !keep {
+			os.RemoveAll(tmpdir)
+		}
+	}()
+
+	m, err := c.fetch(ctx, file, tmpdir, permissions)
+	if err != nil {
+		if errors.Is(err, errResumeStale) {
+			keep = false
+		}
+		return err
+	}
+	rootfi, err := os.Stat(filepath.Join(tmpdir, "go"))
+	if err != nil {
+		return err
+	}
+	if err := writeManifest(filepath.Join(tmpdir, "go"), m); err != nil {
+		return fmt.Errorf("writing manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpdir, "go", UnpackedMarker), nil, 0666); err != nil {
+		return fmt.Errorf("writing marker: %v", err)
+	}
+	if permissions != nil {
+		for _, name := range []string{ManifestFile, UnpackedMarker} {
+			p := filepath.Join(tmpdir, "go", name)
+			if err := os.Chmod(p, permissions.Mode&0666); err != nil {
+				return fmt.Errorf("chmod: %v", err)
+			}
+			if permissions.Uid >= 0 || permissions.Gid >= 0 {
+				if err := os.Lchown(p, permissions.Uid, permissions.Gid); err != nil {
+					return fmt.Errorf("chown: %v", err)
+				}
+			}
+		}
+	}
+	if c.Reproducible {
+		if err := reproducibleFiles(filepath.Join(tmpdir, "go"), rootfi.ModTime(), permissions == nil); err != nil {
+			return err
+		}
+	}
+	if c.Sync {
+		for _, name := range []string{ManifestFile, UnpackedMarker} {
+			if err := syncFile(filepath.Join(tmpdir, "go", name)); err != nil {
+				return fmt.Errorf("sync: %v", err)
+			}
+		}
+		if err := syncDir(filepath.Join(tmpdir, "go")); err != nil {
+			return fmt.Errorf("sync: %v", err)
+		}
+	}
+	if _, err := os.Stat(dir); err == nil {
+		// Moved out of the way, removed with tmpdir.
+		if err := os.Rename(dir, filepath.Join(tmpdir, "old")); err != nil {
+			return fmt.Errorf("moving old install away: %v", err)
+		}
+	}
+	if err := os.Rename(filepath.Join(tmpdir, "go"), dir); err != nil {
+		return fmt.Errorf("moving into place: %v", err)
+	}
+	keep = false
+	removeResumeDirs(dir)
+	c.emit(Event{Type: EventRename, File: file, Dir: dir})
+	if c.Reproducible {
+		// Renaming can change the modification time of the directory.
+		if err := os.Chtimes(dir, rootfi.ModTime(), rootfi.ModTime()); err != nil {
+			return fmt.Errorf("chtimes: %v", err)
+		}
+	}
+	if c.Sync {
+		if err := syncDir(filepath.Dir(dir)); err != nil {
+			return fmt.Errorf("sync: %v", err)
+		}
+	}
+	return nil
+}
+
+// reproducibleFiles sets the modification time of the manifest and marker
+// files in root, and of root itself, to mtime. With fixmode, the files get mode
+// 0644 regardless of the umask.
+func reproducibleFiles(root string, mtime time.Time, fixmode bool) error {
+	for _, name := range []string{ManifestFile, UnpackedMarker} {
+		p := filepath.Join(root, name)
+		if fixmode {
+			if err := os.Chmod(p, 0644); err != nil {
+				return fmt.Errorf("chmod: %v", err)
+			}
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			return fmt.Errorf("chtimes: %v", err)
+		}
+	}
+	if err := os.Chtimes(root, mtime, mtime); err != nil {
+		return fmt.Errorf("chtimes: %v", err)
+	}
+	return nil
+}
+
+// SDKInstalled returns whether version has been installed successfully in
+// directory sdk, i.e. if the UnpackedMarker is present.
+func SDKInstalled(sdk, version string) bool {
+	_, err := os.Stat(filepath.Join(sdk, version, UnpackedMarker))
+	return err == nil
+}
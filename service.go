@@ -0,0 +1,268 @@
+package goreleases
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Service describes a periodic job, e.g. an AutoUpdater or SyncMirror run by
+// the goreleases command, for generating service definitions with
+// SystemdUnits and WinSWConfig.
+type Service struct {
+	Name        string // For units and the Windows service, e.g. "goreleases-autoupdate".
+	Description string
+
+	// Command and arguments running the job once, for a systemd timer.
+	Command []string
+
+	// Command and arguments running the job every Interval until stopped, for a
+	// Windows service.
+	DaemonCommand []string
+
+	// Time between runs. If zero, one hour.
+	Interval time.Duration
+
+	// Environment, as "key=value", e.g. from Client.Environ.
+	Env []string
+
+	// User to run the systemd service as, root if empty.
+	User string
+}
+
+func (s Service) interval() time.Duration {
+	if s.Interval <= 0 {
+		return time.Hour
+	}
+	return s.Interval
+}
+
+// SystemdUnits returns systemd units for the service by file name: a oneshot
+// service running Command, and a timer starting it every Interval, randomly
+// delayed by up to 10% of the interval. Enable with "systemctl enable --now
+// <name>.timer".
+func (s Service) SystemdUnits() map[string]string {
+	var b strings.Builder
+	desc := strings.ReplaceAll(s.Description, "%", "%%")
+	fmt.Fprintf(&b, "[Unit]\nDescription=%s\nWants=network-online.target\nAfter=network-online.target\n\n", desc)
+	b.WriteString("[Service]\nType=oneshot\n")
+	if s.User != "" {
+		fmt.Fprintf(&b, "User=%s\n", s.User)
+	}
+	for _, kv := range s.Env {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(kv, false))
+	}
+	args := make([]string, len(s.Command))
+	for i, arg := range s.Command {
+		args[i] = systemdQuote(arg, true)
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(args, " "))
+	service := b.String()
+
+	d := s.interval()
+	timer := fmt.Sprintf("[Unit]\nDescription=%s\n\n[Timer]\nOnBootSec=%s\nOnUnitActiveSec=%s\nRandomizedDelaySec=%s\nPersistent=true\n\n[Install]\nWantedBy=timers.target\n",
+		desc, systemdDuration(d/10), systemdDuration(d), systemdDuration(d/10))
+
+	return map[string]string{
+		s.Name + ".service": service,
+		s.Name + ".timer":   timer,
+	}
+}
+
+// systemdQuote quotes s as a single word for systemd, escaping specifiers, and
+// variables for command lines.
+func systemdQuote(s string, command bool) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if command {
+		s = strings.ReplaceAll(s, "$", "$$")
+	}
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'\\;") {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// systemdDuration formats d as systemd time span in seconds, at least one.
+func systemdDuration(d time.Duration) string {
+	sec := int64(d / time.Second)
+	if sec < 1 {
+		sec = 1
+	}
+	return fmt.Sprintf("%ds", sec)
+}
+
+// WinSWConfig returns the XML configuration for running DaemonCommand as a
+// Windows service with WinSW, the Windows Service Wrapper. The service is
+// restarted when it fails.
+func (s Service) WinSWConfig() (string, error) {
+	if len(s.DaemonCommand) == 0 {
+		return "", fmt.Errorf("service has no daemon command")
+	}
+	type env struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	}
+	type onFailure struct {
+		Action string `xml:"action,attr"`
+		Delay  string `xml:"delay,attr"`
+	}
+	config := struct {
+		XMLName     xml.Name  `xml:"service"`
+		ID          string    `xml:"id"`
+		Name        string    `xml:"name"`
+		Description string    `xml:"description"`
+		Executable  string    `xml:"executable"`
+		Arguments   string    `xml:"arguments,omitempty"`
+		Env         []env     `xml:"env"`
+		OnFailure   onFailure `xml:"onfailure"`
+		StartMode   string    `xml:"startmode"`
+	}{
+		ID:          s.Name,
+		Name:        s.Name,
+		Description: s.Description,
+		Executable:  s.DaemonCommand[0],
+		OnFailure:   onFailure{"restart", "1 min"},
+		StartMode:   "Automatic",
+	}
+	var args []string
+	for _, arg := range s.DaemonCommand[1:] {
+		args = append(args, windowsQuote(arg))
+	}
+	config.Arguments = strings.Join(args, " ")
+	for _, kv := range s.Env {
+		k, v, _ := strings.Cut(kv, "=")
+		config.Env = append(config.Env, env{k, v})
+	}
+	var b bytes.Buffer
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "\t")
+	if err := enc.Encode(config); err != nil {
+		return "", err
+	}
+	b.WriteString("\n")
+	return b.String(), nil
+}
+
+// windowsQuote quotes s as a single argument for the command line parsing of
+// Windows programs.
+func windowsQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"") {
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for _, c := range s {
+		switch c {
+		case '\\':
+			slashes++
+		case '"':
+			b.WriteString(strings.Repeat(`\`, 2*slashes+1))
+			slashes = 0
+		default:
+			b.WriteString(strings.Repeat(`\`, slashes))
+			slashes = 0
+		}
+		if c != '\\' {
+			b.WriteRune(c)
+		}
+	}
+	b.WriteString(strings.Repeat(`\`, 2*slashes))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// Environ returns the Env* environment variables for the settings of c that
+// can be configured with ApplyEnv, e.g. for Service.Env.
+func (c *Client) Environ() []string {
+	var l []string
+	add := func(name, v string) {
+		if v != "" {
+			l = append(l, name+"="+v)
+		}
+	}
+	add(EnvBaseURL, c.BaseURL)
+	if ms, ok := c.Source.(*MirrorSource); ok {
+		add(EnvMirrors, strings.Join(ms.BaseURLs, ","))
+	}
+	add(EnvCacheDir, c.CacheDir)
+	add(EnvArchiveCacheDir, c.ArchiveCacheDir)
+	add(EnvTempDir, c.TempDir)
+	if c.Offline {
+		add(EnvOffline, "true")
+	}
+	if c.SharedCache {
+		add(EnvSharedCache, "true")
+	}
+	return l
+}
+
+// Service returns a service running the updater with the autoupdate command
+// of the goreleases command at path program, with the settings of the updater
+// and its Manager and Client. Callbacks are not included. Of Client.Exclude,
+// only MinimalExclude can be passed, with flag -minimal.
+func (u *AutoUpdater) Service(program string) Service {
+	m := u.Manager
+	var args []string
+	if strings.Join(m.client().Exclude, "\n") == strings.Join(MinimalExclude, "\n") {
+		args = append(args, "-minimal")
+	}
+	args = append(args, "autoupdate", "-root", m.Root)
+	if u.Os != "" {
+		args = append(args, "-os", u.Os)
+	}
+	if u.Arch != "" {
+		args = append(args, "-arch", u.Arch)
+	}
+	if u.Grace > 0 {
+		args = append(args, "-grace", u.Grace.String())
+	}
+	if m.Shims {
+		args = append(args, "-shims")
+	}
+	if m.Hardlink {
+		args = append(args, "-hardlink")
+	}
+	s := Service{
+		Name:        "goreleases-autoupdate",
+		Description: fmt.Sprintf("Keep Go %s installed in %s", u.Spec, m.Root),
+		Interval:    u.Interval,
+		Env:         m.client().Environ(),
+	}
+	s.Command = append(append([]string{program}, args...), "-once", u.Spec)
+	s.DaemonCommand = append(append([]string{program}, args...), "-interval", s.interval().String(), u.Spec)
+	return s
+}
+
+// SyncMirrorService returns a service running SyncMirror for the mirror in
+// directory dst every interval, with the sync-mirror command of the goreleases
+// command at path program, and the settings of c and opts. Metrics are not
+// included.
+func (c *Client) SyncMirrorService(program, dst string, opts *SyncMirrorOptions, interval time.Duration) Service {
+	var o SyncMirrorOptions
+	if opts != nil {
+		o = *opts
+	}
+	args := []string{"sync-mirror"}
+	if o.All {
+		args = append(args, "-all")
+	}
+	if o.Verify {
+		args = append(args, "-verify")
+	}
+	if o.Concurrency > 0 {
+		args = append(args, "-concurrency", strconv.Itoa(o.Concurrency))
+	}
+	s := Service{
+		Name:        "goreleases-sync-mirror",
+		Description: "Synchronize Go release mirror in " + dst,
+		Interval:    interval,
+		Env:         c.Environ(),
+	}
+	s.Command = append(append([]string{program}, args...), "-once", dst)
+	s.DaemonCommand = append(append([]string{program}, args...), "-interval", s.interval().String(), dst)
+	return s
+}
@@ -0,0 +1,119 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ReleaseSource lists releases and provides their files. A Client implements
+// ReleaseSource, by default for the releases at go.dev. Other sources can be
+// configured with Client.Source.
+type ReleaseSource interface {
+	// List returns the releases, only the supported releases if all is false.
+	List(ctx context.Context, all bool) ([]Release, error)
+
+	// Open returns a reader for the contents of file. Callers verify the
+	// checksum.
+	Open(ctx context.Context, file File) (io.ReadCloser, error)
+}
+
+// SignatureSource is optionally implemented by a ReleaseSource providing pgp
+// signatures for files. Signatures are verified against the Go signing key.
+type SignatureSource interface {
+	// Signature returns the armored detached pgp signature for file, or nil if
+	// the file has no signature.
+	Signature(ctx context.Context, file File) ([]byte, error)
+}
+
+// URLSource is optionally implemented by a ReleaseSource that downloads files
+// over HTTP, for ResolveURL and Probe.
+type URLSource interface {
+	// URL returns the download URL for file.
+	URL(ctx context.Context, file File) (string, error)
+}
+
+// VerifyingSource is optionally implemented by a ReleaseSource whose Open
+// verifies the contents of files itself before returning them, e.g. against a
+// checksum database. Files of such a source are accepted without Sha256 or
+// Checksum, the sha256 is then computed while fetching.
+type VerifyingSource interface {
+	// Verifies returns whether Open verifies the contents of file.
+	Verifies(file File) bool
+}
+
+// sourceVerified returns whether Client.Source verifies file.
+func (c *Client) sourceVerified(file File) bool {
+	vs, ok := c.Source.(VerifyingSource)
+	return ok && vs.Verifies(file)
+}
+
+// List returns releases from Client.Source if set, and from BaseURL otherwise.
+func (c *Client) List(ctx context.Context, all bool) ([]Release, error) {
+	if c.Source != nil {
+		return c.Source.List(ctx, all)
+	}
+	name, url := c.listURL(all)
+	return c.list(ctx, name, url)
+}
+
+// listURL returns the cache name and URL for listing from BaseURL.
+func (c *Client) listURL(all bool) (string, string) {
+	if all {
+		return "all", c.baseURL() + "?mode=json&include=all"
+	}
+	return "supported", c.baseURL() + "?mode=json"
+}
+
+// Open opens file from Client.Source if set, and from BaseURL otherwise.
+func (c *Client) Open(ctx context.Context, file File) (io.ReadCloser, error) {
+	if c.Source != nil {
+		return c.Source.Open(ctx, file)
+	}
+	u, err := c.downloadURL(ctx, file, "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("getting release file: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching file, status %v, expected 200 OK", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// Signature returns the signature of file. If Client.Source is set, the
+// signature is retrieved from the source if it implements SignatureSource, and
+// is nil otherwise. Without Client.Source, the .asc file is fetched from
+// BaseURL. With NoSignatures, nil is returned.
+func (c *Client) Signature(ctx context.Context, file File) ([]byte, error) {
+	if c.NoSignatures {
+		return nil, nil
+	} else if c.Source != nil {
+		if ss, ok := c.Source.(SignatureSource); ok {
+			return ss.Signature(ctx, file)
+		}
+		return nil, nil
+	}
+	u, err := c.downloadURL(ctx, file, ".asc")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("getting .asc signature file: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching .asc signature file, status %v, expected 200 OK", resp.Status)
+	}
+	sigbuf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read .asc signature file: %v", err)
+	}
+	return sigbuf, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !windows
+// +build !linux,!darwin,!freebsd,!windows
+
+package goreleases
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func statFS(dir string) (fsInfo, error) {
+	return fsInfo{}, fmt.Errorf("file system information not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,32 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package goreleases
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// statFS returns information about the file system of dir.
+func statFS(dir string) (fsInfo, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return fsInfo{}, err
+	}
+	// ST_NOEXEC on Linux, MNT_NOEXEC on the BSDs.
+	noexec := uint64(0x4)
+	if runtime.GOOS == "linux" {
+		noexec = 0x8
+	}
+	fi := fsInfo{
+		Free:       int64(st.Bavail) * int64(st.Bsize),
+		FreeInodes: int64(st.Ffree),
+		NoExec:     uint64(st.Flags)&noexec != 0,
+	}
+	// Some file systems, e.g. btrfs, allocate inodes dynamically and report none.
+	if st.Files == 0 {
+		fi.FreeInodes = -1
+	}
+	return fi, nil
+}
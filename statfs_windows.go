@@ -0,0 +1,29 @@
+//go:build windows
+// +build windows
+
+package goreleases
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                = syscall.NewLazyDLL("kernel32.dll")
+	procGetDiskFreeSpaceExW = kernel32.NewProc("GetDiskFreeSpaceExW")
+)
+
+// statFS returns information about the file system of dir. Windows has no
+// inode limits or noexec mounts.
+func statFS(dir string) (fsInfo, error) {
+	p, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return fsInfo{}, err
+	}
+	var avail, total, free uint64
+	r, _, err := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&avail)), uintptr(unsafe.Pointer(&total)), uintptr(unsafe.Pointer(&free)))
+	if r == 0 {
+		return fsInfo{}, err
+	}
+	return fsInfo{Free: int64(avail), FreeInodes: -1}, nil
+}
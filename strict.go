@@ -0,0 +1,83 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// StrictError is returned by fetches with Client.Strict for an archive entry
+// that is not allowed.
+type StrictError struct {
+	Name   string // Of the entry in the archive.
+	Reason string // E.g. "setuid bit set".
+}
+
+func (e *StrictError) Error() string {
+	return fmt.Sprintf("strict mode: entry %q: %s", e.Name, e.Reason)
+}
+
+// strictName checks that an entry name is in the "go" directory.
+func strictName(name string) error {
+	n := strings.TrimSuffix(name, "/")
+	if n != "go" && !strings.HasPrefix(n, "go/") || path.Clean(n) != n {
+		return &StrictError{name, `not in "go" directory`}
+	}
+	return nil
+}
+
+// strictMode checks that a mode has no setuid, setgid or sticky bit.
+func strictMode(name string, mode os.FileMode) error {
+	if mode&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky) != 0 {
+		return &StrictError{name, fmt.Sprintf("mode %v has setuid, setgid or sticky bit", mode)}
+	}
+	return nil
+}
+
+// checkTar checks a tar header in strict mode. Only regular files,
+// directories, and links to entries in the "go" directory are allowed.
+func (x *extractor) checkTar(h *tar.Header) error {
+	if !x.strict {
+		return nil
+	}
+	if err := strictName(h.Name); err != nil {
+		return err
+	}
+	switch h.Typeflag {
+	case tar.TypeReg, tar.TypeDir:
+	case tar.TypeLink:
+		if err := strictName(h.Linkname); err != nil {
+			return &StrictError{h.Name, `link target not in "go" directory`}
+		}
+	case tar.TypeSymlink:
+		target, err := symlinkTarget(h.Name, h.Linkname)
+		if err == nil {
+			err = strictName(target)
+		}
+		if err != nil {
+			return &StrictError{h.Name, `link target not in "go" directory`}
+		}
+	default:
+		return &StrictError{h.Name, fmt.Sprintf("type %q not allowed", h.Typeflag)}
+	}
+	return strictMode(h.Name, h.FileInfo().Mode())
+}
+
+// checkZip checks a zip file entry in strict mode. Only regular files and
+// directories are allowed.
+func (x *extractor) checkZip(zf *zip.File) error {
+	if !x.strict {
+		return nil
+	}
+	if err := strictName(zf.Name); err != nil {
+		return err
+	}
+	mode := zf.Mode()
+	if t := mode.Type() &^ os.ModeDir; t != 0 {
+		return &StrictError{zf.Name, fmt.Sprintf("file type %v not allowed", t)}
+	}
+	return strictMode(zf.Name, mode)
+}
@@ -0,0 +1,91 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Support is the upstream support status of a version. Upstream supports the
+// two most recent minor versions. Support for a minor version ends with the
+// release of the second next minor version, e.g. go1.22.0 ends support for
+// Go 1.20.
+type Support struct {
+	Version      string    // Version the status is for, e.g. "go1.20.3".
+	Supported    bool      // Whether the minor version is still supported. False if Unknown.
+	Unknown      bool      // Version is newer than the latest stable release, its support is not known.
+	LatestPatch  string    // Latest stable release of the minor version, e.g. "go1.20.14". Empty if none.
+	SupersededBy string    // LatestPatch if it is newer than Version, empty otherwise.
+	EndedBy      string    // Release that ended support, e.g. "go1.22.0". Empty if still supported.
+	Ended        time.Time // Release date of EndedBy, if known from the release history.
+}
+
+// SupportStatus returns the support status of version, based on releases,
+// which should include all releases, e.g. from ListAll. If history is not nil,
+// it is used as source for the date support ended.
+func SupportStatus(releases []Release, history []ReleaseNotes, version string) (Support, error) {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return Support{}, err
+	}
+	s := Support{Version: v.String()}
+
+	var latest, latestPatch *Version
+	var endedBy *Version
+	for _, rel := range releases {
+		if !rel.Stable {
+			continue
+		}
+		rv, err := ParseVersion(rel.Version)
+		if err != nil {
+			continue
+		}
+		if latest == nil || rv.Compare(*latest) > 0 {
+			latest = &rv
+		}
+		if rv.SameMinor(v) && (latestPatch == nil || rv.Compare(*latestPatch) > 0) {
+			latestPatch = &rv
+		}
+		if rv.Major == v.Major && rv.Minor == v.Minor+2 && (endedBy == nil || rv.Compare(*endedBy) < 0) {
+			endedBy = &rv
+		}
+	}
+	if latest == nil {
+		return Support{}, fmt.Errorf("no stable releases")
+	}
+	if latestPatch != nil {
+		s.LatestPatch = latestPatch.String()
+		if latestPatch.Compare(v) > 0 {
+			s.SupersededBy = s.LatestPatch
+		}
+	}
+	if v.Compare(*latest) > 0 {
+		s.Unknown = true
+		return s, nil
+	}
+	s.Supported = endedBy == nil && v.Major == latest.Major && v.Minor+1 >= latest.Minor
+	if endedBy != nil {
+		s.EndedBy = endedBy.String()
+		for _, rn := range history {
+			if rn.Version == s.EndedBy {
+				s.Ended = rn.Released
+				break
+			}
+		}
+	}
+	return s, nil
+}
+
+// SupportStatus lists all releases and the release history, and returns the
+// support status of version.
+func (c *Client) SupportStatus(ctx context.Context, version string) (Support, error) {
+	rels, err := c.ListAll(ctx)
+	if err != nil {
+		return Support{}, err
+	}
+	history, err := c.History(ctx)
+	if err != nil {
+		return Support{}, err
+	}
+	return SupportStatus(rels, history, version)
+}
@@ -0,0 +1,39 @@
+package goreleases
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSupportStatus(t *testing.T) {
+	var rels []Release
+	for _, v := range []string{"go1.23rc1", "go1.22.3", "go1.22.0", "go1.21.10", "go1.21.0", "go1.20.14", "go1.20.3", "go1.20"} {
+		rels = append(rels, Release{Version: v, Stable: v != "go1.23rc1"})
+	}
+	history := []ReleaseNotes{{Version: "go1.22.0", Released: time.Date(2024, 2, 6, 0, 0, 0, 0, time.UTC)}}
+
+	s, err := SupportStatus(rels, history, "go1.20.3")
+	if err != nil {
+		t.Fatalf("support status: %s", err)
+	}
+	exp := Support{"go1.20.3", false, false, "go1.20.14", "go1.20.14", "go1.22.0", history[0].Released}
+	if s != exp {
+		t.Fatalf("got %#v, expected %#v", s, exp)
+	}
+
+	s, err = SupportStatus(rels, nil, "go1.21.10")
+	if err != nil {
+		t.Fatalf("support status: %s", err)
+	}
+	exp = Support{"go1.21.10", true, false, "go1.21.10", "", "", time.Time{}}
+	if s != exp {
+		t.Fatalf("got %#v, expected %#v", s, exp)
+	}
+
+	for _, v := range []string{"go1.23rc1", "go1.24.0", "go1.22.4"} {
+		s, err = SupportStatus(rels, nil, v)
+		if err != nil || !s.Unknown || s.Supported {
+			t.Fatalf("support status of %s newer than latest release: %#v %v", v, s, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package goreleases
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// syncFile fsyncs the file at path.
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// syncDir fsyncs a directory, making its entries durable. Windows cannot
+// fsync directories, nothing is done there.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	return syncFile(dir)
+}
+
+// syncDirs fsyncs all directories in the tree at root, and the parent of root.
+func syncDirs(root string) error {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return syncDir(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(root))
+}
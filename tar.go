@@ -2,15 +2,18 @@ package goreleases
 
 import (
 	"archive/tar"
-	"compress/gzip"
 	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 )
 
-func fetchTgz(f *os.File, file File, dst string, permissions *Permissions) error {
+// fetchTar extracts release file f, read as tar stream through format, e.g.
+// a .tar.gz.
+func fetchTar(f *os.File, file File, x *extractor, format ArchiveFormat) error {
+	dst := x.dst
 	fi, err := os.Stat(dst)
 	if err != nil && os.IsNotExist(err) {
 		return fmt.Errorf("dst does not exist")
@@ -22,28 +25,34 @@ func fetchTgz(f *os.File, file File, dst string, permissions *Permissions) error
 		return fmt.Errorf("dst is not a directory")
 	}
 	_, err = os.Stat(filepath.Join(dst, "go"))
-	if err == nil {
+	if err == nil && x.resume == nil {
 		return fmt.Errorf(`directory "go" already exists`)
 	}
 	// we assume it's a not-exists error. if it isn't, eg noperm, we'll probably get the same error later on, which is fine.
 
 	dst = filepath.Clean(dst)
+	if err := x.openRoot(); err != nil {
+		return err
+	}
+	defer x.closeRoot()
 
 	hr := &hashReader{f, sha256.New()}
-	gzr, err := gzip.NewReader(hr)
+	br := x.buffered(hr)
+	tarr, err := format.Tar(br)
 	if err != nil {
-		return fmt.Errorf("gzip reader: %s", err)
+		return err
 	}
-	defer gzr.Close()
+	defer tarr.Close()
 
 	success := false
 	defer func() {
-		if !success {
+		// Partial extractions are kept for resuming.
+		if !success && x.resume == nil {
 			os.RemoveAll(filepath.Join(dst, "go"))
 		}
 	}()
 
-	tr := tar.NewReader(gzr)
+	tr := tar.NewReader(tarr)
 	for {
 		h, err := tr.Next()
 		if err == io.EOF {
@@ -53,17 +62,27 @@ func fetchTgz(f *os.File, file File, dst string, permissions *Permissions) error
 			return fmt.Errorf("reading next header from tar file: %s", err)
 		}
 
+		if err := x.checkTar(h); err != nil {
+			return err
+		}
 		name, err := dstName(dst, h.Name)
 		if err != nil {
 			return err
 		}
+		if x.skip(h.Name, h.Typeflag == tar.TypeDir) || h.Typeflag == tar.TypeLink && x.skip(h.Linkname, false) {
+			continue
+		}
 
-		err = storeTar(dst, tr, h, name, permissions)
+		err = x.storeTar(tr, h, name)
 		if err != nil {
 			return err
 		}
 	}
 
+	// Data after the end of the tar stream is part of the checksum.
+	if _, err := io.Copy(io.Discard, br); err != nil {
+		return fmt.Errorf("reading release file: %v", err)
+	}
 	sum := fmt.Sprintf("%x", hr.h.Sum(nil))
 	if sum != file.Sha256 {
 		return fmt.Errorf("checksum mismatch, got %x, expected %s", sum, file.Sha256)
@@ -72,12 +91,25 @@ func fetchTgz(f *os.File, file File, dst string, permissions *Permissions) error
 	return nil
 }
 
-func storeTar(dst string, tr *tar.Reader, h *tar.Header, name string, perms *Permissions) error {
-	os.MkdirAll(filepath.Dir(name), 0777)
+// storeTar creates the entry of header h at name, through the root of x.
+func (x *extractor) storeTar(tr io.Reader, h *tar.Header, name string) error {
+	dst, perms, root := x.dst, x.perms, x.root
+	rel := path.Clean(h.Name)
+	root.MkdirAll(path.Dir(rel))
+
+	if x.resume != nil && h.Typeflag != tar.TypeDir {
+		if h.Typeflag == tar.TypeReg {
+			if e, ok := x.resume.completed(h.Name, name, h.Size); ok {
+				return x.skipCompleted(tr, h, e)
+			}
+		}
+		// Possibly left by the interrupted extraction.
+		root.Remove(rel)
+	}
 
 	switch h.Typeflag {
 	case tar.TypeReg:
-		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.FileMode(h.Mode)&0777)
+		f, err := root.OpenFile(rel, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.FileMode(h.Mode)&0777)
 		if err != nil {
 			return err
 		}
@@ -87,85 +119,201 @@ func storeTar(dst string, tr *tar.Reader, h *tar.Header, name string, perms *Per
 			}
 		}()
 		lr := io.LimitReader(tr, h.Size)
-		n, err := io.Copy(f, lr)
+		hr := &hashReader{lr, sha256.New()}
+		n, err := x.copy(f, hr)
 		if err != nil {
 			return fmt.Errorf("extracting: %v", err)
 		}
 		if n != h.Size {
 			return fmt.Errorf("extracting %d bytes, expected %d", n, h.Size)
 		}
-		if perms != nil {
-			mode := perms.Mode & 0777
-			if h.Mode&0100 == 0 {
-				mode &= 0666
-			}
-			err = f.Chmod(mode)
+		if err := x.fileMeta(f, h, rel); err != nil {
+			return err
+		}
+		var mode os.FileMode
+		if x.resume != nil {
+			fi, err := f.Stat()
 			if err != nil {
-				return fmt.Errorf("chmod: %s", err)
-			}
-
-			if perms.Uid >= 0 || perms.Gid >= 0 {
-				err := os.Lchown(name, perms.Uid, perms.Gid)
-				if err != nil {
-					return fmt.Errorf("chown: %v", err)
-				}
+				return err
 			}
+			mode = fi.Mode().Perm()
 		}
-		err = os.Chtimes(name, h.AccessTime, h.ModTime)
-		if err != nil {
-			return fmt.Errorf("chtimes: %v", err)
+		if x.sync {
+			if err := f.Sync(); err != nil {
+				return fmt.Errorf("sync: %v", err)
+			}
 		}
 		err = f.Close()
 		if err != nil {
 			return fmt.Errorf("close: %s", err)
 		}
 		f = nil
+		sum := fmt.Sprintf("%x", hr.h.Sum(nil))
+		if err := x.resume.record(h.Name, h.Size, sum, mode); err != nil {
+			return fmt.Errorf("writing resume journal: %v", err)
+		}
+		x.add(h.Name, EntryFile, h.Size, sum, "")
 		return nil
 	case tar.TypeLink:
-		linkname, err := dstName(dst, h.Linkname)
-		if err != nil {
+		if _, err := dstName(dst, h.Linkname); err != nil {
+			return err
+		}
+		if err := root.Link(path.Clean(h.Linkname), rel); err != nil {
 			return err
 		}
-		return os.Link(linkname, name)
+		x.add(h.Name, EntryLink, 0, "", h.Linkname)
+		return nil
 	case tar.TypeSymlink:
-		linkname, err := dstName(dst, h.Linkname)
+		// The target is kept relative, the extraction is moved into place later.
+		target, err := symlinkTarget(h.Name, h.Linkname)
 		if err != nil {
 			return err
 		}
-		err = os.Symlink(linkname, name)
+		if _, err := dstName(dst, target); err != nil {
+			return err
+		}
+		err = root.Symlink(h.Linkname, rel)
 		if err != nil {
 			return err
 		}
 		if perms != nil {
-			err := os.Lchown(name, perms.Uid, perms.Gid)
+			err := root.Lchown(rel, perms.Uid, perms.Gid)
 			if err != nil {
 				return fmt.Errorf("chown: %v", err)
 			}
 		}
+		if err := x.chownHeader(rel, h); err != nil {
+			return err
+		}
+		x.add(h.Name, EntrySymlink, 0, "", h.Linkname)
 		return nil
 	case tar.TypeDir:
-		err := os.Mkdir(name, 0777)
+		err := root.Mkdir(rel, 0777)
+		if err != nil && x.resume != nil && os.IsExist(err) {
+			if fi, serr := root.Lstat(rel); serr == nil && fi.IsDir() {
+				err = nil
+			}
+		}
 		if err != nil {
 			return fmt.Errorf("mkdir: %v", err)
 		}
+		if perms == nil && x.exact {
+			if err := root.Chmod(rel, os.FileMode(h.Mode)&0777); err != nil {
+				return fmt.Errorf("chmod: %s", err)
+			}
+		}
 		if perms != nil {
-			err = os.Chmod(name, perms.Mode)
+			err = root.Chmod(rel, perms.Mode)
 			if err != nil {
 				return fmt.Errorf("chmod: %s", err)
 			}
 
-			err := os.Lchown(name, perms.Uid, perms.Gid)
+			err := root.Lchown(rel, perms.Uid, perms.Gid)
 			if err != nil {
 				return fmt.Errorf("chown: %v", err)
 			}
 		}
-		err = os.Chtimes(name, h.AccessTime, h.ModTime)
+		if err := x.chownHeader(rel, h); err != nil {
+			return err
+		}
+		if x.xattrs {
+			d, err := root.OpenFile(rel, os.O_RDONLY, 0)
+			if err != nil {
+				return err
+			}
+			err = setXattrs(d, h.Name, h.PAXRecords, x.xattrFilter)
+			d.Close()
+			if err != nil {
+				return err
+			}
+		}
+		err = root.Chtimes(rel, h.AccessTime, h.ModTime)
 		if err != nil {
 			return fmt.Errorf("chtimes: %v", err)
 		}
+		x.dirTime(rel, h.ModTime)
+		x.add(h.Name, EntryDir, 0, "", "")
 		return nil
 	case tar.TypeXGlobalHeader, tar.TypeGNUSparse:
 		return nil
 	}
 	return fmt.Errorf("unsupported tar header typeflag %v", h.Typeflag)
 }
+
+// symlinkTarget returns the archive path of the target of symbolic link name,
+// with linkname relative to the directory of the link as in tar files.
+func symlinkTarget(name, linkname string) (string, error) {
+	if linkname == "" || path.IsAbs(linkname) || filepath.IsAbs(linkname) {
+		return "", fmt.Errorf("symlink %q to absolute path %q", name, linkname)
+	}
+	return path.Join(path.Dir(name), linkname), nil
+}
+
+// fileMeta sets the mode, owner, extended attributes and times of the regular
+// file f at rel in the root from header h, and the permissions.
+func (x *extractor) fileMeta(f *os.File, h *tar.Header, rel string) error {
+	perms := x.perms
+	if perms == nil && x.exact {
+		if err := f.Chmod(os.FileMode(h.Mode) & 0777); err != nil {
+			return fmt.Errorf("chmod: %s", err)
+		}
+	}
+	if perms != nil {
+		mode := perms.Mode & 0777
+		if h.Mode&0100 == 0 {
+			mode &= 0666
+		}
+		if err := f.Chmod(mode); err != nil {
+			return fmt.Errorf("chmod: %s", err)
+		}
+
+		if perms.Uid >= 0 || perms.Gid >= 0 {
+			err := f.Chown(perms.Uid, perms.Gid)
+			if err != nil {
+				return fmt.Errorf("chown: %v", err)
+			}
+		}
+	}
+	if err := x.chownHeader(rel, h); err != nil {
+		return err
+	}
+	if x.xattrs {
+		if err := setXattrs(f, h.Name, h.PAXRecords, x.xattrFilter); err != nil {
+			return err
+		}
+	}
+	if err := x.root.Chtimes(rel, h.AccessTime, h.ModTime); err != nil {
+		return fmt.Errorf("chtimes: %v", err)
+	}
+	return nil
+}
+
+// skipCompleted reads the data of the file of header h, extracted completely
+// by an earlier extraction as e, without writing it, and checks it still
+// matches. The metadata is set again, as for a new file.
+func (x *extractor) skipCompleted(tr io.Reader, h *tar.Header, e resumeEntry) error {
+	hr := &hashReader{io.LimitReader(tr, h.Size), sha256.New()}
+	if _, err := x.copy(io.Discard, hr); err != nil {
+		return fmt.Errorf("extracting: %v", err)
+	}
+	if sum := fmt.Sprintf("%x", hr.h.Sum(nil)); sum != e.Sha256 {
+		return fmt.Errorf("%s: %w", h.Name, errResumeStale)
+	}
+	rel := path.Clean(h.Name)
+	f, err := x.root.OpenFile(rel, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// The mode of a new file depends on the umask, it is restored as created.
+	if x.perms == nil && !x.exact && e.Mode != 0 {
+		if err := f.Chmod(e.Mode); err != nil {
+			return fmt.Errorf("chmod: %s", err)
+		}
+	}
+	if err := x.fileMeta(f, h, rel); err != nil {
+		return err
+	}
+	x.add(h.Name, EntryFile, h.Size, e.Sha256, "")
+	return nil
+}
@@ -0,0 +1,170 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultTipURL is the upstream Go repository, served by gitiles.
+const DefaultTipURL = "https://go.googlesource.com/go"
+
+func (c *Client) tipURL() string {
+	if c.TipURL != "" {
+		return strings.TrimSuffix(c.TipURL, "/")
+	}
+	return DefaultTipURL
+}
+
+// TipCommit returns the commit hash that ref, e.g. "master" or
+// "release-branch.go1.22", refers to in the Go repository at Client.TipURL. A
+// full commit hash is returned as is.
+func (c *Client) TipCommit(ctx context.Context, ref string) (string, error) {
+	if isCommitHash(ref) {
+		return ref, nil
+	}
+	u := c.tipURL() + "/+/refs/heads/" + ref + "?format=JSON"
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %v", ref, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("resolving %s: status %v, expected 200 OK", ref, resp.Status)
+	}
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %v", ref, err)
+	}
+	// Gitiles prefixes JSON responses with a line to prevent XSSI.
+	if i := strings.IndexByte(string(buf), '\n'); i >= 0 && strings.HasPrefix(string(buf), ")]}'") {
+		buf = buf[i+1:]
+	}
+	var v struct {
+		Commit string `json:"commit"`
+	}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return "", fmt.Errorf("parsing commit for %s: %v", ref, err)
+	}
+	if !isCommitHash(v.Commit) {
+		return "", fmt.Errorf("bad commit %q for %s", v.Commit, ref)
+	}
+	return v.Commit, nil
+}
+
+func isCommitHash(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// tipVersion is the version of a tip install, in its manifest and VERSION file.
+func tipVersion(commit string) string {
+	return "devel +" + commit[:12]
+}
+
+// FetchTip downloads the Go development tree at commit, see TipCommit, and
+// builds it with the Go installation in bootstrap, like the gotip command. If
+// bootstrap is empty, the GOROOT of the go command in PATH is used. The built
+// tree is placed in dir, which becomes a GOROOT, replacing an existing install
+// in dir only after a successful build. A manifest is written, see Remove.
+//
+// Gitiles does not publish checksums for its archives, the download is only
+// protected by TLS.
+func (c *Client) FetchTip(ctx context.Context, commit, dir, bootstrap string) error {
+	if !isCommitHash(commit) {
+		return fmt.Errorf("bad commit %q", commit)
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0777); err != nil {
+		return err
+	}
+	tmpdir, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	if err := c.extractTip(ctx, commit, tmpdir); err != nil {
+		return err
+	}
+	goroot := filepath.Join(tmpdir, "go")
+	version := tipVersion(commit)
+	if err := os.WriteFile(filepath.Join(goroot, "VERSION"), []byte(version+"\n"), 0666); err != nil {
+		return err
+	}
+	if err := makeGoroot(ctx, goroot, bootstrap); err != nil {
+		return err
+	}
+	m := &Manifest{Version: version, Filename: commit + ".tar.gz"}
+	if err := finishBuild(goroot, dir, tmpdir, m); err != nil {
+		return err
+	}
+	return c.runHooks(ctx, dir, File{Filename: m.Filename, Version: version, Kind: KindSource})
+}
+
+// extractTip downloads the gitiles archive of commit and extracts it into
+// dst/go. The archive has no "go" directory.
+func (c *Client) extractTip(ctx context.Context, commit, dst string) error {
+	resp, err := c.get(ctx, c.tipURL()+"/+archive/"+commit+".tar.gz")
+	if err != nil {
+		return fmt.Errorf("downloading source: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading source: status %v, expected 200 OK", resp.Status)
+	}
+	gzr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return fmt.Errorf("gzip reader: %s", err)
+	}
+	defer gzr.Close()
+	if err := os.Mkdir(filepath.Join(dst, "go"), 0777); err != nil {
+		return err
+	}
+	x := &extractor{dst: dst}
+	if err := x.openRoot(); err != nil {
+		return err
+	}
+	defer x.closeRoot()
+	tr := tar.NewReader(gzr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("reading next header from tar file: %s", err)
+		}
+		if h.Typeflag == tar.TypeSymlink {
+			// Only used in test data, with relative targets that don't fit
+			// storeTar.
+			continue
+		}
+		h.Name = "go/" + strings.TrimPrefix(h.Name, "./")
+		if h.Typeflag == tar.TypeLink {
+			h.Linkname = "go/" + strings.TrimPrefix(h.Linkname, "./")
+		}
+		if h.Name == "go/" {
+			continue
+		}
+		name, err := dstName(dst, h.Name)
+		if err != nil {
+			return err
+		}
+		if err := x.storeTar(tr, h, name); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package goreleases
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestTip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses a shell script as make.bash")
+	}
+	commit := strings.Repeat("ab", 20)
+
+	// Gitiles archives have no "go" directory. Our make.bash just creates bin/go.
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	makeScript := "#!/bin/sh\nmkdir -p ../bin && echo \"$GOROOT_BOOTSTRAP\" >../bin/go\n"
+	for _, f := range []struct {
+		name, data string
+		mode       int64
+	}{
+		{"src/", "", 0755},
+		{"src/make.bash", makeScript, 0755},
+		{"README.md", "readme", 0644},
+	} {
+		h := &tar.Header{Name: f.name, Mode: f.mode, Size: int64(len(f.data)), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(f.name, "/") {
+			h.Typeflag = tar.TypeDir
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatalf("tar header: %s", err)
+		}
+		tw.Write([]byte(f.data))
+	}
+	tw.Close()
+	gzw.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/+/refs/heads/master", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(")]}'\n{\"commit\": \"" + commit + "\"}"))
+	})
+	mux.HandleFunc("/+archive/"+commit+".tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(b.Bytes())
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	m := Manager{Root: t.TempDir(), Client: &Client{TipURL: srv.URL}}
+	dir, err := m.InstallTip(context.Background(), "", "/bootstrap")
+	if err != nil {
+		t.Fatalf("install tip: %s", err)
+	}
+	if buf, err := os.ReadFile(filepath.Join(dir, "bin", "go")); err != nil || string(buf) != "/bootstrap\n" {
+		t.Fatalf("reading built file: %q, %v", buf, err)
+	}
+	if v, err := ReadVersion(dir); err != nil || v != "devel +"+commit[:12] {
+		t.Fatalf("version: %q, %v", v, err)
+	}
+	r, err := m.Client.Install(context.Background(), "tip", dir, nil)
+	if err != nil || r.Action != InstallNone {
+		t.Fatalf("second install: %v, %v", r, err)
+	}
+	if err := m.Use(TipDir); err != nil {
+		t.Fatalf("use tip: %s", err)
+	}
+}
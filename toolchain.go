@@ -0,0 +1,267 @@
+package goreleases
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ToolchainModule is the module with Go toolchains, as downloaded by the go
+// command for GOTOOLCHAIN. Its versions are like
+// "v0.0.1-go1.22.3.linux-amd64".
+const ToolchainModule = "golang.org/toolchain"
+
+// DefaultGoProxy is the default module proxy of the go command.
+const DefaultGoProxy = "https://proxy.golang.org/"
+
+// ToolchainSource is a ReleaseSource with the toolchains of ToolchainModule
+// from a module proxy, for networks that only allow access to a module proxy.
+// Toolchains are available for Go 1.21.0 and later, there are no source
+// files and installers. Files have no checksum in the listing, Open verifies
+// the module zip against the checksum database before returning it.
+//
+// Files are named after the module zips, like
+// "v0.0.1-go1.22.3.linux-amd64.zip". Open returns the module zip converted to
+// the layout of release zip files by ConvertToolchainZip, so it can be
+// fetched and installed like other release files.
+//
+// Use as Client.Source to list and fetch with a Client.
+type ToolchainSource struct {
+	// URL of the module proxy, as in GOPROXY, e.g.
+	// "https://proxy.example.com/". If empty, DefaultGoProxy is used.
+	ProxyURL string
+
+	// URL of the checksum database. If empty, the checksum database is
+	// accessed through the proxy, at ProxyURL + "sumdb/sum.golang.org/", as the
+	// go command does when the proxy supports it.
+	SumDBURL string
+
+	// Verifier key of the checksum database. If empty, DefaultSumDBKey is used.
+	SumDBKey string
+
+	// HTTPClient is used for requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// Directory for temporary files with module zips while they are verified,
+	// default os.TempDir.
+	TempDir string
+}
+
+var _ VerifyingSource = &ToolchainSource{}
+
+func (s *ToolchainSource) proxyURL() string {
+	if s.ProxyURL == "" {
+		return DefaultGoProxy
+	}
+	return strings.TrimSuffix(s.ProxyURL, "/") + "/"
+}
+
+// List returns the releases with toolchains in the module proxy, only the
+// latest releases of the supported minors if all is false.
+func (s *ToolchainSource) List(ctx context.Context, all bool) ([]Release, error) {
+	buf, err := s.get(ctx, s.proxyURL()+ToolchainModule+"/@v/list")
+	if err != nil {
+		return nil, fmt.Errorf("listing toolchain versions: %v", err)
+	}
+	byVersion := map[string]*Release{}
+	var rels []*Release
+	for _, modv := range strings.Fields(string(buf)) {
+		f, ok := toolchainFile(modv)
+		if !ok {
+			continue
+		}
+		rel := byVersion[f.Version]
+		if rel == nil {
+			v, _ := ParseVersion(f.Version)
+			rel = &Release{Version: f.Version, Stable: v.Pre == ""}
+			byVersion[f.Version] = rel
+			rels = append(rels, rel)
+		}
+		rel.Files = append(rel.Files, f)
+	}
+	l := make([]Release, len(rels))
+	for i, rel := range rels {
+		l[i] = *rel
+	}
+	SortReleases(l)
+	if !all {
+		l = supportedReleases(l)
+	}
+	return l, nil
+}
+
+// toolchainFile returns the file for a version of ToolchainModule.
+func toolchainFile(modv string) (File, bool) {
+	t, ok := strings.CutPrefix(modv, "v0.0.1-")
+	if !ok {
+		return File{}, false
+	}
+	i := strings.LastIndex(t, ".")
+	if i < 0 {
+		return File{}, false
+	}
+	version, platform := t[:i], t[i+1:]
+	goos, goarch, ok := strings.Cut(platform, "-")
+	if _, err := ParseVersion(version); err != nil || !ok {
+		return File{}, false
+	}
+	return File{Filename: modv + ".zip", Os: goos, Arch: goarch, Version: version, Kind: KindArchive}, true
+}
+
+// supportedReleases returns the latest release of the two newest minors with
+// a final release, and a newer prerelease, like the upstream listing. Releases
+// must be sorted newest first.
+func supportedReleases(l []Release) []Release {
+	var r []Release
+	var prev Version
+	minors := 0
+	for _, rel := range l {
+		v, err := ParseVersion(rel.Version)
+		if err != nil || len(r) > 0 && v.SameMinor(prev) {
+			continue
+		}
+		if v.Pre != "" {
+			if minors == 0 && len(r) == 0 {
+				r = append(r, rel)
+				prev = v
+			}
+			continue
+		}
+		r = append(r, rel)
+		prev = v
+		minors++
+		if minors == 2 {
+			break
+		}
+	}
+	return r
+}
+
+// Verifies returns true, Open verifies all files.
+func (s *ToolchainSource) Verifies(file File) bool {
+	return true
+}
+
+// Open downloads the module zip of file into a temporary file, verifies it
+// against the checksum database, and returns it converted by
+// ConvertToolchainZip. Closing removes the temporary files.
+func (s *ToolchainSource) Open(ctx context.Context, file File) (io.ReadCloser, error) {
+	modv := strings.TrimSuffix(file.Filename, ".zip")
+	if _, ok := toolchainFile(modv); !ok || modv == file.Filename {
+		return nil, fmt.Errorf("file %q is not a toolchain module zip", file.Filename)
+	}
+	resp, err := s.do(ctx, s.proxyURL()+ToolchainModule+"/@v/"+file.Filename)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	dir := s.TempDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	f, err := os.CreateTemp(dir, "goreleases-toolchain")
+	if err != nil {
+		return nil, err
+	}
+	pf := &partialFile{f}
+	defer pf.Close()
+	size, err := io.Copy(f, resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("downloading module zip: %v", err)
+	}
+	if err := s.verify(ctx, modv, f, size); err != nil {
+		return nil, fmt.Errorf("verifying %s: %w", file.Filename, err)
+	}
+	return toolchainConvert(f, size, dir)
+}
+
+// verify checks the h1 hash of the module zip in f against the checksum
+// database.
+func (s *ToolchainSource) verify(ctx context.Context, modv string, f *os.File, size int64) error {
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return err
+	}
+	sums := map[string][32]byte{}
+	for _, zf := range zr.File {
+		if strings.Contains(zf.Name, "\n") || !strings.HasPrefix(zf.Name, ToolchainModule+"@"+modv+"/") {
+			return fmt.Errorf("bad name %q in module zip", zf.Name)
+		}
+		if _, ok := sums[zf.Name]; ok {
+			return fmt.Errorf("duplicate name %q in module zip", zf.Name)
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("reading %s: %v", zf.Name, err)
+		}
+		var sum [32]byte
+		copy(sum[:], h.Sum(nil))
+		sums[zf.Name] = sum
+	}
+	hash := zipHash1(sums)
+
+	dbURL := s.SumDBURL
+	if dbURL == "" {
+		dbURL = s.proxyURL() + "sumdb/sum.golang.org/"
+	}
+	key := s.SumDBKey
+	if key == "" {
+		key = DefaultSumDBKey
+	}
+	db := &sumDB{strings.TrimSuffix(dbURL, "/") + "/", key, s.get}
+	lines, err := db.lookup(ctx, ToolchainModule, modv)
+	if err != nil {
+		return fmt.Errorf("checksum database: %w", err)
+	}
+	for _, line := range lines {
+		t := strings.Fields(line)
+		if len(t) == 3 && t[0] == ToolchainModule && t[1] == modv {
+			if t[2] != hash {
+				return fmt.Errorf("module zip has hash %s, checksum database has %s", hash, t[2])
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("checksum database has no hash for module zip")
+}
+
+func (s *ToolchainSource) do(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	hc := s.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s, status %v, expected 200 OK", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// get returns the response body for url, of at most 16MB.
+func (s *ToolchainSource) get(ctx context.Context, url string) ([]byte, error) {
+	resp, err := s.do(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+}
@@ -0,0 +1,284 @@
+package goreleases
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+// fakeSumDB serves a checksum database with records, and the tiles of its
+// tree.
+type fakeSumDB struct {
+	key     string
+	signer  note.Signer
+	records [][]byte
+	hashes  []tlog.Hash    // Stored hashes of the tree.
+	ids     map[string]int // By module@version.
+	tamper  []byte         // If set, returned as record by lookups.
+}
+
+func newFakeSumDB(t *testing.T) *fakeSumDB {
+	skey, vkey, err := note.GenerateKey(rand.Reader, "test.sumdb")
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	signer, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	return &fakeSumDB{key: vkey, signer: signer, ids: map[string]int{}}
+}
+
+func (db *fakeSumDB) add(mod, version, hash string) {
+	record := []byte(fmt.Sprintf("%s %s %s\n%s %s/go.mod h1:AAAA\n", mod, version, hash, mod, version))
+	hashes, err := tlog.StoredHashes(int64(len(db.records)), record, db)
+	if err != nil {
+		panic(err)
+	}
+	db.ids[mod+"@"+version] = len(db.records)
+	db.records = append(db.records, record)
+	db.hashes = append(db.hashes, hashes...)
+}
+
+// ReadHashes implements tlog.HashReader.
+func (db *fakeSumDB) ReadHashes(indexes []int64) ([]tlog.Hash, error) {
+	l := make([]tlog.Hash, len(indexes))
+	for i, x := range indexes {
+		if x >= int64(len(db.hashes)) {
+			return nil, fmt.Errorf("no stored hash %d", x)
+		}
+		l[i] = db.hashes[x]
+	}
+	return l, nil
+}
+
+func (db *fakeSumDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := int64(len(db.records))
+	if p, ok := strings.CutPrefix(r.URL.Path, "/lookup/"); ok {
+		id, ok := db.ids[p]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		root, err := tlog.TreeHash(n, db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		signed, err := note.Sign(&note.Note{Text: string(tlog.FormatTree(tlog.Tree{N: n, Hash: root}))}, db.signer)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		record := db.records[id]
+		if db.tamper != nil {
+			record = db.tamper
+		}
+		fmt.Fprintf(w, "%d\n%s\n%s", id, record, signed)
+		return
+	}
+	tile, err := tlog.ParseTilePath(strings.TrimPrefix(r.URL.Path, "/"))
+	partial := strings.Contains(r.URL.Path, ".p/")
+	if err != nil || tile.H != 8 || tile.L < 0 || (tile.N<<8+int64(tile.W))<<(8*tile.L) > n || !partial && tile.W != 256 {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := tlog.ReadTileData(tile, db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// toolchainZip returns a module zip for a toolchain with a go/VERSION file.
+func toolchainZip(t *testing.T, modv string) ([]byte, string) {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	files := map[string]string{
+		"VERSION":        strings.Split(strings.TrimPrefix(modv, "v0.0.1-"), ".linux")[0] + "\n",
+		"bin/go":         "go command",
+		"pkg/tool/x/vet": "vet command",
+		"go.mod":         "module golang.org/toolchain\n",
+	}
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sums bytes.Buffer
+	for _, name := range names {
+		full := ToolchainModule + "@" + modv + "/" + name
+		fw, err := zw.Create(full)
+		if err != nil {
+			t.Fatalf("zip create: %v", err)
+		}
+		io.WriteString(fw, files[name])
+		fmt.Fprintf(&sums, "%x  %s\n", sha256.Sum256([]byte(files[name])), full)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close: %v", err)
+	}
+	sum := sha256.Sum256(sums.Bytes())
+	return b.Bytes(), "h1:" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestToolchainSource(t *testing.T) {
+	db := newFakeSumDB(t)
+	zips := map[string][]byte{}
+	versions := []string{"v0.0.1-go1.21.0.linux-amd64", "v0.0.1-go1.22.2.linux-amd64", "v0.0.1-go1.22.3.linux-amd64", "v0.0.1-go1.23rc1.linux-amd64"}
+	for i := 0; i < 300; i++ {
+		db.add("example.com/m", fmt.Sprintf("v1.0.%d", i), "h1:AAAA")
+		if i == 3 || i == 270 || i == 271 || i == 299 {
+			modv := versions[len(zips)]
+			buf, hash := toolchainZip(t, modv)
+			zips[modv+".zip"] = buf
+			db.add(ToolchainModule, modv, hash)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/sumdb/sum.golang.org/", http.StripPrefix("/sumdb/sum.golang.org", db))
+	mux.HandleFunc("/golang.org/toolchain/@v/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/golang.org/toolchain/@v/")
+		if name == "list" {
+			fmt.Fprintln(w, strings.Join(versions, "\n"))
+			fmt.Fprintln(w, "v0.0.1-bogus")
+			return
+		}
+		if buf, ok := zips[name]; ok {
+			w.Write(buf)
+			return
+		}
+		http.NotFound(w, r)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	src := &ToolchainSource{ProxyURL: srv.URL, SumDBKey: db.key, TempDir: t.TempDir()}
+	c := Client{Source: src}
+	ctx := context.Background()
+
+	rels, err := c.ListAll(ctx)
+	if err != nil {
+		t.Fatalf("list all: %v", err)
+	}
+	if len(rels) != 4 || rels[0].Version != "go1.23rc1" || rels[0].Stable || rels[3].Version != "go1.21.0" {
+		t.Fatalf("unexpected releases %v", rels)
+	}
+	rels, err = c.ListSupported(ctx)
+	if err != nil {
+		t.Fatalf("list supported: %v", err)
+	}
+	if len(rels) != 3 || rels[0].Version != "go1.23rc1" || rels[1].Version != "go1.22.3" || rels[2].Version != "go1.21.0" {
+		t.Fatalf("unexpected supported releases %v", rels)
+	}
+
+	for _, rel := range rels {
+		file, err := rel.FindFile("linux", "amd64", KindArchive)
+		if err != nil {
+			t.Fatalf("find file: %v", err)
+		}
+		dst := t.TempDir()
+		if err := c.Fetch(ctx, file, dst, nil); err != nil {
+			t.Fatalf("fetch %s: %v", file.Filename, err)
+		}
+		checkToolchain(t, filepath.Join(dst, "go"), rel.Version)
+	}
+
+	// Modified zip.
+	file, _ := rels[1].FindFile("linux", "amd64", KindArchive)
+	orig := zips[file.Filename]
+	zips[file.Filename], _ = toolchainZip(t, "v0.0.1-go1.22.3.linux-amd64x")
+	if _, err := c.Open(ctx, file); err == nil {
+		t.Fatalf("open of modified zip succeeded")
+	}
+	zips[file.Filename] = orig
+
+	// Other key.
+	src.SumDBKey = newFakeSumDB(t).key
+	if _, err := c.Open(ctx, file); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Fatalf("open with other key: %v", err)
+	}
+	src.SumDBKey = db.key
+
+	// Record not in the signed tree.
+	_, hash := toolchainZip(t, "v0.0.1-go1.22.3.linux-amd64x")
+	db.tamper = []byte(ToolchainModule + " v0.0.1-go1.22.3.linux-amd64 " + hash + "\n")
+	if _, err := c.Open(ctx, file); err == nil || !strings.Contains(err.Error(), "not included") {
+		t.Fatalf("open with tampered record: %v", err)
+	}
+}
+
+// checkToolchain checks the files from toolchainZip in goroot.
+func checkToolchain(t *testing.T, goroot, version string) {
+	t.Helper()
+	if v, err := ReadVersion(goroot); err != nil || v != version {
+		t.Fatalf("version in %s: %q %v", goroot, v, err)
+	}
+	for name, mode := range map[string]os.FileMode{"bin/go": 0755, "pkg/tool/x/vet": 0755, "VERSION": 0644} {
+		fi, err := os.Stat(filepath.Join(goroot, name))
+		if err != nil || runtime.GOOS != "windows" && fi.Mode().Perm()&^0022 != mode&^0022 {
+			t.Fatalf("stat %s: %v %v", name, fi, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(goroot, "go.mod")); err == nil {
+		t.Fatalf("go.mod of module in goroot")
+	}
+}
+
+func TestUnpackToolchainZip(t *testing.T) {
+	buf, _ := toolchainZip(t, "v0.0.1-go1.22.3.linux-amd64")
+	p := filepath.Join(t.TempDir(), "toolchain.zip")
+	if err := os.WriteFile(p, buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := t.TempDir()
+	if err := UnpackToolchainZip(p, dst); err != nil {
+		t.Fatalf("unpack: %v", err)
+	}
+	checkToolchain(t, filepath.Join(dst, "go"), "go1.22.3")
+	if err := UnpackToolchainZip(p, dst); err == nil {
+		t.Fatalf("unpack into existing go directory succeeded")
+	}
+
+	var b bytes.Buffer
+	if err := ConvertToolchainZip(&b, bytes.NewReader(buf), int64(len(buf))); err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatalf("reading converted zip: %v", err)
+	}
+	var names []string
+	for _, zf := range zr.File {
+		names = append(names, zf.Name)
+	}
+	exp := []string{"go/", "go/VERSION", "go/bin/", "go/bin/go", "go/pkg/", "go/pkg/tool/", "go/pkg/tool/x/", "go/pkg/tool/x/vet"}
+	if !reflect.DeepEqual(names, exp) {
+		t.Fatalf("converted zip has %v, expected %v", names, exp)
+	}
+}
+
+func TestDefaultSumDBKey(t *testing.T) {
+	if v, err := note.NewVerifier(DefaultSumDBKey); err != nil || v.Name() != "sum.golang.org" {
+		t.Fatalf("parsing default key: %v", err)
+	}
+}
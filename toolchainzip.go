@@ -0,0 +1,148 @@
+package goreleases
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ConvertToolchainZip reads a module zip of ToolchainModule from r, of size
+// bytes, and writes it to w as a zip file in the layout of release files:
+// Entries are in directory "go/" instead of "golang.org/toolchain@<version>/",
+// with entries for directories, and the go.mod file of the module is left
+// out.
+//
+// Module zips do not always keep file modes. Files with executable bits in
+// the module zip keep their mode, other files in bin/ and pkg/tool/<os_arch>/
+// are made executable, as the go command does, and all other files get mode
+// 0644. Directories get mode 0755.
+func ConvertToolchainZip(w io.Writer, r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("reading zip file: %v", err)
+	}
+	if len(zr.File) == 0 {
+		return fmt.Errorf("empty module zip")
+	}
+	// Prefix "golang.org/toolchain@<version>/".
+	version, _, ok := strings.Cut(strings.TrimPrefix(zr.File[0].Name, ToolchainModule+"@"), "/")
+	if !ok || !strings.HasPrefix(zr.File[0].Name, ToolchainModule+"@") || version == "" {
+		return fmt.Errorf("not a module zip of %s", ToolchainModule)
+	}
+	prefix := ToolchainModule + "@" + version + "/"
+
+	files := map[string]*zip.File{}
+	dirs := map[string]bool{}
+	var names []string
+	for _, zf := range zr.File {
+		name, ok := strings.CutPrefix(zf.Name, prefix)
+		if !ok || name == "" || strings.HasSuffix(name, "/") || path.Clean(name) != name || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("bad name %q in module zip", zf.Name)
+		}
+		if name == "go.mod" {
+			continue
+		}
+		if files[name] != nil {
+			return fmt.Errorf("duplicate name %q in module zip", zf.Name)
+		}
+		files[name] = zf
+		names = append(names, name)
+		for d := path.Dir(name); d != "." && !dirs[d]; d = path.Dir(d) {
+			dirs[d] = true
+			names = append(names, d+"/")
+		}
+	}
+	names = append(names, "")
+	sort.Strings(names)
+
+	zw := zip.NewWriter(w)
+	for _, name := range names {
+		zf := files[name]
+		if zf == nil {
+			hdr := &zip.FileHeader{Name: "go/" + name, Method: zip.Store, Modified: repackTime}
+			hdr.SetMode(os.ModeDir | 0755)
+			if _, err := zw.CreateHeader(hdr); err != nil {
+				return err
+			}
+			continue
+		}
+		hdr := &zip.FileHeader{Name: "go/" + name, Method: zip.Deflate, Modified: zf.Modified}
+		if hdr.Modified.IsZero() || hdr.Modified.Before(repackTime) {
+			hdr.Modified = repackTime
+		}
+		hdr.SetMode(toolchainMode(name, zf.Mode()))
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return fmt.Errorf("opening %s: %v", zf.Name, err)
+		}
+		_, err = io.Copy(fw, rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("copying %s: %v", zf.Name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// toolchainMode returns the mode for a file in a toolchain module zip.
+func toolchainMode(name string, mode os.FileMode) os.FileMode {
+	if mode&0111 != 0 {
+		return mode.Perm()
+	}
+	t := strings.Split(name, "/")
+	if len(t) == 2 && t[0] == "bin" || len(t) == 4 && t[0] == "pkg" && t[1] == "tool" {
+		return 0755
+	}
+	return 0644
+}
+
+// UnpackToolchainZip converts the module zip of ToolchainModule in file, see
+// ConvertToolchainZip, and extracts it into directory dst, which must not
+// already contain a "go" directory. Afterwards, dst/go is a conventional
+// GOROOT. The module zip must have been verified, e.g. against the checksum
+// database as by ToolchainSource.
+func UnpackToolchainZip(file, dst string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	tf, err := toolchainConvert(f, fi.Size(), "")
+	if err != nil {
+		return err
+	}
+	defer tf.Close()
+	sum, err := fileSha256(tf.Name())
+	if err != nil {
+		return err
+	}
+	x := &extractor{dst: dst, buf: make([]byte, DefaultBufferSize)}
+	return fetchZip(tf.File, File{Filename: fi.Name(), Sha256: sum}, x)
+}
+
+// toolchainConvert converts the module zip in r into a temporary file in dir,
+// which is removed when closed.
+func toolchainConvert(r io.ReaderAt, size int64, dir string) (*partialFile, error) {
+	f, err := os.CreateTemp(dir, "goreleases-toolchain")
+	if err != nil {
+		return nil, err
+	}
+	pf := &partialFile{f}
+	if err := ConvertToolchainZip(f, r, size); err != nil {
+		pf.Close()
+		return nil, fmt.Errorf("converting module zip: %w", err)
+	}
+	return pf, pf.rewind()
+}
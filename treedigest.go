@@ -0,0 +1,53 @@
+package goreleases
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// TreeDigest returns the hex sha256 digest of the directory tree at dir, over
+// the sorted paths with their types, permission bits, modification times (in
+// seconds), and file contents or symlink targets. Ownership is not included.
+// Installs by a Client with Reproducible set of the same release have the same
+// digest, on any machine.
+func TreeDigest(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		var typ, data string
+		switch {
+		case fi.IsDir():
+			typ = EntryDir
+		case fi.Mode()&os.ModeSymlink != 0:
+			typ = EntrySymlink
+			data, err = os.Readlink(p)
+		case fi.Mode().IsRegular():
+			typ = EntryFile
+			data, err = fileSha256(p)
+		default:
+			return fmt.Errorf("%s: unsupported file type %v", p, fi.Mode().Type())
+		}
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(h, "%q %s %o %d %q\n", filepath.ToSlash(name), typ, fi.Mode().Perm(), fi.ModTime().Unix(), data)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
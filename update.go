@@ -0,0 +1,68 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Update holds the newer stable releases available for a version.
+type Update struct {
+	Patch *Release // Latest release of the same minor version, if newer. Nil otherwise.
+	Minor *Release // Latest release of a newer minor version. Nil if none.
+}
+
+// Available returns whether any update is available.
+func (u Update) Available() bool {
+	return u.Patch != nil || u.Minor != nil
+}
+
+// CheckUpdate returns the stable releases newer than version. The version can
+// be from runtime.Version(), e.g. "go1.22.3", extra text after a space like for
+// experiments is ignored. Development versions cannot be checked. For a
+// prerelease, e.g. go1.23rc1, the final release of that minor is a patch
+// update.
+func CheckUpdate(releases []Release, version string) (Update, error) {
+	if strings.HasPrefix(version, "devel") {
+		return Update{}, fmt.Errorf("cannot check development version %q", version)
+	}
+	if i := strings.IndexByte(version, ' '); i >= 0 {
+		version = version[:i]
+	}
+	v, err := ParseVersion(version)
+	if err != nil {
+		return Update{}, err
+	}
+
+	var u Update
+	var pv, mv Version
+	for i, rel := range releases {
+		if !rel.Stable {
+			continue
+		}
+		rv, err := ParseVersion(rel.Version)
+		if err != nil || rv.Compare(v) <= 0 {
+			continue
+		}
+		if rv.SameMinor(v) {
+			if u.Patch == nil || rv.Compare(pv) > 0 {
+				u.Patch = &releases[i]
+				pv = rv
+			}
+		} else if u.Minor == nil || rv.Compare(mv) > 0 {
+			u.Minor = &releases[i]
+			mv = rv
+		}
+	}
+	return u, nil
+}
+
+// CheckUpdate lists the supported releases and returns the updates available
+// for version.
+func (c *Client) CheckUpdate(ctx context.Context, version string) (Update, error) {
+	rels, err := c.ListSupported(ctx)
+	if err != nil {
+		return Update{}, err
+	}
+	return CheckUpdate(rels, version)
+}
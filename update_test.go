@@ -0,0 +1,38 @@
+package goreleases
+
+import (
+	"testing"
+)
+
+func TestCheckUpdate(t *testing.T) {
+	rels := []Release{
+		{Version: "go1.23rc1"},
+		{Version: "go1.22.3", Stable: true},
+		{Version: "go1.21.10", Stable: true},
+	}
+
+	test := func(version, expPatch, expMinor string) {
+		t.Helper()
+		u, err := CheckUpdate(rels, version)
+		if err != nil {
+			t.Fatalf("%s: %s", version, err)
+		}
+		var patch, minor string
+		if u.Patch != nil {
+			patch = u.Patch.Version
+		}
+		if u.Minor != nil {
+			minor = u.Minor.Version
+		}
+		if patch != expPatch || minor != expMinor {
+			t.Fatalf("%s: got patch %q, minor %q, expected %q, %q", version, patch, minor, expPatch, expMinor)
+		}
+	}
+	test("go1.22.3", "", "")
+	test("go1.22.1 X:loopvar", "go1.22.3", "")
+	test("go1.21.5", "go1.21.10", "go1.22.3")
+	test("go1.22rc2", "go1.22.3", "")
+	if _, err := CheckUpdate(rels, "devel go1.23-abcdef"); err == nil {
+		t.Fatalf("expected error for development version")
+	}
+}
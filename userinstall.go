@@ -0,0 +1,71 @@
+package goreleases
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// EnvInstallRoot overrides the directory returned by UserInstallRoot.
+const EnvInstallRoot = "GORELEASES_INSTALL_ROOT"
+
+// UserInstallRoot returns the per-user directory for installs without
+// privileges, in the layout of a Manager root, creating it if needed:
+//
+//   - Windows: %LOCALAPPDATA%\goreleases
+//   - macOS: ~/Library/Application Support/goreleases
+//   - Plan 9: $home/lib/goreleases
+//   - Others: $XDG_DATA_HOME/goreleases, or ~/.local/share/goreleases
+//
+// If set, the directory in EnvInstallRoot is used instead. Use SDKDir for the
+// directory of the golang.org/dl wrappers. Client.Install uses the root for an
+// empty dir, and UserManager makes a Manager for it.
+func UserInstallRoot() (string, error) {
+	dir, err := userInstallRoot()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func userInstallRoot() (string, error) {
+	if dir := os.Getenv(EnvInstallRoot); dir != "" {
+		return filepath.Abs(dir)
+	}
+	if runtime.GOOS == "windows" {
+		dir := os.Getenv("LOCALAPPDATA")
+		if dir == "" {
+			return "", fmt.Errorf("%%LOCALAPPDATA%% is not defined")
+		}
+		return filepath.Join(dir, "goreleases"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	switch runtime.GOOS {
+	case "darwin", "ios":
+		return filepath.Join(home, "Library", "Application Support", "goreleases"), nil
+	case "plan9":
+		return filepath.Join(home, "lib", "goreleases"), nil
+	}
+	// Relative paths are invalid according to the XDG base directory spec.
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "goreleases"), nil
+	}
+	return filepath.Join(home, ".local", "share", "goreleases"), nil
+}
+
+// UserManager returns a Manager for UserInstallRoot, with client c, which can
+// be nil.
+func UserManager(c *Client) (*Manager, error) {
+	root, err := UserInstallRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{Root: root, Client: c}, nil
+}
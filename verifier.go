@@ -0,0 +1,176 @@
+package goreleases
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// Verifier decides whether downloaded release files are accepted, see
+// Client.Verifier.
+type Verifier interface {
+	// NewCheck is called before file is read, e.g. downloaded. Its data is
+	// written to the returned check.
+	NewCheck(ctx context.Context, file File) (Check, error)
+}
+
+// Check receives the data of a release file and decides whether it passes.
+type Check interface {
+	io.Writer
+
+	// Verify is called after all data has been written, and returns an error
+	// if the file does not pass.
+	Verify() error
+}
+
+// Sha256Verifier is the default verification: The data must match the sha256
+// in File.Sha256 and the checksum in File.Checksum, and files must have at
+// least one of them.
+var Sha256Verifier Verifier = sha256Verifier{}
+
+type sha256Verifier struct{}
+
+func (sha256Verifier) NewCheck(ctx context.Context, file File) (Check, error) {
+	if file.Sha256 == "" && file.Checksum == "" {
+		return nil, fmt.Errorf("file %s has no checksum", file.Filename)
+	}
+	ck, err := newChecksumCheck(file)
+	if err != nil {
+		return nil, err
+	}
+	return &sha256Check{sha256.New(), strings.ToLower(file.Sha256), ck}, nil
+}
+
+type sha256Check struct {
+	h   hash.Hash
+	exp string // Empty if only checked by ck.
+	ck  *checksumCheck
+}
+
+func (sc *sha256Check) Write(buf []byte) (int, error) {
+	sc.h.Write(buf)
+	if sc.ck != nil {
+		sc.ck.h.Write(buf)
+	}
+	return len(buf), nil
+}
+
+func (sc *sha256Check) Verify() error {
+	if sum := fmt.Sprintf("%x", sc.h.Sum(nil)); sc.exp != "" && sum != sc.exp {
+		return fmt.Errorf("checksum mismatch, got %s, expected %s", sum, sc.exp)
+	}
+	return sc.ck.verify()
+}
+
+// AllVerifiers returns a verifier that accepts files accepted by all of
+// verifiers, e.g. Sha256Verifier and an AllowlistVerifier.
+func AllVerifiers(verifiers ...Verifier) Verifier {
+	return allVerifiers(verifiers)
+}
+
+type allVerifiers []Verifier
+
+func (av allVerifiers) NewCheck(ctx context.Context, file File) (Check, error) {
+	var l allChecks
+	for _, v := range av {
+		ck, err := v.NewCheck(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, ck)
+	}
+	return l, nil
+}
+
+type allChecks []Check
+
+func (ac allChecks) Write(buf []byte) (int, error) {
+	for _, ck := range ac {
+		if _, err := ck.Write(buf); err != nil {
+			return 0, err
+		}
+	}
+	return len(buf), nil
+}
+
+func (ac allChecks) Verify() error {
+	for _, ck := range ac {
+		if err := ck.Verify(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AllowlistVerifier returns a verifier that only accepts files with one of the
+// hex sha256 checksums, e.g. from a corporate allowlist. Checksums of the
+// listing are not consulted, combine with Sha256Verifier to also check those.
+func AllowlistVerifier(sha256s ...string) Verifier {
+	m := map[string]bool{}
+	for _, s := range sha256s {
+		m[strings.ToLower(s)] = true
+	}
+	return allowlistVerifier(m)
+}
+
+type allowlistVerifier map[string]bool
+
+func (av allowlistVerifier) NewCheck(ctx context.Context, file File) (Check, error) {
+	return &allowlistCheck{sha256.New(), file, av}, nil
+}
+
+type allowlistCheck struct {
+	h       hash.Hash
+	file    File
+	allowed map[string]bool
+}
+
+func (ac *allowlistCheck) Write(buf []byte) (int, error) {
+	return ac.h.Write(buf)
+}
+
+func (ac *allowlistCheck) Verify() error {
+	if sum := fmt.Sprintf("%x", ac.h.Sum(nil)); !ac.allowed[sum] {
+		return fmt.Errorf("file %s with sha256 %s not in allowlist", ac.file.Filename, sum)
+	}
+	return nil
+}
+
+// newCheck returns the check for data of file: of Client.Verifier if set,
+// otherwise of File.Checksum, with the sha256 checked by the caller.
+func (c *Client) newCheck(ctx context.Context, file File) (*checksumCheck, error) {
+	if c.Verifier == nil {
+		return newChecksumCheck(file)
+	}
+	ck, err := c.Verifier.NewCheck(ctx, file)
+	if err != nil {
+		return nil, fmt.Errorf("verifier: %w", err)
+	}
+	return &checksumCheck{check: ck}, nil
+}
+
+// verifyFile runs Client.Verifier, if set, on the file in f, e.g. from the
+// archive cache, and rewinds f.
+func (c *Client) verifyFile(ctx context.Context, file File, f *os.File) error {
+	if c.Verifier == nil {
+		return nil
+	}
+	ck, err := c.newCheck(ctx, file)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(io.Discard, ck.tee(f)); err != nil {
+		return fmt.Errorf("reading release file: %v", err)
+	}
+	if err := ck.verify(); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return fmt.Errorf("rewinding release file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package goreleases
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BlobLister is implemented by a BlobStore that can list its objects. For
+// VerifyMirrorBlobs, to find objects that do not belong in the mirror.
+type BlobLister interface {
+	// List returns the names of all objects in the store.
+	List(ctx context.Context) ([]string, error)
+}
+
+// VerifyMirrorOptions are options for VerifyMirror and VerifyMirrorBlobs.
+type VerifyMirrorOptions struct {
+	// Audit against all releases, not only supported releases. Files of
+	// unsupported releases are never reported as extra.
+	All bool
+
+	// Maximum number of files checksummed concurrently. If zero, 4.
+	Concurrency int
+}
+
+// MirrorAudit is the result of VerifyMirror and VerifyMirrorBlobs.
+type MirrorAudit struct {
+	Checked int // Number of files present and verified, correct or not.
+
+	// Upstream files not in the mirror.
+	Missing []File
+
+	// Files in the mirror with another size or checksum than upstream, or for
+	// which the .sha256 file is missing or has another checksum.
+	Corrupted []File
+
+	// Names in the mirror that are not upstream files, their .asc or .sha256
+	// files, or the listing. Only for directories and a BlobStore that is a
+	// BlobLister.
+	Extra []string
+
+	// Versions of releases in the mirror listing with different files than
+	// upstream, not in the listing while upstream, or not upstream.
+	StaleListing []string
+
+	// Files that could not be read, by name.
+	Failed map[string]error
+}
+
+// OK returns whether the audit found no problems.
+func (a MirrorAudit) OK() bool {
+	return len(a.Missing) == 0 && len(a.Corrupted) == 0 && len(a.Extra) == 0 && len(a.StaleListing) == 0 && len(a.Failed) == 0
+}
+
+// VerifyMirror audits the mirror in directory dir, as created by Mirror or
+// SyncMirror, against the upstream releases. All files in the mirror are
+// checksummed. Nothing is changed, use SyncMirror to repair the mirror.
+func (c *Client) VerifyMirror(ctx context.Context, dir string, opts *VerifyMirrorOptions) (MirrorAudit, error) {
+	open := func(ctx context.Context, name string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, name))
+	}
+	list := func(ctx context.Context) ([]string, error) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names, nil
+	}
+	return c.verifyMirror(ctx, open, list, opts)
+}
+
+// VerifyMirrorBlobs is like VerifyMirror, for a mirror in store, as created
+// by MirrorBlobs. Extra objects are only reported if store is a BlobLister.
+func (c *Client) VerifyMirrorBlobs(ctx context.Context, store BlobStore, opts *VerifyMirrorOptions) (MirrorAudit, error) {
+	var list func(ctx context.Context) ([]string, error)
+	if l, ok := store.(BlobLister); ok {
+		list = l.List
+	}
+	return c.verifyMirror(ctx, store.Get, list, opts)
+}
+
+func (c *Client) verifyMirror(ctx context.Context, open func(ctx context.Context, name string) (io.ReadCloser, error), list func(ctx context.Context) ([]string, error), opts *VerifyMirrorOptions) (MirrorAudit, error) {
+	var o VerifyMirrorOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	a := MirrorAudit{Failed: map[string]error{}}
+	all, err := c.ListAll(ctx)
+	if err != nil {
+		return a, err
+	}
+	rels := all
+	if !o.All {
+		rels, err = c.ListSupported(ctx)
+		if err != nil {
+			return a, err
+		}
+	}
+
+	// The listing, with the same files as upstream.
+	listed := map[string][]File{}
+	if rc, err := open(ctx, MirrorIndex); err != nil {
+		if !os.IsNotExist(err) {
+			return a, fmt.Errorf("reading mirror listing: %v", err)
+		}
+	} else {
+		buf, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return a, fmt.Errorf("reading mirror listing: %v", err)
+		}
+		l, err := parseReleases(buf)
+		if err != nil {
+			return a, fmt.Errorf("parsing mirror listing: %v", err)
+		}
+		for _, rel := range l {
+			listed[rel.Version] = rel.Files
+		}
+	}
+	upstream := map[string][]File{}
+	for _, rel := range all {
+		upstream[rel.Version] = rel.Files
+	}
+	stale := map[string]bool{}
+	for _, rel := range rels {
+		if files, ok := listed[rel.Version]; !ok || !sameFiles(files, rel.Files) {
+			stale[rel.Version] = true
+		}
+	}
+	for version, files := range listed {
+		if ufiles, ok := upstream[version]; !ok || !sameFiles(files, ufiles) {
+			stale[version] = true
+		}
+	}
+	for version := range stale {
+		a.StaleListing = append(a.StaleListing, version)
+	}
+	sortVersions(a.StaleListing)
+
+	if list != nil {
+		names, err := list(ctx)
+		if err != nil {
+			return a, fmt.Errorf("listing mirror: %v", err)
+		}
+		known := map[string]bool{MirrorIndex: true}
+		for _, rel := range all {
+			for _, f := range rel.Files {
+				known[f.Filename] = true
+				known[f.Filename+".asc"] = true
+				known[f.Filename+".sha256"] = true
+			}
+		}
+		for _, name := range names {
+			if !known[name] {
+				a.Extra = append(a.Extra, name)
+			}
+		}
+		sort.Strings(a.Extra)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, o.Concurrency)
+	for _, rel := range rels {
+		for _, f := range rel.Files {
+			f := f
+			if err := acquire(ctx, sem); err != nil {
+				mu.Lock()
+				a.Failed[f.Filename] = err
+				mu.Unlock()
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				ok, err := verifyMirrorFile(ctx, open, f)
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case os.IsNotExist(err):
+					a.Missing = append(a.Missing, f)
+				case err != nil:
+					a.Failed[f.Filename] = err
+				case !ok:
+					a.Checked++
+					a.Corrupted = append(a.Corrupted, f)
+				default:
+					a.Checked++
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	sortFiles := func(l []File) {
+		sort.Slice(l, func(i, j int) bool {
+			return l[i].Filename < l[j].Filename
+		})
+	}
+	sortFiles(a.Missing)
+	sortFiles(a.Corrupted)
+	return a, nil
+}
+
+// verifyMirrorFile returns whether the file in the mirror and its .sha256 file
+// have the checksum of f. An error for which os.IsNotExist is true is returned
+// if the file is missing.
+func verifyMirrorFile(ctx context.Context, open func(ctx context.Context, name string) (io.ReadCloser, error), f File) (bool, error) {
+	rc, err := open(ctx, f.Filename)
+	if err != nil {
+		return false, err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, rc)
+	if err != nil {
+		return false, err
+	}
+	if fmt.Sprintf("%x", h.Sum(nil)) != f.Sha256 || f.Size != 0 && n != f.Size {
+		return false, nil
+	}
+	src, err := open(ctx, f.Filename+".sha256")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer src.Close()
+	buf, err := io.ReadAll(io.LimitReader(src, 1024))
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(buf)) == f.Sha256, nil
+}
+
+// sameFiles returns whether a and b have the same filenames and checksums.
+func sameFiles(a, b []File) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sums := map[string]string{}
+	for _, f := range a {
+		sums[f.Filename] = f.Sha256
+	}
+	for _, f := range b {
+		if sum, ok := sums[f.Filename]; !ok || sum != f.Sha256 {
+			return false
+		}
+	}
+	return true
+}
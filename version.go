@@ -0,0 +1,145 @@
+package goreleases
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed Go version, like "go1.22.3", "go1.21rc2" or "go1.9".
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	Pre   string // Empty for final releases, otherwise "beta" or "rc".
+	PreN  int    // Number for prereleases, e.g. 2 for "rc2".
+}
+
+// ParseVersion parses a Go version, with or without "go" prefix.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	t := strings.TrimPrefix(s, "go")
+	for _, pre := range []string{"beta", "rc"} {
+		if i := strings.Index(t, pre); i > 0 {
+			n, err := strconv.Atoi(t[i+len(pre):])
+			if err != nil || n <= 0 {
+				return Version{}, fmt.Errorf("bad prerelease in version %q", s)
+			}
+			v.Pre = pre
+			v.PreN = n
+			t = t[:i]
+			break
+		}
+	}
+	l := strings.Split(t, ".")
+	if len(l) < 2 || len(l) > 3 {
+		return Version{}, fmt.Errorf("bad version %q", s)
+	}
+	var nums [3]int
+	for i, e := range l {
+		n, err := strconv.Atoi(e)
+		if err != nil || n < 0 || e != strconv.Itoa(n) {
+			return Version{}, fmt.Errorf("bad number %q in version %q", e, s)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	if v.Pre != "" && len(l) == 3 {
+		return Version{}, fmt.Errorf("prerelease with patch version in %q", s)
+	}
+	return v, nil
+}
+
+// String returns the version as used in release names. Go 1.21 and later have
+// a ".0" patch for the first release of a minor, earlier versions do not.
+func (v Version) String() string {
+	s := fmt.Sprintf("go%d.%d", v.Major, v.Minor)
+	if v.Pre != "" {
+		return fmt.Sprintf("%s%s%d", s, v.Pre, v.PreN)
+	}
+	if v.Patch > 0 || v.Major > 1 || v.Minor >= 21 {
+		s += fmt.Sprintf(".%d", v.Patch)
+	}
+	return s
+}
+
+// MinorString returns the major and minor version, e.g. "1.22".
+func (v Version) MinorString() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Compare returns -1, 0 or 1 if v is older than, the same as or newer than o.
+// Prereleases are older than final releases of the same minor.
+func (v Version) Compare(o Version) int {
+	for _, c := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}} {
+		if c[0] != c[1] {
+			return cmpInt(c[0], c[1])
+		}
+	}
+	if (v.Pre == "") != (o.Pre == "") {
+		if v.Pre == "" {
+			return 1
+		}
+		return -1
+	}
+	if v.Pre != o.Pre {
+		// "beta" is before "rc".
+		if v.Pre < o.Pre {
+			return -1
+		}
+		return 1
+	}
+	if v.PreN != o.PreN {
+		return cmpInt(v.PreN, o.PreN)
+	}
+	return cmpInt(v.Patch, o.Patch)
+}
+
+// SameMinor returns whether v and o have the same major and minor version.
+func (v Version) SameMinor(o Version) bool {
+	return v.Major == o.Major && v.Minor == o.Minor
+}
+
+// SortReleases sorts releases newest first, by parsed version, so go1.10 is
+// newer than go1.9 and go1.22.0 is newer than go1.22rc2. Releases with
+// unparsable versions are sorted last, by version.
+func SortReleases(l []Release) {
+	sort.SliceStable(l, func(i, j int) bool {
+		return CompareReleases(l[i], l[j]) > 0
+	})
+}
+
+// CompareReleases compares the versions of releases a and b, see
+// CompareVersions. It can be used with slices.SortFunc, sorting oldest first.
+func CompareReleases(a, b Release) int {
+	return CompareVersions(a.Version, b.Version)
+}
+
+// CompareVersions returns -1, 0 or 1 if version a is older than, the same as,
+// or newer than b, with or without "go" prefix. Unparsable versions are older
+// than parsable versions, and compared as strings. It can be used with
+// slices.SortFunc.
+func CompareVersions(a, b string) int {
+	va, erra := ParseVersion(a)
+	vb, errb := ParseVersion(b)
+	if erra != nil || errb != nil {
+		if (erra == nil) != (errb == nil) {
+			if erra != nil {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	}
+	return va.Compare(vb)
+}
+
+func cmpInt(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
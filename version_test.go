@@ -0,0 +1,96 @@
+package goreleases
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	good := []struct {
+		s    string
+		v    Version
+		norm string
+	}{
+		{"go1.22.3", Version{1, 22, 3, "", 0}, "go1.22.3"},
+		{"go1.21.0", Version{1, 21, 0, "", 0}, "go1.21.0"},
+		{"go1.20", Version{1, 20, 0, "", 0}, "go1.20"},
+		{"1.9.2", Version{1, 9, 2, "", 0}, "go1.9.2"},
+		{"go1.21rc2", Version{1, 21, 0, "rc", 2}, "go1.21rc2"},
+		{"go1.18beta1", Version{1, 18, 0, "beta", 1}, "go1.18beta1"},
+	}
+	for _, g := range good {
+		v, err := ParseVersion(g.s)
+		if err != nil {
+			t.Fatalf("parse %q: %s", g.s, err)
+		}
+		if v != g.v {
+			t.Fatalf("parse %q: got %#v, expected %#v", g.s, v, g.v)
+		}
+		if s := v.String(); s != g.norm {
+			t.Fatalf("string of %q: got %q, expected %q", g.s, s, g.norm)
+		}
+	}
+
+	for _, s := range []string{"", "go", "go1", "go1.x", "go1.22.3.4", "go1.22rc", "go1.22.1rc1", "go1.022"} {
+		if _, err := ParseVersion(s); err == nil {
+			t.Fatalf("parse %q: expected error", s)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	ordered := []string{"go1.9", "go1.9.2", "go1.10", "go1.21rc1", "go1.21rc2", "go1.21.0", "go1.22beta1", "go1.22rc1", "go1.22.0", "go1.22.10"}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := ParseVersion(ordered[i])
+			b, _ := ParseVersion(ordered[j])
+			if c := a.Compare(b); c != cmpInt(i, j) {
+				t.Fatalf("compare %s with %s: got %d, expected %d", ordered[i], ordered[j], c, cmpInt(i, j))
+			}
+		}
+	}
+}
+
+func TestSortReleases(t *testing.T) {
+	l := []Release{{Version: "go1.9"}, {Version: "bogus"}, {Version: "go1.22rc2"}, {Version: "go1.10"}, {Version: "go1.22.0"}}
+	SortReleases(l)
+	var got []string
+	for _, rel := range l {
+		got = append(got, rel.Version)
+	}
+	if exp := []string{"go1.22.0", "go1.22rc2", "go1.10", "go1.9", "bogus"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+	if CompareReleases(l[0], l[1]) != 1 || CompareVersions("1.9", "go1.9") != 0 || CompareVersions("bogus", "go1.9") != -1 {
+		t.Fatalf("unexpected comparison results")
+	}
+}
+
+func TestFindLineage(t *testing.T) {
+	rels := []Release{
+		{Version: "go1.23.1", Stable: true},
+		{Version: "go1.23.0", Stable: true},
+		{Version: "go1.23rc2"},
+		{Version: "go1.23rc1"},
+		{Version: "go1.24rc1"},
+		{Version: "go1.24rc2"},
+	}
+	l, err := FindLineage(rels, "go1.23rc1")
+	if err != nil {
+		t.Fatalf("lineage: %s", err)
+	}
+	exp := Lineage{Version: "go1.23rc1", Final: "go1.23.0", Released: true, Prereleases: []string{"go1.23rc1", "go1.23rc2"}, Superseded: true}
+	if !reflect.DeepEqual(l, exp) {
+		t.Fatalf("got %#v, expected %#v", l, exp)
+	}
+	l, err = FindLineage(rels, "go1.24rc1")
+	if err != nil || l.Superseded || l.Released || l.Final != "go1.24.0" || l.NewerPrerelease != "go1.24rc2" {
+		t.Fatalf("got %#v %v", l, err)
+	}
+	if l, err := FindLineage(rels, "go1.23.1"); err != nil || l.Superseded || l.Final != "go1.23.1" {
+		t.Fatalf("got %#v %v for final release", l, err)
+	}
+	if v, _ := ParseVersion("go1.20rc3"); v.Final().String() != "go1.20" {
+		t.Fatalf("got final %s for go1.20rc3", v.Final())
+	}
+}
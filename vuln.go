@@ -0,0 +1,190 @@
+package goreleases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultVulnDBURL is the Go vulnerability database.
+const DefaultVulnDBURL = "https://vuln.go.dev"
+
+// Advisory is a vulnerability from the Go vulnerability database affecting a
+// toolchain version.
+type Advisory struct {
+	ID       string   // E.g. "GO-2024-2687".
+	Aliases  []string // E.g. CVE IDs.
+	Summary  string
+	Module   string   // "stdlib" or "toolchain".
+	Packages []string // Affected packages, e.g. "net/http" or "cmd/go".
+	Fixed    string   // First version fixing the vulnerability after the checked version, e.g. "go1.22.2". Empty if no fix is known.
+	URL      string   // Page with details.
+}
+
+// osvEntry is the subset of an OSV entry from the vulnerability database we use.
+type osvEntry struct {
+	ID       string   `json:"id"`
+	Summary  string   `json:"summary"`
+	Aliases  []string `json:"aliases"`
+	Affected []struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced string `json:"introduced"`
+				Fixed      string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+		EcosystemSpecific struct {
+			Imports []struct {
+				Path string `json:"path"`
+			} `json:"imports"`
+		} `json:"ecosystem_specific"`
+	} `json:"affected"`
+	DatabaseSpecific struct {
+		URL string `json:"url"`
+	} `json:"database_specific"`
+}
+
+// VulnCheck returns the advisories from the Go vulnerability database for the
+// standard library and toolchain that affect version, e.g. "go1.21.5". The
+// database is queried at Client.VulnDBURL, or DefaultVulnDBURL if empty.
+func (c *Client) VulnCheck(ctx context.Context, version string) ([]Advisory, error) {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	dbURL := c.VulnDBURL
+	if dbURL == "" {
+		dbURL = DefaultVulnDBURL
+	}
+	dbURL = strings.TrimSuffix(dbURL, "/")
+
+	var modules []struct {
+		Path  string `json:"path"`
+		Vulns []struct {
+			ID    string `json:"id"`
+			Fixed string `json:"fixed"`
+		} `json:"vulns"`
+	}
+	if err := c.getJSON(ctx, dbURL+"/index/modules.json", &modules); err != nil {
+		return nil, fmt.Errorf("fetching vulnerability index: %v", err)
+	}
+
+	var l []Advisory
+	for _, m := range modules {
+		if m.Path != "stdlib" && m.Path != "toolchain" {
+			continue
+		}
+		for _, vuln := range m.Vulns {
+			// Skip entries that are fixed in all versions up to and including ours.
+			if vuln.Fixed != "" {
+				if fv, err := parseSemverVersion(vuln.Fixed); err == nil && fv.Compare(v) <= 0 {
+					continue
+				}
+			}
+			var e osvEntry
+			if err := c.getJSON(ctx, dbURL+"/ID/"+vuln.ID+".json", &e); err != nil {
+				return nil, fmt.Errorf("fetching vulnerability %s: %v", vuln.ID, err)
+			}
+			if a, ok := osvAffects(e, m.Path, v); ok {
+				l = append(l, a)
+			}
+		}
+	}
+	return l, nil
+}
+
+// osvAffects returns whether entry e affects version v of module.
+func osvAffects(e osvEntry, module string, v Version) (Advisory, bool) {
+	a := Advisory{ID: e.ID, Aliases: e.Aliases, Summary: e.Summary, Module: module, URL: e.DatabaseSpecific.URL}
+	affected := false
+	for _, aff := range e.Affected {
+		if aff.Package.Name != module {
+			continue
+		}
+		for _, r := range aff.Ranges {
+			if r.Type != "SEMVER" {
+				continue
+			}
+			// Events alternate between introduced and fixed, in order.
+			in := false
+			for _, ev := range r.Events {
+				if ev.Introduced != "" {
+					iv, err := parseSemverVersion(ev.Introduced)
+					in = ev.Introduced == "0" || err == nil && iv.Compare(v) <= 0
+				} else if ev.Fixed != "" {
+					fv, err := parseSemverVersion(ev.Fixed)
+					if err != nil {
+						continue
+					}
+					if in && v.Compare(fv) < 0 {
+						affected = true
+						if a.Fixed == "" {
+							a.Fixed = fv.String()
+						}
+					}
+					in = false
+				}
+			}
+			if in {
+				affected = true
+			}
+		}
+		for _, imp := range aff.EcosystemSpecific.Imports {
+			a.Packages = append(a.Packages, imp.Path)
+		}
+	}
+	return a, affected
+}
+
+// parseSemverVersion parses a semver as used in the vulnerability database for
+// Go versions, e.g. "1.21.9" or "1.22.0-rc.1", into a Version. "0" is parsed as
+// the earliest version.
+func parseSemverVersion(s string) (Version, error) {
+	if s == "0" {
+		return Version{}, nil
+	}
+	t := s
+	var pre string
+	if i := strings.Index(t, "-"); i >= 0 {
+		t, pre = t[:i], t[i+1:]
+	}
+	v, err := ParseVersion(t)
+	if err != nil {
+		return Version{}, err
+	}
+	if pre == "0" {
+		// Used in "1.22.0-0", the very first prerelease of a version.
+		return Version{v.Major, v.Minor, 0, "beta", 0}, nil
+	} else if pre != "" {
+		l := strings.SplitN(pre, ".", 2)
+		if len(l) != 2 || l[0] != "beta" && l[0] != "rc" {
+			return Version{}, fmt.Errorf("bad prerelease in %q", s)
+		}
+		n, err := strconv.Atoi(l[1])
+		if err != nil {
+			return Version{}, fmt.Errorf("bad prerelease number in %q", s)
+		}
+		v.Pre, v.PreN = l[0], n
+	}
+	return v, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, v interface{}) error {
+	resp, err := c.get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http status %d: %s", resp.StatusCode, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
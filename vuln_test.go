@@ -0,0 +1,40 @@
+package goreleases
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOSVAffects(t *testing.T) {
+	const entry = `{
+	"id": "GO-2024-2687",
+	"summary": "HTTP/2 CONTINUATION flood in net/http",
+	"aliases": ["CVE-2023-45288"],
+	"affected": [{
+		"package": {"name": "stdlib", "ecosystem": "Go"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.21.9"}, {"introduced": "1.22.0-0"}, {"fixed": "1.22.2"}]}],
+		"ecosystem_specific": {"imports": [{"path": "net/http"}]}
+	}]
+}`
+	var e osvEntry
+	if err := json.Unmarshal([]byte(entry), &e); err != nil {
+		t.Fatalf("parsing entry: %s", err)
+	}
+
+	test := func(version string, expAffected bool, expFixed string) {
+		t.Helper()
+		v, err := ParseVersion(version)
+		if err != nil {
+			t.Fatalf("parse version: %s", err)
+		}
+		a, affected := osvAffects(e, "stdlib", v)
+		if affected != expAffected || expAffected && a.Fixed != expFixed {
+			t.Fatalf("%s: got affected %v, fixed %q, expected %v, %q", version, affected, a.Fixed, expAffected, expFixed)
+		}
+	}
+	test("go1.21.5", true, "go1.21.9")
+	test("go1.21.9", false, "")
+	test("go1.22rc1", true, "go1.22.2")
+	test("go1.22.1", true, "go1.22.2")
+	test("go1.22.2", false, "")
+}
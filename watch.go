@@ -0,0 +1,128 @@
+package goreleases
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Watcher polls a ReleaseSource for new releases.
+type Watcher struct {
+	// Source to poll. If nil, a zero Client is used.
+	Source ReleaseSource
+
+	// Whether to poll all releases instead of only supported releases.
+	All bool
+
+	// Time between polls. Each interval is randomly lengthened or shortened by up
+	// to 10%, to spread load on the server. If zero, one hour.
+	Interval time.Duration
+
+	// Versions that are already known and not delivered, e.g. stored from an
+	// earlier run.
+	Known []string
+
+	// If set, new releases from the first poll are delivered too. Otherwise the
+	// first poll only marks releases as known.
+	Initial bool
+
+	// If non-empty, only releases for these minor versions, e.g. "1.22", are
+	// delivered.
+	Minors []string
+
+	// If not nil, new releases are posted to the webhook before delivery on the
+	// channel. Failures are passed to Errors, and the release is posted again at
+	// the next poll, only delivered on the channel after the webhook succeeds.
+	Webhook *Webhook
+
+	// If not nil, called with errors from polling. Polling continues after errors.
+	Errors func(err error)
+}
+
+// Watch polls in a new goroutine and returns a channel on which new releases
+// are delivered, each version at most once. The channel is closed when ctx is
+// done. When only using a webhook, the channel must still be read from.
+func (w *Watcher) Watch(ctx context.Context) <-chan Release {
+	c := make(chan Release)
+	go w.watch(ctx, c)
+	return c
+}
+
+func (w *Watcher) watch(ctx context.Context, c chan<- Release) {
+	defer close(c)
+
+	src := w.Source
+	if src == nil {
+		src = &Client{}
+	}
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	known := map[string]bool{}
+	for _, v := range w.Known {
+		known[v] = true
+	}
+	first := !w.Initial
+	for {
+		rels, err := src.List(ctx, w.All)
+		if err != nil && ctx.Err() == nil && w.Errors != nil {
+			w.Errors(err)
+		}
+		for _, rel := range rels {
+			if known[rel.Version] {
+				continue
+			}
+			if first || !w.match(rel) {
+				known[rel.Version] = true
+				continue
+			}
+			if w.Webhook != nil {
+				if err := w.Webhook.Notify(ctx, rel); err != nil {
+					// Retried at the next poll.
+					if ctx.Err() == nil && w.Errors != nil {
+						w.Errors(fmt.Errorf("webhook for %s: %w", rel.Version, err))
+					}
+					continue
+				}
+			}
+			known[rel.Version] = true
+			select {
+			case c <- rel:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err == nil {
+			first = false
+		}
+
+		d := interval + time.Duration((rand.Float64()*0.2-0.1)*float64(interval))
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+	}
+}
+
+func (w *Watcher) match(rel Release) bool {
+	if len(w.Minors) == 0 {
+		return true
+	}
+	v, err := ParseVersion(rel.Version)
+	if err != nil {
+		return false
+	}
+	for _, m := range w.Minors {
+		if v.MinorString() == strings.TrimPrefix(m, "go") {
+			return true
+		}
+	}
+	return false
+}
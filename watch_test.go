@@ -0,0 +1,108 @@
+package goreleases
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	src := &memSource{releases: []Release{{Version: "go1.22.2"}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := Watcher{Source: src, Interval: time.Millisecond}
+	c := w.Watch(ctx)
+
+	// Releases from the first poll are known, only later releases are delivered.
+	time.Sleep(10 * time.Millisecond)
+	src.setReleases([]Release{{Version: "go1.22.3"}, {Version: "go1.22.2"}})
+	select {
+	case rel := <-c:
+		if rel.Version != "go1.22.3" {
+			t.Fatalf("got release %q, expected go1.22.3", rel.Version)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no new release delivered")
+	}
+
+	cancel()
+	for rel := range c {
+		t.Fatalf("unexpected release %q after cancel", rel.Version)
+	}
+}
+
+func TestWatcherWebhook(t *testing.T) {
+	posted := make(chan WebhookPayload, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		posted <- p
+	}))
+	defer srv.Close()
+
+	src := &memSource{releases: []Release{{Version: "go1.21.10"}, {Version: "go1.22.3"}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := Watcher{Source: src, Interval: time.Hour, Initial: true, Minors: []string{"1.22"}, Webhook: &Webhook{URL: srv.URL}}
+	w.Errors = func(err error) {
+		t.Errorf("watcher error: %s", err)
+	}
+	c := w.Watch(ctx)
+	rel := <-c
+	if rel.Version != "go1.22.3" {
+		t.Fatalf("got release %q, expected go1.22.3", rel.Version)
+	}
+	p := <-posted
+	if p.Version != "go1.22.3" {
+		t.Fatalf("got webhook for %q, expected go1.22.3", p.Version)
+	}
+}
+
+func TestWatcherWebhookRetry(t *testing.T) {
+	var mu sync.Mutex
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if requests == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	src := &memSource{releases: []Release{{Version: "go1.22.3"}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 10)
+	w := Watcher{Source: src, Interval: time.Millisecond, Initial: true, Webhook: &Webhook{URL: srv.URL}}
+	w.Errors = func(err error) {
+		errs <- err
+	}
+	c := w.Watch(ctx)
+	select {
+	case rel := <-c:
+		if rel.Version != "go1.22.3" {
+			t.Fatalf("got release %q, expected go1.22.3", rel.Version)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("release not delivered after webhook retry")
+	}
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, expected 1 for the failed webhook", len(errs))
+	}
+	// Once delivered, the release is not posted again.
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 2 {
+		t.Fatalf("got %d webhook requests, expected 2", requests)
+	}
+}
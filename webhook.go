@@ -0,0 +1,54 @@
+package goreleases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Webhook posts a JSON WebhookPayload to a URL, e.g. for new releases found by
+// a Watcher.
+type Webhook struct {
+	URL string
+
+	// HTTPClient is used for requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// WebhookPayload is the JSON body posted by a Webhook.
+type WebhookPayload struct {
+	Version string  `json:"version"`
+	Stable  bool    `json:"stable"`
+	Release Release `json:"release"`
+}
+
+// Notify posts a payload for rel. Responses with a status other than 2xx are
+// returned as error.
+func (wh *Webhook) Notify(ctx context.Context, rel Release) error {
+	buf, err := json.Marshal(WebhookPayload{rel.Version, rel.Stable, rel})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", wh.URL, bytes.NewReader(buf))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	hc := wh.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("posting webhook returned http status %d: %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package goreleases
+
+import (
+	"fmt"
+)
+
+// Access control for extracted trees on Windows, for Client.WindowsACL and
+// SetWindowsACL. File modes in archives do not map to Windows ACLs.
+const (
+	// Only inherit access control entries from the parent directory, removing
+	// explicit entries of all files and directories in the tree, like
+	// "icacls dir /reset /t".
+	WindowsACLInherit = "inherit"
+
+	// Only Administrators and SYSTEM can modify the tree, users can read and
+	// execute. Entries of the parent directory are not inherited. For shared
+	// toolchain directories on build hosts.
+	WindowsACLAdmins = "admins"
+)
+
+// windowsACLAdminsSDDL is the DACL for WindowsACLAdmins: protected, with full
+// access for builtin Administrators and SYSTEM, and read and execute for
+// builtin Users, inherited by all files and directories.
+const windowsACLAdminsSDDL = "D:PAI(A;OICI;FA;;;BA)(A;OICI;FA;;;SY)(A;OICI;0x1200a9;;;BU)"
+
+// SetWindowsACL sets the access control lists of the tree at dir, e.g. an
+// existing install, to acl, WindowsACLInherit or WindowsACLAdmins. Setting
+// WindowsACLAdmins typically requires administrator privileges. Only
+// supported on Windows.
+func SetWindowsACL(dir, acl string) error {
+	if acl != WindowsACLInherit && acl != WindowsACLAdmins {
+		return fmt.Errorf("unknown windows acl %q", acl)
+	}
+	return setWindowsACL(dir, acl)
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package goreleases
+
+import (
+	"fmt"
+)
+
+func setWindowsACL(dir, acl string) error {
+	return fmt.Errorf("setting acls only supported on windows")
+}
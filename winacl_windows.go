@@ -0,0 +1,72 @@
+//go:build windows
+// +build windows
+
+package goreleases
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	procTreeSetNamedSecurityInfoW                            = advapi32.NewProc("TreeSetNamedSecurityInfoW")
+	procConvertStringSecurityDescriptorToSecurityDescriptorW = advapi32.NewProc("ConvertStringSecurityDescriptorToSecurityDescriptorW")
+	procGetSecurityDescriptorDacl                            = advapi32.NewProc("GetSecurityDescriptorDacl")
+	procLocalFree                                            = kernel32.NewProc("LocalFree")
+)
+
+const (
+	seFileObject                       = 1
+	daclSecurityInformation            = 0x4
+	protectedDaclSecurityInformation   = 0x80000000
+	unprotectedDaclSecurityInformation = 0x20000000
+	treeSecInfoReset                   = 2
+	progressInvokeNever                = 1
+	sddlRevision1                      = 1
+)
+
+// acl is the header of an access control list, without entries.
+type acl struct {
+	revision byte
+	sbz1     byte
+	size     uint16
+	count    uint16
+	sbz2     uint16
+}
+
+// setWindowsACL sets the DACL of dir and resets the DACLs of all its
+// descendants to only inherit from it.
+func setWindowsACL(dir, mode string) error {
+	p, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return err
+	}
+
+	var dacl uintptr
+	var info uint32 = daclSecurityInformation
+	if mode == WindowsACLAdmins {
+		var sd uintptr
+		sddl := syscall.StringToUTF16Ptr(windowsACLAdminsSDDL)
+		r, _, err := procConvertStringSecurityDescriptorToSecurityDescriptorW.Call(uintptr(unsafe.Pointer(sddl)), sddlRevision1, uintptr(unsafe.Pointer(&sd)), 0)
+		if r == 0 {
+			return err
+		}
+		defer procLocalFree.Call(sd)
+		var present, defaulted int32
+		r, _, err = procGetSecurityDescriptorDacl.Call(sd, uintptr(unsafe.Pointer(&present)), uintptr(unsafe.Pointer(&dacl)), uintptr(unsafe.Pointer(&defaulted)))
+		if r == 0 {
+			return err
+		}
+		info |= protectedDaclSecurityInformation
+	} else {
+		// An empty, unprotected DACL leaves only the inherited entries.
+		empty := &acl{revision: 2, size: uint16(unsafe.Sizeof(acl{}))}
+		dacl = uintptr(unsafe.Pointer(empty))
+		info |= unprotectedDaclSecurityInformation
+	}
+	r, _, _ := procTreeSetNamedSecurityInfoW.Call(uintptr(unsafe.Pointer(p)), seFileObject, uintptr(info), 0, 0, dacl, 0, treeSecInfoReset, 0, progressInvokeNever, 0)
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	return nil
+}
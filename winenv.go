@@ -0,0 +1,28 @@
+package goreleases
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// RegisterWindowsEnv sets GOROOT to goroot and adds goroot\bin to the front
+// of Path in the persistent environment of the current user, or of the
+// machine if machine is set (requires administrator privileges), like the
+// MSI installer does. Other Go bin directories in Path are not touched.
+// Running programs are notified of the change, new shells see the updated
+// environment. Only supported on Windows.
+func RegisterWindowsEnv(goroot string, machine bool) error {
+	return registerWindowsEnv(goroot, machine)
+}
+
+// prependPath returns the semicolon-separated path list with dir at the front,
+// removing other occurrences of dir.
+func prependPath(path, dir string) string {
+	l := []string{dir}
+	for _, e := range strings.Split(path, ";") {
+		if e != "" && !strings.EqualFold(filepath.Clean(e), filepath.Clean(dir)) {
+			l = append(l, e)
+		}
+	}
+	return strings.Join(l, ";")
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package goreleases
+
+import (
+	"fmt"
+)
+
+func registerWindowsEnv(goroot string, machine bool) error {
+	return fmt.Errorf("registering environment only supported on windows")
+}
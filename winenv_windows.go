@@ -0,0 +1,82 @@
+//go:build windows
+// +build windows
+
+package goreleases
+
+import (
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32           = syscall.NewLazyDLL("advapi32.dll")
+	procRegSetValueExW = advapi32.NewProc("RegSetValueExW")
+
+	user32                  = syscall.NewLazyDLL("user32.dll")
+	procSendMessageTimeoutW = user32.NewProc("SendMessageTimeoutW")
+)
+
+func registerWindowsEnv(goroot string, machine bool) error {
+	root, path := syscall.Handle(syscall.HKEY_CURRENT_USER), `Environment`
+	if machine {
+		root, path = syscall.HKEY_LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Control\Session Manager\Environment`
+	}
+	var k syscall.Handle
+	if err := syscall.RegOpenKeyEx(root, syscall.StringToUTF16Ptr(path), 0, syscall.KEY_READ|syscall.KEY_WRITE, &k); err != nil {
+		return fmt.Errorf("opening environment registry key: %v", err)
+	}
+	defer syscall.RegCloseKey(k)
+
+	cur, err := regGetString(k, "Path")
+	if err != nil {
+		return fmt.Errorf("reading Path: %v", err)
+	}
+	if err := regSetString(k, "GOROOT", goroot, syscall.REG_SZ); err != nil {
+		return fmt.Errorf("setting GOROOT: %v", err)
+	}
+	if err := regSetString(k, "Path", prependPath(cur, filepath.Join(goroot, "bin")), syscall.REG_EXPAND_SZ); err != nil {
+		return fmt.Errorf("setting Path: %v", err)
+	}
+
+	// Let explorer and others reload the environment.
+	const hwndBroadcast, wmSettingChange, smtoAbortIfHung = 0xffff, 0x1a, 0x2
+	env := syscall.StringToUTF16Ptr("Environment")
+	var result uintptr
+	procSendMessageTimeoutW.Call(hwndBroadcast, wmSettingChange, 0, uintptr(unsafe.Pointer(env)), smtoAbortIfHung, 5000, uintptr(unsafe.Pointer(&result)))
+	return nil
+}
+
+// regGetString returns the string value name in k, or an empty string if it
+// does not exist.
+func regGetString(k syscall.Handle, name string) (string, error) {
+	var typ, n uint32
+	pname := syscall.StringToUTF16Ptr(name)
+	err := syscall.RegQueryValueEx(k, pname, nil, &typ, nil, &n)
+	if err == syscall.ERROR_FILE_NOT_FOUND {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	if typ != syscall.REG_SZ && typ != syscall.REG_EXPAND_SZ {
+		return "", fmt.Errorf("unexpected registry value type %d", typ)
+	}
+	buf := make([]uint16, n/2+1)
+	if err := syscall.RegQueryValueEx(k, pname, nil, &typ, (*byte)(unsafe.Pointer(&buf[0])), &n); err != nil {
+		return "", err
+	}
+	return syscall.UTF16ToString(buf), nil
+}
+
+func regSetString(k syscall.Handle, name, value string, typ uint32) error {
+	v, err := syscall.UTF16FromString(value)
+	if err != nil {
+		return err
+	}
+	r, _, _ := procRegSetValueExW.Call(uintptr(k), uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(name))), 0, uintptr(typ), uintptr(unsafe.Pointer(&v[0])), uintptr(len(v)*2))
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package goreleases
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// paxXattrPrefix is the prefix of PAX records with extended attributes, as
+// written by GNU tar and archive/tar.
+const paxXattrPrefix = "SCHILY.xattr."
+
+// QuarantineXattr is the extended attribute macOS sets on downloaded files,
+// making Gatekeeper ask for confirmation before running them.
+const QuarantineXattr = "com.apple.quarantine"
+
+// NoQuarantine is a Client.XattrFilter that skips QuarantineXattr.
+func NoQuarantine(name, attr string, value []byte) ([]byte, bool) {
+	return value, attr != QuarantineXattr
+}
+
+// setXattrs sets the extended attributes from PAX records on the open file or
+// directory f, for entry name in the archive, passing them through filter if
+// not nil.
+func setXattrs(f *os.File, name string, records map[string]string, filter func(name, attr string, value []byte) ([]byte, bool)) error {
+	var keys []string
+	for k := range records {
+		if strings.HasPrefix(k, paxXattrPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attr := strings.TrimPrefix(k, paxXattrPrefix)
+		value := []byte(records[k])
+		if filter != nil {
+			var ok bool
+			if value, ok = filter(name, attr, value); !ok {
+				continue
+			}
+		}
+		if err := setXattr(f, attr, value); err != nil {
+			return fmt.Errorf("setting extended attribute %s: %w", attr, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package goreleases
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func setXattr(f *os.File, name string, value []byte) error {
+	attr, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return err
+	}
+	var p unsafe.Pointer
+	if len(value) > 0 {
+		p = unsafe.Pointer(&value[0])
+	}
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = rc.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall6(syscall.SYS_FSETXATTR, fd, uintptr(unsafe.Pointer(attr)), uintptr(p), uintptr(len(value)), 0, 0)
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
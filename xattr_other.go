@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package goreleases
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func setXattr(f *os.File, name string, value []byte) error {
+	return fmt.Errorf("extended attributes not supported on %s", runtime.GOOS)
+}
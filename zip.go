@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
 
-func fetchZip(f *os.File, file File, dst string, permissions *Permissions) error {
+func fetchZip(f *os.File, file File, x *extractor) error {
+	dst := x.dst
 	fi, err := os.Stat(dst)
 	if err != nil {
 		return err
@@ -26,6 +28,10 @@ func fetchZip(f *os.File, file File, dst string, permissions *Permissions) error
 	// we assume it's a not-exists error. if it isn't, eg noperm, we'll probably get the same error later on, which is fine.
 
 	dst = filepath.Clean(dst)
+	if err := x.openRoot(); err != nil {
+		return err
+	}
+	defer x.closeRoot()
 
 	b := &bytes.Buffer{}
 	hr := &hashReader{f, sha256.New()}
@@ -52,20 +58,43 @@ func fetchZip(f *os.File, file File, dst string, permissions *Permissions) error
 		return fmt.Errorf("reading zip file: %v", err)
 	}
 	for _, zf := range r.File {
-		name, err := dstName(dst, zf.Name)
-		if err != nil {
+		if err := x.checkZip(zf); err != nil {
+			return err
+		}
+		if _, err := dstName(dst, zf.Name); err != nil {
 			return err
 		}
+		if x.skip(zf.Name, strings.HasSuffix(zf.Name, "/")) {
+			continue
+		}
 
 		if strings.HasSuffix(zf.Name, "/") {
-			err = os.Mkdir(name, 0775)
+			rel := path.Clean(zf.Name)
+			err = x.root.Mkdir(rel, 0775)
 			if err != nil {
 				return err
 			}
+			if x.perms == nil && x.exact {
+				if err := x.root.Chmod(rel, zf.Mode().Perm()); err != nil {
+					return fmt.Errorf("chmod: %s", err)
+				}
+			}
+			if perms := x.perms; perms != nil {
+				if err := x.root.Chmod(rel, perms.Mode); err != nil {
+					return fmt.Errorf("chmod: %s", err)
+				}
+				if perms.Uid >= 0 || perms.Gid >= 0 {
+					if err := x.root.Lchown(rel, perms.Uid, perms.Gid); err != nil {
+						return fmt.Errorf("chown: %v", err)
+					}
+				}
+			}
+			x.dirTime(rel, zf.Modified)
+			x.add(zf.Name, EntryDir, 0, "", "")
 			continue
 		}
 
-		err = storeZip(zf, name, permissions)
+		err = x.storeZip(zf)
 		if err != nil {
 			return fmt.Errorf("storing file: %v", err)
 		}
@@ -75,14 +104,16 @@ func fetchZip(f *os.File, file File, dst string, permissions *Permissions) error
 	return nil
 }
 
-func storeZip(zf *zip.File, name string, perms *Permissions) error {
+// storeZip creates the file zf through the root of x.
+func (x *extractor) storeZip(zf *zip.File) error {
+	perms := x.perms
 	sf, err := zf.Open()
 	if err != nil {
 		return fmt.Errorf("opening file in zip: %v", err)
 	}
 	defer sf.Close()
 
-	df, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, zf.Mode()&0777)
+	df, err := x.root.OpenFile(path.Clean(zf.Name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, zf.Mode()&0777)
 	if err != nil {
 		return fmt.Errorf("creating file: %v", err)
 	}
@@ -92,6 +123,11 @@ func storeZip(zf *zip.File, name string, perms *Permissions) error {
 		}
 	}()
 
+	if perms == nil && x.exact {
+		if err := df.Chmod(zf.Mode().Perm()); err != nil {
+			return fmt.Errorf("chmod: %s", err)
+		}
+	}
 	if perms != nil {
 		mode := perms.Mode & 0777
 		if zf.Mode()&0100 == 0 {
@@ -103,23 +139,33 @@ func storeZip(zf *zip.File, name string, perms *Permissions) error {
 		}
 
 		if perms.Uid >= 0 || perms.Gid >= 0 {
-			err := os.Lchown(name, perms.Uid, perms.Gid)
+			err := df.Chown(perms.Uid, perms.Gid)
 			if err != nil {
 				return fmt.Errorf("chown: %v", err)
 			}
 		}
 	}
 
-	err = os.Chtimes(name, zf.Modified, zf.Modified)
+	err = x.root.Chtimes(path.Clean(zf.Name), zf.Modified, zf.Modified)
 	if err != nil {
 		return fmt.Errorf("chtimes: %v", err)
 	}
 
-	_, err = io.Copy(df, sf)
+	hr := &hashReader{sf, sha256.New()}
+	n, err := x.copy(df, hr)
 	if err != nil {
 		return fmt.Errorf("writing file: %v", err)
 	}
+	if x.sync {
+		if err := df.Sync(); err != nil {
+			return fmt.Errorf("sync: %v", err)
+		}
+	}
 	err = df.Close()
 	df = nil
-	return err
+	if err != nil {
+		return err
+	}
+	x.add(zf.Name, EntryFile, n, fmt.Sprintf("%x", hr.h.Sum(nil)), "")
+	return nil
 }